@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	taxobjDelivery "github.com/fairyhunter13/tax-calculator/internal/taxobj/delivery"
+	taxUsecase "github.com/fairyhunter13/tax-calculator/internal/taxobj/usecase"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+)
+
+//The formats of the output of the calc command.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+//calc calculates the bill of the tax objects in the CSV or the JSON file, or the standard input if it's not given,
+//with the same rules as the application without the database, and prints the bill and its total.
+//Every tax object is validated like the request creating it, and the invalid ones are printed with their line.
+//It exits with exitFailed if any tax object is invalid, and the bill isn't printed, so the partial bill is never mistaken for the whole bill.
+//The input and the outputs are given, and the jurisdictions are only given to the calculation, so the command has no global state.
+func calc(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet(commandCalc, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", formatTable, "The format of the output, i.e. table, json, or csv.")
+	jurisdictionsFile := flags.String("jurisdictions", "", "The JSON file of the jurisdictions, only the default rules are used if it's empty.")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: taxcalculator %s [flags] [file]\n\nFlags:\n", commandCalc)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitError
+	}
+	switch *format {
+	case formatTable, formatJSON, formatCSV:
+	default:
+		fmt.Fprintf(stderr, "Unknown format: %s\n", *format)
+		return exitError
+	}
+	rules := taxcalc.NewJurisdictions()
+	if *jurisdictionsFile != "" {
+		jurisdictions, err := taxUsecase.LoadJurisdictions(*jurisdictionsFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to load the jurisdictions: %s\n", err)
+			return exitError
+		}
+		rules = taxcalc.NewJurisdictions(jurisdictions...)
+	}
+	input := stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to open the file: %s\n", err)
+			return exitError
+		}
+		defer file.Close()
+		input = file
+	}
+	taxObjects, line, err := readTaxObjects(input)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read the tax objects: %s\n", err)
+		return exitFailed
+	}
+	//The jurisdictions are checked against the rules calculating the bill.
+	invalid := false
+	for index := range taxObjects {
+		if err = taxobjDelivery.Validate(&taxObjects[index], rules); err != nil {
+			//The errors of the fields are printed in the line of their tax object.
+			fmt.Fprintf(stderr, "%s: %s\n", line(index), strings.Replace(err.Error(), "\n", "; ", -1))
+			invalid = true
+		}
+	}
+	if invalid {
+		return exitFailed
+	}
+	bills, _, total := taxcalc.Calculate(rules, taxobj.Calculables(taxObjects), nil, nil)
+	switch *format {
+	case formatJSON:
+		err = writeJSON(stdout, taxobjDelivery.PreviewResponse{Bills: bills, Total: total})
+	case formatCSV:
+		err = bill.WriteCSV(stdout, bills)
+	default:
+		err = bill.WriteTable(stdout, bills, total)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to write the bill: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+//readTaxObjects read the tax objects from the JSON document if it starts with the object or the list,
+//otherwise from the CSV file, and return the function naming the line of the tax object with the index.
+func readTaxObjects(reader io.Reader) (taxObjects []taxobj.TaxObject, line func(index int) string, err error) {
+	buffered := bufio.NewReader(reader)
+	if isJSON(buffered) {
+		line = func(index int) string {
+			return fmt.Sprintf("Tax object %d", index+1)
+		}
+		taxObjects, err = taxobj.ReadJSON(buffered)
+		return
+	}
+	var lines []int
+	line = func(index int) string {
+		return fmt.Sprintf("Line %d", lines[index])
+	}
+	taxObjects, lines, err = taxobj.ReadCSVLines(buffered)
+	return
+}
+
+//isJSON return true if the first character of the input after the spaces starts the JSON object or list.
+func isJSON(reader *bufio.Reader) bool {
+	for size := 1; ; size++ {
+		peeked, err := reader.Peek(size)
+		trimmed := bytes.TrimSpace(peeked)
+		if len(trimmed) > 0 {
+			return trimmed[0] == '{' || trimmed[0] == '['
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// +build unit
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	billRepository "github.com/fairyhunter13/tax-calculator/internal/bill/repository"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	taxobjDelivery "github.com/fairyhunter13/tax-calculator/internal/taxobj/delivery"
+	taxUsecase "github.com/fairyhunter13/tax-calculator/internal/taxobj/usecase"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+	"github.com/stretchr/testify/assert"
+)
+
+//testJurisdictions is the file of the jurisdictions used to calculate the fixtures.
+const testJurisdictions = "testdata/jurisdictions.json"
+
+//readFixture return the content of the file in the testdata directory.
+func readFixture(t *testing.T, name string) string {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestCalc(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Table",
+			args:       []string{"-jurisdictions", testJurisdictions, "testdata/receipt.csv"},
+			wantCode:   exitOK,
+			wantStdout: readFixture(t, "bill.table.golden"),
+		},
+		{
+			name:       "JSON",
+			args:       []string{"-jurisdictions", testJurisdictions, "-format", formatJSON, "testdata/receipt.csv"},
+			wantCode:   exitOK,
+			wantStdout: readFixture(t, "bill.json.golden"),
+		},
+		{
+			name:       "CSV from JSON",
+			args:       []string{"-jurisdictions", testJurisdictions, "-format", formatCSV, "testdata/receipt.json"},
+			wantCode:   exitOK,
+			wantStdout: readFixture(t, "bill.csv.golden"),
+		},
+		{
+			name:       "Standard Input",
+			args:       []string{"-jurisdictions", testJurisdictions, "-format", formatCSV, "-"},
+			stdin:      readFixture(t, "receipt.csv"),
+			wantCode:   exitOK,
+			wantStdout: readFixture(t, "bill.csv.golden"),
+		},
+		{
+			name:       "Standard Input without File",
+			args:       []string{"-jurisdictions", testJurisdictions, "-format", formatCSV},
+			stdin:      readFixture(t, "receipt.json"),
+			wantCode:   exitOK,
+			wantStdout: readFixture(t, "bill.csv.golden"),
+		},
+		{
+			name:       "Unknown Jurisdiction",
+			args:       []string{"-format", formatCSV, "testdata/receipt.csv"},
+			wantCode:   exitFailed,
+			wantStderr: "Line 3: Jurisdiction not found\n",
+		},
+		{
+			name:       "Unknown Jurisdiction in JSON",
+			args:       []string{"testdata/receipt.json"},
+			wantCode:   exitFailed,
+			wantStderr: "Tax object 2: Jurisdiction not found\n",
+		},
+		{
+			name:     "Invalid Rows",
+			args:     []string{"testdata/invalid.csv"},
+			wantCode: exitFailed,
+			wantStderr: "Line 3: Key: 'TaxObject.TaxCode' Error:Field validation for 'TaxCode' failed on the 'lte' tag\n" +
+				"Line 4: Key: 'TaxObject.Price' Error:Field validation for 'Price' failed on the 'gt' tag\n",
+		},
+		{
+			name:       "Invalid Rows after Blank Lines",
+			args:       []string{"-"},
+			stdin:      "name,tax_code,price\n\n\"Big Mac,\nLarge\",1,1000\n\nMovie,4,150\n",
+			wantCode:   exitFailed,
+			wantStderr: "Line 6: Key: 'TaxObject.TaxCode' Error:Field validation for 'TaxCode' failed on the 'lte' tag\n",
+		},
+		{
+			name:       "Malformed Row",
+			args:       []string{"testdata/malformed.csv"},
+			wantCode:   exitFailed,
+			wantStderr: "Failed to read the tax objects: Line 2: Invalid tax_code \"one\"\n",
+		},
+		{
+			name:       "Unknown Format",
+			args:       []string{"-format", "xml", "testdata/receipt.csv"},
+			wantCode:   exitError,
+			wantStderr: "Unknown format: xml\n",
+		},
+		{
+			name:       "Missing Jurisdictions",
+			args:       []string{"-jurisdictions", "testdata/missing.json", "testdata/receipt.csv"},
+			wantCode:   exitError,
+			wantStderr: "Failed to load the jurisdictions: open testdata/missing.json: no such file or directory\n",
+		},
+		{
+			name:       "Missing File",
+			args:       []string{"testdata/missing.csv"},
+			wantCode:   exitError,
+			wantStderr: "Failed to open the file: open testdata/missing.csv: no such file or directory\n",
+		},
+		{
+			name:     "Unknown Flag",
+			args:     []string{"-bogus"},
+			wantCode: exitError,
+			wantStderr: "flag provided but not defined: -bogus\nUsage: taxcalculator calc [flags] [file]\n\nFlags:\n" +
+				"  -format string\n    \tThe format of the output, i.e. table, json, or csv. (default \"table\")\n" +
+				"  -jurisdictions string\n    \tThe JSON file of the jurisdictions, only the default rules are used if it's empty.\n",
+		},
+		{
+			name:     "Too Many Files",
+			args:     []string{"testdata/receipt.csv", "testdata/receipt.json"},
+			wantCode: exitError,
+			wantStderr: "Usage: taxcalculator calc [flags] [file]\n\nFlags:\n" +
+				"  -format string\n    \tThe format of the output, i.e. table, json, or csv. (default \"table\")\n" +
+				"  -jurisdictions string\n    \tThe JSON file of the jurisdictions, only the default rules are used if it's empty.\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			assert.Equal(t, tt.wantCode, calc(tt.args, strings.NewReader(tt.stdin), stdout, stderr))
+			assert.Equal(t, tt.wantStdout, stdout.String())
+			assert.Equal(t, tt.wantStderr, stderr.String())
+		})
+	}
+}
+
+func TestCalc_StackedTaxes(t *testing.T) {
+	t.Parallel()
+	//The stacked taxes of the CSV file are calculated like the stacked taxes of the JSON document.
+	inputs := []string{
+		"name,tax_code,unit_price,taxes\nLucky Stretch,2,1000,3;1:compound\n",
+		`[{"name": "Lucky Stretch", "tax_code": 2, "unit_price": 1000, "taxes": [{"tax_code": 3}, {"tax_code": 1, "compound": true}]}]`,
+	}
+	outputs := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		if !assert.Equal(t, exitOK, calc([]string{"-format", formatJSON}, strings.NewReader(input), stdout, stderr), stderr.String()) {
+			return
+		}
+		outputs = append(outputs, stdout.String())
+	}
+	assert.Equal(t, outputs[0], outputs[1])
+	var got taxobjDelivery.PreviewResponse
+	if assert.NoError(t, json.Unmarshal([]byte(outputs[0]), &got)) && assert.Len(t, got.Bills, 1) {
+		assert.Len(t, got.Bills[0].Taxes, 3)
+	}
+}
+
+func TestCalc_ServerTotal(t *testing.T) {
+	t.Parallel()
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	if !assert.Equal(t, exitOK, calc([]string{"-jurisdictions", testJurisdictions, "-format", formatJSON, "testdata/receipt.csv"}, strings.NewReader(""), stdout, stderr), stderr.String()) {
+		return
+	}
+	var got taxobjDelivery.PreviewResponse
+	if !assert.NoError(t, json.Unmarshal(stdout.Bytes(), &got)) {
+		return
+	}
+
+	//The same tax objects added to the bill of the server have the same lines and total.
+	jurisdictions, err := taxUsecase.LoadJurisdictions(testJurisdictions)
+	if !assert.NoError(t, err) {
+		return
+	}
+	file, err := os.Open("testdata/receipt.csv")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer file.Close()
+	taxObjects, err := taxobj.ReadCSV(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rules := taxcalc.NewJurisdictions(jurisdictions...)
+	repo := billRepository.NewCacheRepository(rules, logger.Discard())
+	for index := range taxObjects {
+		taxObjects[index].ID = int64(index + 1)
+		if !assert.NoError(t, taxobjDelivery.Validate(&taxObjects[index], rules)) {
+			return
+		}
+		repo.Add(context.Background(), taxObjects[index])
+	}
+	wantBills, wantTotal := repo.GetAll(context.Background())
+	//The bill of the server rounds its sums to the nanounit, so the totals are the same within it.
+	assert.InDeltaSlice(t, []float64{
+		wantTotal.PriceSubtotal, wantTotal.DiscountSubtotal, wantTotal.CouponSubtotal, wantTotal.NetSubtotal,
+		wantTotal.TaxSubtotal, wantTotal.ChargeSubtotal, wantTotal.ChargeTaxSubtotal, wantTotal.GrandTotal,
+	}, []float64{
+		got.Total.PriceSubtotal, got.Total.DiscountSubtotal, got.Total.CouponSubtotal, got.Total.NetSubtotal,
+		got.Total.TaxSubtotal, got.Total.ChargeSubtotal, got.Total.ChargeTaxSubtotal, got.Total.GrandTotal,
+	}, 1e-9)
+	if assert.Len(t, got.Total.Taxes, len(wantTotal.Taxes)) {
+		for index, summary := range wantTotal.Taxes {
+			assert.Equal(t, summary.Jurisdiction, got.Total.Taxes[index].Jurisdiction)
+			assert.Equal(t, summary.TaxCode, got.Total.Taxes[index].TaxCode)
+			assert.InDeltaSlice(t, []float64{summary.Base, summary.Tax}, []float64{got.Total.Taxes[index].Base, got.Total.Taxes[index].Tax}, 1e-9)
+		}
+	}
+	if assert.Len(t, got.Bills, len(wantBills)) {
+		for index := range wantBills {
+			assert.Equal(t, wantBills[index].Amount, got.Bills[index].Amount)
+			assert.Equal(t, wantBills[index].Tax, got.Bills[index].Tax)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	//commandVerify verifies the hash chain of the audit events.
+	commandVerify = "verify"
+	//commandCalc calculates the bill of the tax objects in the file without the server.
+	commandCalc = "calc"
+)
+
+//Exit codes of the commands.
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitError  = 2
+)
+
+//runCommand runs the command with its arguments and return the exit code.
+func runCommand(name string, args []string) int {
+	switch name {
+	case commandVerify:
+		return verify()
+	case commandCalc:
+		return calc(args, os.Stdin, os.Stdout, os.Stderr)
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
+	return exitError
+}
+
+//verify verifies the hash chains of the audit events of all tenants and prints the result.
+//It exits with exitFailed at the first broken link, and with exitError if the chain can't be read.
+func verify() int {
+	application, err := newApp()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse the config: %s\n", err)
+		return exitError
+	}
+	defer application.Close()
+	verification, err := application.VerifyAuditChain(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to verify the audit chain: %s\n", err)
+		return exitError
+	}
+	writeJSON(os.Stdout, verification)
+	if verification.Broken != nil {
+		return exitFailed
+	}
+	return exitOK
+}
+
+//writeJSON writes the value as the indented JSON.
+func writeJSON(writer io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
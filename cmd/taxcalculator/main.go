@@ -2,12 +2,12 @@ package main
 
 import (
 	"database/sql"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/fairyhunter13/tax-calculator/internal/app"
+	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -22,26 +22,40 @@ func init() {
 	signal.Notify(osSignal, os.Interrupt, syscall.SIGINT)
 }
 
+//main serves the application, or runs the command given in the first argument.
 func main() {
-	application := app.NewApp()
-	defer application.Close()
-	defer close(osSignal)
-	appConfig := new(app.Config)
-	err := application.ParseConfig(configPath, appConfig)
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+	application, err := newApp()
 	if err != nil {
 		log.Fatalf("[App] Failed to parse the config: %s", err)
 	}
-	application.SetConfig(appConfig)
-	startConnection(appConfig, application)
+	defer application.Close()
+	defer close(osSignal)
+	logger := application.Logger()
 	err = application.Migrate()
 	if err != nil {
-		log.Fatalf("[App] Failed to migrate the database: %s", err)
+		logger.Fatalf("[App] Failed to migrate the database: %s", err)
 	}
 	err = application.Run(osSignal)
 	if err != nil {
-		log.Fatalf("[App] Failed to run the application: %s", err)
+		logger.Fatalf("[App] Failed to run the application: %s", err)
 	}
-	log.Printf("[App] Shutting down!")
+	logger.Infof("[App] Shutting down!")
+}
+
+//newApp return the application initialized with the config.
+func newApp() (application *app.App, err error) {
+	application = app.NewApp()
+	appConfig := new(app.Config)
+	err = application.ParseConfig(configPath, appConfig)
+	if err != nil {
+		return
+	}
+	application.SetConfig(appConfig)
+	startConnection(appConfig, application)
+	return
 }
 
 func startConnection(appConfig *app.Config, application *app.App) {
@@ -49,5 +63,8 @@ func startConnection(appConfig *app.Config, application *app.App) {
 	if err != nil {
 		log.Fatalf("[Connection] Failed to connect to the database: %s", err)
 	}
-	application.Init(pool)
+	err = application.Init(pool)
+	if err != nil {
+		log.Fatalf("[App] Failed to initialize the application: %s", err)
+	}
 }
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/pkg/client"
+)
+
+const (
+	//commandAdd adds the tax object to the open bill.
+	commandAdd = "add"
+	//commandBill prints the open bill.
+	commandBill = "bill"
+	//commandImport adds the tax objects of the CSV file to the open bill.
+	commandImport = "import"
+	//commandExport exports the lines of the open bill.
+	commandExport = "export"
+)
+
+//apiClient define the requests of the API sent by the commands, it's implemented by *client.Client.
+type apiClient interface {
+	CreateTaxObject(ctx context.Context, taxObject client.TaxObject) (client.TaxObject, error)
+	GetBill(ctx context.Context, explain bool) (client.BillResponse, error)
+}
+
+//taxctl runs the commands with the client of the API.
+//The input and the outputs are the standard ones, they're only fields so the commands don't use them directly.
+type taxctl struct {
+	api     apiClient
+	timeout time.Duration
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+//run runs the command with its arguments and return the exit code.
+func (ctl *taxctl) run(name string, args []string) int {
+	switch name {
+	case commandAdd:
+		return ctl.add(args)
+	case commandBill:
+		return ctl.bill(args)
+	case commandImport:
+		return ctl.importCSV(args)
+	case commandExport:
+		return ctl.export(args)
+	}
+	fmt.Fprintf(ctl.stderr, "Unknown command: %s\n", name)
+	return exitError
+}
+
+//add adds the tax object given by the flags to the open bill and prints the created tax object.
+func (ctl *taxctl) add(args []string) int {
+	flags := ctl.newFlagSet(commandAdd, "")
+	format := formatFlag(flags, formatTable)
+	name := flags.String("name", "", "The name of the tax object.")
+	taxCode := flags.Int64("code", 0, "The tax code of the tax object.")
+	price := flags.Float64("price", 0, "The unit price of the tax object, i.e. its price if the quantity is one.")
+	quantity := flags.Float64("quantity", 1, "The quantity of the tax object.")
+	jurisdiction := flags.String("jurisdiction", "", "The jurisdiction of the tax object, the default rules are used if it's empty.")
+	taxInclusive := flags.Bool("tax-inclusive", false, "The price includes the tax.")
+	if flags.Parse(args) != nil || !format.valid(ctl.stderr) {
+		return exitError
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ctl.timeout)
+	defer cancel()
+	created, err := ctl.api.CreateTaxObject(ctx, client.TaxObject{
+		Name:         *name,
+		TaxCode:      *taxCode,
+		Jurisdiction: *jurisdiction,
+		Quantity:     *quantity,
+		UnitPrice:    *price,
+		TaxInclusive: *taxInclusive,
+	})
+	if err != nil {
+		fmt.Fprintf(ctl.stderr, "Failed to add the tax object: %s\n", err)
+		return exitFailed
+	}
+	return ctl.writeTaxObjects(format.value, []taxobj.TaxObject{fromClientTaxObject(created)})
+}
+
+//bill prints the lines and the total of the open bill.
+func (ctl *taxctl) bill(args []string) int {
+	flags := ctl.newFlagSet(commandBill, "")
+	format := formatFlag(flags, formatTable)
+	explain := flags.Bool("explain", false, "Explain the taxes of the lines, only in the JSON format.")
+	if flags.Parse(args) != nil || !format.valid(ctl.stderr) {
+		return exitError
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ctl.timeout)
+	defer cancel()
+	billResp, err := ctl.api.GetBill(ctx, *explain)
+	if err != nil {
+		fmt.Fprintf(ctl.stderr, "Failed to get the bill: %s\n", err)
+		return exitFailed
+	}
+	switch format.value {
+	case formatJSON:
+		err = writeJSON(ctl.stdout, billResp)
+	case formatCSV:
+		err = bill.WriteCSV(ctl.stdout, fromClientBills(billResp.Bill))
+	default:
+		err = bill.WriteTable(ctl.stdout, fromClientBills(billResp.Bill), fromClientTotal(billResp.Total))
+	}
+	return ctl.written(err)
+}
+
+//importCSV adds the tax objects of the CSV file to the open bill in their order and prints the created tax objects.
+//The file is read from the standard input if it's "-".
+//The tax objects failing to be added are reported with their line, and the next ones are still added.
+func (ctl *taxctl) importCSV(args []string) int {
+	flags := ctl.newFlagSet(commandImport, " <file.csv>")
+	format := formatFlag(flags, formatTable)
+	if flags.Parse(args) != nil || !format.valid(ctl.stderr) {
+		return exitError
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitError
+	}
+	taxObjects, lines, err := ctl.readCSV(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(ctl.stderr, "Failed to read the tax objects: %s\n", err)
+		return exitFailed
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ctl.timeout)
+	defer cancel()
+	created := make([]taxobj.TaxObject, 0, len(taxObjects))
+	failed := false
+	for index, taxObject := range taxObjects {
+		//The id is assigned by the API, so the exported tax objects are added as the new ones.
+		taxObject.ID = 0
+		createdTaxObject, err := ctl.api.CreateTaxObject(ctx, toClientTaxObject(taxObject))
+		if err != nil {
+			fmt.Fprintln(ctl.stderr, &taxobj.CSVError{Line: lines[index], Err: err})
+			failed = true
+			continue
+		}
+		created = append(created, fromClientTaxObject(createdTaxObject))
+	}
+	if code := ctl.writeTaxObjects(format.value, created); code != exitOK {
+		return code
+	}
+	if failed {
+		return exitFailed
+	}
+	return exitOK
+}
+
+//export writes the lines of the open bill to the standard output or the file.
+func (ctl *taxctl) export(args []string) int {
+	flags := ctl.newFlagSet(commandExport, "")
+	format := formatFlag(flags, formatCSV)
+	output := flags.String("output", "", "The file to write, the standard output is used if it's empty.")
+	if flags.Parse(args) != nil || !format.valid(ctl.stderr) {
+		return exitError
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ctl.timeout)
+	defer cancel()
+	billResp, err := ctl.api.GetBill(ctx, false)
+	if err != nil {
+		fmt.Fprintf(ctl.stderr, "Failed to get the bill: %s\n", err)
+		return exitFailed
+	}
+	writer := ctl.stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(ctl.stderr, "Failed to create the file: %s\n", err)
+			return exitFailed
+		}
+		defer file.Close()
+		writer = file
+	}
+	switch format.value {
+	case formatJSON:
+		err = writeJSON(writer, billResp.Bill)
+	case formatTable:
+		err = bill.WriteTable(writer, fromClientBills(billResp.Bill), fromClientTotal(billResp.Total))
+	default:
+		err = bill.WriteCSV(writer, fromClientBills(billResp.Bill))
+	}
+	return ctl.written(err)
+}
+
+//newFlagSet return the flag set of the command printing its usage to the standard error.
+func (ctl *taxctl) newFlagSet(name, arguments string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(ctl.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(ctl.stderr, "Usage: taxctl %s [flags]%s\n\nFlags:\n", name, arguments)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+//readCSV read the tax objects and their lines from the CSV file, or from the standard input if the path is "-".
+func (ctl *taxctl) readCSV(path string) (taxObjects []taxobj.TaxObject, lines []int, err error) {
+	if path == "-" {
+		return taxobj.ReadCSVLines(ctl.stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	return taxobj.ReadCSVLines(file)
+}
+
+//writeTaxObjects prints the tax objects in the format.
+func (ctl *taxctl) writeTaxObjects(format string, taxObjects []taxobj.TaxObject) int {
+	var err error
+	switch format {
+	case formatJSON:
+		err = writeJSON(ctl.stdout, taxObjects)
+	case formatCSV:
+		err = taxobj.WriteCSV(ctl.stdout, taxObjects)
+	default:
+		err = writeTaxObjectTable(ctl.stdout, taxObjects)
+	}
+	return ctl.written(err)
+}
+
+//written return the exit code of the command after writing its output.
+func (ctl *taxctl) written(err error) int {
+	if err != nil {
+		fmt.Fprintf(ctl.stderr, "Failed to write the output: %s\n", err)
+		return exitFailed
+	}
+	return exitOK
+}
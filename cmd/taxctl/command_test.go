@@ -0,0 +1,343 @@
+// +build unit
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+//testBill is the open bill returned by the fake API.
+var testBill = client.BillResponse{
+	ID: 1,
+	Bill: []client.Bill{
+		{
+			Name:        "Big Mac",
+			TaxCode:     1,
+			Type:        "Food & Beverage",
+			Refundable:  "Yes",
+			Quantity:    2,
+			UnitPrice:   1000,
+			Price:       2000,
+			Discount:    100,
+			TaxableBase: 1900,
+			Tax:         190,
+			Amount:      2090,
+		},
+		{
+			Name:         "Movie",
+			TaxCode:      3,
+			Jurisdiction: "TEST",
+			Type:         "Entertainment",
+			Refundable:   "No",
+			Quantity:     1.5,
+			UnitPrice:    150,
+			Price:        225,
+			TaxInclusive: true,
+			TaxableBase:  224.5,
+			Tax:          0.5,
+			Amount:       225,
+		},
+		{
+			Name:        "Lucky Stretch",
+			TaxCode:     2,
+			Type:        "Tobacco",
+			Refundable:  "No",
+			Quantity:    1,
+			UnitPrice:   1000,
+			Price:       1000,
+			TaxableBase: 1000,
+			Tax:         133,
+			Taxes: []client.TaxComponent{
+				{TaxCode: 2, Type: "Tobacco", Base: 1000, Tax: 30},
+				{TaxCode: 1, Type: "Food & Beverage", Compound: true, Base: 1030, Tax: 103},
+			},
+			Amount: 1133,
+		},
+	},
+	Total: client.Total{PriceSubtotal: 3225, DiscountSubtotal: 100, NetSubtotal: 3124.5, TaxSubtotal: 323.5, GrandTotal: 3448},
+}
+
+//fakeAPI define the API answering the commands without the server.
+//The tax object named failName fails to be created, and every request fails with err if it's not nil.
+type fakeAPI struct {
+	mutex    sync.Mutex
+	created  []client.TaxObject
+	failName string
+	err      error
+}
+
+//CreateTaxObject record the created tax object and return it with its id and price.
+func (api *fakeAPI) CreateTaxObject(ctx context.Context, taxObject client.TaxObject) (client.TaxObject, error) {
+	if api.err != nil {
+		return client.TaxObject{}, api.err
+	}
+	if taxObject.Name == api.failName {
+		return client.TaxObject{}, client.ErrInvalidInput
+	}
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	api.created = append(api.created, taxObject)
+	taxObject.ID = int64(len(api.created))
+	if taxObject.UnitPrice == 0 {
+		taxObject.UnitPrice = taxObject.Price
+	}
+	taxObject.Price = taxObject.Quantity * taxObject.UnitPrice
+	return taxObject, nil
+}
+
+//GetBill return the test bill.
+func (api *fakeAPI) GetBill(ctx context.Context, explain bool) (client.BillResponse, error) {
+	if api.err != nil {
+		return client.BillResponse{}, api.err
+	}
+	return testBill, nil
+}
+
+//newTestTaxctl return taxctl running the commands with the API, the input, and the buffered outputs.
+func newTestTaxctl(api apiClient, stdin string) (ctl *taxctl, stdout *bytes.Buffer, stderr *bytes.Buffer) {
+	stdout, stderr = new(bytes.Buffer), new(bytes.Buffer)
+	ctl = &taxctl{
+		api:     api,
+		timeout: time.Second,
+		stdin:   strings.NewReader(stdin),
+		stdout:  stdout,
+		stderr:  stderr,
+	}
+	return
+}
+
+func TestTaxctl_run(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		stdin       string
+		api         *fakeAPI
+		wantCode    int
+		wantStdout  []string
+		wantStderr  string
+		wantCreated []client.TaxObject
+	}{
+		// TODO: Add test cases.
+		{
+			name:        "Add",
+			command:     commandAdd,
+			args:        []string{"-name", "Big Mac", "-code", "1", "-price", "1000", "-quantity", "2", "-jurisdiction", "TEST", "-tax-inclusive", "-format", "csv"},
+			api:         &fakeAPI{},
+			wantCode:    exitOK,
+			wantStdout:  []string{"id,name,tax_code,jurisdiction,quantity,unit_price,price,discount_type,discount_value,tax_inclusive,taxes\n1,Big Mac,1,TEST,2,1000,2000,,0,true,\n"},
+			wantCreated: []client.TaxObject{{Name: "Big Mac", TaxCode: 1, Jurisdiction: "TEST", Quantity: 2, UnitPrice: 1000, TaxInclusive: true}},
+		},
+		{
+			name:        "Add Table",
+			command:     commandAdd,
+			args:        []string{"-name", "Big Mac", "-code", "1", "-price", "1000"},
+			api:         &fakeAPI{},
+			wantCode:    exitOK,
+			wantStdout:  []string{"ID", "TAX INCLUSIVE", "Big Mac", "1000.00"},
+			wantCreated: []client.TaxObject{{Name: "Big Mac", TaxCode: 1, Quantity: 1, UnitPrice: 1000}},
+		},
+		{
+			name:       "Add Invalid Flag",
+			command:    commandAdd,
+			args:       []string{"-code", "one"},
+			api:        &fakeAPI{},
+			wantCode:   exitError,
+			wantStderr: `invalid value "one" for flag -code`,
+		},
+		{
+			name:       "Add Unknown Format",
+			command:    commandAdd,
+			args:       []string{"-name", "Big Mac", "-format", "xml"},
+			api:        &fakeAPI{},
+			wantCode:   exitError,
+			wantStderr: "Unknown format: xml\n",
+		},
+		{
+			name:       "Add Failed",
+			command:    commandAdd,
+			args:       []string{"-name", "Big Mac", "-code", "1", "-price", "1000"},
+			api:        &fakeAPI{err: client.ErrBillFull},
+			wantCode:   exitFailed,
+			wantStderr: "Failed to add the tax object: 413 The bill has reached the maximum lines\n",
+		},
+		{
+			name:       "Bill Table",
+			command:    commandBill,
+			api:        &fakeAPI{},
+			wantCode:   exitOK,
+			wantStdout: []string{"Big Mac", "Movie", "GRAND TOTAL", "3448.00"},
+		},
+		{
+			name:       "Bill JSON",
+			command:    commandBill,
+			args:       []string{"-format", "json"},
+			api:        &fakeAPI{},
+			wantCode:   exitOK,
+			wantStdout: []string{"{\n  \"id\": 1,\n  \"bill\": [\n", `"grand_total": 3448`},
+		},
+		{
+			name:    "Bill CSV",
+			command: commandBill,
+			args:    []string{"-format", "csv"},
+			api:     &fakeAPI{},
+			wantStdout: []string{"name,tax_code,jurisdiction,type,refundable,quantity,unit_price,price,discount,coupon,tax_inclusive,taxable_base,tax,amount,taxes\n" +
+				"Big Mac,1,,Food & Beverage,Yes,2,1000,2000,100,0,false,1900,190,2090,\n" +
+				"Movie,3,TEST,Entertainment,No,1.5,150,225,0,0,true,224.5,0.5,225,\n" +
+				"Lucky Stretch,2,,Tobacco,No,1,1000,1000,0,0,false,1000,133,1133,1:compound\n"},
+			wantCode: exitOK,
+		},
+		{
+			name:       "Bill Failed",
+			command:    commandBill,
+			api:        &fakeAPI{err: client.ErrUnauthorized},
+			wantCode:   exitFailed,
+			wantStderr: "Failed to get the bill: 401 Unauthorized\n",
+		},
+		{
+			name:        "Import Standard Input",
+			command:     commandImport,
+			args:        []string{"-format", "csv", "-"},
+			stdin:       "name,tax_code,price\nBig Mac,1,1000\n",
+			api:         &fakeAPI{},
+			wantCode:    exitOK,
+			wantStdout:  []string{"1,Big Mac,1,,1,1000,1000,,0,false,\n"},
+			wantCreated: []client.TaxObject{{Name: "Big Mac", TaxCode: 1, Quantity: 1, Price: 1000}},
+		},
+		{
+			name:        "Import Failed Line",
+			command:     commandImport,
+			args:        []string{"-format", "csv", "-"},
+			stdin:       "id,name,tax_code,price\n7,Whopper,1,1000\n8,Big Mac,1,1000\n",
+			api:         &fakeAPI{failName: "Whopper"},
+			wantCode:    exitFailed,
+			wantStdout:  []string{"1,Big Mac,1,,1,1000,1000,,0,false,\n"},
+			wantStderr:  "Line 2: 400 Invalid input\n",
+			wantCreated: []client.TaxObject{{Name: "Big Mac", TaxCode: 1, Quantity: 1, Price: 1000}},
+		},
+		{
+			name:        "Import Failed Line after Blank Lines",
+			command:     commandImport,
+			args:        []string{"-format", "csv", "-"},
+			stdin:       "name,tax_code,price\n\n\"Big\nMac\",1,1000\n\nWhopper,1,1000\n",
+			api:         &fakeAPI{failName: "Whopper"},
+			wantCode:    exitFailed,
+			wantStderr:  "Line 6: 400 Invalid input\n",
+			wantCreated: []client.TaxObject{{Name: "Big\nMac", TaxCode: 1, Quantity: 1, Price: 1000}},
+		},
+		{
+			name:       "Import Invalid CSV",
+			command:    commandImport,
+			args:       []string{"-"},
+			stdin:      "name,vat\n",
+			api:        &fakeAPI{},
+			wantCode:   exitFailed,
+			wantStderr: "Failed to read the tax objects: Line 1: Unknown column \"vat\"\n",
+		},
+		{
+			name:       "Import Missing File",
+			command:    commandImport,
+			api:        &fakeAPI{},
+			wantCode:   exitError,
+			wantStderr: "Usage: taxctl import [flags] <file.csv>",
+		},
+		{
+			name:       "Export JSON",
+			command:    commandExport,
+			args:       []string{"-format", "json"},
+			api:        &fakeAPI{},
+			wantCode:   exitOK,
+			wantStdout: []string{"[\n  {\n    \"name\": \"Big Mac\",\n"},
+		},
+		{
+			name:       "Export Failed",
+			command:    commandExport,
+			api:        &fakeAPI{err: client.ErrTooManyRequests},
+			wantCode:   exitFailed,
+			wantStderr: "Failed to get the bill: 429 Too Many Requests\n",
+		},
+		{
+			name:       "Unknown Command",
+			command:    "remove",
+			api:        &fakeAPI{},
+			wantCode:   exitError,
+			wantStderr: "Unknown command: remove\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctl, stdout, stderr := newTestTaxctl(tt.api, tt.stdin)
+			assert.Equal(t, tt.wantCode, ctl.run(tt.command, tt.args))
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+			if tt.wantStderr == "" {
+				assert.Empty(t, stderr.String())
+			} else {
+				assert.Contains(t, stderr.String(), tt.wantStderr)
+			}
+			assert.Equal(t, tt.wantCreated, tt.api.created)
+		})
+	}
+}
+
+func TestTaxctl_ExportImport(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "taxctl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bill.csv")
+
+	//The exported lines of the bill are imported again as the same tax objects.
+	ctl, stdout, stderr := newTestTaxctl(&fakeAPI{}, "")
+	if !assert.Equal(t, exitOK, ctl.run(commandExport, []string{"-output", path}), stderr.String()) {
+		return
+	}
+	assert.Empty(t, stdout.String())
+	api := &fakeAPI{}
+	ctl, _, stderr = newTestTaxctl(api, "")
+	assert.Equal(t, exitOK, ctl.run(commandImport, []string{path}), stderr.String())
+	assert.Equal(t, []client.TaxObject{
+		{
+			Name:      "Big Mac",
+			TaxCode:   1,
+			Quantity:  2,
+			UnitPrice: 1000,
+			Price:     2000,
+			Discount:  &client.Discount{Type: "fixed", Value: 100},
+		},
+		{
+			Name:         "Movie",
+			TaxCode:      3,
+			Jurisdiction: "TEST",
+			Quantity:     1.5,
+			UnitPrice:    150,
+			Price:        225,
+			TaxInclusive: true,
+		},
+		{
+			Name:      "Lucky Stretch",
+			TaxCode:   2,
+			Quantity:  1,
+			UnitPrice: 1000,
+			Price:     1000,
+			Taxes:     []client.Tax{{TaxCode: 1, Compound: true}},
+		},
+	}, api.created)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/pkg/client"
+)
+
+//The client has its own models, so they are converted to the models read and written by the formats of the tax calculator.
+
+//toClientTaxObject return the tax object sent by the client.
+func toClientTaxObject(taxObject taxobj.TaxObject) client.TaxObject {
+	converted := client.TaxObject{
+		ID:           taxObject.ID,
+		Name:         taxObject.Name,
+		TaxCode:      taxObject.TaxCode,
+		Jurisdiction: taxObject.Jurisdiction,
+		Quantity:     taxObject.Quantity,
+		UnitPrice:    taxObject.UnitPrice,
+		Price:        taxObject.Price,
+		TaxInclusive: taxObject.TaxInclusive,
+		BillID:       taxObject.BillID,
+	}
+	for _, tax := range taxObject.Taxes {
+		converted.Taxes = append(converted.Taxes, client.Tax(tax))
+	}
+	if taxObject.Discount != nil {
+		discount := client.Discount(*taxObject.Discount)
+		converted.Discount = &discount
+	}
+	return converted
+}
+
+//fromClientTaxObject return the tax object returned by the client.
+func fromClientTaxObject(taxObject client.TaxObject) taxobj.TaxObject {
+	converted := taxobj.TaxObject{
+		ID:           taxObject.ID,
+		Name:         taxObject.Name,
+		TaxCode:      taxObject.TaxCode,
+		Jurisdiction: taxObject.Jurisdiction,
+		Quantity:     taxObject.Quantity,
+		UnitPrice:    taxObject.UnitPrice,
+		Price:        taxObject.Price,
+		TaxInclusive: taxObject.TaxInclusive,
+		BillID:       taxObject.BillID,
+	}
+	for _, tax := range taxObject.Taxes {
+		converted.Taxes = append(converted.Taxes, taxobj.Tax(tax))
+	}
+	if taxObject.Discount != nil {
+		discount := taxobj.Discount(*taxObject.Discount)
+		converted.Discount = &discount
+	}
+	return converted
+}
+
+//fromClientBills return the lines of the bill returned by the client without their explanations.
+func fromClientBills(bills []client.Bill) []bill.Bill {
+	converted := make([]bill.Bill, len(bills))
+	for index, line := range bills {
+		converted[index] = bill.Bill{
+			Name:         line.Name,
+			TaxCode:      line.TaxCode,
+			Jurisdiction: line.Jurisdiction,
+			Type:         line.Type,
+			Refundable:   line.Refundable,
+			Quantity:     line.Quantity,
+			UnitPrice:    line.UnitPrice,
+			Price:        line.Price,
+			Discount:     line.Discount,
+			Coupon:       line.Coupon,
+			TaxInclusive: line.TaxInclusive,
+			TaxableBase:  line.TaxableBase,
+			Tax:          line.Tax,
+			Amount:       line.Amount,
+		}
+		for _, component := range line.Taxes {
+			converted[index].Taxes = append(converted[index].Taxes, bill.TaxComponent(component))
+		}
+	}
+	return converted
+}
+
+//fromClientTotal return the total of the bill returned by the client.
+func fromClientTotal(total client.Total) bill.Total {
+	converted := bill.Total{
+		PriceSubtotal:     total.PriceSubtotal,
+		DiscountSubtotal:  total.DiscountSubtotal,
+		CouponSubtotal:    total.CouponSubtotal,
+		NetSubtotal:       total.NetSubtotal,
+		TaxSubtotal:       total.TaxSubtotal,
+		ChargeSubtotal:    total.ChargeSubtotal,
+		ChargeTaxSubtotal: total.ChargeTaxSubtotal,
+		GrandTotal:        total.GrandTotal,
+	}
+	for _, summary := range total.Taxes {
+		converted.Taxes = append(converted.Taxes, bill.TaxSummary(summary))
+	}
+	return converted
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/pkg/client"
+)
+
+const (
+	//defaultURL is the url of the API if it's neither given by the flag nor by the environment.
+	defaultURL = "http://localhost:8080"
+	//defaultTimeout is the timeout of every command.
+	defaultTimeout = 30 * time.Second
+)
+
+//The environment variables configuring the client, the flags take precedence over them.
+const (
+	envURL    = "TAXCTL_URL"
+	envAPIKey = "TAXCTL_API_KEY"
+	envToken  = "TAXCTL_TOKEN"
+)
+
+//Exit codes of the commands.
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitError  = 2
+)
+
+const usage = `Usage: taxctl [flags] <command> [command flags] [arguments]
+
+Commands:
+  add      Add the tax object to the open bill.
+  bill     Print the open bill.
+  import   Add the tax objects of the CSV file to the open bill.
+  export   Export the lines of the open bill.
+
+Flags:
+`
+
+//main runs the command of the tax calculator API given in the arguments.
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	baseURL := flag.String("url", getEnv(envURL, defaultURL), "The url of the API, or $"+envURL+".")
+	apiKey := flag.String("api-key", os.Getenv(envAPIKey), "The api key of the client, or $"+envAPIKey+".")
+	token := flag.String("token", os.Getenv(envToken), "The bearer token used if the api key is empty, or $"+envToken+".")
+	timeout := flag.Duration("timeout", defaultTimeout, "The timeout of the command.")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(exitError)
+	}
+	ctl := &taxctl{
+		api: client.New(*baseURL, client.Options{
+			APIKey: *apiKey,
+			Token:  *token,
+		}),
+		timeout: *timeout,
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+	}
+	os.Exit(ctl.run(flag.Arg(0), flag.Args()[1:]))
+}
+
+//getEnv return the environment variable, or the fallback if it's empty.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+)
+
+//The formats of the output.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+//format define the format flag of the command.
+type format struct {
+	value string
+}
+
+//formatFlag define the format flag of the flag set with the default format.
+func formatFlag(flags *flag.FlagSet, defaultFormat string) *format {
+	format := &format{}
+	flags.StringVar(&format.value, "format", defaultFormat, "The format of the output, i.e. table, json, or csv.")
+	return format
+}
+
+//valid return true if the format is known, otherwise it prints the error.
+func (format *format) valid(stderr io.Writer) bool {
+	switch format.value {
+	case formatTable, formatJSON, formatCSV:
+		return true
+	}
+	fmt.Fprintf(stderr, "Unknown format: %s\n", format.value)
+	return false
+}
+
+//writeJSON write the value as the indented JSON.
+func writeJSON(writer io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+//writeTaxObjectTable write the tax objects as the table aligned for the terminal.
+func writeTaxObjectTable(writer io.Writer, taxObjects []taxobj.TaxObject) error {
+	tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "ID\tNAME\tTAX CODE\tJURISDICTION\tQUANTITY\tUNIT PRICE\tPRICE\tTAX INCLUSIVE\t")
+	for _, taxObject := range taxObjects {
+		fmt.Fprintf(tableWriter, "%d\t%s\t%d\t%s\t%s\t%.2f\t%.2f\t%t\t\n",
+			taxObject.ID,
+			taxObject.Name,
+			taxObject.TaxCode,
+			taxObject.Jurisdiction,
+			strconv.FormatFloat(taxObject.Quantity, 'f', -1, 64),
+			taxObject.UnitPrice,
+			taxObject.Price,
+			taxObject.TaxInclusive,
+		)
+	}
+	return tableWriter.Flush()
+}
@@ -3,20 +3,41 @@ package app
 import (
 	"context"
 	"database/sql"
+	"net"
 	"os"
 	"time"
 
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	auditDelivery "github.com/fairyhunter13/tax-calculator/internal/audit/delivery"
+	auditRepository "github.com/fairyhunter13/tax-calculator/internal/audit/repository"
+	auditUsecase "github.com/fairyhunter13/tax-calculator/internal/audit/usecase"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	authDelivery "github.com/fairyhunter13/tax-calculator/internal/auth/delivery"
+	authRepository "github.com/fairyhunter13/tax-calculator/internal/auth/repository"
+	authUsecase "github.com/fairyhunter13/tax-calculator/internal/auth/usecase"
+
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
 	billDelivery "github.com/fairyhunter13/tax-calculator/internal/bill/delivery"
 	billRepository "github.com/fairyhunter13/tax-calculator/internal/bill/repository"
 	billUsecase "github.com/fairyhunter13/tax-calculator/internal/bill/usecase"
 
+	"github.com/fairyhunter13/tax-calculator/internal/limit"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/metrics"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
 	taxDelivery "github.com/fairyhunter13/tax-calculator/internal/taxobj/delivery"
 	taxRepository "github.com/fairyhunter13/tax-calculator/internal/taxobj/repository"
 	taxUsecase "github.com/fairyhunter13/tax-calculator/internal/taxobj/usecase"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
 
 	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	ini "gopkg.in/ini.v1"
 
 	//Using the pq library for the database.
@@ -25,19 +46,35 @@ import (
 
 //App defines the group of connection, config, repository, usecase, and etc.
 type App struct {
-	config    *Config
-	pool      *sql.DB
-	billRepo  bill.Repository
-	billUcase bill.Usecase
-	taxRepo   taxobj.Repository
-	taxUcase  taxobj.Usecase
-	echoMux   *echo.Echo
+	config      *Config
+	pool        *sql.DB
+	billRepo    bill.Repository
+	billUcase   bill.Usecase
+	invoiceRepo bill.InvoiceRepository
+	taxRepo     taxobj.Repository
+	taxUcase    taxobj.Usecase
+	authRepo    auth.Repository
+	authUcase   auth.Usecase
+	auditRepo   audit.Repository
+	auditUcase  audit.Usecase
+	echoMux     *echo.Echo
+	registry    *prometheus.Registry
+	log         *logrus.Logger
+	tracer      *sdktrace.TracerProvider
 }
 
 //Config define all configs needed to store configured variables.
 type Config struct {
 	Database
 	Server
+	Metrics
+	Log
+	Tracing
+	Auth
+	Policy
+	Limit
+	Retention
+	Tax
 }
 
 //Database define the config for conection string.
@@ -50,6 +87,82 @@ type Server struct {
 	Port string `ini:"port"`
 }
 
+//Metrics define the config for exposing the prometheus metrics.
+type Metrics struct {
+	Enabled bool   `ini:"enabled"`
+	Path    string `ini:"path"`
+}
+
+//Log define the config for the level and the output format of the logger.
+type Log struct {
+	Level  string `ini:"level"`
+	Format string `ini:"format"`
+}
+
+//Tracing define the config for exporting the OpenTelemetry spans.
+//The exporter can be none, stdout, or otlp.
+type Tracing struct {
+	Exporter    string `ini:"exporter"`
+	Endpoint    string `ini:"endpoint"`
+	Insecure    bool   `ini:"insecure"`
+	ServiceName string `ini:"service_name"`
+}
+
+//Auth define the config for authenticating the clients.
+//The admin key hash is the hex encoded SHA-256 hash of the key managing the api keys.
+//The JWT bearer tokens are verified using the shared secret or the public keys in the JWKS file.
+type Auth struct {
+	Enabled      bool   `ini:"enabled"`
+	AdminKeyHash string `ini:"admin_key_hash"`
+	JWTSecret    string `ini:"jwt_secret"`
+	JWKSFile     string `ini:"jwks_file"`
+	JWTIssuer    string `ini:"jwt_issuer"`
+	JWTAudience  string `ini:"jwt_audience"`
+}
+
+//Policy define the config for the roles granted for every permission.
+type Policy struct {
+	CreateTax    []string `ini:"create_tax" delim:","`
+	PreviewTax   []string `ini:"preview_tax" delim:","`
+	UpdateTax    []string `ini:"update_tax" delim:","`
+	DeleteTax    []string `ini:"delete_tax" delim:","`
+	ReadBill     []string `ini:"read_bill" delim:","`
+	FinalizeBill []string `ini:"finalize_bill" delim:","`
+	RefundBill   []string `ini:"refund_bill" delim:","`
+	DiscountBill []string `ini:"discount_bill" delim:","`
+	ChargeBill   []string `ini:"charge_bill" delim:","`
+	ReadAudit    []string `ini:"read_audit" delim:","`
+}
+
+//Limit define the config for limiting the requests of every client.
+//The requests of every IP address are limited before the authentication by the IP rate,
+//and the requests of every authenticated principal are limited by the rate.
+//The forwarded IP address is only used if the request is sent by the trusted proxies.
+//The rate limiting is disabled if the rate is 0, the same goes for the other limits.
+type Limit struct {
+	Rate           float64  `ini:"rate"`
+	Burst          int      `ini:"burst"`
+	IPRate         float64  `ini:"ip_rate"`
+	IPBurst        int      `ini:"ip_burst"`
+	TrustedProxies []string `ini:"trusted_proxies" delim:","`
+	MaxBodyBytes   int64    `ini:"max_body_bytes"`
+	MaxBillLines   int      `ini:"max_bill_lines"`
+}
+
+//Retention define the config for purging the deleted tax objects.
+//The deleted tax objects are purged permanently after the period, checked every interval.
+//The purge is disabled if the period is 0.
+type Retention struct {
+	Period   time.Duration `ini:"period"`
+	Interval time.Duration `ini:"interval"`
+}
+
+//Tax define the config for the tax rules.
+//The jurisdictions file defines the rules of the jurisdictions other than the default jurisdiction.
+type Tax struct {
+	JurisdictionsFile string `ini:"jurisdictions_file"`
+}
+
 //NewApp return the new defined App
 func NewApp() *App {
 	return new(App)
@@ -61,10 +174,28 @@ func (app *App) Migrate() (err error) {
 	if err != nil {
 		return
 	}
-	err = app.billUcase.LoadData()
+	err = app.authRepo.Migrate()
+	if err != nil {
+		return
+	}
+	err = app.auditRepo.Migrate()
+	if err != nil {
+		return
+	}
+	//The bills are migrated after the tax objects, so the existing tax objects are bound to the open bills.
+	err = app.invoiceRepo.Migrate()
+	if err != nil {
+		return
+	}
+	err = app.billUcase.LoadData(context.Background())
 	return
 }
 
+//VerifyAuditChain verifies the hash chains of the audit events of all tenants.
+func (app *App) VerifyAuditChain(ctx context.Context) (verification audit.Verification, err error) {
+	return app.auditUcase.VerifyChain(ctx)
+}
+
 //ParseConfig parse config defined in the path to the given struct.
 func (app *App) ParseConfig(configPath string, appConfig *Config) (err error) {
 	err = ini.MapTo(appConfig, configPath)
@@ -78,20 +209,218 @@ func (app *App) SetConfig(config *Config) {
 
 //Init begin the initialization of application.
 //This process initialize all connection, usecase, repositories, config, and etc.
-func (app *App) Init(pool *sql.DB) {
+//The error is returned if the jurisdictions file or the JWKS file in the config can't be loaded,
+//or if the trusted proxies in the config are invalid, or if the metrics can't be registered.
+func (app *App) Init(pool *sql.DB) (err error) {
 	app.pool = pool
-	app.billRepo = billRepository.NewCacheRepository()
-	app.taxRepo = taxRepository.NewPqRepository(app.pool)
-	app.billUcase = billUsecase.NewBillUsecase(app.billRepo, app.taxRepo)
-	app.taxUcase = taxUsecase.NewTaxObjectUsecase(app.taxRepo, app.billRepo)
+	app.initLogger()
+	rules, err := app.initJurisdictions()
+	if err != nil {
+		return
+	}
+	jwtKeys, err := app.jwtKeys()
+	if err != nil {
+		return
+	}
+	trustedProxies, err := app.trustedProxies()
+	if err != nil {
+		return
+	}
+	app.billRepo = billRepository.NewCacheRepository(rules, app.log)
+	//The usecases changing the bill share the locker, so every change of the tenant is applied to the cache in order.
+	locker := tenant.NewLocker()
+	app.auditRepo = auditRepository.NewPqRepository(app.pool, app.log)
+	app.auditUcase = auditUsecase.NewAuditUsecase(app.auditRepo, app.log)
+	app.invoiceRepo = billRepository.NewPqRepository(app.pool, rules, app.auditRepo, app.log)
+	app.taxRepo = taxRepository.NewPqRepository(app.pool, app.invoiceRepo, app.auditRepo, app.log)
+	app.billUcase = billUsecase.NewBillUsecase(app.billRepo, app.taxRepo, app.invoiceRepo, locker, app.log)
+	app.taxUcase = taxUsecase.NewTaxObjectUsecase(app.taxRepo, app.billRepo, app.maxBillLines(), locker, app.log)
+	app.authRepo = authRepository.NewPqRepository(app.pool, app.log)
+	app.authUcase = authUsecase.NewAuthUsecase(app.authRepo, jwtKeys, app.log)
 	app.echoMux = echo.New()
-	billDelivery.NewHTTPBillHandler(app.echoMux, app.billUcase)
-	taxDelivery.NewTaxObjectHandler(app.echoMux, app.taxUcase)
+	app.initTracing()
+	app.echoMux.Use(logger.Middleware(app.log))
+	if err = app.initMetrics(); err != nil {
+		return
+	}
+	app.initLimit(trustedProxies)
+	guard := app.guard()
+	billDelivery.NewHTTPBillHandler(app.echoMux, app.billUcase, rules, app.log, guard)
+	taxDelivery.NewTaxObjectHandler(app.echoMux, app.taxUcase, app.billUcase, rules, app.log, guard)
+	auditDelivery.NewHTTPAuditHandler(app.echoMux, app.auditUcase, app.log, guard)
+	authDelivery.NewAPIKeyHandler(app.echoMux, app.authUcase, app.log, authDelivery.AdminMiddleware(app.adminKeyHash(), app.log))
+	return
+}
+
+//initJurisdictions return the rules of the default jurisdiction and the jurisdictions in the jurisdictions file of the config.
+//The rules are given to the repositories and the handlers, so the bills are calculated, validated, and explained by the same rules.
+//The error is returned if the file can't be loaded, so the application doesn't start with only the default jurisdiction.
+func (app *App) initJurisdictions() (rules taxcalc.Jurisdictions, err error) {
+	if app.config == nil || app.config.Tax.JurisdictionsFile == "" {
+		rules = taxcalc.NewJurisdictions()
+		return
+	}
+	jurisdictions, err := taxUsecase.LoadJurisdictions(app.config.Tax.JurisdictionsFile)
+	if err != nil {
+		app.log.WithError(err).Error("[App] Failed to load the jurisdictions file")
+		return
+	}
+	rules = taxcalc.NewJurisdictions(jurisdictions...)
+	return
+}
+
+//jwtKeys return the keys to verify the JWT bearer tokens based on the config.
+//The error is returned if the JWKS file can't be loaded, so the tokens signed by the public keys aren't silently rejected.
+func (app *App) jwtKeys() (keys authUsecase.JWTKeys, err error) {
+	if app.config == nil {
+		return
+	}
+	keys = authUsecase.JWTKeys{
+		Secret:   []byte(app.config.Auth.JWTSecret),
+		Issuer:   app.config.Auth.JWTIssuer,
+		Audience: app.config.Auth.JWTAudience,
+	}
+	if app.config.Auth.JWKSFile == "" {
+		return
+	}
+	public, err := authUsecase.LoadJWKS(app.config.Auth.JWKSFile)
+	if err != nil {
+		app.log.WithError(err).Error("[App] Failed to load the JWKS file")
+		return
+	}
+	keys.Public = public
+	return
+}
+
+//adminKeyHash return the hash of the admin key in the config.
+func (app *App) adminKeyHash() string {
+	if app.config == nil {
+		return ""
+	}
+	return app.config.Auth.AdminKeyHash
+}
+
+//guard return the guard authenticating and authorizing the requests to the bill and tax routes.
+//The authentication is disabled if there is no config, e.g. in the tests.
+func (app *App) guard() authDelivery.Guard {
+	if app.config == nil || !app.config.Auth.Enabled {
+		return nil
+	}
+	policy := auth.Policy{
+		auth.PermissionCreateTax:    app.config.Policy.CreateTax,
+		auth.PermissionPreviewTax:   app.config.Policy.PreviewTax,
+		auth.PermissionUpdateTax:    app.config.Policy.UpdateTax,
+		auth.PermissionDeleteTax:    app.config.Policy.DeleteTax,
+		auth.PermissionReadBill:     app.config.Policy.ReadBill,
+		auth.PermissionFinalizeBill: app.config.Policy.FinalizeBill,
+		auth.PermissionRefundBill:   app.config.Policy.RefundBill,
+		auth.PermissionDiscountBill: app.config.Policy.DiscountBill,
+		auth.PermissionChargeBill:   app.config.Policy.ChargeBill,
+		auth.PermissionReadAudit:    app.config.Policy.ReadAudit,
+	}
+	if app.config.Limit.Rate > 0 {
+		limiter := limit.NewRateLimiter(app.config.Limit.Rate, app.config.Limit.Burst)
+		return authDelivery.NewGuard(app.authUcase, policy, app.log, limit.RateLimit(limiter, authDelivery.PrincipalKey, app.log))
+	}
+	return authDelivery.NewGuard(app.authUcase, policy, app.log)
+}
+
+//maxBillLines return the maximum lines of the bill of every tenant in the config.
+func (app *App) maxBillLines() int {
+	if app.config == nil {
+		return 0
+	}
+	return app.config.Limit.MaxBillLines
+}
+
+//trustedProxies return the networks of the trusted proxies in the config.
+func (app *App) trustedProxies() (trusted []*net.IPNet, err error) {
+	if app.config == nil {
+		return
+	}
+	return limit.ParseTrustedProxies(app.config.Limit.TrustedProxies)
+}
+
+//initLimit limit the rate of every IP address and the body size of the requests if they are enabled in the config.
+//The rate of every principal is limited by the guard after the authentication.
+//The requests are not limited if there is no config, e.g. in the tests.
+func (app *App) initLimit(trustedProxies []*net.IPNet) {
+	if app.config == nil {
+		return
+	}
+	if app.config.Limit.IPRate > 0 {
+		limiter := limit.NewRateLimiter(app.config.Limit.IPRate, app.config.Limit.IPBurst)
+		app.echoMux.Use(limit.RateLimit(limiter, limit.IPKey(trustedProxies), app.log))
+	}
+	if app.config.Limit.MaxBodyBytes > 0 {
+		app.echoMux.Use(limit.BodyLimit(app.config.Limit.MaxBodyBytes, app.log))
+	}
+}
+
+//initLogger creates the logger based on the config.
+//The logger discards all logs if there is no config, e.g. in the tests.
+func (app *App) initLogger() {
+	if app.config == nil {
+		app.log = logger.Discard()
+		return
+	}
+	app.log = logger.New(app.config.Log.Level, app.config.Log.Format)
+}
+
+//Logger return the logger of the application.
+func (app *App) Logger() logrus.FieldLogger {
+	return app.log
+}
+
+//initTracing creates the tracer provider and traces every request if it is enabled in the config.
+//The tracing is disabled if there is no config, e.g. in the tests.
+func (app *App) initTracing() {
+	if app.config == nil {
+		return
+	}
+	tracer, err := tracing.NewProvider(context.Background(), tracing.Options{
+		Exporter:    app.config.Tracing.Exporter,
+		Endpoint:    app.config.Tracing.Endpoint,
+		Insecure:    app.config.Tracing.Insecure,
+		ServiceName: app.config.Tracing.ServiceName,
+	})
+	if err != nil {
+		app.log.WithError(err).Error("[App] Failed to create the tracer provider, tracing is disabled")
+		return
+	}
+	if tracer == nil {
+		return
+	}
+	app.tracer = tracer
+	tracing.Register(app.tracer)
+	app.echoMux.Use(tracing.Middleware())
+}
+
+//initMetrics registers the metrics to the registry of the app, or to a new registry if it isn't set,
+//and exposes them if it is enabled in the config.
+//The metrics are disabled if there is no config, e.g. in the tests.
+//The error is returned if any collector can't be registered, so the exposed metrics are never incomplete.
+func (app *App) initMetrics() (err error) {
+	if app.config == nil || !app.config.Metrics.Enabled {
+		return
+	}
+	if app.registry == nil {
+		app.registry = prometheus.NewRegistry()
+	}
+	if err = metrics.Register(app.registry); err != nil {
+		app.log.WithError(err).Error("[App] Failed to register the metrics")
+		return
+	}
+	app.echoMux.Use(metrics.Middleware())
+	app.echoMux.GET(app.config.Metrics.Path, echo.WrapHandler(metrics.Handler(app.registry)))
 	return
 }
 
 //Run run the application using graceful shutdown
 func (app *App) Run(osSignal chan os.Signal) (err error) {
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go app.runRetention(jobCtx)
 	go func() {
 		if err = app.echoMux.Start(app.config.Server.Port); err != nil {
 			return
@@ -106,8 +435,39 @@ func (app *App) Run(osSignal chan os.Signal) (err error) {
 	return
 }
 
+//runRetention purges the deleted tax objects older than the retention period every interval until ctx is done.
+//The failed purge is logged and retried in the next interval.
+func (app *App) runRetention(ctx context.Context) {
+	if app.config == nil || app.config.Retention.Period <= 0 {
+		return
+	}
+	interval := app.config.Retention.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		app.taxUcase.PurgeTaxObjects(ctx, app.config.Retention.Period)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 //Close closes the app and all connections.
 func (app *App) Close() {
 	app.taxRepo.Close()
+	app.authRepo.Close()
+	app.auditRepo.Close()
+	app.invoiceRepo.Close()
 	app.pool.Close()
+	if app.tracer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		//Flush the remaining spans before exiting.
+		app.tracer.Shutdown(ctx)
+	}
 }
@@ -41,10 +41,10 @@ func TestApp_Run(t *testing.T) {
 			fields: fields{
 				echoMux: echo.New(),
 				config: &Config{
-					Database{
+					Database: Database{
 						ConnectionString: "",
 					},
-					Server{
+					Server: Server{
 						Port: ":8080",
 					},
 				},
@@ -3,18 +3,34 @@
 package app
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	mocksAudit "github.com/fairyhunter13/tax-calculator/internal/audit/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	mocksAuth "github.com/fairyhunter13/tax-calculator/internal/auth/mocks"
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
 	mocksBill "github.com/fairyhunter13/tax-calculator/internal/bill/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/limit"
+	"github.com/fairyhunter13/tax-calculator/internal/metrics"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
 	mocksTax "github.com/fairyhunter13/tax-calculator/internal/taxobj/mocks"
 	"github.com/labstack/echo"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
@@ -24,13 +40,16 @@ var (
 func TestApp_Migrate(t *testing.T) {
 	t.Parallel()
 	type fields struct {
-		config    *Config
-		pool      *sql.DB
-		billRepo  bill.Repository
-		billUcase bill.Usecase
-		taxRepo   taxobj.Repository
-		taxUcase  taxobj.Usecase
-		echoMux   *echo.Echo
+		config      *Config
+		pool        *sql.DB
+		billRepo    bill.Repository
+		billUcase   bill.Usecase
+		invoiceRepo bill.InvoiceRepository
+		taxRepo     taxobj.Repository
+		taxUcase    taxobj.Usecase
+		authRepo    auth.Repository
+		auditRepo   audit.Repository
+		echoMux     *echo.Echo
 	}
 	tests := []struct {
 		name    string
@@ -44,14 +63,74 @@ func TestApp_Migrate(t *testing.T) {
 				allFields := fields{}
 				taxRepo := &mocksTax.Repository{}
 				taxRepo.On("Migrate").Return(nil)
+				authRepo := &mocksAuth.Repository{}
+				authRepo.On("Migrate").Return(nil)
+				auditRepo := &mocksAudit.Repository{}
+				auditRepo.On("Migrate").Return(nil)
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("Migrate").Return(nil)
 				billUcase := &mocksBill.Usecase{}
-				billUcase.On("LoadData").Return(nil)
+				billUcase.On("LoadData", mock.Anything).Return(nil)
 				allFields.billUcase = billUcase
 				allFields.taxRepo = taxRepo
+				allFields.authRepo = authRepo
+				allFields.auditRepo = auditRepo
+				allFields.invoiceRepo = invoiceRepo
 				return allFields
 			},
 			wantErr: false,
 		},
+		{
+			name: "Invoice Repository Migrate Error",
+			fields: func() fields {
+				allFields := fields{}
+				taxRepo := &mocksTax.Repository{}
+				taxRepo.On("Migrate").Return(nil)
+				authRepo := &mocksAuth.Repository{}
+				authRepo.On("Migrate").Return(nil)
+				auditRepo := &mocksAudit.Repository{}
+				auditRepo.On("Migrate").Return(nil)
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("Migrate").Return(errMigrate)
+				allFields.taxRepo = taxRepo
+				allFields.authRepo = authRepo
+				allFields.auditRepo = auditRepo
+				allFields.invoiceRepo = invoiceRepo
+				return allFields
+			},
+			wantErr: true,
+		},
+		{
+			name: "Audit Repository Migrate Error",
+			fields: func() fields {
+				allFields := fields{}
+				taxRepo := &mocksTax.Repository{}
+				taxRepo.On("Migrate").Return(nil)
+				authRepo := &mocksAuth.Repository{}
+				authRepo.On("Migrate").Return(nil)
+				auditRepo := &mocksAudit.Repository{}
+				auditRepo.On("Migrate").Return(errMigrate)
+				allFields.taxRepo = taxRepo
+				allFields.authRepo = authRepo
+				allFields.auditRepo = auditRepo
+				return allFields
+			},
+			wantErr: true,
+		},
+		{
+			name: "Auth Repository Migrate Error",
+			fields: func() fields {
+				allFields := fields{}
+				taxRepo := &mocksTax.Repository{}
+				taxRepo.On("Migrate").Return(nil)
+				authRepo := &mocksAuth.Repository{}
+				authRepo.On("Migrate").Return(errMigrate)
+				allFields.taxRepo = taxRepo
+				allFields.authRepo = authRepo
+				return allFields
+			},
+			wantErr: true,
+		},
 		{
 			name: "Tax Repository Migrate Error",
 			fields: func() fields {
@@ -68,13 +147,16 @@ func TestApp_Migrate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			fields := tt.fields()
 			app := &App{
-				config:    fields.config,
-				pool:      fields.pool,
-				billRepo:  fields.billRepo,
-				billUcase: fields.billUcase,
-				taxRepo:   fields.taxRepo,
-				taxUcase:  fields.taxUcase,
-				echoMux:   fields.echoMux,
+				config:      fields.config,
+				pool:        fields.pool,
+				billRepo:    fields.billRepo,
+				billUcase:   fields.billUcase,
+				invoiceRepo: fields.invoiceRepo,
+				taxRepo:     fields.taxRepo,
+				taxUcase:    fields.taxUcase,
+				authRepo:    fields.authRepo,
+				auditRepo:   fields.auditRepo,
+				echoMux:     fields.echoMux,
 			}
 			if err := app.Migrate(); (err != nil) != tt.wantErr {
 				t.Errorf("App.Migrate() error = %v, wantErr %v", err, tt.wantErr)
@@ -142,6 +224,24 @@ func TestApp_ParseConfig(t *testing.T) {
 	}
 }
 
+func TestApp_ParseConfig_Policy(t *testing.T) {
+	t.Parallel()
+	config := &Config{}
+	if err := NewApp().ParseConfig("../../configs/config.ini", config); err != nil {
+		t.Fatalf("Error parsing the config: %s", err)
+	}
+	assert.Equal(t, []string{"clerk", "supervisor"}, config.Policy.CreateTax)
+	assert.Equal(t, []string{"supervisor"}, config.Policy.DeleteTax)
+	assert.Equal(t, []string{"clerk", "supervisor", "auditor"}, config.Policy.ReadBill)
+	assert.Equal(t, []string{"supervisor"}, config.Policy.FinalizeBill)
+	assert.Equal(t, []string{"supervisor"}, config.Policy.RefundBill)
+	assert.Equal(t, []string{"clerk", "supervisor"}, config.Policy.DiscountBill)
+	assert.Equal(t, []string{"clerk", "supervisor"}, config.Policy.ChargeBill)
+	assert.Equal(t, []string{"clerk", "supervisor"}, config.Policy.PreviewTax)
+	assert.Equal(t, 2160*time.Hour, config.Retention.Period)
+	assert.Equal(t, time.Hour, config.Retention.Interval)
+}
+
 func TestApp_SetConfig(t *testing.T) {
 	t.Parallel()
 	type fields struct {
@@ -201,9 +301,10 @@ func TestApp_Init(t *testing.T) {
 		pool *sql.DB
 	}
 	tests := []struct {
-		name   string
-		fields fields
-		args   args
+		name         string
+		fields       fields
+		args         args
+		wantRegistry bool
 	}{
 		// TODO: Add test cases.
 		{
@@ -212,6 +313,22 @@ func TestApp_Init(t *testing.T) {
 			args: args{
 				pool: new(sql.DB),
 			},
+			wantRegistry: false,
+		},
+		{
+			name: "Initialize the application with metrics",
+			fields: fields{
+				config: &Config{
+					Metrics: Metrics{
+						Enabled: true,
+						Path:    "/metrics",
+					},
+				},
+			},
+			args: args{
+				pool: new(sql.DB),
+			},
+			wantRegistry: true,
 		},
 	}
 	for _, tt := range tests {
@@ -225,21 +342,228 @@ func TestApp_Init(t *testing.T) {
 				taxUcase:  tt.fields.taxUcase,
 				echoMux:   tt.fields.echoMux,
 			}
-			app.Init(tt.args.pool)
+			if assert.NoError(t, app.Init(tt.args.pool)) {
+				assert.Equal(t, tt.wantRegistry, app.registry != nil)
+			}
+		})
+	}
+}
+
+func TestApp_Init_DuplicateMetrics(t *testing.T) {
+	t.Parallel()
+	registry := prometheus.NewRegistry()
+	if err := metrics.Register(registry); err != nil {
+		t.Fatalf("Error registering the metrics: %s", err)
+	}
+	app := &App{
+		config: &Config{
+			Metrics: Metrics{
+				Enabled: true,
+				Path:    "/metrics",
+			},
+		},
+		registry: registry,
+	}
+	assert.Error(t, app.Init(new(sql.DB)))
+}
+
+func TestApp_Init_Auth(t *testing.T) {
+	t.Parallel()
+	app := &App{
+		config: &Config{
+			Auth: Auth{
+				Enabled: true,
+			},
+		},
+	}
+	if err := app.Init(new(sql.DB)); err != nil {
+		t.Fatalf("Error initializing the application: %s", err)
+	}
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/bill", nil),
+		httptest.NewRequest(http.MethodPost, "/tax", nil),
+		httptest.NewRequest(http.MethodGet, "/admin/apikeys", nil),
+		httptest.NewRequest(http.MethodGet, "/audit", nil),
+	} {
+		rec := httptest.NewRecorder()
+		app.echoMux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestApp_Init_Limit(t *testing.T) {
+	t.Parallel()
+	app := &App{
+		config: &Config{
+			Limit: Limit{
+				IPRate:       1,
+				IPBurst:      1,
+				MaxBodyBytes: 16,
+			},
+		},
+	}
+	if err := app.Init(new(sql.DB)); err != nil {
+		t.Fatalf("Error initializing the application: %s", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(`{"name":"Lucky Stretch"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	app.echoMux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/tax", nil)
+	rec = httptest.NewRecorder()
+	app.echoMux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(limit.HeaderRetryAfter))
+}
+
+func TestApp_Init_Jurisdictions(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "jurisdictions")
+	if err != nil {
+		t.Fatalf("Error creating the temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jurisdictions.json")
+	content := `[{"code": "APP", "name": "App", "rules": {
+		"1": {"type": "Food & Beverage", "refundable": true, "rate": 5},
+		"2": {"type": "Tobacco", "fixed": 20, "rate": 5},
+		"3": {"type": "Entertainment", "rate": 1, "threshold": 100}
+	}}]`
+	if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Error writing the jurisdictions file: %s", err)
+	}
+	app := &App{
+		config: &Config{
+			Tax: Tax{
+				JurisdictionsFile: path,
+			},
+		},
+	}
+	if err = app.Init(new(sql.DB)); err != nil {
+		t.Fatalf("Error initializing the application: %s", err)
+	}
+	//The bills are calculated by the rules of the file given to the repositories, not by the registered rules.
+	app.billRepo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "Lucky Stretch", TaxCode: 2, Jurisdiction: "APP", Quantity: 1, UnitPrice: 1000, Price: 1000})
+	bills, _ := app.billRepo.GetAll(context.Background())
+	if assert.Len(t, bills, 1) {
+		assert.Equal(t, float64(70), bills[0].Tax)
+	}
+	//The handlers validate the jurisdictions by the same rules, so the jurisdiction of the file is found.
+	req := httptest.NewRequest(http.MethodPost, "/tax/preview", strings.NewReader(`{"name":"Lucky Stretch","tax_code":2,"jurisdiction":"APP","price":1000}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	app.echoMux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"tax":70`)
+}
+
+func TestApp_Init_InvalidConfig(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "invalid")
+	if err != nil {
+		t.Fatalf("Error creating the temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	invalid := filepath.Join(dir, "invalid.json")
+	if err = ioutil.WriteFile(invalid, []byte(`{"keys": [`), 0600); err != nil {
+		t.Fatalf("Error writing the invalid file: %s", err)
+	}
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		// TODO: Add test cases.
+		{
+			name:   "Missing Jurisdictions File",
+			config: &Config{Tax: Tax{JurisdictionsFile: filepath.Join(dir, "missing.json")}},
+		},
+		{
+			name:   "Invalid Jurisdictions File",
+			config: &Config{Tax: Tax{JurisdictionsFile: invalid}},
+		},
+		{
+			name:   "Missing JWKS File",
+			config: &Config{Auth: Auth{Enabled: true, JWKSFile: filepath.Join(dir, "missing.json")}},
+		},
+		{
+			name:   "Invalid JWKS File",
+			config: &Config{Auth: Auth{Enabled: true, JWKSFile: invalid}},
+		},
+		{
+			name:   "Invalid Trusted Proxy",
+			config: &Config{Limit: Limit{IPRate: 1, TrustedProxies: []string{"proxy"}}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			app := &App{config: tt.config}
+			assert.Error(t, app.Init(new(sql.DB)))
+			//The application isn't served if the config can't be loaded.
+			assert.Nil(t, app.echoMux)
 		})
 	}
 }
 
+func TestApp_RunRetention(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	taxUcase := &mocksTax.Usecase{}
+	taxUcase.On("PurgeTaxObjects", mock.Anything, 720*time.Hour).Return(int64(0), nil).Twice()
+	taxUcase.On("PurgeTaxObjects", mock.Anything, 720*time.Hour).Return(int64(0), nil).Run(func(mock.Arguments) {
+		cancel()
+	})
+	app := &App{
+		config: &Config{
+			Retention: Retention{
+				Period:   720 * time.Hour,
+				Interval: time.Millisecond,
+			},
+		},
+		taxUcase: taxUcase,
+	}
+	done := make(chan struct{})
+	go func() {
+		app.runRetention(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The retention job is not stopped")
+	}
+	taxUcase.AssertNumberOfCalls(t, "PurgeTaxObjects", 3)
+}
+
+func TestApp_RunRetention_Disabled(t *testing.T) {
+	t.Parallel()
+	taxUcase := &mocksTax.Usecase{}
+	app := &App{
+		config:   &Config{},
+		taxUcase: taxUcase,
+	}
+	app.runRetention(context.Background())
+	taxUcase.AssertNotCalled(t, "PurgeTaxObjects", mock.Anything, mock.Anything)
+}
+
 func TestApp_Close(t *testing.T) {
 	t.Parallel()
 	type fields struct {
-		config    *Config
-		pool      *sql.DB
-		billRepo  bill.Repository
-		billUcase bill.Usecase
-		taxRepo   taxobj.Repository
-		taxUcase  taxobj.Usecase
-		echoMux   *echo.Echo
+		config      *Config
+		pool        *sql.DB
+		billRepo    bill.Repository
+		billUcase   bill.Usecase
+		invoiceRepo bill.InvoiceRepository
+		taxRepo     taxobj.Repository
+		taxUcase    taxobj.Usecase
+		authRepo    auth.Repository
+		auditRepo   audit.Repository
+		echoMux     *echo.Echo
 	}
 	tests := []struct {
 		name   string
@@ -260,6 +584,15 @@ func TestApp_Close(t *testing.T) {
 				taxRepo := new(mocksTax.Repository)
 				taxRepo.On("Close")
 				fields.taxRepo = taxRepo
+				authRepo := new(mocksAuth.Repository)
+				authRepo.On("Close")
+				fields.authRepo = authRepo
+				auditRepo := new(mocksAudit.Repository)
+				auditRepo.On("Close")
+				fields.auditRepo = auditRepo
+				invoiceRepo := new(mocksBill.InvoiceRepository)
+				invoiceRepo.On("Close")
+				fields.invoiceRepo = invoiceRepo
 				return fields
 			},
 		},
@@ -268,13 +601,16 @@ func TestApp_Close(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			fields := tt.fields()
 			app := &App{
-				config:    fields.config,
-				pool:      fields.pool,
-				billRepo:  fields.billRepo,
-				billUcase: fields.billUcase,
-				taxRepo:   fields.taxRepo,
-				taxUcase:  fields.taxUcase,
-				echoMux:   fields.echoMux,
+				config:      fields.config,
+				pool:        fields.pool,
+				billRepo:    fields.billRepo,
+				billUcase:   fields.billUcase,
+				invoiceRepo: fields.invoiceRepo,
+				taxRepo:     fields.taxRepo,
+				taxUcase:    fields.taxUcase,
+				authRepo:    fields.authRepo,
+				auditRepo:   fields.auditRepo,
+				echoMux:     fields.echoMux,
 			}
 			app.Close()
 		})
@@ -0,0 +1,228 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+)
+
+//Actions recorded in the audit events.
+const (
+	//ActionCreate defines the event of creating the entity.
+	ActionCreate = "create"
+	//ActionUpdate defines the event of correcting the entity.
+	ActionUpdate = "update"
+	//ActionDelete defines the event of deleting the entity.
+	ActionDelete = "delete"
+	//ActionRestore defines the event of restoring the deleted entity.
+	ActionRestore = "restore"
+	//ActionPurge defines the event of permanently deleting the entity after the retention period.
+	ActionPurge = "purge"
+	//ActionFinalize defines the event of finalizing the bill into the invoice.
+	ActionFinalize = "finalize"
+	//ActionRefund defines the event of refunding the lines of the invoice with the credit note.
+	ActionRefund = "refund"
+)
+
+const (
+	//EntityTaxObject defines the tax object entity.
+	EntityTaxObject = "tax_object"
+	//EntityBill defines the bill entity.
+	EntityBill = "bill"
+	//EntityInvoice defines the invoice entity.
+	EntityInvoice = "invoice"
+	//ActorAnonymous defines the actor of the change made without the authenticated principal,
+	//e.g. if the authentication is disabled.
+	ActorAnonymous = "anonymous"
+)
+
+var (
+	//GenesisHash defines the previous hash of the first event in the chain of every tenant.
+	GenesisHash = strings.Repeat("0", sha256.Size*2)
+)
+
+//Event define the model for the audit event of a change to an entity.
+//Before is empty for the created entity and After is empty for the deleted entity.
+//The events of every tenant form a hash chain, every event includes the hash of the previous one.
+type Event struct {
+	ID        int64           `json:"id"`
+	Tenant    string          `json:"-"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+//ChainHead define the latest link of the hash chain of a tenant, published for the external anchoring.
+//The event id is 0 and the hash is GenesisHash if the chain is still empty.
+type ChainHead struct {
+	EventID int64  `json:"event_id"`
+	Hash    string `json:"hash"`
+}
+
+//BrokenLink define the first event whose link in the hash chain doesn't match.
+type BrokenLink struct {
+	Tenant  string `json:"tenant"`
+	EventID int64  `json:"event_id"`
+	Reason  string `json:"reason"`
+}
+
+//Verification define the result of walking the hash chains of all tenants.
+//Broken is nil if all chains are intact.
+type Verification struct {
+	Tenants int         `json:"tenants"`
+	Events  int         `json:"events"`
+	Broken  *BrokenLink `json:"broken,omitempty"`
+}
+
+//Filter define the filter of the audit events.
+//The empty fields don't filter the events.
+type Filter struct {
+	Actor    string
+	Action   string
+	Entity   string
+	EntityID int64
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+//NewEvent return the audit event of the change made by the principal in ctx.
+//The before and after values are nil if the entity doesn't exist before or after the change.
+func NewEvent(ctx context.Context, action string, entity string, entityID int64, before interface{}, after interface{}) (event Event, err error) {
+	event = Event{
+		Tenant:    tenant.FromContext(ctx),
+		Actor:     ActorAnonymous,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		RequestID: logger.RequestID(ctx),
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		event.Actor = principal.Subject
+	}
+	if event.Before, err = marshal(before); err != nil {
+		return
+	}
+	event.After, err = marshal(after)
+	return
+}
+
+//marshal return the JSON of the value, or nil if the value is nil.
+func marshal(value interface{}) (raw json.RawMessage, err error) {
+	if value == nil {
+		return
+	}
+	return json.Marshal(value)
+}
+
+//ComputeHash return the hash of the event chained to its previous hash.
+//The JSON values are canonicalized, so the hash doesn't depend on how the database formats them.
+func (event Event) ComputeHash() (hash string, err error) {
+	before, err := canonical(event.Before)
+	if err != nil {
+		return
+	}
+	after, err := canonical(event.After)
+	if err != nil {
+		return
+	}
+	content, err := json.Marshal([]interface{}{
+		event.PrevHash,
+		event.Tenant,
+		event.Actor,
+		event.Action,
+		event.Entity,
+		event.EntityID,
+		before,
+		after,
+		event.RequestID,
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+//canonical return the JSON with the sorted keys and without the spaces, or empty if there is no JSON.
+func canonical(raw json.RawMessage) (text string, err error) {
+	if len(raw) == 0 {
+		return
+	}
+	var value interface{}
+	if err = json.Unmarshal(raw, &value); err != nil {
+		return
+	}
+	content, err := json.Marshal(value)
+	return string(content), err
+}
+
+//Verifier verifies the hash chains of the events walked in order of the tenant and the id.
+//The events recorded before the hash chain existed have no hash and are skipped
+//until the first event with the hash of the tenant.
+type Verifier struct {
+	heads        map[string]string
+	verification Verification
+}
+
+//NewVerifier return the verifier of the hash chains.
+func NewVerifier() *Verifier {
+	return &Verifier{
+		heads: make(map[string]string),
+	}
+}
+
+//Check checks the link of the next event in the chain of its tenant.
+//It return false at the first broken link, which is kept in the verification.
+func (verifier *Verifier) Check(event Event) bool {
+	head, started := verifier.heads[event.Tenant]
+	if !started {
+		if event.Hash == "" {
+			return true
+		}
+		head = GenesisHash
+		verifier.verification.Tenants++
+	}
+	verifier.verification.Events++
+	reason := ""
+	switch {
+	case event.Hash == "":
+		reason = "The event has no hash"
+	case event.PrevHash != head:
+		reason = "The previous hash doesn't match the hash of the previous event"
+	default:
+		if hash, err := event.ComputeHash(); err != nil || hash != event.Hash {
+			reason = "The hash doesn't match the content of the event"
+		}
+	}
+	if reason != "" {
+		verifier.verification.Broken = &BrokenLink{
+			Tenant:  event.Tenant,
+			EventID: event.ID,
+			Reason:  reason,
+		}
+		return false
+	}
+	verifier.heads[event.Tenant] = event.Hash
+	return true
+}
+
+//Verification return the result of the events checked so far.
+func (verifier *Verifier) Verification() Verification {
+	return verifier.verification
+}
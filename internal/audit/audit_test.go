@@ -0,0 +1,227 @@
+// +build unit
+
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEvent(t *testing.T) {
+	t.Parallel()
+	type value struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		before    interface{}
+		after     interface{}
+		wantEvent Event
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Authenticated Principal",
+			ctx: auth.WithPrincipal(
+				logger.WithRequestID(tenant.WithTenant(context.Background(), "merchant-a"), "abc"),
+				auth.Principal{Subject: "api_key:1"},
+			),
+			before: value{Name: "MACD", Price: 20000},
+			after:  value{Name: "MACD", Price: 25000},
+			wantEvent: Event{
+				Tenant:    "merchant-a",
+				Actor:     "api_key:1",
+				Action:    ActionUpdate,
+				Entity:    EntityTaxObject,
+				EntityID:  1,
+				Before:    []byte(`{"name":"MACD","price":20000}`),
+				After:     []byte(`{"name":"MACD","price":25000}`),
+				RequestID: "abc",
+			},
+		},
+		{
+			name:   "Anonymous Actor",
+			ctx:    context.Background(),
+			before: nil,
+			after:  value{Name: "MACD", Price: 20000},
+			wantEvent: Event{
+				Tenant:   tenant.Default,
+				Actor:    ActorAnonymous,
+				Action:   ActionUpdate,
+				Entity:   EntityTaxObject,
+				EntityID: 1,
+				After:    []byte(`{"name":"MACD","price":20000}`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := NewEvent(tt.ctx, ActionUpdate, EntityTaxObject, 1, tt.before, tt.after)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantEvent, event)
+			}
+		})
+	}
+}
+
+func TestEvent_ComputeHash(t *testing.T) {
+	t.Parallel()
+	event := Event{
+		Tenant:    "merchant-a",
+		Actor:     "api_key:1",
+		Action:    ActionUpdate,
+		Entity:    EntityTaxObject,
+		EntityID:  1,
+		Before:    []byte(`{"name":"MACD","price":20000}`),
+		After:     []byte(`{"name":"MACD","price":25000}`),
+		RequestID: "abc",
+		CreatedAt: time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC),
+		PrevHash:  GenesisHash,
+	}
+	tests := []struct {
+		name     string
+		modify   func(event *Event)
+		wantSame bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "JSON Formatted by the Database",
+			modify: func(event *Event) {
+				event.Before = []byte(`{"price": 20000, "name": "MACD"}`)
+			},
+			wantSame: true,
+		},
+		{
+			name: "Time in Another Location",
+			modify: func(event *Event) {
+				event.CreatedAt = event.CreatedAt.In(time.FixedZone("WIB", 7*60*60))
+			},
+			wantSame: true,
+		},
+		{
+			name: "Different Previous Hash",
+			modify: func(event *Event) {
+				event.PrevHash = strings.Repeat("a", 64)
+			},
+			wantSame: false,
+		},
+		{
+			name: "Different Content",
+			modify: func(event *Event) {
+				event.After = []byte(`{"name":"MACD","price":30000}`)
+			},
+			wantSame: false,
+		},
+	}
+	want, err := event.ComputeHash()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, want, len(GenesisHash))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := event
+			tt.modify(&modified)
+			got, err := modified.ComputeHash()
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantSame, got == want)
+			}
+		})
+	}
+}
+
+func TestVerifier_Check(t *testing.T) {
+	t.Parallel()
+	//chain return the chained events of the tenant starting from the id.
+	chain := func(tenant string, id int64, count int) (events []Event) {
+		prevHash := GenesisHash
+		for ; count > 0; count-- {
+			event := Event{ID: id, Tenant: tenant, Action: ActionCreate, PrevHash: prevHash}
+			event.Hash, _ = event.ComputeHash()
+			prevHash = event.Hash
+			events = append(events, event)
+			id++
+		}
+		return
+	}
+	tests := []struct {
+		name             string
+		events           func() []Event
+		wantVerification Verification
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Intact Chains after Legacy Events",
+			events: func() []Event {
+				events := []Event{{ID: 1, Tenant: "merchant-a"}}
+				events = append(events, chain("merchant-a", 2, 2)...)
+				return append(events, chain("merchant-b", 4, 1)...)
+			},
+			wantVerification: Verification{Tenants: 2, Events: 3},
+		},
+		{
+			name: "Missing Hash",
+			events: func() []Event {
+				events := chain("merchant-a", 1, 1)
+				return append(events, Event{ID: 2, Tenant: "merchant-a"})
+			},
+			wantVerification: Verification{
+				Tenants: 1,
+				Events:  2,
+				Broken:  &BrokenLink{Tenant: "merchant-a", EventID: 2, Reason: "The event has no hash"},
+			},
+		},
+		{
+			name: "Removed Event",
+			events: func() []Event {
+				events := chain("merchant-a", 1, 3)
+				return append(events[:1], events[2:]...)
+			},
+			wantVerification: Verification{
+				Tenants: 1,
+				Events:  2,
+				Broken: &BrokenLink{
+					Tenant:  "merchant-a",
+					EventID: 3,
+					Reason:  "The previous hash doesn't match the hash of the previous event",
+				},
+			},
+		},
+		{
+			name: "Tampered Event",
+			events: func() []Event {
+				events := chain("merchant-a", 1, 2)
+				events[0].EntityID = 2
+				return events
+			},
+			wantVerification: Verification{
+				Tenants: 1,
+				Events:  1,
+				Broken: &BrokenLink{
+					Tenant:  "merchant-a",
+					EventID: 1,
+					Reason:  "The hash doesn't match the content of the event",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier()
+			for _, event := range tt.events() {
+				if !verifier.Check(event) {
+					break
+				}
+			}
+			assert.Equal(t, tt.wantVerification, verifier.Verification())
+		})
+	}
+}
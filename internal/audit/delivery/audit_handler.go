@@ -0,0 +1,105 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	authDelivery "github.com/fairyhunter13/tax-calculator/internal/auth/delivery"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	//ErrInvalidFilter defines the error response returned if the filter in the query is not valid.
+	ErrInvalidFilter = echo.NewHTTPError(http.StatusBadRequest, "Invalid filter")
+)
+
+var (
+	httpHandler *HTTPAuditHandler
+)
+
+//HTTPAuditHandler defines the http delivery layer for the audit log.
+type HTTPAuditHandler struct {
+	auditUcase audit.Usecase
+	log        logrus.FieldLogger
+}
+
+//NewHTTPAuditHandler create the HTTPAuditHandler with customed routing for echo.
+//Every route is protected by the guard with the permission of the route.
+func NewHTTPAuditHandler(e *echo.Echo, auditUcase audit.Usecase, log logrus.FieldLogger, guard authDelivery.Guard) {
+	httpHandler = &HTTPAuditHandler{
+		auditUcase,
+		log,
+	}
+	e.GET("/audit", httpHandler.GetEvents, guard.Protect(auth.PermissionReadAudit)...)
+	e.GET("/audit/head", httpHandler.GetHead, guard.Protect(auth.PermissionReadAudit)...)
+}
+
+//GetEvents handle request for listing the audit events.
+//The events are filtered by the actor, action, entity, entity_id, from, to, and limit query parameters.
+//The from and to parameters are in RFC 3339 format.
+func (handler *HTTPAuditHandler) GetEvents(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPAuditHandler.GetEvents")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	filter, err := parseFilter(c)
+	if err != nil {
+		logger.FromContext(ctx, handler.log).WithError(err).Warn("[HTTPAuditHandler] Failed to parse the filter")
+		err = ErrInvalidFilter
+		return
+	}
+	events, err := handler.auditUcase.GetEvents(ctx, filter)
+	if err != nil {
+		return
+	}
+	err = c.JSON(http.StatusOK, events)
+	return
+}
+
+//GetHead handle request for the head of the hash chain of the audit events.
+func (handler *HTTPAuditHandler) GetHead(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPAuditHandler.GetHead")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	head, err := handler.auditUcase.GetHead(ctx)
+	if err != nil {
+		return
+	}
+	err = c.JSON(http.StatusOK, head)
+	return
+}
+
+//parseFilter parse the filter from the query parameters.
+func parseFilter(c echo.Context) (filter audit.Filter, err error) {
+	filter = audit.Filter{
+		Actor:  c.QueryParam("actor"),
+		Action: c.QueryParam("action"),
+		Entity: c.QueryParam("entity"),
+	}
+	if value := c.QueryParam("entity_id"); value != "" {
+		if filter.EntityID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return
+		}
+	}
+	if value := c.QueryParam("from"); value != "" {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			return
+		}
+	}
+	if value := c.QueryParam("to"); value != "" {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			return
+		}
+	}
+	if value := c.QueryParam("limit"); value != "" {
+		filter.Limit, err = strconv.Atoi(value)
+	}
+	return
+}
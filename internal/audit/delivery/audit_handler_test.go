@@ -0,0 +1,165 @@
+// +build unit
+
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/fairyhunter13/tax-calculator/internal/audit/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	hash = strings.Repeat("b", 64)
+)
+
+func TestHTTPAuditHandler_GetEvents(t *testing.T) {
+	t.Parallel()
+	errDatabaseNotOnline := errors.New("Database is not online")
+	tests := []struct {
+		name       string
+		query      string
+		filter     audit.Filter
+		events     []audit.Event
+		ucaseErr   error
+		wantStatus int
+		wantBody   string
+		wantErr    error
+	}{
+		// TODO: Add test cases.
+		{
+			name:  "Filtered Events",
+			query: "?actor=api_key:1&action=update&entity=tax_object&entity_id=1&from=2019-03-01T00:00:00Z&to=2019-03-02T00:00:00Z&limit=10",
+			filter: audit.Filter{
+				Actor:    "api_key:1",
+				Action:   audit.ActionUpdate,
+				Entity:   audit.EntityTaxObject,
+				EntityID: 1,
+				From:     time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2019, time.March, 2, 0, 0, 0, 0, time.UTC),
+				Limit:    10,
+			},
+			events: []audit.Event{
+				{
+					ID:        1,
+					Tenant:    "merchant-a",
+					Actor:     "api_key:1",
+					Action:    audit.ActionUpdate,
+					Entity:    audit.EntityTaxObject,
+					EntityID:  1,
+					Before:    []byte(`{"price":20000}`),
+					After:     []byte(`{"price":25000}`),
+					RequestID: "abc",
+					CreatedAt: time.Date(2019, time.March, 1, 1, 0, 0, 0, time.UTC),
+					PrevHash:  audit.GenesisHash,
+					Hash:      hash,
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"id":1,"actor":"api_key:1","action":"update","entity":"tax_object","entity_id":1,"before":{"price":20000},"after":{"price":25000},"request_id":"abc","created_at":"2019-03-01T01:00:00Z","prev_hash":"` + audit.GenesisHash + `","hash":"` + hash + `"}]`,
+		},
+		{
+			name:    "Invalid Time",
+			query:   "?from=yesterday",
+			wantErr: ErrInvalidFilter,
+		},
+		{
+			name:     "Internal Server Error",
+			query:    "",
+			filter:   audit.Filter{},
+			ucaseErr: errDatabaseNotOnline,
+			wantErr:  errDatabaseNotOnline,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/audit"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			auditUcase := &mocks.Usecase{}
+			auditUcase.On("GetEvents", mock.Anything, tt.filter).Return(tt.events, tt.ucaseErr)
+			h := &HTTPAuditHandler{
+				auditUcase: auditUcase,
+				log:        logger.Discard(),
+			}
+			err := h.GetEvents(e.NewContext(req, rec))
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantStatus, rec.Code)
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHTTPAuditHandler_GetHead(t *testing.T) {
+	t.Parallel()
+	errDatabaseNotOnline := errors.New("Database is not online")
+	tests := []struct {
+		name       string
+		head       audit.ChainHead
+		ucaseErr   error
+		wantStatus int
+		wantBody   string
+		wantErr    error
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Positive Case",
+			head:       audit.ChainHead{EventID: 2, Hash: hash},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"event_id":2,"hash":"` + hash + `"}`,
+		},
+		{
+			name:     "Internal Server Error",
+			ucaseErr: errDatabaseNotOnline,
+			wantErr:  errDatabaseNotOnline,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/audit/head", nil)
+			rec := httptest.NewRecorder()
+			auditUcase := &mocks.Usecase{}
+			auditUcase.On("GetHead", mock.Anything).Return(tt.head, tt.ucaseErr)
+			h := &HTTPAuditHandler{
+				auditUcase: auditUcase,
+				log:        logger.Discard(),
+			}
+			err := h.GetHead(e.NewContext(req, rec))
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantStatus, rec.Code)
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewHTTPAuditHandler(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	NewHTTPAuditHandler(e, &mocks.Usecase{}, logger.Discard(), nil)
+	paths := make([]string, 0)
+	for _, route := range e.Routes() {
+		assert.Equal(t, http.MethodGet, route.Method)
+		paths = append(paths, route.Path)
+	}
+	assert.ElementsMatch(t, []string{"/audit", "/audit/head"}, paths)
+}
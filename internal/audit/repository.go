@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+)
+
+//Recorder define the behavior of recording the audit event in the transaction of the change,
+//so the change is never stored without its audit event.
+type Recorder interface {
+	Record(context.Context, *sql.Tx, *Event) error
+}
+
+//Repository define the required behavior of data management in the audit event.
+//The audit events are append-only, so they can't be updated or deleted.
+type Repository interface {
+	Recorder
+	GetAll(context.Context, Filter) ([]Event, error)
+	GetHead(context.Context) (ChainHead, error)
+	Walk(context.Context, func(Event) bool) error
+	Close()
+	Migrate() error
+}
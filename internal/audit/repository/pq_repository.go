@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/metrics"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+//PqRepository is the repository for managing the audit events using postgre.
+type PqRepository struct {
+	pool      *sql.DB
+	log       logrus.FieldLogger
+	statement statement
+}
+
+//statement defines the prepared statements of the queries outside the transaction.
+type statement struct {
+	selectAll  *sql.Stmt
+	selectHead *sql.Stmt
+}
+
+//Query names used to label the database metrics.
+const (
+	nameLockChain   = "audit_event_lock_chain"
+	nameInsert      = "audit_event_insert"
+	nameSelectAll   = "audit_event_select_all"
+	nameSelectHead  = "audit_event_select_head"
+	nameSelectChain = "audit_event_select_chain"
+	nameSelectOne   = "audit_event_select_one"
+	nameCreateTable = "audit_event_create_table"
+	nameAddChain    = "audit_event_add_chain"
+)
+
+const (
+	//queryLockChain serializes appending to the chain of the tenant until the end of the transaction.
+	queryLockChain = `
+		SELECT pg_advisory_xact_lock(hashtext($1))
+	`
+	queryInsert = `
+		INSERT INTO audit_event
+			(id, tenant, actor, action, entity, entity_id, before, after, request_id, created_at, prev_hash, hash)
+		VALUES
+			(DEFAULT, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+	querySelectHead = `
+		SELECT
+			id, hash
+		FROM
+			audit_event
+		WHERE
+			tenant = $1 AND hash IS NOT NULL
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	querySelectChain = `
+		SELECT
+			id, tenant, actor, action, entity, entity_id, before, after, request_id, created_at,
+			COALESCE(prev_hash, ''), COALESCE(hash, '')
+		FROM
+			audit_event
+		ORDER BY tenant, id
+	`
+	//querySelectAll filters the events by the non-empty filters.
+	querySelectAll = `
+		SELECT
+			id, tenant, actor, action, entity, entity_id, before, after, request_id, created_at,
+			COALESCE(prev_hash, ''), COALESCE(hash, '')
+		FROM
+			audit_event
+		WHERE
+			tenant = $1
+			AND ($2::text = '' OR actor = $2::text)
+			AND ($3::text = '' OR action = $3::text)
+			AND ($4::text = '' OR entity = $4::text)
+			AND ($5::bigint = 0 OR entity_id = $5::bigint)
+			AND ($6::timestamptz IS NULL OR created_at >= $6)
+			AND ($7::timestamptz IS NULL OR created_at < $7)
+		ORDER BY id DESC
+		LIMIT $8
+	`
+	querySelectOne = `
+		SELECT
+			id
+		FROM
+			audit_event
+		LIMIT 1
+	`
+	//queryCreateTable creates the append-only table, the rules discard every update and delete.
+	queryCreateTable = `
+		CREATE TABLE audit_event (
+			id bigserial PRIMARY KEY,
+			tenant VARCHAR(255) NOT NULL,
+			actor VARCHAR(255) NOT NULL,
+			action VARCHAR(16) NOT NULL,
+			entity VARCHAR(64) NOT NULL,
+			entity_id bigint NOT NULL,
+			before jsonb,
+			after jsonb,
+			request_id VARCHAR(255) NOT NULL,
+			created_at timestamp with time zone NOT NULL DEFAULT now(),
+			prev_hash CHAR(64),
+			hash CHAR(64)
+		);
+		CREATE INDEX audit_event_tenant_idx ON audit_event (tenant, created_at);
+		CREATE RULE audit_event_no_update AS ON UPDATE TO audit_event DO INSTEAD NOTHING;
+		CREATE RULE audit_event_no_delete AS ON DELETE TO audit_event DO INSTEAD NOTHING
+	`
+	//queryAddChain adds the hash chain to the table created before the hash chain.
+	//The existing events have no hash and precede the chain.
+	queryAddChain = `
+		ALTER TABLE audit_event
+			ADD COLUMN IF NOT EXISTS prev_hash CHAR(64),
+			ADD COLUMN IF NOT EXISTS hash CHAR(64)
+	`
+)
+
+//NewPqRepository creates the pq repository for audit event with postgre connection.
+func NewPqRepository(pool *sql.DB, log logrus.FieldLogger) audit.Repository {
+	return &PqRepository{
+		pool:      pool,
+		log:       log,
+		statement: statement{},
+	}
+}
+
+//Record append the audit event to the hash chain of its tenant in the transaction of the change.
+//The chain is locked until the end of the transaction, so the concurrent changes don't fork it.
+func (repo *PqRepository) Record(ctx context.Context, tx *sql.Tx, event *audit.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "AuditPqRepository.Record", tracing.Query(nameInsert, queryInsert)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameInsert, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	if _, err = tx.ExecContext(ctx, queryLockChain, event.Tenant); err != nil {
+		return
+	}
+	var id int64
+	err = tx.QueryRowContext(ctx, querySelectHead, event.Tenant).Scan(&id, &event.PrevHash)
+	if err == sql.ErrNoRows {
+		event.PrevHash, err = audit.GenesisHash, nil
+	}
+	if err != nil {
+		return
+	}
+	//The timestamp is stored in microseconds, so it's truncated before hashing.
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if event.Hash, err = event.ComputeHash(); err != nil {
+		return
+	}
+	row := tx.QueryRowContext(
+		ctx,
+		queryInsert,
+		event.Tenant,
+		event.Actor,
+		event.Action,
+		event.Entity,
+		event.EntityID,
+		nullJSON(event.Before),
+		nullJSON(event.After),
+		event.RequestID,
+		event.CreatedAt,
+		event.PrevHash,
+		event.Hash,
+	)
+	err = row.Scan(
+		&event.ID,
+	)
+	return
+}
+
+//GetAll return the audit events of the tenant in ctx matching the filter, the latest first.
+func (repo *PqRepository) GetAll(ctx context.Context, filter audit.Filter) (events []audit.Event, err error) {
+	events = make([]audit.Event, 0)
+	ctx, span := tracing.Start(ctx, "AuditPqRepository.GetAll", tracing.Query(nameSelectAll, querySelectAll)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectAll, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectAll, querySelectAll)
+	if err != nil {
+		return
+	}
+	rows, err := stmt.QueryContext(
+		ctx,
+		tenant.FromContext(ctx),
+		filter.Actor,
+		filter.Action,
+		filter.Entity,
+		filter.EntityID,
+		nullTime(filter.From),
+		nullTime(filter.To),
+		filter.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event audit.Event
+		if event, err = scan(rows); err != nil {
+			return
+		}
+		events = append(events, event)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//GetHead return the head of the hash chain of the tenant in ctx.
+func (repo *PqRepository) GetHead(ctx context.Context) (head audit.ChainHead, err error) {
+	ctx, span := tracing.Start(ctx, "AuditPqRepository.GetHead", tracing.Query(nameSelectHead, querySelectHead)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectHead, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectHead, querySelectHead)
+	if err != nil {
+		return
+	}
+	err = stmt.QueryRowContext(ctx, tenant.FromContext(ctx)).Scan(&head.EventID, &head.Hash)
+	if err == sql.ErrNoRows {
+		head, err = audit.ChainHead{Hash: audit.GenesisHash}, nil
+	}
+	return
+}
+
+//Walk calls the function with every audit event of all tenants in order of the tenant and the id.
+//The walk stops if the function return false.
+func (repo *PqRepository) Walk(ctx context.Context, function func(audit.Event) bool) (err error) {
+	ctx, span := tracing.Start(ctx, "AuditPqRepository.Walk", tracing.Query(nameSelectChain, querySelectChain)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectChain, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	rows, err := repo.pool.QueryContext(ctx, querySelectChain)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event audit.Event
+		if event, err = scan(rows); err != nil {
+			return
+		}
+		if !function(event) {
+			return
+		}
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//Migrate create the table in the database if it doesn't exist.
+func (repo *PqRepository) Migrate() (err error) {
+	ctx, span := tracing.Start(context.Background(), "AuditPqRepository.Migrate")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	var id int64
+	begin := time.Now()
+	row := repo.pool.QueryRowContext(ctx, querySelectOne)
+	err = row.Scan(&id)
+	if err == nil || err == sql.ErrNoRows {
+		metrics.ObserveQuery(nameSelectOne, begin, nil)
+		begin = time.Now()
+		_, err = repo.pool.ExecContext(ctx, queryAddChain)
+		repo.observe(ctx, nameAddChain, begin, err)
+		return
+	}
+	//The select query is expected to fail if the table doesn't exist yet.
+	metrics.ObserveQuery(nameSelectOne, begin, err)
+	repo.log.WithError(err).Info("[AuditPqRepository] Creating the audit_event table")
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryCreateTable)
+	repo.observe(ctx, nameCreateTable, begin, err)
+	return
+}
+
+//Close close all prepared statements in this repository.
+func (repo *PqRepository) Close() {
+	for _, stmt := range []*sql.Stmt{
+		repo.statement.selectAll,
+		repo.statement.selectHead,
+	} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+//prepare lazily prepare the statement for the query and store it in the given field.
+func (repo *PqRepository) prepare(field **sql.Stmt, query string) (stmt *sql.Stmt, err error) {
+	if *field != nil {
+		return *field, nil
+	}
+	stmt, err = repo.pool.Prepare(query)
+	if err != nil {
+		return
+	}
+	*field = stmt
+	return
+}
+
+//observe records the metrics of the query started at begin and logs the error if the query failed.
+func (repo *PqRepository) observe(ctx context.Context, query string, begin time.Time, err error) {
+	metrics.ObserveQuery(query, begin, err)
+	if err != nil {
+		logger.FromContext(ctx, repo.log).
+			WithError(err).
+			WithField("query", query).
+			Error("[AuditPqRepository] Query failed")
+	}
+}
+
+//scanner defines the row or rows that can be scanned.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//scan scan the audit event from the row.
+func scan(row scanner) (event audit.Event, err error) {
+	var before, after []byte
+	err = row.Scan(
+		&event.ID,
+		&event.Tenant,
+		&event.Actor,
+		&event.Action,
+		&event.Entity,
+		&event.EntityID,
+		&before,
+		&after,
+		&event.RequestID,
+		&event.CreatedAt,
+		&event.PrevHash,
+		&event.Hash,
+	)
+	event.Before, event.After = before, after
+	return
+}
+
+//nullJSON return the JSON to store, or nil to store NULL if it is empty.
+func nullJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+//nullTime return the time to filter, or nil to not filter if it is zero.
+func nullTime(value time.Time) interface{} {
+	if value.IsZero() {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,423 @@
+// +build unit
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	regexQueryLockChain = `
+		SELECT pg_advisory_xact_lock(.+)
+	`
+	regexQueryInsert = `
+		INSERT INTO audit_event
+			(.+)
+		VALUES
+			(.+)
+		RETURNING id
+	`
+	regexQuerySelectHead = `
+		SELECT
+			id, hash
+		FROM
+			audit_event
+		WHERE
+			(.+)
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	regexQuerySelectChain = `
+		SELECT
+			(.+)
+		FROM
+			audit_event
+		ORDER BY tenant, id
+	`
+	regexQuerySelectAll = `
+		SELECT
+			(.+)
+		FROM
+			audit_event
+		WHERE
+			(.+)
+	`
+	regexQuerySelectOne = `
+		SELECT
+			id
+		FROM
+			audit_event
+		LIMIT 1
+	`
+	regexQueryCreateTable = `
+		CREATE TABLE audit_event (.+)
+	`
+	regexQueryAddChain = `
+		ALTER TABLE audit_event
+			(.+)
+	`
+)
+
+var (
+	errQuerying         = errors.New("Error in querying rows")
+	errRelationNotExist = errors.New("Relation still doesn't exist")
+	createdAt           = time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)
+	columns             = []string{"id", "tenant", "actor", "action", "entity", "entity_id", "before", "after", "request_id", "created_at", "prev_hash", "hash"}
+	prevHash            = strings.Repeat("a", 64)
+	hash                = strings.Repeat("b", 64)
+)
+
+func newRepository(t *testing.T) (*PqRepository, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error starting the mocker: %s", err)
+	}
+	repo := NewPqRepository(db, logger.Discard())
+	return repo.(*PqRepository), mock, db
+}
+
+func TestPqRepository_Record(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		expect       func(mock sqlmock.Sqlmock)
+		wantPrevHash string
+		wantErr      bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockChain).WithArgs("merchant-a").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectHead).
+					WithArgs("merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow(1, prevHash))
+				mock.ExpectQuery(regexQueryInsert).
+					WithArgs("merchant-a", "api_key:1", audit.ActionCreate, audit.EntityTaxObject, 1, nil, `{"name":"MACD"}`, "abc", sqlmock.AnyArg(), prevHash, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+			},
+			wantPrevHash: prevHash,
+			wantErr:      false,
+		},
+		{
+			name: "First Event of the Chain",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockChain).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectHead).WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}))
+				mock.ExpectQuery(regexQueryInsert).
+					WithArgs("merchant-a", "api_key:1", audit.ActionCreate, audit.EntityTaxObject, 1, nil, `{"name":"MACD"}`, "abc", sqlmock.AnyArg(), audit.GenesisHash, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+			},
+			wantPrevHash: audit.GenesisHash,
+			wantErr:      false,
+		},
+		{
+			name: "Error locking the chain",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockChain).WillReturnError(errQuerying)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error querying rows",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockChain).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectHead).WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}))
+				mock.ExpectQuery(regexQueryInsert).WillReturnError(errQuerying)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			mock.ExpectBegin()
+			tt.expect(mock)
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatalf("Error beginning the transaction: %s", err)
+			}
+			event := &audit.Event{
+				Tenant:    "merchant-a",
+				Actor:     "api_key:1",
+				Action:    audit.ActionCreate,
+				Entity:    audit.EntityTaxObject,
+				EntityID:  1,
+				After:     []byte(`{"name":"MACD"}`),
+				RequestID: "abc",
+			}
+			err = repo.Record(context.Background(), tx, event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.Record() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				assert.Equal(t, int64(2), event.ID)
+				assert.Equal(t, tt.wantPrevHash, event.PrevHash)
+				wantHash, _ := event.ComputeHash()
+				assert.Equal(t, wantHash, event.Hash)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Record() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_GetAll(t *testing.T) {
+	t.Parallel()
+	from := createdAt.Add(-time.Hour)
+	tests := []struct {
+		name       string
+		filter     audit.Filter
+		expect     func(mock sqlmock.Sqlmock)
+		wantEvents []audit.Event
+		wantErr    bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:   "Positive Case",
+			filter: audit.Filter{Actor: "api_key:1", EntityID: 1, From: from, Limit: 100},
+			expect: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(2, "merchant-a", "api_key:1", audit.ActionDelete, audit.EntityTaxObject, 1, []byte(`{"name":"MACD"}`), nil, "abc", createdAt, prevHash, hash)
+				mock.ExpectPrepare(regexQuerySelectAll)
+				mock.ExpectQuery(regexQuerySelectAll).
+					WithArgs("merchant-a", "api_key:1", "", "", 1, from, nil, 100).
+					WillReturnRows(rows)
+			},
+			wantEvents: []audit.Event{
+				{
+					ID:        2,
+					Tenant:    "merchant-a",
+					Actor:     "api_key:1",
+					Action:    audit.ActionDelete,
+					Entity:    audit.EntityTaxObject,
+					EntityID:  1,
+					Before:    []byte(`{"name":"MACD"}`),
+					RequestID: "abc",
+					CreatedAt: createdAt,
+					PrevHash:  prevHash,
+					Hash:      hash,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "Error querying rows",
+			filter: audit.Filter{Limit: 100},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectAll)
+				mock.ExpectQuery(regexQuerySelectAll).WillReturnError(errQuerying)
+			},
+			wantEvents: []audit.Event{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			tt.expect(mock)
+			gotEvents, err := repo.GetAll(tenant.WithTenant(context.Background(), "merchant-a"), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.GetAll() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotEvents, tt.wantEvents) {
+				t.Errorf("PqRepository.GetAll() = %v, want %v", gotEvents, tt.wantEvents)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.GetAll() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_GetHead(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		expect   func(mock sqlmock.Sqlmock)
+		wantHead audit.ChainHead
+		wantErr  bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectHead)
+				mock.ExpectQuery(regexQuerySelectHead).
+					WithArgs("merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow(2, hash))
+			},
+			wantHead: audit.ChainHead{EventID: 2, Hash: hash},
+			wantErr:  false,
+		},
+		{
+			name: "Empty Chain",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectHead)
+				mock.ExpectQuery(regexQuerySelectHead).WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}))
+			},
+			wantHead: audit.ChainHead{Hash: audit.GenesisHash},
+			wantErr:  false,
+		},
+		{
+			name: "Error querying rows",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectHead)
+				mock.ExpectQuery(regexQuerySelectHead).WillReturnError(errQuerying)
+			},
+			wantHead: audit.ChainHead{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			tt.expect(mock)
+			gotHead, err := repo.GetHead(tenant.WithTenant(context.Background(), "merchant-a"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.GetHead() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantHead, gotHead)
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.GetHead() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Walk(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		stopAt  int64
+		wantIDs []int64
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Walk all events",
+			expect: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "merchant-a", "", audit.ActionCreate, audit.EntityTaxObject, 1, nil, nil, "", createdAt, "", "").
+					AddRow(2, "merchant-a", "api_key:1", audit.ActionDelete, audit.EntityTaxObject, 1, nil, nil, "abc", createdAt, prevHash, hash)
+				mock.ExpectQuery(regexQuerySelectChain).WillReturnRows(rows)
+			},
+			wantIDs: []int64{1, 2},
+			wantErr: false,
+		},
+		{
+			name: "Stop the walk",
+			expect: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "merchant-a", "", audit.ActionCreate, audit.EntityTaxObject, 1, nil, nil, "", createdAt, "", "").
+					AddRow(2, "merchant-a", "api_key:1", audit.ActionDelete, audit.EntityTaxObject, 1, nil, nil, "abc", createdAt, prevHash, hash)
+				mock.ExpectQuery(regexQuerySelectChain).WillReturnRows(rows)
+			},
+			stopAt:  1,
+			wantIDs: []int64{1},
+			wantErr: false,
+		},
+		{
+			name: "Error querying rows",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectChain).WillReturnError(errQuerying)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			tt.expect(mock)
+			var gotIDs []int64
+			err := repo.Walk(context.Background(), func(event audit.Event) bool {
+				gotIDs = append(gotIDs, event.ID)
+				return event.ID != tt.stopAt
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.Walk() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Walk() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Migrate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Table exists",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexQueryAddChain).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error adding the hash chain",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexQueryAddChain).WillReturnError(errQuerying)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Create the table",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnError(errRelationNotExist)
+				mock.ExpectExec(regexQueryCreateTable).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error creating the table",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnError(errRelationNotExist)
+				mock.ExpectExec(regexQueryCreateTable).WillReturnError(errQuerying)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			tt.expect(mock)
+			if err := repo.Migrate(); (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.Migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Migrate() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package audit
+
+import (
+	"context"
+)
+
+//Usecase defines the required behavior for business logic in the audit log.
+type Usecase interface {
+	GetEvents(context.Context, Filter) ([]Event, error)
+	GetHead(context.Context) (ChainHead, error)
+	VerifyChain(context.Context) (Verification, error)
+}
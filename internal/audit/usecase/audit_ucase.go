@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//DefaultLimit defines the number of the events returned if the filter has no limit.
+	DefaultLimit = 100
+	//MaxLimit defines the maximum number of the events returned at once.
+	MaxLimit = 1000
+)
+
+//AuditUsecase defines all the business logic for the audit log.
+type AuditUsecase struct {
+	auditRepo audit.Repository
+	log       logrus.FieldLogger
+}
+
+//NewAuditUsecase return the audit usecase.
+func NewAuditUsecase(auditRepo audit.Repository, log logrus.FieldLogger) audit.Usecase {
+	return &AuditUsecase{
+		auditRepo,
+		log,
+	}
+}
+
+//GetEvents return the audit events of the tenant in ctx matching the filter, the latest first.
+//The number of the events is bounded by the limit of the filter.
+func (ucase *AuditUsecase) GetEvents(ctx context.Context, filter audit.Filter) (events []audit.Event, err error) {
+	ctx, span := tracing.Start(ctx, "AuditUsecase.GetEvents")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	}
+	if filter.Limit > MaxLimit {
+		filter.Limit = MaxLimit
+	}
+	return ucase.auditRepo.GetAll(ctx, filter)
+}
+
+//GetHead return the head of the hash chain of the tenant in ctx.
+//The head can be published externally to detect the removal of the latest events.
+func (ucase *AuditUsecase) GetHead(ctx context.Context) (head audit.ChainHead, err error) {
+	ctx, span := tracing.Start(ctx, "AuditUsecase.GetHead")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return ucase.auditRepo.GetHead(ctx)
+}
+
+//VerifyChain verifies the hash chains of all tenants and reports the first broken link.
+func (ucase *AuditUsecase) VerifyChain(ctx context.Context) (verification audit.Verification, err error) {
+	ctx, span := tracing.Start(ctx, "AuditUsecase.VerifyChain")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	verifier := audit.NewVerifier()
+	if err = ucase.auditRepo.Walk(ctx, verifier.Check); err != nil {
+		return
+	}
+	verification = verifier.Verification()
+	log := logger.FromContext(ctx, ucase.log).
+		WithField("tenants", verification.Tenants).
+		WithField("events", verification.Events)
+	if verification.Broken != nil {
+		log.WithField("tenant", verification.Broken.Tenant).
+			WithField("event_id", verification.Broken.EventID).
+			Warn("[AuditUsecase] " + verification.Broken.Reason)
+		return
+	}
+	log.Info("[AuditUsecase] The hash chain is intact")
+	return
+}
@@ -0,0 +1,128 @@
+// +build unit
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/fairyhunter13/tax-calculator/internal/audit/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditUsecase_GetEvents(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		filter    audit.Filter
+		wantLimit int
+	}{
+		// TODO: Add test cases.
+		{
+			name:      "Default Limit",
+			filter:    audit.Filter{Actor: "api_key:1"},
+			wantLimit: DefaultLimit,
+		},
+		{
+			name:      "Limit Within Maximum",
+			filter:    audit.Filter{Actor: "api_key:1", Limit: 10},
+			wantLimit: 10,
+		},
+		{
+			name:      "Limit Above Maximum",
+			filter:    audit.Filter{Actor: "api_key:1", Limit: MaxLimit + 1},
+			wantLimit: MaxLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := []audit.Event{{ID: 1, Actor: "api_key:1"}}
+			auditRepo := &mocks.Repository{}
+			auditRepo.On("GetAll", mock.Anything, audit.Filter{Actor: "api_key:1", Limit: tt.wantLimit}).Return(events, nil)
+			ucase := NewAuditUsecase(auditRepo, logger.Discard())
+			gotEvents, err := ucase.GetEvents(context.Background(), tt.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, events, gotEvents)
+			auditRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuditUsecase_VerifyChain(t *testing.T) {
+	t.Parallel()
+	chain := func() (events []audit.Event) {
+		prevHash := audit.GenesisHash
+		for id := int64(1); id <= 3; id++ {
+			event := audit.Event{ID: id, Tenant: "merchant-a", Actor: "api_key:1", Action: audit.ActionCreate, PrevHash: prevHash}
+			event.Hash, _ = event.ComputeHash()
+			prevHash = event.Hash
+			events = append(events, event)
+		}
+		return
+	}
+	tests := []struct {
+		name             string
+		events           func() []audit.Event
+		walkErr          error
+		wantVerification audit.Verification
+		wantErr          bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:             "Intact Chain",
+			events:           chain,
+			wantVerification: audit.Verification{Tenants: 1, Events: 3},
+			wantErr:          false,
+		},
+		{
+			name: "Tampered Event",
+			events: func() []audit.Event {
+				events := chain()
+				events[1].Actor = "api_key:2"
+				return events
+			},
+			wantVerification: audit.Verification{
+				Tenants: 1,
+				Events:  2,
+				Broken: &audit.BrokenLink{
+					Tenant:  "merchant-a",
+					EventID: 2,
+					Reason:  "The hash doesn't match the content of the event",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Error walking the chain",
+			events:  chain,
+			walkErr: errors.New("Database is not online"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := tt.events()
+			auditRepo := &mocks.Repository{}
+			auditRepo.On("Walk", mock.Anything, mock.Anything).Return(tt.walkErr).Run(func(args mock.Arguments) {
+				check := args.Get(1).(func(audit.Event) bool)
+				for _, event := range events {
+					if !check(event) {
+						return
+					}
+				}
+			})
+			ucase := NewAuditUsecase(auditRepo, logger.Discard())
+			gotVerification, err := ucase.VerifyChain(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuditUsecase.VerifyChain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantVerification, gotVerification)
+			auditRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	//MethodAPIKey defines the principal authenticated using the api key.
+	MethodAPIKey = "api_key"
+	//MethodJWT defines the principal authenticated using the JWT bearer token.
+	MethodJWT = "jwt"
+)
+
+//Permissions checked before the request is handled.
+const (
+	//PermissionCreateTax defines the permission to add the tax objects.
+	PermissionCreateTax = "create_tax"
+	//PermissionPreviewTax defines the permission to preview the bill lines of the tax objects without adding them.
+	PermissionPreviewTax = "preview_tax"
+	//PermissionUpdateTax defines the permission to correct the tax objects.
+	PermissionUpdateTax = "update_tax"
+	//PermissionDeleteTax defines the permission to delete the tax objects.
+	PermissionDeleteTax = "delete_tax"
+	//PermissionReadBill defines the permission to read the bill.
+	PermissionReadBill = "read_bill"
+	//PermissionFinalizeBill defines the permission to finalize the bill into the invoice.
+	PermissionFinalizeBill = "finalize_bill"
+	//PermissionRefundBill defines the permission to refund the invoice with the credit note.
+	PermissionRefundBill = "refund_bill"
+	//PermissionDiscountBill defines the permission to apply the coupons to the bill.
+	PermissionDiscountBill = "discount_bill"
+	//PermissionChargeBill defines the permission to add the charges to the bill.
+	PermissionChargeBill = "charge_bill"
+	//PermissionReadAudit defines the permission to read the audit log.
+	PermissionReadAudit = "read_audit"
+)
+
+var (
+	//ErrInvalidCredentials defines the error if the api key or the token is not valid.
+	ErrInvalidCredentials = errors.New("Invalid credentials")
+	//ErrNotFound defines the error if the api key doesn't exist.
+	ErrNotFound = errors.New("API key not found")
+)
+
+type contextKey struct{}
+
+var (
+	principalKey = contextKey{}
+)
+
+//APIKey define the model for the api key used by the client to authenticate.
+//Only the hash of the key is stored, the plain key is shown once when it is created.
+//The client using the key acts on behalf of the tenant of the key with the role of the key.
+type APIKey struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name" validate:"required"`
+	Tenant    string     `json:"tenant" validate:"required"`
+	Role      string     `json:"role" validate:"required"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+//Principal define the authenticated client of the request.
+//The tenant isolates the data of the client from the other tenants
+//and the roles define what the client is allowed to do.
+type Principal struct {
+	Subject string   `json:"subject"`
+	Method  string   `json:"method"`
+	Tenant  string   `json:"tenant"`
+	Roles   []string `json:"roles"`
+}
+
+//Policy define the roles granted for every permission.
+type Policy map[string][]string
+
+//Authorize return the reason of the denial if none of the roles of the principal is granted the permission.
+//The returned error is nil if the principal is allowed.
+func (policy Policy) Authorize(principal Principal, permission string) error {
+	granted := policy[permission]
+	if len(granted) == 0 {
+		return fmt.Errorf("The permission %s is not granted to any role", permission)
+	}
+	if len(principal.Roles) == 0 {
+		return fmt.Errorf("The principal %s has no role", principal.Subject)
+	}
+	for _, role := range principal.Roles {
+		for _, grantedRole := range granted {
+			if role == grantedRole {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf(
+		"The role %s is not allowed to %s, it requires the role %s",
+		strings.Join(principal.Roles, ", "),
+		permission,
+		strings.Join(granted, " or "),
+	)
+}
+
+//HashKey return the hex encoded SHA-256 hash of the plain key.
+func HashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+//WithPrincipal return the copy of ctx carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+//PrincipalFromContext return the authenticated principal stored in ctx.
+func PrincipalFromContext(ctx context.Context) (principal Principal, ok bool) {
+	principal, ok = ctx.Value(principalKey).(Principal)
+	return
+}
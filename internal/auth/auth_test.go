@@ -0,0 +1,68 @@
+// +build unit
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Authorize(t *testing.T) {
+	t.Parallel()
+	policy := Policy{
+		PermissionCreateTax: []string{"clerk", "supervisor"},
+		PermissionDeleteTax: []string{"supervisor"},
+		PermissionReadBill:  []string{"clerk", "supervisor", "auditor"},
+	}
+	tests := []struct {
+		name       string
+		principal  Principal
+		permission string
+		wantReason string
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Clerk Adds Tax Object",
+			principal:  Principal{Subject: "api_key:1", Roles: []string{"clerk"}},
+			permission: PermissionCreateTax,
+			wantReason: "",
+		},
+		{
+			name:       "Auditor Reads Bill",
+			principal:  Principal{Subject: "api_key:2", Roles: []string{"auditor"}},
+			permission: PermissionReadBill,
+			wantReason: "",
+		},
+		{
+			name:       "Auditor Adds Tax Object",
+			principal:  Principal{Subject: "api_key:2", Roles: []string{"auditor"}},
+			permission: PermissionCreateTax,
+			wantReason: "The role auditor is not allowed to create_tax, it requires the role clerk or supervisor",
+		},
+		{
+			name:       "Principal Without Role",
+			principal:  Principal{Subject: "cashier"},
+			permission: PermissionReadBill,
+			wantReason: "The principal cashier has no role",
+		},
+		{
+			name:       "Permission Not In Policy",
+			principal:  Principal{Subject: "api_key:3", Roles: []string{"supervisor"}},
+			permission: PermissionUpdateTax,
+			wantReason: "The permission update_tax is not granted to any role",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Authorize(tt.principal, tt.permission)
+			if tt.wantReason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, tt.wantReason, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/labstack/echo"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/sirupsen/logrus"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+var (
+	//ErrInvalidInput defines the error response returned by the handler
+	//if the request is not valid JSON or have any invalid value.
+	ErrInvalidInput = echo.NewHTTPError(http.StatusBadRequest, "Invalid input")
+	//ErrNotFound defines the error response returned if the api key doesn't exist.
+	ErrNotFound = echo.NewHTTPError(http.StatusNotFound, "API key not found")
+)
+
+var (
+	httpHandler      *HTTPAPIKeyHandler
+	once             sync.Once
+	requestValidator *validator.Validate
+	sanitizer        *bluemonday.Policy
+)
+
+func init() {
+	//Init once sanitizer and request validator.
+	once.Do(func() {
+		requestValidator = validator.New()
+		sanitizer = bluemonday.UGCPolicy()
+	})
+}
+
+//HTTPAPIKeyHandler defines the http delivery layer for managing the api keys.
+type HTTPAPIKeyHandler struct {
+	authUcase auth.Usecase
+	log       logrus.FieldLogger
+}
+
+//CreatedAPIKey define the response of the created api key.
+//The plain key is only returned in this response.
+type CreatedAPIKey struct {
+	auth.APIKey
+	Key string `json:"key"`
+}
+
+//NewAPIKeyHandler create the HTTPAPIKeyHandler with customed routing for echo.
+func NewAPIKeyHandler(e *echo.Echo, authUcase auth.Usecase, log logrus.FieldLogger, middlewares ...echo.MiddlewareFunc) {
+	httpHandler = &HTTPAPIKeyHandler{
+		authUcase,
+		log,
+	}
+	e.POST("/admin/apikeys", httpHandler.CreateAPIKey, middlewares...)
+	e.GET("/admin/apikeys", httpHandler.GetAPIKeys, middlewares...)
+	e.DELETE("/admin/apikeys/:id", httpHandler.RevokeAPIKey, middlewares...)
+}
+
+//CreateAPIKey handle request for creating the api key.
+func (handler *HTTPAPIKeyHandler) CreateAPIKey(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	apiKey := auth.APIKey{}
+	if err = c.Bind(&apiKey); err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	if err = requestValidator.Struct(&apiKey); err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	apiKey.Name = sanitizer.Sanitize(apiKey.Name)
+	apiKey.Tenant = sanitizer.Sanitize(apiKey.Tenant)
+	apiKey.Role = sanitizer.Sanitize(apiKey.Role)
+	key, err := handler.authUcase.CreateAPIKey(ctx, &apiKey)
+	if err != nil {
+		return
+	}
+	err = c.JSON(http.StatusCreated, &CreatedAPIKey{
+		APIKey: apiKey,
+		Key:    key,
+	})
+	return
+}
+
+//GetAPIKeys handle request for listing the api keys.
+func (handler *HTTPAPIKeyHandler) GetAPIKeys(c echo.Context) (err error) {
+	apiKeys, err := handler.authUcase.GetAPIKeys(c.Request().Context())
+	if err != nil {
+		return
+	}
+	err = c.JSON(http.StatusOK, apiKeys)
+	return
+}
+
+//RevokeAPIKey handle request for revoking the api key.
+func (handler *HTTPAPIKeyHandler) RevokeAPIKey(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	err = handler.authUcase.RevokeAPIKey(ctx, id)
+	if err == auth.ErrNotFound {
+		logger.FromContext(ctx, handler.log).WithField("api_key_id", id).Warn("[HTTPAPIKeyHandler] API key not found")
+		err = ErrNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+	err = c.NoContent(http.StatusNoContent)
+	return
+}
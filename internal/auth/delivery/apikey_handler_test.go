@@ -0,0 +1,143 @@
+// +build unit
+
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/auth/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHTTPAPIKeyHandler_CreateAPIKey(t *testing.T) {
+	t.Parallel()
+	authUcase := &mocks.Usecase{}
+	authUcase.On("CreateAPIKey", mock.Anything, &auth.APIKey{Name: "cashier", Tenant: "merchant-a", Role: "clerk"}).
+		Run(func(args mock.Arguments) {
+			apiKey := args.Get(1).(*auth.APIKey)
+			apiKey.ID = 1
+			apiKey.Prefix = "tc_0123abcd"
+		}).
+		Return("tc_0123abcdef", nil)
+	h := &HTTPAPIKeyHandler{
+		authUcase: authUcase,
+		log:       logger.Discard(),
+	}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/admin/apikeys", strings.NewReader(`{"name": "cashier", "tenant": "merchant-a", "role": "clerk"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if assert.NoError(t, h.CreateAPIKey(e.NewContext(req, rec))) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		resp := map[string]interface{}{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Error in unmarshaling json: %s", err)
+		}
+		assert.Equal(t, "tc_0123abcdef", resp["key"])
+		assert.Equal(t, "tc_0123abcd", resp["prefix"])
+		assert.Equal(t, float64(1), resp["id"])
+		assert.Equal(t, "merchant-a", resp["tenant"])
+		assert.Equal(t, "clerk", resp["role"])
+	}
+}
+
+func TestHTTPAPIKeyHandler_CreateAPIKey_InvalidInput(t *testing.T) {
+	t.Parallel()
+	h := &HTTPAPIKeyHandler{
+		authUcase: &mocks.Usecase{},
+		log:       logger.Discard(),
+	}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/admin/apikeys", strings.NewReader(`{"name": "cashier"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	err := h.CreateAPIKey(e.NewContext(req, rec))
+	if assert.Error(t, err) {
+		assert.Equal(t, fmt.Sprintf("%s", ErrInvalidInput), fmt.Sprintf("%s", err))
+	}
+}
+
+func TestHTTPAPIKeyHandler_GetAPIKeys(t *testing.T) {
+	t.Parallel()
+	authUcase := &mocks.Usecase{}
+	authUcase.On("GetAPIKeys", mock.Anything).Return([]auth.APIKey{
+		{ID: 1, Name: "cashier", Prefix: "tc_0123abcd", Hash: auth.HashKey("key")},
+	}, nil)
+	h := &HTTPAPIKeyHandler{
+		authUcase: authUcase,
+		log:       logger.Discard(),
+	}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/admin/apikeys", nil)
+	rec := httptest.NewRecorder()
+
+	if assert.NoError(t, h.GetAPIKeys(e.NewContext(req, rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"name":"cashier"`)
+		assert.NotContains(t, rec.Body.String(), auth.HashKey("key"))
+	}
+}
+
+func TestHTTPAPIKeyHandler_RevokeAPIKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+		wantErr    error
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Positive Case",
+			id:         "1",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:    "Unknown Key",
+			id:      "2",
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "abc",
+			wantErr: ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authUcase := &mocks.Usecase{}
+			authUcase.On("RevokeAPIKey", mock.Anything, int64(1)).Return(nil)
+			authUcase.On("RevokeAPIKey", mock.Anything, int64(2)).Return(auth.ErrNotFound)
+			h := &HTTPAPIKeyHandler{
+				authUcase: authUcase,
+				log:       logger.Discard(),
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/admin/apikeys/"+tt.id, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			err := h.RevokeAPIKey(c)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
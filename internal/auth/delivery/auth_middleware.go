@@ -0,0 +1,154 @@
+package delivery
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//HeaderAPIKey defines the header used by the client to send the api key.
+	HeaderAPIKey = "X-API-Key"
+	//HeaderAdminKey defines the header used by the administrator to manage the api keys.
+	HeaderAdminKey = "X-Admin-Key"
+
+	bearerPrefix = "Bearer "
+)
+
+var (
+	//ErrUnauthorized defines the error response returned if the request is not authenticated.
+	ErrUnauthorized = echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+)
+
+//Denial define the error body returned if the principal is not allowed to do the request.
+type Denial struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+}
+
+//Guard return the middlewares protecting the route requiring the permission.
+type Guard func(permission string) []echo.MiddlewareFunc
+
+//NewGuard return the guard authenticating the request and authorizing the principal with the policy.
+//The middlewares run after the authentication, e.g. limiting the requests of every principal.
+func NewGuard(authUcase auth.Usecase, policy auth.Policy, log logrus.FieldLogger, middlewares ...echo.MiddlewareFunc) Guard {
+	authenticate := Middleware(authUcase, log)
+	return func(permission string) []echo.MiddlewareFunc {
+		guarded := make([]echo.MiddlewareFunc, 0, len(middlewares)+2)
+		guarded = append(guarded, authenticate)
+		guarded = append(guarded, middlewares...)
+		return append(guarded, Authorize(policy, permission, log))
+	}
+}
+
+//PrincipalKey return the key identifying the authenticated principal of the request, e.g. to limit its requests.
+//The key is empty if the request is not authenticated.
+func PrincipalKey(c echo.Context) string {
+	principal, ok := auth.PrincipalFromContext(c.Request().Context())
+	if !ok {
+		return ""
+	}
+	return "principal:" + principal.Tenant + "/" + principal.Subject
+}
+
+//Protect return the middlewares protecting the route requiring the permission.
+//The nil guard doesn't protect the route, e.g. if the authentication is disabled.
+func (guard Guard) Protect(permission string) []echo.MiddlewareFunc {
+	if guard == nil {
+		return nil
+	}
+	return guard(permission)
+}
+
+//Middleware return the echo middleware authenticating the request using the api key or the JWT bearer token.
+//The authenticated principal and its tenant are stored in the request context.
+func Middleware(authUcase auth.Usecase, log logrus.FieldLogger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			req := c.Request()
+			ctx := req.Context()
+			var principal auth.Principal
+			if key := req.Header.Get(HeaderAPIKey); key != "" {
+				principal, err = authUcase.AuthenticateAPIKey(ctx, key)
+			} else if token := bearerToken(req); token != "" {
+				principal, err = authUcase.AuthenticateToken(ctx, token)
+			} else {
+				err = auth.ErrInvalidCredentials
+			}
+			if err == auth.ErrInvalidCredentials {
+				logger.FromContext(ctx, log).Warn("[AuthMiddleware] Unauthenticated request")
+				return unauthorized(c)
+			}
+			if err != nil {
+				return
+			}
+			ctx = auth.WithPrincipal(ctx, principal)
+			ctx = tenant.WithTenant(ctx, principal.Tenant)
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+//Authorize return the echo middleware checking that the authenticated principal is granted the permission.
+//The reason of the denial is returned in the error body.
+func Authorize(policy auth.Policy, permission string, log logrus.FieldLogger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			ctx := c.Request().Context()
+			principal, ok := auth.PrincipalFromContext(ctx)
+			if !ok {
+				return unauthorized(c)
+			}
+			if err = policy.Authorize(principal, permission); err != nil {
+				logger.FromContext(ctx, log).
+					WithField("subject", principal.Subject).
+					WithField("permission", permission).
+					WithField("reason", err.Error()).
+					Warn("[AuthorizeMiddleware] Request denied")
+				return echo.NewHTTPError(http.StatusForbidden, &Denial{
+					Message: "Forbidden",
+					Reason:  err.Error(),
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+//AdminMiddleware return the echo middleware authenticating the administrator using the admin key.
+//All requests are rejected if the hash of the admin key is not configured.
+func AdminMiddleware(adminKeyHash string, log logrus.FieldLogger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			key := c.Request().Header.Get(HeaderAdminKey)
+			if adminKeyHash == "" || key == "" ||
+				subtle.ConstantTimeCompare([]byte(auth.HashKey(key)), []byte(strings.ToLower(adminKeyHash))) != 1 {
+				logger.FromContext(c.Request().Context(), log).Warn("[AdminMiddleware] Unauthenticated request")
+				return unauthorized(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+//bearerToken return the bearer token in the authorization header.
+func bearerToken(req *http.Request) string {
+	authorization := req.Header.Get(echo.HeaderAuthorization)
+	if !strings.HasPrefix(authorization, bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(bearerPrefix):])
+}
+
+//unauthorized return the unauthorized error with the challenge header.
+func unauthorized(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, strings.TrimSpace(bearerPrefix))
+	return ErrUnauthorized
+}
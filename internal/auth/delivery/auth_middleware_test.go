@@ -0,0 +1,267 @@
+// +build unit
+
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/auth/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/limit"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	errDatabaseNotOnline = errors.New("Database is not online")
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	apiKeyPrincipal := auth.Principal{Subject: "api_key:1", Method: auth.MethodAPIKey, Tenant: "merchant-a"}
+	tokenPrincipal := auth.Principal{Subject: "cashier", Method: auth.MethodJWT, Tenant: "merchant-b"}
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		wantStatus    int
+		wantPrincipal auth.Principal
+	}{
+		// TODO: Add test cases.
+		{
+			name:          "Valid API Key",
+			headers:       map[string]string{HeaderAPIKey: "valid"},
+			wantStatus:    http.StatusOK,
+			wantPrincipal: apiKeyPrincipal,
+		},
+		{
+			name:          "Valid Bearer Token",
+			headers:       map[string]string{echo.HeaderAuthorization: "Bearer valid"},
+			wantStatus:    http.StatusOK,
+			wantPrincipal: tokenPrincipal,
+		},
+		{
+			name:       "Invalid API Key",
+			headers:    map[string]string{HeaderAPIKey: "invalid"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Invalid Bearer Token",
+			headers:    map[string]string{echo.HeaderAuthorization: "Bearer invalid"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Missing Credentials",
+			headers:    map[string]string{echo.HeaderAuthorization: "Basic dXNlcjpwYXNz"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Database Error",
+			headers:    map[string]string{HeaderAPIKey: "error"},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authUcase := &mocks.Usecase{}
+			authUcase.On("AuthenticateAPIKey", mock.Anything, "valid").Return(apiKeyPrincipal, nil)
+			authUcase.On("AuthenticateAPIKey", mock.Anything, "invalid").Return(auth.Principal{}, auth.ErrInvalidCredentials)
+			authUcase.On("AuthenticateAPIKey", mock.Anything, "error").Return(auth.Principal{}, errDatabaseNotOnline)
+			authUcase.On("AuthenticateToken", mock.Anything, "valid").Return(tokenPrincipal, nil)
+			authUcase.On("AuthenticateToken", mock.Anything, "invalid").Return(auth.Principal{}, auth.ErrInvalidCredentials)
+
+			var gotPrincipal auth.Principal
+			var gotTenant string
+			e := echo.New()
+			e.GET("/bill", func(c echo.Context) error {
+				gotPrincipal, _ = auth.PrincipalFromContext(c.Request().Context())
+				gotTenant = tenant.FromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}, Middleware(authUcase, logger.Discard()))
+			req := httptest.NewRequest(http.MethodGet, "/bill", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantPrincipal, gotPrincipal)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantPrincipal.Tenant, gotTenant)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	t.Parallel()
+	clerk := auth.Principal{Subject: "api_key:1", Method: auth.MethodAPIKey, Tenant: "merchant-a", Roles: []string{"clerk"}}
+	auditor := auth.Principal{Subject: "api_key:2", Method: auth.MethodAPIKey, Tenant: "merchant-a", Roles: []string{"auditor"}}
+	authUcase := &mocks.Usecase{}
+	authUcase.On("AuthenticateAPIKey", mock.Anything, "clerk").Return(clerk, nil)
+	authUcase.On("AuthenticateAPIKey", mock.Anything, "auditor").Return(auditor, nil)
+	guard := NewGuard(authUcase, auth.Policy{
+		auth.PermissionCreateTax: []string{"clerk", "supervisor"},
+		auth.PermissionReadBill:  []string{"clerk", "supervisor", "auditor"},
+	}, logger.Discard())
+	tests := []struct {
+		name       string
+		method     string
+		apiKey     string
+		wantStatus int
+		wantBody   string
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Clerk Adds Tax Object",
+			method:     http.MethodPost,
+			apiKey:     "clerk",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Auditor Reads Bill",
+			method:     http.MethodGet,
+			apiKey:     "auditor",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Auditor Adds Tax Object",
+			method:     http.MethodPost,
+			apiKey:     "auditor",
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"message":"Forbidden","reason":"The role auditor is not allowed to create_tax, it requires the role clerk or supervisor"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.POST("/tax", func(c echo.Context) error {
+				return c.NoContent(http.StatusCreated)
+			}, guard.Protect(auth.PermissionCreateTax)...)
+			e.GET("/bill", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, guard.Protect(auth.PermissionReadBill)...)
+			req := httptest.NewRequest(tt.method, map[string]string{
+				http.MethodPost: "/tax",
+				http.MethodGet:  "/bill",
+			}[tt.method], nil)
+			req.Header.Set(HeaderAPIKey, tt.apiKey)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+
+	assert.Nil(t, Guard(nil).Protect(auth.PermissionReadBill))
+}
+
+func TestGuard_RateLimit(t *testing.T) {
+	t.Parallel()
+	clerk := auth.Principal{Subject: "api_key:1", Method: auth.MethodAPIKey, Tenant: "merchant-a", Roles: []string{"clerk"}}
+	anotherClerk := auth.Principal{Subject: "api_key:1", Method: auth.MethodAPIKey, Tenant: "merchant-b", Roles: []string{"clerk"}}
+	authUcase := &mocks.Usecase{}
+	authUcase.On("AuthenticateAPIKey", mock.Anything, "clerk").Return(clerk, nil)
+	authUcase.On("AuthenticateAPIKey", mock.Anything, "another clerk").Return(anotherClerk, nil)
+	authUcase.On("AuthenticateAPIKey", mock.Anything, "invalid").Return(auth.Principal{}, auth.ErrInvalidCredentials)
+	guard := NewGuard(authUcase, auth.Policy{
+		auth.PermissionReadBill: []string{"clerk"},
+	}, logger.Discard(), limit.RateLimit(limit.NewRateLimiter(1, 1), PrincipalKey, logger.Discard()))
+	e := echo.New()
+	e.GET("/bill", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, guard.Protect(auth.PermissionReadBill)...)
+	tests := []struct {
+		name       string
+		apiKey     string
+		wantStatus int
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Unauthenticated Request",
+			apiKey:     "invalid",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "First Request",
+			apiKey:     "clerk",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Exceeded Rate Limit",
+			apiKey:     "clerk",
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "Principal of Another Tenant",
+			apiKey:     "another clerk",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/bill", nil)
+			req.Header.Set(HeaderAPIKey, tt.apiKey)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		adminKeyHash string
+		adminKey     string
+		wantStatus   int
+	}{
+		// TODO: Add test cases.
+		{
+			name:         "Valid Admin Key",
+			adminKeyHash: auth.HashKey("admin"),
+			adminKey:     "admin",
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "Invalid Admin Key",
+			adminKeyHash: auth.HashKey("admin"),
+			adminKey:     "other",
+			wantStatus:   http.StatusUnauthorized,
+		},
+		{
+			name:         "Admin Key Not Configured",
+			adminKeyHash: "",
+			adminKey:     "admin",
+			wantStatus:   http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/admin/apikeys", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, AdminMiddleware(tt.adminKeyHash, logger.Discard()))
+			req := httptest.NewRequest(http.MethodGet, "/admin/apikeys", nil)
+			req.Header.Set(HeaderAdminKey, tt.adminKey)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
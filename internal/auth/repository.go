@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"context"
+)
+
+//Repository define the required behavior of data management in the api key.
+type Repository interface {
+	GetAll(context.Context) ([]APIKey, error)
+	GetByHash(context.Context, string) (APIKey, error)
+	Create(context.Context, *APIKey) error
+	Revoke(context.Context, int64) error
+	Close()
+	Migrate() error
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/metrics"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+//PqRepository is the repository for managing the api keys using postgre.
+type PqRepository struct {
+	pool      *sql.DB
+	log       logrus.FieldLogger
+	statement statement
+}
+
+type statement struct {
+	insert       *sql.Stmt
+	selectAll    *sql.Stmt
+	selectByHash *sql.Stmt
+	revoke       *sql.Stmt
+}
+
+//Query names used to label the database metrics.
+const (
+	nameInsert       = "api_key_insert"
+	nameSelectAll    = "api_key_select_all"
+	nameSelectByHash = "api_key_select_by_hash"
+	nameRevoke       = "api_key_revoke"
+	nameSelectOne    = "api_key_select_one"
+	nameCreateTable  = "api_key_create_table"
+	nameAddColumns   = "api_key_add_columns"
+)
+
+const (
+	queryInsert = `
+		INSERT INTO api_key
+			(id, name, tenant, role, prefix, hash, created_at)
+		VALUES
+			(DEFAULT, $1, $2, $3, $4, $5, DEFAULT)
+		RETURNING id, created_at
+	`
+	querySelectAll = `
+		SELECT
+			id, name, tenant, role, prefix, hash, created_at, revoked_at
+		FROM
+			api_key
+		ORDER BY id
+	`
+	querySelectByHash = `
+		SELECT
+			id, name, tenant, role, prefix, hash, created_at, revoked_at
+		FROM
+			api_key
+		WHERE
+			hash = $1 AND revoked_at IS NULL
+	`
+	queryRevoke = `
+		UPDATE api_key
+		SET
+			revoked_at = now()
+		WHERE
+			id = $1 AND revoked_at IS NULL
+	`
+	querySelectOne = `
+		SELECT
+			id
+		FROM
+			api_key
+		LIMIT 1
+	`
+	queryCreateTable = `
+		CREATE TABLE api_key (
+			id serial PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			tenant VARCHAR(255) NOT NULL,
+			role VARCHAR(64) NOT NULL,
+			prefix VARCHAR(16) NOT NULL,
+			hash CHAR(64) NOT NULL UNIQUE,
+			created_at timestamp with time zone NOT NULL DEFAULT now(),
+			revoked_at timestamp with time zone
+		)
+	`
+	//queryAddColumns adds the columns to the table created by the previous version.
+	//The existing api keys belong to the default tenant and have the clerk role.
+	queryAddColumns = `
+		ALTER TABLE api_key
+			ADD COLUMN IF NOT EXISTS tenant VARCHAR(255) NOT NULL DEFAULT 'default',
+			ADD COLUMN IF NOT EXISTS role VARCHAR(64) NOT NULL DEFAULT 'clerk'
+	`
+)
+
+//NewPqRepository creates the pq repository for api key with postgre connection.
+func NewPqRepository(pool *sql.DB, log logrus.FieldLogger) auth.Repository {
+	return &PqRepository{
+		pool:      pool,
+		log:       log,
+		statement: statement{},
+	}
+}
+
+//GetAll return all api keys including the revoked ones.
+func (repo *PqRepository) GetAll(ctx context.Context) (apiKeys []auth.APIKey, err error) {
+	apiKeys = make([]auth.APIKey, 0)
+	ctx, span := tracing.Start(ctx, "AuthPqRepository.GetAll", tracing.Query(nameSelectAll, querySelectAll)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectAll, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectAll, querySelectAll)
+	if err != nil {
+		return
+	}
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var apiKey auth.APIKey
+		if apiKey, err = scan(rows); err != nil {
+			return
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//GetByHash return the api key that is not revoked with the given hash.
+func (repo *PqRepository) GetByHash(ctx context.Context, hash string) (apiKey auth.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "AuthPqRepository.GetByHash", tracing.Query(nameSelectByHash, querySelectByHash)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectByHash, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectByHash, querySelectByHash)
+	if err != nil {
+		return
+	}
+	apiKey, err = scan(stmt.QueryRowContext(ctx, hash))
+	if err == sql.ErrNoRows {
+		err = auth.ErrNotFound
+	}
+	return
+}
+
+//Create create a new api key in the database.
+func (repo *PqRepository) Create(ctx context.Context, apiKey *auth.APIKey) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthPqRepository.Create", tracing.Query(nameInsert, queryInsert)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameInsert, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.insert, queryInsert)
+	if err != nil {
+		return
+	}
+	row := stmt.QueryRowContext(ctx, apiKey.Name, apiKey.Tenant, apiKey.Role, apiKey.Prefix, apiKey.Hash)
+	err = row.Scan(
+		&apiKey.ID,
+		&apiKey.CreatedAt,
+	)
+	return
+}
+
+//Revoke revoke the api key with the given id.
+func (repo *PqRepository) Revoke(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthPqRepository.Revoke", tracing.Query(nameRevoke, queryRevoke)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameRevoke, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.revoke, queryRevoke)
+	if err != nil {
+		return
+	}
+	result, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected == 0 {
+		return auth.ErrNotFound
+	}
+	return
+}
+
+//Migrate create the table in the database if it doesn't exist.
+func (repo *PqRepository) Migrate() (err error) {
+	ctx, span := tracing.Start(context.Background(), "AuthPqRepository.Migrate")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	var id int64
+	begin := time.Now()
+	row := repo.pool.QueryRowContext(ctx, querySelectOne)
+	err = row.Scan(&id)
+	if err == nil || err == sql.ErrNoRows {
+		metrics.ObserveQuery(nameSelectOne, begin, nil)
+		begin = time.Now()
+		_, err = repo.pool.ExecContext(ctx, queryAddColumns)
+		repo.observe(ctx, nameAddColumns, begin, err)
+		return
+	}
+	//The select query is expected to fail if the table doesn't exist yet.
+	metrics.ObserveQuery(nameSelectOne, begin, err)
+	repo.log.WithError(err).Info("[AuthPqRepository] Creating the api_key table")
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryCreateTable)
+	repo.observe(ctx, nameCreateTable, begin, err)
+	return
+}
+
+//Close close all prepared statements in this repository.
+func (repo *PqRepository) Close() {
+	for _, stmt := range []*sql.Stmt{
+		repo.statement.insert,
+		repo.statement.selectAll,
+		repo.statement.selectByHash,
+		repo.statement.revoke,
+	} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+//prepare lazily prepare the statement for the query and store it in the given field.
+func (repo *PqRepository) prepare(field **sql.Stmt, query string) (stmt *sql.Stmt, err error) {
+	if *field != nil {
+		return *field, nil
+	}
+	stmt, err = repo.pool.Prepare(query)
+	if err != nil {
+		return
+	}
+	*field = stmt
+	return
+}
+
+//observe records the metrics of the query started at begin and logs the error if the query failed.
+func (repo *PqRepository) observe(ctx context.Context, query string, begin time.Time, err error) {
+	metrics.ObserveQuery(query, begin, err)
+	if err != nil {
+		logger.FromContext(ctx, repo.log).
+			WithError(err).
+			WithField("query", query).
+			Error("[AuthPqRepository] Query failed")
+	}
+}
+
+//queryError return the error of the query itself.
+//The missing api key is not the failure of the query.
+func queryError(err error) error {
+	if err == auth.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+//scanner defines the row or rows that can be scanned.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//scan scan the api key from the row.
+func scan(row scanner) (apiKey auth.APIKey, err error) {
+	err = row.Scan(
+		&apiKey.ID,
+		&apiKey.Name,
+		&apiKey.Tenant,
+		&apiKey.Role,
+		&apiKey.Prefix,
+		&apiKey.Hash,
+		&apiKey.CreatedAt,
+		&apiKey.RevokedAt,
+	)
+	return
+}
@@ -0,0 +1,319 @@
+// +build unit
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	regexQueryInsert = `
+		INSERT INTO api_key
+			(.+)
+		VALUES
+			(.+)
+		RETURNING id, created_at
+	`
+	regexQuerySelectAll = `
+		SELECT
+			(.+)
+		FROM
+			api_key
+		ORDER BY id
+	`
+	regexQuerySelectByHash = `
+		SELECT
+			(.+)
+		FROM
+			api_key
+		WHERE
+			hash = (.+)
+	`
+	regexQueryRevoke = `
+		UPDATE api_key
+		SET
+			(.+)
+	`
+	regexQuerySelectOne = `
+		SELECT
+			id
+		FROM
+			api_key
+		LIMIT 1
+	`
+	regexQueryCreateTable = `
+		CREATE TABLE api_key (.+)
+	`
+	regexQueryAddColumns = `
+		ALTER TABLE api_key
+			ADD COLUMN IF NOT EXISTS (.+)
+	`
+)
+
+var (
+	errQuerying         = errors.New("Error in querying rows")
+	errRelationNotExist = errors.New("Relation still doesn't exist")
+	createdAt           = time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)
+	columns             = []string{"id", "name", "tenant", "role", "prefix", "hash", "created_at", "revoked_at"}
+)
+
+func newRepository(t *testing.T) (*PqRepository, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error starting the mocker: %s", err)
+	}
+	repo := NewPqRepository(db, logger.Discard())
+	return repo.(*PqRepository), mock, db
+}
+
+func TestPqRepository_GetAll(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		expect      func(mock sqlmock.Sqlmock)
+		wantAPIKeys []auth.APIKey
+		wantErr     bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "cashier", "merchant-a", "clerk", "tc_0123abcd", auth.HashKey("key"), createdAt, nil)
+				mock.ExpectPrepare(regexQuerySelectAll)
+				mock.ExpectQuery(regexQuerySelectAll).WillReturnRows(rows)
+			},
+			wantAPIKeys: []auth.APIKey{
+				{
+					ID:        1,
+					Name:      "cashier",
+					Tenant:    "merchant-a",
+					Role:      "clerk",
+					Prefix:    "tc_0123abcd",
+					Hash:      auth.HashKey("key"),
+					CreatedAt: createdAt,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error querying rows",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectAll)
+				mock.ExpectQuery(regexQuerySelectAll).WillReturnError(errQuerying)
+			},
+			wantAPIKeys: []auth.APIKey{},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			tt.expect(mock)
+			gotAPIKeys, err := repo.GetAll(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.GetAll() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotAPIKeys, tt.wantAPIKeys) {
+				t.Errorf("PqRepository.GetAll() = %v, want %v", gotAPIKeys, tt.wantAPIKeys)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.GetAll() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_GetByHash(t *testing.T) {
+	t.Parallel()
+	hash := auth.HashKey("key")
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		wantAPIKey auth.APIKey
+		wantErr    error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "cashier", "merchant-a", "clerk", "tc_0123abcd", hash, createdAt, nil)
+				mock.ExpectPrepare(regexQuerySelectByHash)
+				mock.ExpectQuery(regexQuerySelectByHash).WithArgs(hash).WillReturnRows(rows)
+			},
+			wantAPIKey: auth.APIKey{
+				ID:        1,
+				Name:      "cashier",
+				Tenant:    "merchant-a",
+				Role:      "clerk",
+				Prefix:    "tc_0123abcd",
+				Hash:      hash,
+				CreatedAt: createdAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Unknown or revoked key",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectByHash)
+				mock.ExpectQuery(regexQuerySelectByHash).WithArgs(hash).WillReturnRows(sqlmock.NewRows(columns))
+			},
+			wantAPIKey: auth.APIKey{},
+			wantErr:    auth.ErrNotFound,
+		},
+		{
+			name: "Error querying row",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectByHash)
+				mock.ExpectQuery(regexQuerySelectByHash).WithArgs(hash).WillReturnError(errQuerying)
+			},
+			wantAPIKey: auth.APIKey{},
+			wantErr:    errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			tt.expect(mock)
+			gotAPIKey, err := repo.GetByHash(context.Background(), hash)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantAPIKey, gotAPIKey)
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.GetByHash() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Create(t *testing.T) {
+	t.Parallel()
+	repo, mock, db := newRepository(t)
+	defer db.Close()
+	apiKey := &auth.APIKey{
+		Name:   "cashier",
+		Tenant: "merchant-a",
+		Role:   "clerk",
+		Prefix: "tc_0123abcd",
+		Hash:   auth.HashKey("key"),
+	}
+	mock.ExpectPrepare(regexQueryInsert)
+	mock.ExpectQuery(regexQueryInsert).
+		WithArgs(apiKey.Name, apiKey.Tenant, apiKey.Role, apiKey.Prefix, apiKey.Hash).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+
+	if assert.NoError(t, repo.Create(context.Background(), apiKey)) {
+		assert.Equal(t, int64(1), apiKey.ID)
+		assert.Equal(t, createdAt, apiKey.CreatedAt)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("PqRepository.Create() mock expectation were not met: %s", err)
+	}
+}
+
+func TestPqRepository_Revoke(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQueryRevoke)
+				mock.ExpectExec(regexQueryRevoke).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Unknown or already revoked key",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQueryRevoke)
+				mock.ExpectExec(regexQueryRevoke).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: auth.ErrNotFound,
+		},
+		{
+			name: "Error executing the query",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQueryRevoke)
+				mock.ExpectExec(regexQueryRevoke).WithArgs(1).WillReturnError(errQuerying)
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			tt.expect(mock)
+			assert.Equal(t, tt.wantErr, repo.Revoke(context.Background(), 1))
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Revoke() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Migrate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Table exists",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexQueryAddColumns).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: false,
+		},
+		{
+			name: "Create the table",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnError(errRelationNotExist)
+				mock.ExpectExec(regexQueryCreateTable).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error creating the table",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnError(errRelationNotExist)
+				mock.ExpectExec(regexQueryCreateTable).WillReturnError(errQuerying)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newRepository(t)
+			defer db.Close()
+			tt.expect(mock)
+			if err := repo.Migrate(); (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.Migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Migrate() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
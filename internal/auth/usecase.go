@@ -0,0 +1,14 @@
+package auth
+
+import (
+	"context"
+)
+
+//Usecase defines the required behavior for business logic in the authentication.
+type Usecase interface {
+	CreateAPIKey(context.Context, *APIKey) (string, error)
+	GetAPIKeys(context.Context) ([]APIKey, error)
+	RevokeAPIKey(context.Context, int64) error
+	AuthenticateAPIKey(context.Context, string) (Principal, error)
+	AuthenticateToken(context.Context, string) (Principal, error)
+}
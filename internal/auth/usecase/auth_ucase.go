@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//keyPrefix defines the prefix of every generated api key.
+	keyPrefix = "tc_"
+	//keyLength defines the number of random bytes in the generated api key.
+	keyLength = 32
+	//displayedPrefixLength defines the length of the key prefix stored to identify the key.
+	displayedPrefixLength = 11
+)
+
+//AuthUsecase defines all the business logic for the authentication.
+type AuthUsecase struct {
+	authRepo auth.Repository
+	jwtKeys  JWTKeys
+	log      logrus.FieldLogger
+}
+
+//NewAuthUsecase return the authentication usecase.
+func NewAuthUsecase(authRepo auth.Repository, jwtKeys JWTKeys, log logrus.FieldLogger) auth.Usecase {
+	return &AuthUsecase{
+		authRepo,
+		jwtKeys,
+		log,
+	}
+}
+
+//CreateAPIKey generate a new api key, store its hash, and return the plain key.
+//The plain key is not stored, so it can't be retrieved anymore.
+func (ucase *AuthUsecase) CreateAPIKey(ctx context.Context, apiKey *auth.APIKey) (key string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthUsecase.CreateAPIKey")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	random := make([]byte, keyLength)
+	if _, err = rand.Read(random); err != nil {
+		return
+	}
+	key = keyPrefix + hex.EncodeToString(random)
+	apiKey.Prefix = key[:displayedPrefixLength]
+	apiKey.Hash = auth.HashKey(key)
+	if err = ucase.authRepo.Create(ctx, apiKey); err != nil {
+		key = ""
+		return
+	}
+	logger.FromContext(ctx, ucase.log).
+		WithField("api_key_id", apiKey.ID).
+		Info("[AuthUsecase] API key created")
+	return
+}
+
+//GetAPIKeys return all api keys.
+func (ucase *AuthUsecase) GetAPIKeys(ctx context.Context) (apiKeys []auth.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "AuthUsecase.GetAPIKeys")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return ucase.authRepo.GetAll(ctx)
+}
+
+//RevokeAPIKey revoke the api key so it can't be used anymore.
+func (ucase *AuthUsecase) RevokeAPIKey(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthUsecase.RevokeAPIKey")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	if err = ucase.authRepo.Revoke(ctx, id); err != nil {
+		return
+	}
+	logger.FromContext(ctx, ucase.log).
+		WithField("api_key_id", id).
+		Info("[AuthUsecase] API key revoked")
+	return
+}
+
+//AuthenticateAPIKey return the principal owning the plain api key.
+func (ucase *AuthUsecase) AuthenticateAPIKey(ctx context.Context, key string) (principal auth.Principal, err error) {
+	ctx, span := tracing.Start(ctx, "AuthUsecase.AuthenticateAPIKey")
+	defer span.End()
+	apiKey, err := ucase.authRepo.GetByHash(ctx, auth.HashKey(key))
+	if err == auth.ErrNotFound {
+		err = auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return
+	}
+	principal = auth.Principal{
+		Subject: auth.MethodAPIKey + ":" + strconv.FormatInt(apiKey.ID, 10),
+		Method:  auth.MethodAPIKey,
+		Tenant:  apiKey.Tenant,
+		Roles:   []string{apiKey.Role},
+	}
+	return
+}
+
+//AuthenticateToken return the principal of the JWT bearer token.
+func (ucase *AuthUsecase) AuthenticateToken(ctx context.Context, token string) (principal auth.Principal, err error) {
+	_, span := tracing.Start(ctx, "AuthUsecase.AuthenticateToken")
+	defer span.End()
+	claims, err := ucase.jwtKeys.Verify(token)
+	if err != nil {
+		logger.FromContext(ctx, ucase.log).WithError(err).Debug("[AuthUsecase] Invalid token")
+		err = auth.ErrInvalidCredentials
+		return
+	}
+	principal = auth.Principal{
+		Subject: claims.Subject,
+		Method:  auth.MethodJWT,
+		Tenant:  claims.Tenant,
+		Roles:   claims.Roles,
+	}
+	return
+}
@@ -0,0 +1,181 @@
+// +build unit
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/fairyhunter13/tax-calculator/internal/auth/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	errDatabaseNotOnline = errors.New("Database is not online")
+	secret               = []byte("secret")
+)
+
+func TestAuthUsecase_CreateAPIKey(t *testing.T) {
+	t.Parallel()
+	authRepo := &mocks.Repository{}
+	authRepo.On("Create", mock.Anything, mock.AnythingOfType("*auth.APIKey")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*auth.APIKey).ID = 1
+		}).
+		Return(nil)
+	ucase := NewAuthUsecase(authRepo, JWTKeys{}, logger.Discard())
+
+	apiKey := &auth.APIKey{Name: "cashier"}
+	key, err := ucase.CreateAPIKey(context.Background(), apiKey)
+	if assert.NoError(t, err) {
+		assert.True(t, strings.HasPrefix(key, keyPrefix))
+		assert.Len(t, key, len(keyPrefix)+keyLength*2)
+		assert.Equal(t, key[:displayedPrefixLength], apiKey.Prefix)
+		assert.Equal(t, auth.HashKey(key), apiKey.Hash)
+		assert.Equal(t, int64(1), apiKey.ID)
+	}
+}
+
+func TestAuthUsecase_CreateAPIKey_Error(t *testing.T) {
+	t.Parallel()
+	authRepo := &mocks.Repository{}
+	authRepo.On("Create", mock.Anything, mock.Anything).Return(errDatabaseNotOnline)
+	ucase := NewAuthUsecase(authRepo, JWTKeys{}, logger.Discard())
+
+	key, err := ucase.CreateAPIKey(context.Background(), &auth.APIKey{Name: "cashier"})
+	assert.Equal(t, errDatabaseNotOnline, err)
+	assert.Empty(t, key)
+}
+
+func TestAuthUsecase_AuthenticateAPIKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		apiKey        auth.APIKey
+		repoErr       error
+		wantPrincipal auth.Principal
+		wantErr       error
+	}{
+		// TODO: Add test cases.
+		{
+			name:   "Valid Key",
+			apiKey: auth.APIKey{ID: 7, Name: "cashier", Tenant: "merchant-a", Role: "clerk"},
+			wantPrincipal: auth.Principal{
+				Subject: "api_key:7",
+				Method:  auth.MethodAPIKey,
+				Tenant:  "merchant-a",
+				Roles:   []string{"clerk"},
+			},
+		},
+		{
+			name:    "Unknown Key",
+			repoErr: auth.ErrNotFound,
+			wantErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:    "Database Error",
+			repoErr: errDatabaseNotOnline,
+			wantErr: errDatabaseNotOnline,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authRepo := &mocks.Repository{}
+			authRepo.On("GetByHash", mock.Anything, auth.HashKey("key")).Return(tt.apiKey, tt.repoErr)
+			ucase := NewAuthUsecase(authRepo, JWTKeys{}, logger.Discard())
+
+			principal, err := ucase.AuthenticateAPIKey(context.Background(), "key")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantPrincipal, principal)
+		})
+	}
+}
+
+func TestAuthUsecase_AuthenticateToken(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		claims        Claims
+		wantPrincipal auth.Principal
+		wantErr       error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Valid Token",
+			claims: Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   "cashier",
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				},
+				Tenant: "merchant-a",
+				Roles:  []string{"clerk", "auditor"},
+			},
+			wantPrincipal: auth.Principal{
+				Subject: "cashier",
+				Method:  auth.MethodJWT,
+				Tenant:  "merchant-a",
+				Roles:   []string{"clerk", "auditor"},
+			},
+		},
+		{
+			name: "Expired Token",
+			claims: Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   "cashier",
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+				},
+				Tenant: "merchant-a",
+			},
+			wantErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name: "Missing Expiration",
+			claims: Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: "cashier"},
+				Tenant:           "merchant-a",
+			},
+			wantErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name: "Missing Tenant",
+			claims: Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   "cashier",
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				},
+			},
+			wantErr: auth.ErrInvalidCredentials,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString(secret)
+			if err != nil {
+				t.Fatalf("Error signing the token: %s", err)
+			}
+			ucase := NewAuthUsecase(&mocks.Repository{}, JWTKeys{Secret: secret}, logger.Discard())
+
+			principal, err := ucase.AuthenticateToken(context.Background(), token)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantPrincipal, principal)
+		})
+	}
+}
+
+func TestAuthUsecase_RevokeAPIKey(t *testing.T) {
+	t.Parallel()
+	authRepo := &mocks.Repository{}
+	authRepo.On("Revoke", mock.Anything, int64(1)).Return(nil)
+	authRepo.On("Revoke", mock.Anything, int64(2)).Return(auth.ErrNotFound)
+	ucase := NewAuthUsecase(authRepo, JWTKeys{}, logger.Discard())
+
+	assert.NoError(t, ucase.RevokeAPIKey(context.Background(), 1))
+	assert.Equal(t, auth.ErrNotFound, ucase.RevokeAPIKey(context.Background(), 2))
+}
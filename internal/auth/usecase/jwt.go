@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	//ErrNoKey defines the error if there is no key to verify the token.
+	ErrNoKey = errors.New("No key to verify the token")
+	//ErrInvalidClaims defines the error if the token doesn't have the expected claims.
+	ErrInvalidClaims = errors.New("Invalid token claims")
+	//ErrUnsupportedKey defines the error if the key in the JWKS file is not supported.
+	ErrUnsupportedKey = errors.New("Unsupported JWKS key")
+)
+
+var (
+	validMethods = []string{
+		"HS256", "HS384", "HS512",
+		"RS256", "RS384", "RS512",
+		"ES256", "ES384", "ES512",
+	}
+	curves = map[string]elliptic.Curve{
+		"P-256": elliptic.P256(),
+		"P-384": elliptic.P384(),
+		"P-521": elliptic.P521(),
+	}
+)
+
+//JWTKeys define the keys and the expected claims to verify the JWT bearer token.
+//The shared secret verifies the HMAC tokens and the public keys verify the RSA and ECDSA tokens.
+type JWTKeys struct {
+	Secret   []byte
+	Public   map[string]interface{}
+	Issuer   string
+	Audience string
+}
+
+//Claims define the claims of the JWT bearer token.
+//The exp claim is required, so the token can't be used forever.
+//The tenant claim is required to isolate the data of the tenants.
+//The roles claim defines what the client is allowed to do.
+type Claims struct {
+	jwt.RegisteredClaims
+	Tenant string   `json:"tenant"`
+	Roles  []string `json:"roles"`
+}
+
+//jwks define the JSON Web Key Set file.
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+//jwk define the public key in the JSON Web Key Set file.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+//Verify verify the signature and the claims of the token.
+//The token without the exp claim is rejected, so every token expires.
+func (keys JWTKeys) Verify(token string) (claims *Claims, err error) {
+	claims = new(Claims)
+	_, err = jwt.ParseWithClaims(token, claims, keys.keyfunc, jwt.WithValidMethods(validMethods))
+	if err != nil {
+		return
+	}
+	if claims.Subject == "" || claims.Tenant == "" || claims.ExpiresAt == nil ||
+		(keys.Issuer != "" && !claims.VerifyIssuer(keys.Issuer, true)) ||
+		(keys.Audience != "" && !claims.VerifyAudience(keys.Audience, true)) {
+		err = ErrInvalidClaims
+	}
+	return
+}
+
+//keyfunc return the key to verify the token based on its signing method and key id.
+func (keys JWTKeys) keyfunc(token *jwt.Token) (key interface{}, err error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(keys.Secret) == 0 {
+			return nil, ErrNoKey
+		}
+		return keys.Secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keys.Public[kid]
+	if !ok {
+		return nil, ErrNoKey
+	}
+	return
+}
+
+//LoadJWKS load the RSA and ECDSA public keys in the JWKS file indexed by their key id.
+func LoadJWKS(path string) (keys map[string]interface{}, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	set := jwks{}
+	if err = json.Unmarshal(content, &set); err != nil {
+		return
+	}
+	keys = make(map[string]interface{}, len(set.Keys))
+	for _, key := range set.Keys {
+		var publicKey interface{}
+		if publicKey, err = key.publicKey(); err != nil {
+			return nil, err
+		}
+		keys[key.Kid] = publicKey
+	}
+	return
+}
+
+//publicKey return the RSA or ECDSA public key of the JWK.
+func (key jwk) publicKey() (publicKey interface{}, err error) {
+	switch key.Kty {
+	case "RSA":
+		var n, e *big.Int
+		if n, err = decodeBase64Int(key.N); err != nil {
+			return
+		}
+		if e, err = decodeBase64Int(key.E); err != nil {
+			return
+		}
+		publicKey = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		curve, ok := curves[key.Crv]
+		if !ok {
+			return nil, ErrUnsupportedKey
+		}
+		var x, y *big.Int
+		if x, err = decodeBase64Int(key.X); err != nil {
+			return
+		}
+		if y, err = decodeBase64Int(key.Y); err != nil {
+			return
+		}
+		publicKey = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	default:
+		err = ErrUnsupportedKey
+	}
+	return
+}
+
+//decodeBase64Int decode the base64url encoded big-endian integer.
+func decodeBase64Int(value string) (number *big.Int, err error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return
+	}
+	number = new(big.Int).SetBytes(decoded)
+	return
+}
@@ -0,0 +1,191 @@
+// +build unit
+
+package usecase
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTKeys_Verify(t *testing.T) {
+	t.Parallel()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating the RSA key: %s", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating the ECDSA key: %s", err)
+	}
+	keys := JWTKeys{
+		Secret: secret,
+		Public: map[string]interface{}{
+			"rsa": &rsaKey.PublicKey,
+			"ec":  &ecKey.PublicKey,
+		},
+		Issuer:   "https://issuer.example.com",
+		Audience: "tax-calculator",
+	}
+	validClaims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "cashier",
+			Issuer:    keys.Issuer,
+			Audience:  jwt.ClaimStrings{keys.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Tenant: "merchant-a",
+	}
+	sign := func(method jwt.SigningMethod, kid string, claims Claims, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("Error signing the token: %s", err)
+		}
+		return signed
+	}
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:    "HMAC Token",
+			token:   sign(jwt.SigningMethodHS256, "", validClaims, secret),
+			wantErr: false,
+		},
+		{
+			name:    "RSA Token",
+			token:   sign(jwt.SigningMethodRS256, "rsa", validClaims, rsaKey),
+			wantErr: false,
+		},
+		{
+			name:    "ECDSA Token",
+			token:   sign(jwt.SigningMethodES256, "ec", validClaims, ecKey),
+			wantErr: false,
+		},
+		{
+			name:    "Unknown Key ID",
+			token:   sign(jwt.SigningMethodRS256, "other", validClaims, rsaKey),
+			wantErr: true,
+		},
+		{
+			name:    "Wrong Secret",
+			token:   sign(jwt.SigningMethodHS256, "", validClaims, []byte("other")),
+			wantErr: true,
+		},
+		{
+			name: "Wrong Audience",
+			token: sign(jwt.SigningMethodHS256, "", Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   "cashier",
+					Issuer:    keys.Issuer,
+					Audience:  jwt.ClaimStrings{"other"},
+					ExpiresAt: validClaims.ExpiresAt,
+				},
+				Tenant: "merchant-a",
+			}, secret),
+			wantErr: true,
+		},
+		{
+			name: "Missing Subject",
+			token: sign(jwt.SigningMethodHS256, "", Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    keys.Issuer,
+					Audience:  jwt.ClaimStrings{keys.Audience},
+					ExpiresAt: validClaims.ExpiresAt,
+				},
+				Tenant: "merchant-a",
+			}, secret),
+			wantErr: true,
+		},
+		{
+			name: "Missing Expiration",
+			token: sign(jwt.SigningMethodHS256, "", Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:  "cashier",
+					Issuer:   keys.Issuer,
+					Audience: jwt.ClaimStrings{keys.Audience},
+				},
+				Tenant: "merchant-a",
+			}, secret),
+			wantErr: true,
+		},
+		{
+			name:    "Unsigned Token",
+			token:   sign(jwt.SigningMethodNone, "", validClaims, jwt.UnsafeAllowNoneSignatureType),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := keys.Verify(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JWTKeys.Verify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, "cashier", claims.Subject)
+				assert.Equal(t, "merchant-a", claims.Tenant)
+			}
+		})
+	}
+}
+
+func TestLoadJWKS(t *testing.T) {
+	t.Parallel()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating the RSA key: %s", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating the ECDSA key: %s", err)
+	}
+	encode := func(number *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(number.Bytes())
+	}
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatalf("Error creating the temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jwks.json")
+	content := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q}
+	]}`, encode(rsaKey.N), encode(big.NewInt(int64(rsaKey.E))), encode(ecKey.X), encode(ecKey.Y))
+	if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Error writing the JWKS file: %s", err)
+	}
+
+	keys, err := LoadJWKS(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &rsaKey.PublicKey, keys["rsa"])
+		assert.Equal(t, ecKey.PublicKey.X, keys["ec"].(*ecdsa.PublicKey).X)
+		assert.Equal(t, ecKey.PublicKey.Y, keys["ec"].(*ecdsa.PublicKey).Y)
+	}
+
+	unsupported := filepath.Join(dir, "unsupported.json")
+	if err = ioutil.WriteFile(unsupported, []byte(`{"keys": [{"kty": "oct", "kid": "hmac"}]}`), 0600); err != nil {
+		t.Fatalf("Error writing the JWKS file: %s", err)
+	}
+	_, err = LoadJWKS(unsupported)
+	assert.Equal(t, ErrUnsupportedKey, err)
+}
@@ -1,21 +1,111 @@
 package bill
 
-//Bill define the data model for bill.
-//Bill list all the calculated data from the tax objects.
-//This data that will be seen by user.
-type Bill struct {
-	Name       string  `json:"name"`
-	TaxCode    int64   `json:"tax_code"`
-	Type       string  `json:"type"`
-	Refundable string  `json:"refundable"`
-	Price      float64 `json:"price"`
-	Tax        float64 `json:"tax"`
-	Amount     float64 `json:"amount"`
+import (
+	"errors"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+)
+
+var (
+	//ErrNotFound defines the error if the bill doesn't exist in the tenant.
+	ErrNotFound = errors.New("Bill not found")
+	//ErrFinalized defines the error if the bill has been finalized, so its tax objects can't be changed.
+	ErrFinalized = errors.New("The bill has been finalized")
+	//ErrEmpty defines the error if the bill to finalize has no lines.
+	ErrEmpty = errors.New("The bill is empty")
+	//ErrInvoiceNotFound defines the error if the invoice doesn't exist in the tenant.
+	ErrInvoiceNotFound = errors.New("Invoice not found")
+	//ErrLineNotFound defines the error if the line to refund doesn't exist in the invoice.
+	ErrLineNotFound = errors.New("Invoice line not found")
+	//ErrRefunded defines the error if the line to refund has been refunded, or all lines have been refunded.
+	ErrRefunded = errors.New("The line has been refunded")
+	//ErrCreditNoteNotFound defines the error if the credit note doesn't exist in the tenant.
+	ErrCreditNoteNotFound = errors.New("Credit note not found")
+	//ErrCouponExists defines the error if the coupon with the same code has been applied to the bill.
+	ErrCouponExists = errors.New("The coupon has been applied")
+	//ErrCouponNotFound defines the error if the coupon hasn't been applied to the bill.
+	ErrCouponNotFound = errors.New("Coupon not found")
+	//ErrChargeExists defines the error if the charge with the same name has been added to the bill.
+	ErrChargeExists = errors.New("The charge has been added")
+	//ErrChargeNotFound defines the error if the charge hasn't been added to the bill.
+	ErrChargeNotFound = errors.New("Charge not found")
+)
+
+const (
+	//ChargePercent defines the charge of the percentage of the subtotal of the bill.
+	ChargePercent = taxcalc.ChargePercent
+	//ChargeFixed defines the charge of the fixed amount.
+	ChargeFixed = taxcalc.ChargeFixed
+)
+
+//The calculated lines, charges, and totals of the bill are the models of the calculation.
+type (
+	//Bill define the data model for bill.
+	//Bill list all the calculated data from the tax objects.
+	//This data that will be seen by user.
+	Bill = taxcalc.Line
+	//Explanation define how the taxes of the bill line are derived from the rules of its jurisdiction.
+	Explanation = taxcalc.Explanation
+	//TaxExplanation define how the tax of one tax component of the bill line is derived from its rule.
+	TaxExplanation = taxcalc.TaxExplanation
+	//TaxComponent define the tax of the line calculated by the rule of one tax code.
+	TaxComponent = taxcalc.TaxComponent
+	//TaxSummary define the base and the tax of all taxes of one tax code of one jurisdiction in the bill.
+	TaxSummary = taxcalc.TaxSummary
+	//Total define the total calculation for each price, discount, tax, and amount.
+	Total = taxcalc.Total
+	//Breakdown define the lines of one tax code of one jurisdiction in the bill with their subtotals.
+	Breakdown = taxcalc.Breakdown
+	//Coupon define the discount of the bill applied to all its lines after the discounts of the lines.
+	Coupon = taxcalc.Coupon
+	//Charge define the charge of the bill, e.g. the service charge or the tip.
+	Charge = taxcalc.Charge
+	//ChargeLine define the calculated charge of the bill.
+	ChargeLine = taxcalc.ChargeLine
+)
+
+//Snapshot define the open bill of the tenant read at once,
+//so its lines, coupons, charges, breakdown, total, and refunds are consistent with each other.
+//The id is 0 if the tenant has no open bill yet.
+type Snapshot struct {
+	ID        int64
+	Bills     []Bill
+	Coupons   []Coupon
+	Charges   []ChargeLine
+	Breakdown []Breakdown
+	Total     Total
+	Refunds   Total
+}
+
+//Invoice define the finalized bill with its lines and total frozen as issued.
+//The number is sequential and gap-free in every tenant.
+//The coupons are the coupons applied to the bill when it's finalized, and the charges are the charges calculated then.
+type Invoice struct {
+	Number   int64        `json:"number"`
+	BillID   int64        `json:"bill_id"`
+	Bill     []Bill       `json:"bill"`
+	Coupons  []Coupon     `json:"coupons,omitempty"`
+	Charges  []ChargeLine `json:"charges,omitempty"`
+	Total    Total        `json:"total"`
+	IssuedAt time.Time    `json:"issued_at"`
+	Tenant   string       `json:"-"`
+}
+
+//CreditLine define the refunded line of the invoice with the negative price, tax, and amount.
+//The line is the index of the refunded line in the invoice.
+type CreditLine struct {
+	Line int `json:"line"`
+	Bill
 }
 
-//Total define the total calculation for each price, tax, and amount.
-type Total struct {
-	PriceSubtotal float64 `json:"price_subtotal"`
-	TaxSubtotal   float64 `json:"tax_subtotal"`
-	GrandTotal    float64 `json:"grand_total"`
+//CreditNote define the document refunding the lines of the invoice.
+//The number is sequential and gap-free in every tenant, separately from the invoice numbers.
+type CreditNote struct {
+	Number        int64        `json:"number"`
+	InvoiceNumber int64        `json:"invoice_number"`
+	Lines         []CreditLine `json:"lines"`
+	Total         Total        `json:"total"`
+	IssuedAt      time.Time    `json:"issued_at"`
+	Tenant        string       `json:"-"`
 }
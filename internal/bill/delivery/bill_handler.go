@@ -2,41 +2,386 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"sync"
 
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	authDelivery "github.com/fairyhunter13/tax-calculator/internal/auth/delivery"
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
 	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+var (
+	//ErrInvalidInput defines the error response returned if the id or the number in the path is not valid.
+	ErrInvalidInput = echo.NewHTTPError(http.StatusBadRequest, "Invalid input")
+	//ErrJurisdictionNotFound defines the error response returned if the jurisdiction of the charge isn't in the rules.
+	ErrJurisdictionNotFound = echo.NewHTTPError(http.StatusBadRequest, "Jurisdiction not found")
+	//ErrNotFound defines the error response returned if the bill doesn't exist in the tenant.
+	ErrNotFound = echo.NewHTTPError(http.StatusNotFound, "Bill not found")
+	//ErrInvoiceNotFound defines the error response returned if the invoice doesn't exist in the tenant.
+	ErrInvoiceNotFound = echo.NewHTTPError(http.StatusNotFound, "Invoice not found")
+	//ErrFinalized defines the error response returned if the bill has been finalized.
+	ErrFinalized = echo.NewHTTPError(http.StatusConflict, "The bill has been finalized")
+	//ErrEmpty defines the error response returned if the bill to finalize has no lines.
+	ErrEmpty = echo.NewHTTPError(http.StatusConflict, "The bill is empty")
+	//ErrLineNotFound defines the error response returned if the line to refund doesn't exist in the invoice.
+	ErrLineNotFound = echo.NewHTTPError(http.StatusBadRequest, "Invoice line not found")
+	//ErrRefunded defines the error response returned if the line to refund has been refunded.
+	ErrRefunded = echo.NewHTTPError(http.StatusConflict, "The line has been refunded")
+	//ErrCreditNoteNotFound defines the error response returned if the credit note doesn't exist in the tenant.
+	ErrCreditNoteNotFound = echo.NewHTTPError(http.StatusNotFound, "Credit note not found")
+	//ErrCouponExists defines the error response returned if the coupon with the same code has been applied to the bill.
+	ErrCouponExists = echo.NewHTTPError(http.StatusConflict, "The coupon has been applied")
+	//ErrCouponNotFound defines the error response returned if the coupon hasn't been applied to the bill.
+	ErrCouponNotFound = echo.NewHTTPError(http.StatusNotFound, "Coupon not found")
+	//ErrChargeExists defines the error response returned if the charge with the same name has been added to the bill.
+	ErrChargeExists = echo.NewHTTPError(http.StatusConflict, "The charge has been added")
+	//ErrChargeNotFound defines the error response returned if the charge hasn't been added to the bill.
+	ErrChargeNotFound = echo.NewHTTPError(http.StatusNotFound, "Charge not found")
 )
 
 //HTTPBillHandler define the http delivery layer for the bill.
+//The rules validate the jurisdictions of the charges and explain the bill lines, so they must be the rules calculating them.
 type HTTPBillHandler struct {
 	billUcase bill.Usecase
+	rules     taxcalc.Rules
+	log       logrus.FieldLogger
 }
 
 //BillResponse define the default json response for the bill.
+//The id is the id of the open bill to finalize, it's omitted if the tenant has no open bill yet.
+//The coupons are the coupons applied to the open bill in the order they are applied.
+//The charges are the calculated charges of the open bill, shown separately from the lines.
+//The breakdown groups the lines of the open bill by their tax code.
+//The refunds are the total of all credit notes of the tenant.
 type BillResponse struct {
-	Bill  []bill.Bill `json:"bill"`
-	Total bill.Total  `json:"total"`
+	ID        int64             `json:"id,omitempty"`
+	Bill      []bill.Bill       `json:"bill"`
+	Coupons   []bill.Coupon     `json:"coupons"`
+	Charges   []bill.ChargeLine `json:"charges"`
+	Breakdown []bill.Breakdown  `json:"breakdown"`
+	Total     bill.Total        `json:"total"`
+	Refunds   bill.Total        `json:"refunds"`
+}
+
+//RefundRequest define the json request to refund the invoice.
+//The lines are the indexes of the invoice lines to refund, all lines that haven't been refunded if it's empty.
+type RefundRequest struct {
+	Lines []int `json:"lines"`
 }
 
 var (
-	httpHandler *HTTPBillHandler
+	httpHandler      *HTTPBillHandler
+	once             sync.Once
+	requestValidator *validator.Validate
 )
 
+func init() {
+	//Init once request validator.
+	once.Do(func() {
+		requestValidator = validator.New()
+	})
+}
+
 //NewHTTPBillHandler define the routing for HTTPBillHandler.
-func NewHTTPBillHandler(e *echo.Echo, billUcase bill.Usecase) {
+//Every route is protected by the guard with the permission of the route.
+func NewHTTPBillHandler(e *echo.Echo, billUcase bill.Usecase, rules taxcalc.Rules, log logrus.FieldLogger, guard authDelivery.Guard) {
 	httpHandler = &HTTPBillHandler{
 		billUcase,
+		rules,
+		log,
 	}
-	e.GET("/bill", httpHandler.GetBill)
+	e.GET("/bill", httpHandler.GetBill, guard.Protect(auth.PermissionReadBill)...)
+	e.POST("/bills/:id/finalize", httpHandler.FinalizeBill, guard.Protect(auth.PermissionFinalizeBill)...)
+	e.POST("/bills/:id/coupons", httpHandler.AddCoupon, guard.Protect(auth.PermissionDiscountBill)...)
+	e.DELETE("/bills/:id/coupons/:code", httpHandler.RemoveCoupon, guard.Protect(auth.PermissionDiscountBill)...)
+	e.POST("/bills/:id/charges", httpHandler.AddCharge, guard.Protect(auth.PermissionChargeBill)...)
+	e.DELETE("/bills/:id/charges/:name", httpHandler.RemoveCharge, guard.Protect(auth.PermissionChargeBill)...)
+	e.GET("/invoices/:number", httpHandler.GetInvoice, guard.Protect(auth.PermissionReadBill)...)
+	e.POST("/invoices/:number/refunds", httpHandler.RefundInvoice, guard.Protect(auth.PermissionRefundBill)...)
+	e.GET("/credit-notes/:number", httpHandler.GetCreditNote, guard.Protect(auth.PermissionReadBill)...)
 }
 
 //GetBill get the bill list that has been calculated.
+//The lines are explained if the explain query parameter is true.
 func (handler *HTTPBillHandler) GetBill(c echo.Context) (err error) {
-	bills, total := handler.billUcase.GetBill()
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.GetBill")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	explain, err := ParseExplain(c)
+	if err != nil {
+		return
+	}
+	snapshot := handler.billUcase.GetBill(ctx)
+	bills := snapshot.Bills
+	if explain {
+		bills = taxcalc.ExplainAll(handler.rules, bills)
+	}
+	logger.FromContext(ctx, handler.log).
+		WithField("count", len(bills)).
+		Debug("[HTTPBillHandler] Bill fetched")
 	billResp := &BillResponse{
-		Bill:  bills,
-		Total: total,
+		ID:        snapshot.ID,
+		Bill:      bills,
+		Coupons:   snapshot.Coupons,
+		Charges:   snapshot.Charges,
+		Breakdown: snapshot.Breakdown,
+		Total:     snapshot.Total,
+		Refunds:   snapshot.Refunds,
 	}
 	c.JSON(http.StatusOK, billResp)
 	return
 }
+
+//ParseExplain return true if the explain query parameter of the request is true,
+//or ErrInvalidInput if it's not a boolean.
+func ParseExplain(c echo.Context) (explain bool, err error) {
+	value := c.QueryParam("explain")
+	if value == "" {
+		return
+	}
+	if explain, err = strconv.ParseBool(value); err != nil {
+		err = ErrInvalidInput
+	}
+	return
+}
+
+//FinalizeBill handle request for finalizing the bill into the invoice.
+func (handler *HTTPBillHandler) FinalizeBill(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.FinalizeBill")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	invoice, err := handler.billUcase.FinalizeBill(ctx, id)
+	switch err {
+	case nil:
+	case bill.ErrNotFound:
+		err = ErrNotFound
+		return
+	case bill.ErrFinalized:
+		err = ErrFinalized
+		return
+	case bill.ErrEmpty:
+		err = ErrEmpty
+		return
+	default:
+		return
+	}
+	err = c.JSON(http.StatusCreated, &invoice)
+	return
+}
+
+//AddCoupon handle request for applying the coupon to the open bill.
+func (handler *HTTPBillHandler) AddCoupon(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.AddCoupon")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	coupon := bill.Coupon{}
+	if err = c.Bind(&coupon); err != nil {
+		logger.FromContext(ctx, handler.log).WithError(err).Warn("[HTTPBillHandler] Failed to bind the request")
+		err = ErrInvalidInput
+		return
+	}
+	if err = requestValidator.Struct(&coupon); err != nil {
+		logger.FromContext(ctx, handler.log).WithError(err).Warn("[HTTPBillHandler] Failed to validate the request")
+		err = ErrInvalidInput
+		return
+	}
+	coupons, err := handler.billUcase.AddCoupon(ctx, id, coupon)
+	if err = changeError(err); err != nil {
+		return
+	}
+	err = c.JSON(http.StatusCreated, coupons)
+	return
+}
+
+//RemoveCoupon handle request for removing the coupon from the open bill.
+func (handler *HTTPBillHandler) RemoveCoupon(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.RemoveCoupon")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	if err = changeError(handler.billUcase.RemoveCoupon(ctx, id, c.Param("code"))); err != nil {
+		return
+	}
+	err = c.NoContent(http.StatusNoContent)
+	return
+}
+
+//AddCharge handle request for adding the charge to the open bill.
+func (handler *HTTPBillHandler) AddCharge(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.AddCharge")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	charge := bill.Charge{}
+	if err = c.Bind(&charge); err != nil {
+		logger.FromContext(ctx, handler.log).WithError(err).Warn("[HTTPBillHandler] Failed to bind the request")
+		err = ErrInvalidInput
+		return
+	}
+	if err = requestValidator.Struct(&charge); err != nil {
+		logger.FromContext(ctx, handler.log).WithError(err).Warn("[HTTPBillHandler] Failed to validate the request")
+		err = ErrInvalidInput
+		return
+	}
+	if !handler.rules.HasJurisdiction(charge.Jurisdiction) {
+		logger.FromContext(ctx, handler.log).
+			WithField("jurisdiction", charge.Jurisdiction).
+			Warn("[HTTPBillHandler] Jurisdiction not found")
+		err = ErrJurisdictionNotFound
+		return
+	}
+	charges, err := handler.billUcase.AddCharge(ctx, id, charge)
+	if err = changeError(err); err != nil {
+		return
+	}
+	err = c.JSON(http.StatusCreated, charges)
+	return
+}
+
+//RemoveCharge handle request for removing the charge from the open bill.
+func (handler *HTTPBillHandler) RemoveCharge(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.RemoveCharge")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	if err = changeError(handler.billUcase.RemoveCharge(ctx, id, c.Param("name"))); err != nil {
+		return
+	}
+	err = c.NoContent(http.StatusNoContent)
+	return
+}
+
+//GetInvoice handle request for getting the invoice as it was issued.
+func (handler *HTTPBillHandler) GetInvoice(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.GetInvoice")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	number, err := strconv.ParseInt(c.Param("number"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	invoice, err := handler.billUcase.GetInvoice(ctx, number)
+	if err == bill.ErrInvoiceNotFound {
+		err = ErrInvoiceNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+	err = c.JSON(http.StatusOK, &invoice)
+	return
+}
+
+//RefundInvoice handle request for refunding the lines of the invoice with the credit note.
+func (handler *HTTPBillHandler) RefundInvoice(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.RefundInvoice")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	number, err := strconv.ParseInt(c.Param("number"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	request := RefundRequest{}
+	if err = c.Bind(&request); err != nil {
+		logger.FromContext(ctx, handler.log).WithError(err).Warn("[HTTPBillHandler] Failed to bind the request")
+		err = ErrInvalidInput
+		return
+	}
+	creditNote, err := handler.billUcase.RefundInvoice(ctx, number, request.Lines)
+	switch err {
+	case nil:
+	case bill.ErrInvoiceNotFound:
+		err = ErrInvoiceNotFound
+		return
+	case bill.ErrLineNotFound:
+		err = ErrLineNotFound
+		return
+	case bill.ErrRefunded:
+		err = ErrRefunded
+		return
+	default:
+		return
+	}
+	err = c.JSON(http.StatusCreated, &creditNote)
+	return
+}
+
+//GetCreditNote handle request for getting the credit note as it was issued.
+func (handler *HTTPBillHandler) GetCreditNote(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPBillHandler.GetCreditNote")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	number, err := strconv.ParseInt(c.Param("number"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	creditNote, err := handler.billUcase.GetCreditNote(ctx, number)
+	if err == bill.ErrCreditNoteNotFound {
+		err = ErrCreditNoteNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+	err = c.JSON(http.StatusOK, &creditNote)
+	return
+}
+
+//changeError return the error response of the error changing the coupons or the charges of the bill.
+func changeError(err error) error {
+	switch err {
+	case bill.ErrNotFound:
+		return ErrNotFound
+	case bill.ErrFinalized:
+		return ErrFinalized
+	case bill.ErrCouponExists:
+		return ErrCouponExists
+	case bill.ErrCouponNotFound:
+		return ErrCouponNotFound
+	case bill.ErrChargeExists:
+		return ErrChargeExists
+	case bill.ErrChargeNotFound:
+		return ErrChargeNotFound
+	}
+	return err
+}
@@ -6,12 +6,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
 	"github.com/fairyhunter13/tax-calculator/internal/bill/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestHTTPBillHandler_GetBill_EmptyData(t *testing.T) {
@@ -22,12 +28,23 @@ func TestHTTPBillHandler_GetBill_EmptyData(t *testing.T) {
 	ctx := e.NewContext(req, rec)
 	billUcase := &mocks.Usecase{}
 	actualResponse := BillResponse{
-		Bill:  []bill.Bill{},
-		Total: bill.Total{},
+		Bill:      []bill.Bill{},
+		Coupons:   []bill.Coupon{},
+		Charges:   []bill.ChargeLine{},
+		Breakdown: []bill.Breakdown{},
+		Total:     bill.Total{},
 	}
-	billUcase.On("GetBill").Return(actualResponse.Bill, actualResponse.Total)
+	billUcase.On("GetBill", mock.Anything).Return(bill.Snapshot{
+		Bills:     actualResponse.Bill,
+		Coupons:   actualResponse.Coupons,
+		Charges:   actualResponse.Charges,
+		Breakdown: actualResponse.Breakdown,
+		Total:     actualResponse.Total,
+	})
 	h := &HTTPBillHandler{
 		billUcase: billUcase,
+		rules:     taxcalc.NewJurisdictions(),
+		log:       logger.Discard(),
 	}
 
 	// Assertions using testify framework
@@ -51,26 +68,70 @@ func TestHTTPBillHandler_GetBill_AvailableData(t *testing.T) {
 	ctx := e.NewContext(req, rec)
 	billUcase := &mocks.Usecase{}
 	actualResponse := BillResponse{
+		ID: 7,
 		Bill: []bill.Bill{
 			bill.Bill{
-				Name:       "MACD",
-				TaxCode:    1,
-				Price:      20000,
-				Tax:        2000,
-				Type:       "Food & Beverage",
-				Refundable: "Yes",
-				Amount:     22000,
+				Name:        "MACD",
+				TaxCode:     1,
+				Price:       20000,
+				Discount:    2000,
+				Coupon:      1000,
+				TaxableBase: 17000,
+				Tax:         1700,
+				Type:        "Food & Beverage",
+				Refundable:  "Yes",
+				Amount:      18700,
+			},
+		},
+		Coupons: []bill.Coupon{
+			{Code: "WELCOME", Discount: taxobj.Discount{Type: taxobj.DiscountFixed, Value: 1000}},
+		},
+		Charges: []bill.ChargeLine{
+			{
+				Charge: bill.Charge{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 10},
+				Price:  1700,
+				Tax:    170,
+				Amount: 1870,
+			},
+		},
+		Breakdown: []bill.Breakdown{
+			{
+				TaxCode:       1,
+				Type:          "Food & Beverage",
+				Lines:         1,
+				PriceSubtotal: 20000,
+				TaxSubtotal:   1700,
+				RefundableTax: 1700,
 			},
 		},
 		Total: bill.Total{
-			PriceSubtotal: 20000,
-			TaxSubtotal:   2000,
-			GrandTotal:    22000,
+			PriceSubtotal:     20000,
+			DiscountSubtotal:  2000,
+			CouponSubtotal:    1000,
+			TaxSubtotal:       1700,
+			ChargeSubtotal:    1700,
+			ChargeTaxSubtotal: 170,
+			GrandTotal:        20570,
+		},
+		Refunds: bill.Total{
+			PriceSubtotal: -1000,
+			TaxSubtotal:   -100,
+			GrandTotal:    -1100,
 		},
 	}
-	billUcase.On("GetBill").Return(actualResponse.Bill, actualResponse.Total)
+	billUcase.On("GetBill", mock.Anything).Return(bill.Snapshot{
+		ID:        actualResponse.ID,
+		Bills:     actualResponse.Bill,
+		Coupons:   actualResponse.Coupons,
+		Charges:   actualResponse.Charges,
+		Breakdown: actualResponse.Breakdown,
+		Total:     actualResponse.Total,
+		Refunds:   actualResponse.Refunds,
+	})
 	h := &HTTPBillHandler{
 		billUcase: billUcase,
+		rules:     taxcalc.NewJurisdictions(),
+		log:       logger.Discard(),
 	}
 
 	// Assertions using testify framework
@@ -86,6 +147,698 @@ func TestHTTPBillHandler_GetBill_AvailableData(t *testing.T) {
 	}
 }
 
+func TestHTTPBillHandler_GetBill_Explain(t *testing.T) {
+	t.Parallel()
+	bills := []bill.Bill{
+		{
+			Name:        "MACD",
+			TaxCode:     1,
+			Quantity:    1,
+			UnitPrice:   20000,
+			Price:       20000,
+			TaxableBase: 20000,
+			Tax:         2000,
+			Taxes:       []bill.TaxComponent{{TaxCode: 1, Type: "Food & Beverage", Base: 20000, Tax: 2000}},
+			Amount:      22000,
+		},
+	}
+	tests := []struct {
+		name            string
+		query           string
+		wantExplanation bool
+		wantErr         error
+	}{
+		// TODO: Add test cases.
+		{
+			name:            "Explained",
+			query:           "?explain=true",
+			wantExplanation: true,
+		},
+		{
+			name:  "Not Explained",
+			query: "?explain=false",
+		},
+		{
+			name:    "Invalid Explain",
+			query:   "?explain=maybe",
+			wantErr: ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/bill"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			billUcase := &mocks.Usecase{}
+			billUcase.On("GetBill", mock.Anything).Return(bill.Snapshot{
+				ID:        7,
+				Bills:     bills,
+				Coupons:   []bill.Coupon{},
+				Charges:   []bill.ChargeLine{},
+				Breakdown: []bill.Breakdown{},
+			})
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.GetBill(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			billResp := BillResponse{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &billResp); err != nil {
+				t.Fatalf("Error unmarshaling bill response: %s", err)
+			}
+			if !tt.wantExplanation {
+				assert.Nil(t, billResp.Bill[0].Explanation)
+				return
+			}
+			if assert.NotNil(t, billResp.Bill[0].Explanation) {
+				assert.Equal(t, "1 * (0 + 10% * (20000 - 0)) = 2000", billResp.Bill[0].Explanation.Taxes[0].Formula)
+			}
+			//The lines of the bill are not changed by the explanation.
+			assert.Nil(t, bills[0].Explanation)
+		})
+	}
+}
+
+func TestHTTPBillHandler_FinalizeBill(t *testing.T) {
+	t.Parallel()
+	invoice := bill.Invoice{
+		Number: 1,
+		BillID: 7,
+		Bill: []bill.Bill{
+			bill.Bill{
+				Name:       "MACD",
+				TaxCode:    1,
+				Price:      20000,
+				Tax:        2000,
+				Type:       "Food & Beverage",
+				Refundable: "Yes",
+				Amount:     22000,
+			},
+		},
+		Total: bill.Total{
+			PriceSubtotal: 20000,
+			TaxSubtotal:   2000,
+			GrandTotal:    22000,
+		},
+		IssuedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name     string
+		id       string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Positive Case",
+			id:       "7",
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "seven",
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Bill Not Found",
+			id:      "7",
+			err:     bill.ErrNotFound,
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "Bill Finalized",
+			id:      "7",
+			err:     bill.ErrFinalized,
+			wantErr: ErrFinalized,
+		},
+		{
+			name:    "Bill Empty",
+			id:      "7",
+			err:     bill.ErrEmpty,
+			wantErr: ErrEmpty,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/bills/:id/finalize")
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(tt.id)
+			billUcase := &mocks.Usecase{}
+			billUcase.On("FinalizeBill", mock.Anything, int64(7)).Return(invoice, tt.err)
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.FinalizeBill(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCode, rec.Code)
+			got := bill.Invoice{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Error unmarshaling invoice response: %s", err)
+			}
+			assert.EqualValues(t, invoice, got)
+		})
+	}
+}
+
+func TestHTTPBillHandler_GetInvoice(t *testing.T) {
+	t.Parallel()
+	invoice := bill.Invoice{
+		Number:   1,
+		BillID:   7,
+		Bill:     []bill.Bill{},
+		IssuedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name     string
+		number   string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Positive Case",
+			number:   "1",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Invalid Number",
+			number:  "one",
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Invoice Not Found",
+			number:  "1",
+			err:     bill.ErrInvoiceNotFound,
+			wantErr: ErrInvoiceNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/invoices/:number")
+			ctx.SetParamNames("number")
+			ctx.SetParamValues(tt.number)
+			billUcase := &mocks.Usecase{}
+			billUcase.On("GetInvoice", mock.Anything, int64(1)).Return(invoice, tt.err)
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.GetInvoice(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCode, rec.Code)
+			got := bill.Invoice{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Error unmarshaling invoice response: %s", err)
+			}
+			assert.EqualValues(t, invoice, got)
+		})
+	}
+}
+
+func TestHTTPBillHandler_RefundInvoice(t *testing.T) {
+	t.Parallel()
+	creditNote := bill.CreditNote{
+		Number:        1,
+		InvoiceNumber: 3,
+		Lines: []bill.CreditLine{
+			bill.CreditLine{
+				Line: 1,
+				Bill: bill.Bill{
+					Name:       "Lucky Stretch",
+					TaxCode:    2,
+					Price:      -1000,
+					Type:       "Tobacco",
+					Refundable: "No",
+					Amount:     -1000,
+				},
+			},
+		},
+		Total: bill.Total{
+			PriceSubtotal: -1000,
+			GrandTotal:    -1000,
+		},
+		IssuedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name     string
+		number   string
+		body     string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Positive Case",
+			number:   "3",
+			body:     `{"lines":[1]}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:    "Invalid Number",
+			number:  "three",
+			body:    `{"lines":[1]}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Invalid Body",
+			number:  "3",
+			body:    `{"lines":"one"}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Invoice Not Found",
+			number:  "3",
+			body:    `{"lines":[1]}`,
+			err:     bill.ErrInvoiceNotFound,
+			wantErr: ErrInvoiceNotFound,
+		},
+		{
+			name:    "Line Not Found",
+			number:  "3",
+			body:    `{"lines":[1]}`,
+			err:     bill.ErrLineNotFound,
+			wantErr: ErrLineNotFound,
+		},
+		{
+			name:    "Line Refunded",
+			number:  "3",
+			body:    `{"lines":[1]}`,
+			err:     bill.ErrRefunded,
+			wantErr: ErrRefunded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/invoices/:number/refunds")
+			ctx.SetParamNames("number")
+			ctx.SetParamValues(tt.number)
+			billUcase := &mocks.Usecase{}
+			billUcase.On("RefundInvoice", mock.Anything, int64(3), []int{1}).Return(creditNote, tt.err)
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.RefundInvoice(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCode, rec.Code)
+			got := bill.CreditNote{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Error unmarshaling credit note response: %s", err)
+			}
+			assert.EqualValues(t, creditNote, got)
+		})
+	}
+}
+
+func TestHTTPBillHandler_AddCoupon(t *testing.T) {
+	t.Parallel()
+	coupon := bill.Coupon{Code: "WELCOME", Discount: taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10}}
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Positive Case",
+			id:       "7",
+			body:     `{"code":"WELCOME","type":"percent","value":10}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "seven",
+			body:    `{"code":"WELCOME","type":"percent","value":10}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Invalid Type",
+			id:      "7",
+			body:    `{"code":"WELCOME","type":"free","value":10}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Missing Code",
+			id:      "7",
+			body:    `{"type":"percent","value":10}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Coupon Exists",
+			id:      "7",
+			body:    `{"code":"WELCOME","type":"percent","value":10}`,
+			err:     bill.ErrCouponExists,
+			wantErr: ErrCouponExists,
+		},
+		{
+			name:    "Bill Finalized",
+			id:      "7",
+			body:    `{"code":"WELCOME","type":"percent","value":10}`,
+			err:     bill.ErrFinalized,
+			wantErr: ErrFinalized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/bills/:id/coupons")
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(tt.id)
+			billUcase := &mocks.Usecase{}
+			billUcase.On("AddCoupon", mock.Anything, int64(7), coupon).Return([]bill.Coupon{coupon}, tt.err)
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.AddCoupon(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCode, rec.Code)
+			got := []bill.Coupon{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Error unmarshaling coupons response: %s", err)
+			}
+			assert.Equal(t, []bill.Coupon{coupon}, got)
+		})
+	}
+}
+
+func TestHTTPBillHandler_RemoveCoupon(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		id       string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Positive Case",
+			id:       "7",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "seven",
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Coupon Not Found",
+			id:      "7",
+			err:     bill.ErrCouponNotFound,
+			wantErr: ErrCouponNotFound,
+		},
+		{
+			name:    "Bill Not Found",
+			id:      "7",
+			err:     bill.ErrNotFound,
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/bills/:id/coupons/:code")
+			ctx.SetParamNames("id", "code")
+			ctx.SetParamValues(tt.id, "WELCOME")
+			billUcase := &mocks.Usecase{}
+			billUcase.On("RemoveCoupon", mock.Anything, int64(7), "WELCOME").Return(tt.err)
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.RemoveCoupon(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestHTTPBillHandler_AddCharge(t *testing.T) {
+	t.Parallel()
+	charge := bill.Charge{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 10}
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Positive Case",
+			id:       "7",
+			body:     `{"name":"Service","tax_code":1,"type":"percent","value":10}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "seven",
+			body:    `{"name":"Service","tax_code":1,"type":"percent","value":10}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Invalid Tax Code",
+			id:      "7",
+			body:    `{"name":"Service","tax_code":4,"type":"percent","value":10}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Missing Value",
+			id:      "7",
+			body:    `{"name":"Service","tax_code":1,"type":"percent"}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Jurisdiction Not Found",
+			id:      "7",
+			body:    `{"name":"Service","tax_code":1,"jurisdiction":"UNKNOWN","type":"percent","value":10}`,
+			wantErr: ErrJurisdictionNotFound,
+		},
+		{
+			name:    "Charge Exists",
+			id:      "7",
+			body:    `{"name":"Service","tax_code":1,"type":"percent","value":10}`,
+			err:     bill.ErrChargeExists,
+			wantErr: ErrChargeExists,
+		},
+		{
+			name:    "Bill Finalized",
+			id:      "7",
+			body:    `{"name":"Service","tax_code":1,"type":"percent","value":10}`,
+			err:     bill.ErrFinalized,
+			wantErr: ErrFinalized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/bills/:id/charges")
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(tt.id)
+			billUcase := &mocks.Usecase{}
+			billUcase.On("AddCharge", mock.Anything, int64(7), charge).Return([]bill.Charge{charge}, tt.err)
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.AddCharge(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCode, rec.Code)
+			got := []bill.Charge{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Error unmarshaling charges response: %s", err)
+			}
+			assert.Equal(t, []bill.Charge{charge}, got)
+		})
+	}
+}
+
+func TestHTTPBillHandler_RemoveCharge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		id       string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Positive Case",
+			id:       "7",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "seven",
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Charge Not Found",
+			id:      "7",
+			err:     bill.ErrChargeNotFound,
+			wantErr: ErrChargeNotFound,
+		},
+		{
+			name:    "Bill Finalized",
+			id:      "7",
+			err:     bill.ErrFinalized,
+			wantErr: ErrFinalized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/bills/:id/charges/:name")
+			ctx.SetParamNames("id", "name")
+			ctx.SetParamValues(tt.id, "Service")
+			billUcase := &mocks.Usecase{}
+			billUcase.On("RemoveCharge", mock.Anything, int64(7), "Service").Return(tt.err)
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.RemoveCharge(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestHTTPBillHandler_GetCreditNote(t *testing.T) {
+	t.Parallel()
+	creditNote := bill.CreditNote{
+		Number:        1,
+		InvoiceNumber: 3,
+		Lines:         []bill.CreditLine{},
+		IssuedAt:      time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name     string
+		number   string
+		err      error
+		wantCode int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Positive Case",
+			number:   "1",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Invalid Number",
+			number:  "one",
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Credit Note Not Found",
+			number:  "1",
+			err:     bill.ErrCreditNoteNotFound,
+			wantErr: ErrCreditNoteNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/credit-notes/:number")
+			ctx.SetParamNames("number")
+			ctx.SetParamValues(tt.number)
+			billUcase := &mocks.Usecase{}
+			billUcase.On("GetCreditNote", mock.Anything, int64(1)).Return(creditNote, tt.err)
+			h := &HTTPBillHandler{
+				billUcase: billUcase,
+				rules:     taxcalc.NewJurisdictions(),
+				log:       logger.Discard(),
+			}
+			err := h.GetCreditNote(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, tt.wantCode, rec.Code)
+			got := bill.CreditNote{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Error unmarshaling credit note response: %s", err)
+			}
+			assert.EqualValues(t, creditNote, got)
+		})
+	}
+}
+
 func TestNewHTTPBillHandler(t *testing.T) {
 	t.Parallel()
 	type args struct {
@@ -107,7 +860,7 @@ func TestNewHTTPBillHandler(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			NewHTTPBillHandler(tt.args.e, tt.args.billUcase)
+			NewHTTPBillHandler(tt.args.e, tt.args.billUcase, taxcalc.NewJurisdictions(), logger.Discard(), nil)
 		})
 	}
 }
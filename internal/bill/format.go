@@ -0,0 +1,159 @@
+package bill
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+)
+
+//CSVColumns are the columns of the bill lines written to the CSV file in their order,
+//they are named like the JSON fields of the bill.
+//The taxes are the taxes stacked on the tax of the tax code in the format of the taxes of the imported tax objects.
+var CSVColumns = []string{
+	"name",
+	"tax_code",
+	"jurisdiction",
+	"type",
+	"refundable",
+	"quantity",
+	"unit_price",
+	"price",
+	"discount",
+	"coupon",
+	"tax_inclusive",
+	"taxable_base",
+	"tax",
+	"amount",
+	taxobj.ColumnTaxes,
+}
+
+//tableColumns are the columns of the bill lines in the table, in the same order as the CSV columns without the taxes.
+var tableColumns = []string{
+	"NAME",
+	"TAX CODE",
+	"JURISDICTION",
+	"TYPE",
+	"REFUNDABLE",
+	"QUANTITY",
+	"UNIT PRICE",
+	"PRICE",
+	"DISCOUNT",
+	"COUPON",
+	"TAX INCLUSIVE",
+	"TAXABLE BASE",
+	"TAX",
+	"AMOUNT",
+}
+
+//WriteCSV write the bill lines to the CSV file with the header.
+func WriteCSV(writer io.Writer, bills []Bill) (err error) {
+	csvWriter := csv.NewWriter(writer)
+	if err = csvWriter.Write(CSVColumns); err != nil {
+		return
+	}
+	for _, billObject := range bills {
+		if err = csvWriter.Write(append(billRecord(billObject, formatFloat), stackedTaxes(billObject))); err != nil {
+			return
+		}
+	}
+	csvWriter.Flush()
+	err = csvWriter.Error()
+	return
+}
+
+//WriteTable write the bill lines and the total of the bill as the table aligned for the terminal.
+//The amounts are rounded to two decimals, the exact amounts are written by WriteCSV.
+func WriteTable(writer io.Writer, bills []Bill, total Total) (err error) {
+	tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if err = writeRow(tableWriter, tableColumns); err != nil {
+		return
+	}
+	for _, billObject := range bills {
+		if err = writeRow(tableWriter, billRecord(billObject, formatAmount)); err != nil {
+			return
+		}
+	}
+	if err = tableWriter.Flush(); err != nil {
+		return
+	}
+	fmt.Fprintln(writer)
+	tableWriter = tabwriter.NewWriter(writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+	totals := []struct {
+		name   string
+		amount float64
+	}{
+		{"PRICE SUBTOTAL", total.PriceSubtotal},
+		{"DISCOUNT SUBTOTAL", total.DiscountSubtotal},
+		{"COUPON SUBTOTAL", total.CouponSubtotal},
+		{"NET SUBTOTAL", total.NetSubtotal},
+		{"TAX SUBTOTAL", total.TaxSubtotal},
+		{"CHARGE SUBTOTAL", total.ChargeSubtotal},
+		{"CHARGE TAX SUBTOTAL", total.ChargeTaxSubtotal},
+		{"GRAND TOTAL", total.GrandTotal},
+	}
+	for _, row := range totals {
+		if err = writeRow(tableWriter, []string{row.name, formatAmount(row.amount)}); err != nil {
+			return
+		}
+	}
+	err = tableWriter.Flush()
+	return
+}
+
+//billRecord return the cells of the bill line in the order of the columns with the amounts formatted by format.
+func billRecord(billObject Bill, format func(float64) string) []string {
+	return []string{
+		billObject.Name,
+		strconv.FormatInt(billObject.TaxCode, 10),
+		billObject.Jurisdiction,
+		billObject.Type,
+		billObject.Refundable,
+		formatFloat(billObject.Quantity),
+		format(billObject.UnitPrice),
+		format(billObject.Price),
+		format(billObject.Discount),
+		format(billObject.Coupon),
+		strconv.FormatBool(billObject.TaxInclusive),
+		format(billObject.TaxableBase),
+		format(billObject.Tax),
+		format(billObject.Amount),
+	}
+}
+
+//stackedTaxes return the cell of the taxes stacked on the tax of the tax code of the line,
+//so the exported line is imported again with the same taxes.
+func stackedTaxes(billObject Bill) string {
+	taxes := make([]taxobj.Tax, 0, len(billObject.Taxes))
+	for index, component := range billObject.Taxes {
+		//The first component is the tax of the tax code of the line.
+		if index > 0 {
+			taxes = append(taxes, taxobj.Tax{TaxCode: component.TaxCode, Compound: component.Compound})
+		}
+	}
+	return taxobj.FormatTaxes(taxes)
+}
+
+//writeRow write the cells as one row of the table.
+func writeRow(writer io.Writer, cells []string) (err error) {
+	for _, cell := range cells {
+		if _, err = fmt.Fprintf(writer, "%s\t", cell); err != nil {
+			return
+		}
+	}
+	_, err = fmt.Fprintln(writer)
+	return
+}
+
+//formatFloat format the number without the trailing zeros.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+//formatAmount format the amount with two decimals.
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
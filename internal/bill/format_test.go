@@ -0,0 +1,116 @@
+// +build unit
+
+package bill
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testBills = []Bill{
+	{
+		Name:        "Big Mac",
+		TaxCode:     1,
+		Type:        "Food & Beverage",
+		Refundable:  "Yes",
+		Quantity:    1,
+		UnitPrice:   1000,
+		Price:       1000,
+		TaxableBase: 1000,
+		Tax:         100,
+		Amount:      1100,
+	},
+	{
+		Name:         "Movie",
+		TaxCode:      3,
+		Jurisdiction: "TEST",
+		Type:         "Entertainment",
+		Refundable:   "No",
+		Quantity:     1.5,
+		UnitPrice:    150,
+		Price:        225,
+		TaxableBase:  225,
+		Tax:          1.875,
+		Amount:       226.875,
+	},
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		bills []Bill
+		want  string
+	}{
+		// TODO: Add test cases.
+		{
+			name:  "Empty",
+			bills: []Bill{},
+			want:  "name,tax_code,jurisdiction,type,refundable,quantity,unit_price,price,discount,coupon,tax_inclusive,taxable_base,tax,amount,taxes\n",
+		},
+		{
+			name:  "Positive Case",
+			bills: testBills,
+			want: "name,tax_code,jurisdiction,type,refundable,quantity,unit_price,price,discount,coupon,tax_inclusive,taxable_base,tax,amount,taxes\n" +
+				"Big Mac,1,,Food & Beverage,Yes,1,1000,1000,0,0,false,1000,100,1100,\n" +
+				"Movie,3,TEST,Entertainment,No,1.5,150,225,0,0,false,225,1.875,226.875,\n",
+		},
+		{
+			name: "Stacked Taxes",
+			bills: []Bill{
+				{
+					Name:        "Lucky Stretch",
+					TaxCode:     2,
+					Type:        "Tobacco",
+					Refundable:  "No",
+					Quantity:    1,
+					UnitPrice:   1000,
+					Price:       1000,
+					TaxableBase: 1000,
+					Tax:         133,
+					Taxes: []TaxComponent{
+						{TaxCode: 2, Type: "Tobacco", Base: 1000, Tax: 30},
+						{TaxCode: 1, Type: "Food & Beverage", Compound: true, Base: 1030, Tax: 103},
+					},
+					Amount: 1133,
+				},
+			},
+			want: "name,tax_code,jurisdiction,type,refundable,quantity,unit_price,price,discount,coupon,tax_inclusive,taxable_base,tax,amount,taxes\n" +
+				"Lucky Stretch,2,,Tobacco,No,1,1000,1000,0,0,false,1000,133,1133,1:compound\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buffer := new(bytes.Buffer)
+			if assert.NoError(t, WriteCSV(buffer, tt.bills)) {
+				assert.Equal(t, tt.want, buffer.String())
+			}
+		})
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	t.Parallel()
+	buffer := new(bytes.Buffer)
+	if !assert.NoError(t, WriteTable(buffer, testBills, Total{PriceSubtotal: 1225, NetSubtotal: 1225, TaxSubtotal: 101.875, GrandTotal: 1326.875})) {
+		return
+	}
+	lines := strings.Split(strings.TrimRight(buffer.String(), "\n"), "\n")
+	if !assert.Len(t, lines, 12) {
+		return
+	}
+	//The lines of the bill are aligned under the header, and the amounts are rounded to two decimals.
+	assert.Equal(t, len(lines[0]), len(lines[1]))
+	assert.Equal(t, len(lines[0]), len(lines[2]))
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(lines[0]), "AMOUNT"))
+	assert.Contains(t, lines[2], "226.88")
+	assert.Empty(t, lines[3])
+	assert.True(t, strings.HasSuffix(lines[4], "1225.00"))
+	assert.Equal(t, "GRAND TOTAL", strings.Fields(lines[11])[0]+" "+strings.Fields(lines[11])[1])
+	assert.True(t, strings.HasSuffix(lines[11], "1326.88"))
+}
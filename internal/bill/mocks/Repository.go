@@ -3,6 +3,7 @@
 package mocks
 
 import bill "github.com/fairyhunter13/tax-calculator/internal/bill"
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 import taxobj "github.com/fairyhunter13/tax-calculator/internal/taxobj"
 
@@ -11,18 +12,136 @@ type Repository struct {
 	mock.Mock
 }
 
-// Add provides a mock function with given fields: _a0
-func (_m *Repository) Add(_a0 taxobj.TaxObject) {
-	_m.Called(_a0)
+// Add provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Add(_a0 context.Context, _a1 taxobj.TaxObject) {
+	_m.Called(_a0, _a1)
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *Repository) GetAll() ([]bill.Bill, bill.Total) {
-	ret := _m.Called()
+// AddRefund provides a mock function with given fields: _a0, _a1
+func (_m *Repository) AddRefund(_a0 context.Context, _a1 bill.Total) {
+	_m.Called(_a0, _a1)
+}
+
+// GetAll provides a mock function with given fields: _a0
+func (_m *Repository) GetAll(_a0 context.Context) ([]bill.Bill, bill.Total) {
+	ret := _m.Called(_a0)
+
+	var r0 []bill.Bill
+	if rf, ok := ret.Get(0).(func(context.Context) []bill.Bill); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bill.Bill)
+		}
+	}
+
+	var r1 bill.Total
+	if rf, ok := ret.Get(1).(func(context.Context) bill.Total); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Get(1).(bill.Total)
+	}
+
+	return r0, r1
+}
+
+// GetBreakdown provides a mock function with given fields: _a0
+func (_m *Repository) GetBreakdown(_a0 context.Context) []bill.Breakdown {
+	ret := _m.Called(_a0)
+
+	var r0 []bill.Breakdown
+	if rf, ok := ret.Get(0).(func(context.Context) []bill.Breakdown); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bill.Breakdown)
+		}
+	}
+
+	return r0
+}
+
+// GetCharges provides a mock function with given fields: _a0
+func (_m *Repository) GetCharges(_a0 context.Context) []bill.ChargeLine {
+	ret := _m.Called(_a0)
+
+	var r0 []bill.ChargeLine
+	if rf, ok := ret.Get(0).(func(context.Context) []bill.ChargeLine); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bill.ChargeLine)
+		}
+	}
+
+	return r0
+}
+
+// GetCoupons provides a mock function with given fields: _a0
+func (_m *Repository) GetCoupons(_a0 context.Context) []bill.Coupon {
+	ret := _m.Called(_a0)
+
+	var r0 []bill.Coupon
+	if rf, ok := ret.Get(0).(func(context.Context) []bill.Coupon); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bill.Coupon)
+		}
+	}
+
+	return r0
+}
+
+// GetID provides a mock function with given fields: _a0
+func (_m *Repository) GetID(_a0 context.Context) int64 {
+	ret := _m.Called(_a0)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	return r0
+}
+
+// GetRefunds provides a mock function with given fields: _a0
+func (_m *Repository) GetRefunds(_a0 context.Context) bill.Total {
+	ret := _m.Called(_a0)
+
+	var r0 bill.Total
+	if rf, ok := ret.Get(0).(func(context.Context) bill.Total); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bill.Total)
+	}
+
+	return r0
+}
+
+// GetSnapshot provides a mock function with given fields: _a0
+func (_m *Repository) GetSnapshot(_a0 context.Context) bill.Snapshot {
+	ret := _m.Called(_a0)
+
+	var r0 bill.Snapshot
+	if rf, ok := ret.Get(0).(func(context.Context) bill.Snapshot); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bill.Snapshot)
+	}
+
+	return r0
+}
+
+// Preview provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Preview(_a0 context.Context, _a1 []taxobj.TaxObject) ([]bill.Bill, bill.Total) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 []bill.Bill
-	if rf, ok := ret.Get(0).(func() []bill.Bill); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, []taxobj.TaxObject) []bill.Bill); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]bill.Bill)
@@ -30,11 +149,36 @@ func (_m *Repository) GetAll() ([]bill.Bill, bill.Total) {
 	}
 
 	var r1 bill.Total
-	if rf, ok := ret.Get(1).(func() bill.Total); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, []taxobj.TaxObject) bill.Total); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Get(1).(bill.Total)
 	}
 
 	return r0, r1
 }
+
+// Remove provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Remove(_a0 context.Context, _a1 int64) {
+	_m.Called(_a0, _a1)
+}
+
+// RemoveBill provides a mock function with given fields: _a0, _a1
+func (_m *Repository) RemoveBill(_a0 context.Context, _a1 int64) {
+	_m.Called(_a0, _a1)
+}
+
+// SetCharges provides a mock function with given fields: _a0, _a1
+func (_m *Repository) SetCharges(_a0 context.Context, _a1 []bill.Charge) {
+	_m.Called(_a0, _a1)
+}
+
+// SetCoupons provides a mock function with given fields: _a0, _a1
+func (_m *Repository) SetCoupons(_a0 context.Context, _a1 []bill.Coupon) {
+	_m.Called(_a0, _a1)
+}
+
+// Update provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Update(_a0 context.Context, _a1 taxobj.TaxObject) {
+	_m.Called(_a0, _a1)
+}
@@ -3,20 +3,159 @@
 package mocks
 
 import bill "github.com/fairyhunter13/tax-calculator/internal/bill"
+import context "context"
 import mock "github.com/stretchr/testify/mock"
+import taxobj "github.com/fairyhunter13/tax-calculator/internal/taxobj"
 
 // Usecase is an autogenerated mock type for the Usecase type
 type Usecase struct {
 	mock.Mock
 }
 
-// GetBill provides a mock function with given fields:
-func (_m *Usecase) GetBill() ([]bill.Bill, bill.Total) {
-	ret := _m.Called()
+// AddCharge provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Usecase) AddCharge(_a0 context.Context, _a1 int64, _a2 bill.Charge) ([]bill.Charge, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []bill.Charge
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bill.Charge) []bill.Charge); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bill.Charge)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bill.Charge) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddCoupon provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Usecase) AddCoupon(_a0 context.Context, _a1 int64, _a2 bill.Coupon) ([]bill.Coupon, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []bill.Coupon
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bill.Coupon) []bill.Coupon); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bill.Coupon)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bill.Coupon) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FinalizeBill provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) FinalizeBill(_a0 context.Context, _a1 int64) (bill.Invoice, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 bill.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bill.Invoice); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(bill.Invoice)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBill provides a mock function with given fields: _a0
+func (_m *Usecase) GetBill(_a0 context.Context) bill.Snapshot {
+	ret := _m.Called(_a0)
+
+	var r0 bill.Snapshot
+	if rf, ok := ret.Get(0).(func(context.Context) bill.Snapshot); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bill.Snapshot)
+	}
+
+	return r0
+}
+
+// GetCreditNote provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) GetCreditNote(_a0 context.Context, _a1 int64) (bill.CreditNote, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 bill.CreditNote
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bill.CreditNote); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(bill.CreditNote)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoice provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) GetInvoice(_a0 context.Context, _a1 int64) (bill.Invoice, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 bill.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bill.Invoice); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(bill.Invoice)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadData provides a mock function with given fields: _a0
+func (_m *Usecase) LoadData(_a0 context.Context) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PreviewBill provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) PreviewBill(_a0 context.Context, _a1 []taxobj.TaxObject) ([]bill.Bill, bill.Total) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 []bill.Bill
-	if rf, ok := ret.Get(0).(func() []bill.Bill); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, []taxobj.TaxObject) []bill.Bill); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]bill.Bill)
@@ -24,8 +163,8 @@ func (_m *Usecase) GetBill() ([]bill.Bill, bill.Total) {
 	}
 
 	var r1 bill.Total
-	if rf, ok := ret.Get(1).(func() bill.Total); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, []taxobj.TaxObject) bill.Total); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Get(1).(bill.Total)
 	}
@@ -33,13 +172,48 @@ func (_m *Usecase) GetBill() ([]bill.Bill, bill.Total) {
 	return r0, r1
 }
 
-// LoadData provides a mock function with given fields:
-func (_m *Usecase) LoadData() error {
-	ret := _m.Called()
+// RefundInvoice provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Usecase) RefundInvoice(_a0 context.Context, _a1 int64, _a2 []int) (bill.CreditNote, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 bill.CreditNote
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []int) bill.CreditNote); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(bill.CreditNote)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, []int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveCharge provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Usecase) RemoveCharge(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveCoupon provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Usecase) RemoveCoupon(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		r0 = ret.Error(0)
 	}
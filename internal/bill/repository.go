@@ -1,11 +1,54 @@
 package bill
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
 )
 
 //Repository define the required behavior of data management in the bill.
+//The bill of every tenant lists the tax objects of its open bill.
 type Repository interface {
-	Add(taxobj.TaxObject)
-	GetAll() ([]Bill, Total)
+	Add(context.Context, taxobj.TaxObject)
+	Update(context.Context, taxobj.TaxObject)
+	Remove(context.Context, int64)
+	RemoveBill(context.Context, int64)
+	GetAll(context.Context) ([]Bill, Total)
+	GetSnapshot(context.Context) Snapshot
+	Preview(context.Context, []taxobj.TaxObject) ([]Bill, Total)
+	GetBreakdown(context.Context) []Breakdown
+	GetID(context.Context) int64
+	AddRefund(context.Context, Total)
+	GetRefunds(context.Context) Total
+	SetCoupons(context.Context, []Coupon)
+	GetCoupons(context.Context) []Coupon
+	SetCharges(context.Context, []Charge)
+	GetCharges(context.Context) []ChargeLine
+}
+
+//Binder define the behavior of binding the changes of the tax objects to their bill in the transaction of the change,
+//so the tax objects of the finalized bill are never changed.
+type Binder interface {
+	OpenBill(context.Context, *sql.Tx) (int64, error)
+	CheckOpen(context.Context, *sql.Tx, int64) error
+}
+
+//InvoiceRepository define the required behavior of data management in the bills and their invoices.
+//The invoices and the credit notes are append-only, so they can't be updated or deleted.
+type InvoiceRepository interface {
+	Binder
+	Finalize(context.Context, int64) (Invoice, error)
+	GetInvoice(context.Context, int64) (Invoice, error)
+	Refund(context.Context, int64, []int) (CreditNote, error)
+	GetCreditNote(context.Context, int64) (CreditNote, error)
+	GetRefundTotals(context.Context) (map[string]Total, error)
+	AddCoupon(context.Context, int64, Coupon) ([]Coupon, error)
+	RemoveCoupon(context.Context, int64, string) ([]Coupon, error)
+	GetOpenCoupons(context.Context) (map[string][]Coupon, error)
+	AddCharge(context.Context, int64, Charge) ([]Charge, error)
+	RemoveCharge(context.Context, int64, string) ([]Charge, error)
+	GetOpenCharges(context.Context) (map[string][]Charge, error)
+	Close()
+	Migrate() error
 }
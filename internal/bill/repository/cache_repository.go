@@ -1,107 +1,531 @@
 package repository
 
 import (
+	"context"
+	"math"
 	"sync"
 
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/metrics"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+	"github.com/sirupsen/logrus"
 
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
 )
 
 const (
 	//Refundable defines the text to show if it's refundable.
-	Refundable = "Yes"
+	Refundable = taxcalc.Refundable
 	//NotRefundable defines the text to show if it's not refundable.
-	NotRefundable = "No"
+	NotRefundable = taxcalc.NotRefundable
+	//precision is the scale the sums kept line by line are rounded to,
+	//so the rounding of adding and subtracting the lines doesn't accumulate in the long-lived bill.
+	precision = 1e9
 )
 
 //CacheRepository defines the data management for the bill.
+//Every tenant has its own bill, so one tenant never reads or totals another tenant's items.
+//The bill is calculated by taxcalc with the rules given to the repository.
+//The bill without the coupons is kept line by line, i.e. only the changed line is added to or subtracted from
+//the total and the breakdown, while the bill with the coupons is calculated again, because the coupons are shared by all lines.
 type CacheRepository struct {
+	log   logrus.FieldLogger
+	rules taxcalc.Rules
 	mutex *sync.Mutex
 	//mutex here protected the following fileds.
-	bills []bill.Bill
+	tenants map[string]*tenantBill
+	//lines and total are the aggregates of all tenants for the metrics.
+	lines int
 	total bill.Total
 }
 
-var (
-	typeMap = map[int64]string{
-		1: "Food & Beverage",
-		2: "Tobacco",
-		3: "Entertainment",
-	}
-)
+//tenantBill defines the bill list and total of a tenant.
+//The ids are the ids of the tax objects of the bills in the same order,
+//and the billIDs are the ids of the bills owning them.
+type tenantBill struct {
+	ids        []int64
+	billIDs    []int64
+	taxObjects []taxobj.TaxObject
+	bills      []bill.Bill
+	total      bill.Total
+	//lineTotal is the total of the bill list without the charges, the charges are calculated from it.
+	lineTotal bill.Total
+	//breakdown is the bill list grouped by the jurisdiction and the tax code, it's kept with the bill list so it's not calculated for every read.
+	breakdown []bill.Breakdown
+	//taxCodes count the lines and the tax components summed in every tax code of every jurisdiction,
+	//so the breakdown group and the tax summary of the tax code are removed with its last line.
+	taxCodes map[taxKey]int
+	//billID is the id of the open bill of the tenant.
+	billID int64
+	//finalized are the ids of the bills of the tenant that have been removed,
+	//so the tax object committed to them before the finalization but added after it is ignored.
+	finalized map[int64]bool
+	//coupons are the coupons applied to the open bill of the tenant.
+	coupons []bill.Coupon
+	//charges are the charges of the open bill of the tenant, and chargeLines are their calculation.
+	charges     []bill.Charge
+	chargeLines []bill.ChargeLine
+	//refunds is the total of the credit notes of the tenant.
+	refunds bill.Total
+}
 
-//NewCacheRepository return the concrete implementation of repository using cache.
-func NewCacheRepository() bill.Repository {
+//taxKey defines the tax code of the jurisdiction, i.e. the breakdown group and the tax summary of the bill.
+type taxKey struct {
+	jurisdiction string
+	taxCode      int64
+}
+
+//NewCacheRepository return the concrete implementation of repository using cache calculating the bill with the rules.
+func NewCacheRepository(rules taxcalc.Rules, log logrus.FieldLogger) bill.Repository {
 	cacheRepo := &CacheRepository{
-		mutex: new(sync.Mutex),
-		total: bill.Total{},
-		bills: make([]bill.Bill, 0),
+		log:     log,
+		rules:   rules,
+		mutex:   new(sync.Mutex),
+		tenants: make(map[string]*tenantBill),
+		total:   bill.Total{},
 	}
 	return cacheRepo
 }
 
-//Add add tax object to the bill list.
-func (repo *CacheRepository) Add(taxObject taxobj.TaxObject) {
+//Add add tax object to the bill list of its tenant.
+//The tax object of the bill that has been removed is ignored, because its bill is already finalized.
+func (repo *CacheRepository) Add(ctx context.Context, taxObject taxobj.TaxObject) {
+	_, span := tracing.Start(ctx, "CacheRepository.Add")
+	defer span.End()
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	owner := repo.tenant(taxObject.Tenant)
+	if owner.finalized[taxObject.BillID] {
+		logger.FromContext(ctx, repo.log).
+			WithField("tax_object_id", taxObject.ID).
+			WithField("bill_id", taxObject.BillID).
+			WithField("tenant", taxObject.Tenant).
+			Debug("[CacheRepository] Tax object of the finalized bill ignored")
+		return
+	}
+	//Build the new lists so the list returned by GetAll is not changed.
+	owner.ids = append(owner.ids[:len(owner.ids):len(owner.ids)], taxObject.ID)
+	owner.billIDs = append(owner.billIDs[:len(owner.billIDs):len(owner.billIDs)], taxObject.BillID)
+	owner.taxObjects = append(owner.taxObjects[:len(owner.taxObjects):len(owner.taxObjects)], taxObject)
+	if taxObject.BillID != 0 {
+		owner.billID = taxObject.BillID
+	}
+	repo.lines++
+	if len(owner.coupons) > 0 {
+		repo.recalculate(owner)
+	} else {
+		line := taxcalc.CalculateLine(repo.rules, taxObject.Calculable())
+		owner.bills = append(owner.bills[:len(owner.bills):len(owner.bills)], line)
+		repo.applyLines(owner, nil, []bill.Bill{line})
+	}
+	logger.FromContext(ctx, repo.log).
+		WithField("tax_object_id", taxObject.ID).
+		WithField("tenant", taxObject.Tenant).
+		Debug("[CacheRepository] Tax object added to the bill")
+}
+
+//Update replace the bill of the corrected tax object in the bill list of its tenant.
+func (repo *CacheRepository) Update(ctx context.Context, taxObject taxobj.TaxObject) {
+	_, span := tracing.Start(ctx, "CacheRepository.Update")
+	defer span.End()
 	repo.mutex.Lock()
 	defer repo.mutex.Unlock()
-	//Adding bill to bill cache
-	billObject := bill.Bill{
-		Name:       taxObject.Name,
-		Price:      taxObject.Price,
-		TaxCode:    taxObject.TaxCode,
-		Refundable: repo.getRefundable(taxObject.TaxCode),
-		Type:       repo.getType(taxObject.TaxCode),
-		Tax:        repo.getTax(taxObject.TaxCode, taxObject.Price),
-	}
-	billObject.Amount = billObject.Tax + billObject.Price
-	repo.bills = append(repo.bills, billObject)
-	//Calculating total cache
-	repo.total.PriceSubtotal += taxObject.Price
-	repo.total.TaxSubtotal += billObject.Tax
-	repo.total.GrandTotal += billObject.Amount
-}
-
-//GetAll return the bill list.
-func (repo *CacheRepository) GetAll() ([]bill.Bill, bill.Total) {
+	owner := repo.tenant(taxObject.Tenant)
+	index := owner.index(taxObject.ID)
+	if index < 0 {
+		return
+	}
+	//Copy the tax object list so the list of the previous calculation is not changed.
+	taxObjects := make([]taxobj.TaxObject, len(owner.taxObjects))
+	copy(taxObjects, owner.taxObjects)
+	taxObjects[index] = taxObject
+	owner.taxObjects = taxObjects
+	if len(owner.coupons) > 0 {
+		repo.recalculate(owner)
+	} else {
+		removed := owner.bills[index]
+		line := taxcalc.CalculateLine(repo.rules, taxObject.Calculable())
+		bills := make([]bill.Bill, len(owner.bills))
+		copy(bills, owner.bills)
+		bills[index] = line
+		owner.bills = bills
+		repo.applyLines(owner, []bill.Bill{removed}, []bill.Bill{line})
+	}
+	logger.FromContext(ctx, repo.log).
+		WithField("tax_object_id", taxObject.ID).
+		WithField("tenant", taxObject.Tenant).
+		Debug("[CacheRepository] Tax object updated in the bill")
+}
+
+//Remove remove the bill of the deleted tax object from the bill list of the tenant in ctx.
+func (repo *CacheRepository) Remove(ctx context.Context, id int64) {
+	_, span := tracing.Start(ctx, "CacheRepository.Remove")
+	defer span.End()
 	repo.mutex.Lock()
 	defer repo.mutex.Unlock()
-	return repo.bills, repo.total
+	owner := repo.tenant(tenant.FromContext(ctx))
+	index := owner.index(id)
+	if index < 0 {
+		return
+	}
+	//Build the new lists so the list returned by GetAll is not changed.
+	ids := make([]int64, 0, len(owner.ids)-1)
+	owner.ids = append(append(ids, owner.ids[:index]...), owner.ids[index+1:]...)
+	billIDs := make([]int64, 0, len(owner.billIDs)-1)
+	owner.billIDs = append(append(billIDs, owner.billIDs[:index]...), owner.billIDs[index+1:]...)
+	taxObjects := make([]taxobj.TaxObject, 0, len(owner.taxObjects)-1)
+	owner.taxObjects = append(append(taxObjects, owner.taxObjects[:index]...), owner.taxObjects[index+1:]...)
+	repo.lines--
+	if len(owner.coupons) > 0 {
+		repo.recalculate(owner)
+	} else {
+		removed := owner.bills[index]
+		bills := make([]bill.Bill, 0, len(owner.bills)-1)
+		owner.bills = append(append(bills, owner.bills[:index]...), owner.bills[index+1:]...)
+		repo.applyLines(owner, []bill.Bill{removed}, nil)
+	}
+	logger.FromContext(ctx, repo.log).
+		WithField("tax_object_id", id).
+		WithField("tenant", tenant.FromContext(ctx)).
+		Debug("[CacheRepository] Tax object removed from the bill")
 }
 
-//getRefundable return the refundable text to display based on the tax code.
-func (repo *CacheRepository) getRefundable(taxCode int64) (refundable string) {
-	switch taxCode {
-	case 1:
-		refundable = Refundable
-	case 2:
-		refundable = NotRefundable
-	case 3:
-		refundable = NotRefundable
+//RemoveBill remove the lines of the finalized bill from the bill list of the tenant in ctx.
+//The lines added to the next open bill in the meantime are kept,
+//and the lines of the finalized bill added later are ignored.
+func (repo *CacheRepository) RemoveBill(ctx context.Context, id int64) {
+	_, span := tracing.Start(ctx, "CacheRepository.RemoveBill")
+	defer span.End()
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	owner := repo.tenant(tenant.FromContext(ctx))
+	if id != 0 {
+		owner.finalized[id] = true
+	}
+	//Build the new lists so the list returned by GetAll is not changed.
+	ids := make([]int64, 0, len(owner.ids))
+	billIDs := make([]int64, 0, len(owner.billIDs))
+	taxObjects := make([]taxobj.TaxObject, 0, len(owner.taxObjects))
+	bills := make([]bill.Bill, 0, len(owner.bills))
+	removed := make([]bill.Bill, 0)
+	for index, billID := range owner.billIDs {
+		if billID != id {
+			ids = append(ids, owner.ids[index])
+			billIDs = append(billIDs, billID)
+			taxObjects = append(taxObjects, owner.taxObjects[index])
+			bills = append(bills, owner.bills[index])
+			continue
+		}
+		removed = append(removed, owner.bills[index])
+		repo.lines--
 	}
+	owner.ids, owner.billIDs, owner.taxObjects = ids, billIDs, taxObjects
+	//The lines of the bill with the coupons change with them, so they are calculated again.
+	couponed := len(owner.coupons) > 0
+	if owner.billID == id {
+		//The coupons and the charges belong to the finalized bill, so the next bill has none of them.
+		owner.billID = 0
+		owner.coupons = make([]bill.Coupon, 0)
+		owner.charges = make([]bill.Charge, 0)
+	}
+	if couponed {
+		repo.recalculate(owner)
+	} else {
+		owner.bills = bills
+		repo.applyLines(owner, removed, nil)
+	}
+	logger.FromContext(ctx, repo.log).
+		WithField("bill_id", id).
+		WithField("tenant", tenant.FromContext(ctx)).
+		Debug("[CacheRepository] Bill removed")
+}
+
+//GetID return the id of the open bill of the tenant in ctx, or 0 if the tenant has no open bill yet.
+func (repo *CacheRepository) GetID(ctx context.Context) int64 {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.tenant(tenant.FromContext(ctx)).billID
+}
+
+//SetCoupons replace the coupons of the open bill of the tenant in ctx and recalculate its bill,
+//as the coupons are shared by all lines.
+func (repo *CacheRepository) SetCoupons(ctx context.Context, coupons []bill.Coupon) {
+	_, span := tracing.Start(ctx, "CacheRepository.SetCoupons")
+	defer span.End()
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	owner := repo.tenant(tenant.FromContext(ctx))
+	owner.coupons = coupons
+	repo.recalculate(owner)
+	logger.FromContext(ctx, repo.log).
+		WithField("coupons", len(coupons)).
+		WithField("tenant", tenant.FromContext(ctx)).
+		Debug("[CacheRepository] Coupons applied to the bill")
+}
+
+//GetCoupons return the coupons of the open bill of the tenant in ctx.
+func (repo *CacheRepository) GetCoupons(ctx context.Context) []bill.Coupon {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.tenant(tenant.FromContext(ctx)).coupons
+}
+
+//SetCharges replace the charges of the open bill of the tenant in ctx and calculate them again.
+//The charges are calculated from the total of the lines, so the lines aren't calculated again.
+func (repo *CacheRepository) SetCharges(ctx context.Context, charges []bill.Charge) {
+	_, span := tracing.Start(ctx, "CacheRepository.SetCharges")
+	defer span.End()
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	owner := repo.tenant(tenant.FromContext(ctx))
+	owner.charges = charges
+	repo.calculateCharges(owner)
+	logger.FromContext(ctx, repo.log).
+		WithField("charges", len(charges)).
+		WithField("tenant", tenant.FromContext(ctx)).
+		Debug("[CacheRepository] Charges applied to the bill")
+}
+
+//GetCharges return the calculated charges of the open bill of the tenant in ctx.
+func (repo *CacheRepository) GetCharges(ctx context.Context) []bill.ChargeLine {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.tenant(tenant.FromContext(ctx)).chargeLines
+}
+
+//AddRefund add the total of the credit note to the refunds of the tenant in ctx.
+func (repo *CacheRepository) AddRefund(ctx context.Context, total bill.Total) {
+	_, span := tracing.Start(ctx, "CacheRepository.AddRefund")
+	defer span.End()
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.tenant(tenant.FromContext(ctx)).refunds.Add(total, 1)
+}
+
+//GetRefunds return the total of the credit notes of the tenant in ctx.
+func (repo *CacheRepository) GetRefunds(ctx context.Context) bill.Total {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.tenant(tenant.FromContext(ctx)).refunds
+}
+
+//GetBreakdown return the lines of the bill list of the tenant in ctx grouped by their tax code.
+func (repo *CacheRepository) GetBreakdown(ctx context.Context) []bill.Breakdown {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.tenant(tenant.FromContext(ctx)).breakdown
+}
+
+//GetAll return the bill list of the tenant in ctx.
+func (repo *CacheRepository) GetAll(ctx context.Context) ([]bill.Bill, bill.Total) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	owner := repo.tenant(tenant.FromContext(ctx))
+	return owner.bills, owner.total
+}
+
+//GetSnapshot return the open bill of the tenant in ctx read under one lock,
+//so the lines, the coupons, the charges, the breakdown, the total, and the refunds are never from different changes.
+func (repo *CacheRepository) GetSnapshot(ctx context.Context) bill.Snapshot {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	owner := repo.tenant(tenant.FromContext(ctx))
+	return bill.Snapshot{
+		ID:        owner.billID,
+		Bills:     owner.bills,
+		Coupons:   owner.coupons,
+		Charges:   owner.chargeLines,
+		Breakdown: owner.breakdown,
+		Total:     owner.total,
+		Refunds:   owner.refunds,
+	}
+}
+
+//Preview return the bill lines of the tax objects and the total of the bill of the tenant in ctx as if they were added to it.
+//The lines are calculated with the coupons and the charges of the bill, but the bill isn't changed.
+func (repo *CacheRepository) Preview(ctx context.Context, taxObjects []taxobj.TaxObject) (bills []bill.Bill, total bill.Total) {
+	_, span := tracing.Start(ctx, "CacheRepository.Preview")
+	defer span.End()
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	owner := repo.tenant(tenant.FromContext(ctx))
+	if len(owner.coupons) > 0 {
+		//The coupons are shared by the previewed lines too, so the whole bill is calculated.
+		previewed := make([]taxobj.TaxObject, 0, len(owner.taxObjects)+len(taxObjects))
+		previewed = append(append(previewed, owner.taxObjects...), taxObjects...)
+		bills, _, total = taxcalc.Calculate(repo.rules, taxobj.Calculables(previewed), owner.coupons, owner.charges)
+		bills = bills[len(owner.taxObjects):]
+		return
+	}
+	bills = make([]bill.Bill, 0, len(taxObjects))
+	total = owner.lineTotal
+	for _, taxObject := range taxObjects {
+		line := taxcalc.CalculateLine(repo.rules, taxObject.Calculable())
+		bills = append(bills, line)
+		total.AddLine(line)
+	}
+	taxcalc.CalculateCharges(repo.rules, owner.charges, &total)
 	return
 }
 
-//getType return the type text for the given tax code.
-func (repo *CacheRepository) getType(taxCode int64) string {
-	return typeMap[taxCode]
+//recalculate calculate the bill list and the total of the tenant again from its tax objects, coupons, and charges,
+//because the fixed coupon is shared by all lines, so every change of the bill with the coupons may change the other lines.
+func (repo *CacheRepository) recalculate(owner *tenantBill) {
+	owner.bills, _, _ = taxcalc.Calculate(repo.rules, taxobj.Calculables(owner.taxObjects), owner.coupons, nil)
+	owner.lineTotal = bill.Total{}
+	owner.breakdown = make([]bill.Breakdown, 0)
+	owner.taxCodes = make(map[taxKey]int)
+	for _, line := range owner.bills {
+		owner.applyLine(repo.rules, line, 1)
+	}
+	repo.calculateCharges(owner)
+}
+
+//applyLines subtract the removed lines from the total and the breakdown of the tenant, add the added lines to them,
+//and calculate the charges again, so the other lines aren't calculated again.
+func (repo *CacheRepository) applyLines(owner *tenantBill, removed []bill.Bill, added []bill.Bill) {
+	//Copy the breakdown so the breakdown returned by GetBreakdown is not changed.
+	breakdown := make([]bill.Breakdown, len(owner.breakdown))
+	copy(breakdown, owner.breakdown)
+	owner.breakdown = breakdown
+	for _, line := range removed {
+		owner.applyLine(repo.rules, line, -1)
+	}
+	for _, line := range added {
+		owner.applyLine(repo.rules, line, 1)
+	}
+	repo.calculateCharges(owner)
+}
+
+//calculateCharges calculate the charges of the tenant from the total of its lines,
+//and replace the total of the tenant and its share of the total of all tenants.
+func (repo *CacheRepository) calculateCharges(owner *tenantBill) {
+	repo.total.Add(owner.total, -1)
+	owner.total = owner.lineTotal
+	owner.chargeLines = taxcalc.CalculateCharges(repo.rules, owner.charges, &owner.total)
+	repo.total.Add(owner.total, 1)
+	metrics.SetBillCache(repo.lines, repo.total)
 }
 
-//getTax return the calculated tax for the given tax code and price.
-func (repo *CacheRepository) getTax(taxCode int64, price float64) (tax float64) {
-	if price <= 0 {
+//applyLine add the line multiplied by the sign to the total of the lines and the breakdown of the tenant.
+//The sign is -1 to subtract the line, and the breakdown group and the tax summary of the tax code
+//are removed with the last line summed in it, like they are never calculated without it.
+//The sums are rounded to the precision, and the bill without any line is reset to empty.
+func (owner *tenantBill) applyLine(rules taxcalc.Rules, line bill.Bill, sign float64) {
+	delta := bill.Total{}
+	delta.AddLine(line)
+	owner.lineTotal.Add(delta, sign)
+	owner.breakdown = taxcalc.AddBreakdown(rules, owner.breakdown, line, sign)
+	keys := make([]taxKey, 0, len(line.Taxes)+1)
+	keys = append(keys, taxKey{line.Jurisdiction, line.TaxCode})
+	for _, component := range line.Taxes {
+		keys = append(keys, taxKey{line.Jurisdiction, component.TaxCode})
+	}
+	for _, key := range keys {
+		owner.taxCodes[key] += int(sign)
+		if owner.taxCodes[key] > 0 {
+			continue
+		}
+		delete(owner.taxCodes, key)
+		owner.breakdown = removeBreakdown(owner.breakdown, key)
+		owner.lineTotal.Taxes = removeTax(owner.lineTotal.Taxes, key)
+	}
+	if len(owner.taxCodes) == 0 {
+		//Every line sums its tax code, so the bill without the tax codes has no lines and is exactly empty.
+		owner.lineTotal = bill.Total{}
+		owner.breakdown = make([]bill.Breakdown, 0)
+		return
+	}
+	roundTotal(&owner.lineTotal)
+	for index := range owner.breakdown {
+		owner.breakdown[index].PriceSubtotal = round(owner.breakdown[index].PriceSubtotal)
+		owner.breakdown[index].TaxSubtotal = round(owner.breakdown[index].TaxSubtotal)
+		owner.breakdown[index].RefundableTax = round(owner.breakdown[index].RefundableTax)
+	}
+}
+
+//roundTotal round the sums of the total to the precision.
+//The tax summaries are copied, so the totals sharing them are not changed.
+func roundTotal(total *bill.Total) {
+	total.PriceSubtotal = round(total.PriceSubtotal)
+	total.DiscountSubtotal = round(total.DiscountSubtotal)
+	total.CouponSubtotal = round(total.CouponSubtotal)
+	total.NetSubtotal = round(total.NetSubtotal)
+	total.TaxSubtotal = round(total.TaxSubtotal)
+	total.ChargeSubtotal = round(total.ChargeSubtotal)
+	total.ChargeTaxSubtotal = round(total.ChargeTaxSubtotal)
+	total.GrandTotal = round(total.GrandTotal)
+	if total.Taxes == nil {
 		return
 	}
-	switch taxCode {
-	case 1:
-		tax = float64(10) / float64(100) * price
-	case 2:
-		tax = float64(10) + (float64(2) / float64(100) * price)
-	case 3:
-		if price < 100 {
-			return
+	taxes := make([]bill.TaxSummary, len(total.Taxes))
+	for index, summary := range total.Taxes {
+		summary.Base = round(summary.Base)
+		summary.Tax = round(summary.Tax)
+		taxes[index] = summary
+	}
+	total.Taxes = taxes
+}
+
+//round return the value rounded to the precision.
+func round(value float64) float64 {
+	return math.Round(value*precision) / precision
+}
+
+//removeBreakdown return the breakdowns without the group of the tax code.
+//The breakdowns are changed, so they must not be shared.
+func removeBreakdown(breakdowns []bill.Breakdown, key taxKey) []bill.Breakdown {
+	for index, breakdown := range breakdowns {
+		if breakdown.Jurisdiction == key.jurisdiction && breakdown.TaxCode == key.taxCode {
+			return append(breakdowns[:index], breakdowns[index+1:]...)
 		}
-		tax = float64(1) / float64(100) * (price - float64(100))
 	}
-	return
+	return breakdowns
+}
+
+//removeTax return the copy of the tax summaries without the summary of the tax code,
+//so the totals sharing them are not changed.
+//The copy is nil without the summaries, like the total calculated without any tax.
+func removeTax(taxes []bill.TaxSummary, key taxKey) (removed []bill.TaxSummary) {
+	for _, summary := range taxes {
+		if summary.Jurisdiction != key.jurisdiction || summary.TaxCode != key.taxCode {
+			removed = append(removed, summary)
+		}
+	}
+	return removed
+}
+
+//index return the index of the tax object in the bill list, or -1 if it doesn't exist.
+func (owner *tenantBill) index(id int64) int {
+	for index, billID := range owner.ids {
+		if billID == id {
+			return index
+		}
+	}
+	return -1
+}
+
+//tenant return the bill of the tenant, creating it if it doesn't exist yet.
+//The tax objects without tenant belong to the default tenant.
+func (repo *CacheRepository) tenant(name string) *tenantBill {
+	if name == "" {
+		name = tenant.Default
+	}
+	owner, ok := repo.tenants[name]
+	if !ok {
+		owner = &tenantBill{
+			bills:       make([]bill.Bill, 0),
+			breakdown:   make([]bill.Breakdown, 0),
+			coupons:     make([]bill.Coupon, 0),
+			charges:     make([]bill.Charge, 0),
+			chargeLines: make([]bill.ChargeLine, 0),
+			taxCodes:    make(map[taxKey]int),
+			finalized:   make(map[int64]bool),
+		}
+		repo.tenants[name] = owner
+	}
+	return owner
 }
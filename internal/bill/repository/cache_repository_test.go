@@ -3,15 +3,31 @@
 package repository
 
 import (
+	"context"
+	"math/rand"
 	"reflect"
 	"sync"
 	"testing"
 
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
 	"github.com/stretchr/testify/assert"
 )
 
+//testJurisdiction is the jurisdiction with the other rates of the food and beverage and the tobacco.
+var testJurisdiction = taxobj.Jurisdiction{
+	Code: "TEST",
+	Name: "Test",
+	Rules: map[int64]taxobj.Rule{
+		1: {Type: "Food & Beverage", Refundable: true, Rate: 5},
+		2: {Type: "Tobacco", Fixed: 20, Rate: 5},
+		3: {Type: "Entertainment", Rate: 1, Threshold: 100},
+	},
+}
+
 func TestCacheRepository_Add(t *testing.T) {
 	t.Parallel()
 	type fields struct {
@@ -50,10 +66,16 @@ func TestCacheRepository_Add(t *testing.T) {
 			expectedState: expectedState{
 				bills: []bill.Bill{
 					bill.Bill{
-						Name:       "MACD",
-						TaxCode:    1,
-						Price:      20000,
-						Tax:        2000,
+						Name:        "MACD",
+						TaxCode:     1,
+						Quantity:    1,
+						UnitPrice:   20000,
+						Price:       20000,
+						TaxableBase: 20000,
+						Tax:         2000,
+						Taxes: []bill.TaxComponent{
+							{TaxCode: 1, Type: "Food & Beverage", Base: 20000, Tax: 2000},
+						},
 						Type:       "Food & Beverage",
 						Refundable: "Yes",
 						Amount:     22000,
@@ -61,8 +83,195 @@ func TestCacheRepository_Add(t *testing.T) {
 				},
 				total: bill.Total{
 					PriceSubtotal: 20000,
+					NetSubtotal:   20000,
 					TaxSubtotal:   2000,
 					GrandTotal:    22000,
+					Taxes: []bill.TaxSummary{
+						{TaxCode: 1, Type: "Food & Beverage", Base: 20000, Tax: 2000},
+					},
+				},
+			},
+		},
+		{
+			name: "Discount before Tax",
+			fields: fields{
+				mutex: new(sync.Mutex),
+				bills: []bill.Bill{},
+				total: bill.Total{},
+			},
+			args: args{
+				taxObject: taxobj.TaxObject{
+					Name:     "MACD",
+					TaxCode:  1,
+					Price:    20000,
+					Discount: &taxobj.Discount{Type: taxobj.DiscountPercent, Value: 25},
+				},
+			},
+			expectedState: expectedState{
+				bills: []bill.Bill{
+					bill.Bill{
+						Name:        "MACD",
+						TaxCode:     1,
+						Quantity:    1,
+						UnitPrice:   20000,
+						Price:       20000,
+						Discount:    5000,
+						TaxableBase: 15000,
+						Tax:         1500,
+						Taxes: []bill.TaxComponent{
+							{TaxCode: 1, Type: "Food & Beverage", Base: 15000, Tax: 1500},
+						},
+						Type:       "Food & Beverage",
+						Refundable: "Yes",
+						Amount:     16500,
+					},
+				},
+				total: bill.Total{
+					PriceSubtotal:    20000,
+					DiscountSubtotal: 5000,
+					NetSubtotal:      15000,
+					TaxSubtotal:      1500,
+					GrandTotal:       16500,
+					Taxes: []bill.TaxSummary{
+						{TaxCode: 1, Type: "Food & Beverage", Base: 15000, Tax: 1500},
+					},
+				},
+			},
+		},
+		{
+			name: "Tax for Every Unit",
+			fields: fields{
+				mutex: new(sync.Mutex),
+				bills: []bill.Bill{},
+				total: bill.Total{},
+			},
+			args: args{
+				taxObject: taxobj.TaxObject{
+					Name:      "Lucky Stretch",
+					TaxCode:   2,
+					Quantity:  3,
+					UnitPrice: 1000,
+					Price:     3000,
+				},
+			},
+			expectedState: expectedState{
+				bills: []bill.Bill{
+					bill.Bill{
+						Name:        "Lucky Stretch",
+						TaxCode:     2,
+						Quantity:    3,
+						UnitPrice:   1000,
+						Price:       3000,
+						TaxableBase: 3000,
+						Tax:         90,
+						Taxes: []bill.TaxComponent{
+							{TaxCode: 2, Type: "Tobacco", Base: 3000, Tax: 90},
+						},
+						Type:       "Tobacco",
+						Refundable: "No",
+						Amount:     3090,
+					},
+				},
+				total: bill.Total{
+					PriceSubtotal: 3000,
+					NetSubtotal:   3000,
+					TaxSubtotal:   90,
+					GrandTotal:    3090,
+					Taxes: []bill.TaxSummary{
+						{TaxCode: 2, Type: "Tobacco", Base: 3000, Tax: 90},
+					},
+				},
+			},
+		},
+		{
+			name: "Compound Taxes",
+			fields: fields{
+				mutex: new(sync.Mutex),
+				bills: []bill.Bill{},
+				total: bill.Total{},
+			},
+			args: args{
+				taxObject: taxobj.TaxObject{
+					Name:    "Cigar",
+					TaxCode: 2,
+					Taxes:   []taxobj.Tax{{TaxCode: 1, Compound: true}},
+					Price:   1000,
+				},
+			},
+			expectedState: expectedState{
+				bills: []bill.Bill{
+					bill.Bill{
+						Name:        "Cigar",
+						TaxCode:     2,
+						Quantity:    1,
+						UnitPrice:   1000,
+						Price:       1000,
+						TaxableBase: 1000,
+						Tax:         133,
+						Taxes: []bill.TaxComponent{
+							{TaxCode: 2, Type: "Tobacco", Base: 1000, Tax: 30},
+							{TaxCode: 1, Type: "Food & Beverage", Compound: true, Base: 1030, Tax: 103},
+						},
+						Type:       "Tobacco",
+						Refundable: "No",
+						Amount:     1133,
+					},
+				},
+				total: bill.Total{
+					PriceSubtotal: 1000,
+					NetSubtotal:   1000,
+					TaxSubtotal:   133,
+					GrandTotal:    1133,
+					Taxes: []bill.TaxSummary{
+						{TaxCode: 1, Type: "Food & Beverage", Base: 1030, Tax: 103},
+						{TaxCode: 2, Type: "Tobacco", Base: 1000, Tax: 30},
+					},
+				},
+			},
+		},
+		{
+			name: "Tax Inclusive",
+			fields: fields{
+				mutex: new(sync.Mutex),
+				bills: []bill.Bill{},
+				total: bill.Total{},
+			},
+			args: args{
+				taxObject: taxobj.TaxObject{
+					Name:         "Lucky Stretch",
+					TaxCode:      2,
+					Quantity:     2,
+					UnitPrice:    1030,
+					TaxInclusive: true,
+				},
+			},
+			expectedState: expectedState{
+				bills: []bill.Bill{
+					bill.Bill{
+						Name:         "Lucky Stretch",
+						TaxCode:      2,
+						Quantity:     2,
+						UnitPrice:    1030,
+						Price:        2060,
+						TaxInclusive: true,
+						TaxableBase:  2000,
+						Tax:          60,
+						Taxes: []bill.TaxComponent{
+							{TaxCode: 2, Type: "Tobacco", Base: 2000, Tax: 60},
+						},
+						Type:       "Tobacco",
+						Refundable: "No",
+						Amount:     2060,
+					},
+				},
+				total: bill.Total{
+					PriceSubtotal: 2060,
+					NetSubtotal:   2000,
+					TaxSubtotal:   60,
+					GrandTotal:    2060,
+					Taxes: []bill.TaxSummary{
+						{TaxCode: 2, Type: "Tobacco", Base: 2000, Tax: 60},
+					},
 				},
 			},
 		},
@@ -70,17 +279,132 @@ func TestCacheRepository_Add(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &CacheRepository{
+				log:   logger.Discard(),
+				rules: taxcalc.NewJurisdictions(),
 				mutex: tt.fields.mutex,
-				bills: tt.fields.bills,
-				total: tt.fields.total,
+				tenants: map[string]*tenantBill{
+					tenant.Default: {
+						bills:     tt.fields.bills,
+						total:     tt.fields.total,
+						lineTotal: tt.fields.total,
+						taxCodes:  make(map[taxKey]int),
+					},
+				},
 			}
-			repo.Add(tt.args.taxObject)
-			assert.Equal(t, repo.bills, tt.expectedState.bills)
-			assert.Equal(t, repo.total, tt.expectedState.total)
+			repo.Add(context.Background(), tt.args.taxObject)
+			assert.Equal(t, repo.tenants[tenant.Default].bills, tt.expectedState.bills)
+			assert.Equal(t, repo.tenants[tenant.Default].total, tt.expectedState.total)
 		})
 	}
 }
 
+func TestCacheRepository_Preview(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "KFC", TaxCode: 1, Quantity: 1, UnitPrice: 5000, Price: 5000})
+	repo.SetCharges(context.Background(), []bill.Charge{{Name: "Tip", Type: bill.ChargePercent, Value: 10}})
+	beforeBills, beforeTotal := repo.GetAll(context.Background())
+
+	//The previewed lines are calculated with the charges of the bill.
+	bills, total := repo.Preview(context.Background(), []taxobj.TaxObject{
+		{Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, UnitPrice: 1000, Price: 1000},
+	})
+	if assert.Len(t, bills, 1) {
+		assert.Equal(t, "Lucky Stretch", bills[0].Name)
+		assert.Equal(t, float64(30), bills[0].Tax)
+		assert.Equal(t, float64(1030), bills[0].Amount)
+	}
+	assert.Equal(t, float64(6000), total.PriceSubtotal)
+	assert.Equal(t, float64(530), total.TaxSubtotal)
+	assert.Equal(t, float64(600), total.ChargeSubtotal)
+	assert.Equal(t, float64(7130), total.GrandTotal)
+
+	//The bill isn't changed by the preview.
+	afterBills, afterTotal := repo.GetAll(context.Background())
+	assert.Equal(t, beforeBills, afterBills)
+	assert.Equal(t, beforeTotal, afterTotal)
+	assert.Equal(t, float64(6000), afterTotal.GrandTotal)
+}
+
+func TestCacheRepository_Explain(t *testing.T) {
+	t.Parallel()
+	rules := taxcalc.NewJurisdictions()
+	repo := NewCacheRepository(rules, logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150, Price: 300})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "Ticket", TaxCode: 3, Quantity: 1, UnitPrice: 50, Price: 50})
+	repo.Add(context.Background(), taxobj.TaxObject{
+		ID:        3,
+		Name:      "Cigar",
+		TaxCode:   2,
+		Taxes:     []taxobj.Tax{{TaxCode: 1, Compound: true}},
+		Quantity:  1,
+		UnitPrice: 1000,
+		Price:     1000,
+	})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 4, Name: "Matches", TaxCode: 2, Quantity: 1, UnitPrice: 5, Price: 5, TaxInclusive: true})
+	bills, _ := repo.GetAll(context.Background())
+	explained := taxcalc.ExplainAll(rules, bills)
+
+	entertainment, _ := rules.RuleOf(taxobj.DefaultJurisdiction, 3)
+	assert.Equal(t, bill.Explanation{
+		Version: "1",
+		Taxes: []bill.TaxExplanation{
+			{
+				TaxCode:   3,
+				Rule:      entertainment,
+				Formula:   "2 * (0 + 1% * (150 - 100)) = 1",
+				Quantity:  2,
+				UnitBase:  150,
+				TaxedBase: 50,
+				UnitTax:   0.5,
+				Tax:       1,
+			},
+		},
+	}, *explained[0].Explanation)
+	assert.Equal(t, "1 * 0 = 0, the unit base 50 is under the threshold 100", explained[1].Explanation.Taxes[0].Formula)
+	assert.Equal(t, "1 * (10 + 2% * (1000 - 0)) = 30", explained[2].Explanation.Taxes[0].Formula)
+	assert.Equal(t, "1 * (0 + 10% * (1030 - 0)) = 103", explained[2].Explanation.Taxes[1].Formula)
+	//The price of the tax-inclusive line not covering the fixed tax is all tax, so the tax differs from the formula.
+	assert.Equal(t, "1 * 0 = 0, the unit base is not positive", explained[3].Explanation.Taxes[0].Formula)
+	assert.Equal(t, float64(5), explained[3].Explanation.Taxes[0].Rounding)
+
+	//The lines of the bill are not explained.
+	for _, billObject := range bills {
+		assert.Nil(t, billObject.Explanation)
+	}
+}
+
+func TestCacheRepository_Explain_Rules(t *testing.T) {
+	t.Parallel()
+	//The jurisdiction is only in the rules calculating the bill, so it's explained by them.
+	rules := taxcalc.NewJurisdictions(taxobj.Jurisdiction{
+		Code:    "EXPLAIN",
+		Version: "2024-1",
+		Rules:   map[int64]taxobj.Rule{1: {Type: "Food & Beverage", Rate: 5}},
+	})
+	repo := NewCacheRepository(rules, logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Jurisdiction: "EXPLAIN", Quantity: 1, UnitPrice: 1000, Price: 1000})
+	bills, _ := repo.GetAll(context.Background())
+	explained := taxcalc.ExplainAll(rules, bills)
+
+	assert.Equal(t, bill.Explanation{
+		Jurisdiction: "EXPLAIN",
+		Version:      "2024-1",
+		Taxes: []bill.TaxExplanation{
+			{
+				TaxCode:   1,
+				Rule:      taxobj.Rule{Type: "Food & Beverage", Rate: 5},
+				Formula:   "1 * (0 + 5% * (1000 - 0)) = 50",
+				Quantity:  1,
+				UnitBase:  1000,
+				TaxedBase: 1000,
+				UnitTax:   50,
+				Tax:       50,
+			},
+		},
+	}, *explained[0].Explanation)
+}
+
 func TestCacheRepository_GetAll(t *testing.T) {
 	t.Parallel()
 	type fields struct {
@@ -122,6 +446,7 @@ func TestCacheRepository_GetAll(t *testing.T) {
 				},
 				total: bill.Total{
 					PriceSubtotal: 20000,
+					NetSubtotal:   20000,
 					TaxSubtotal:   2000,
 					GrandTotal:    22000,
 				},
@@ -139,6 +464,7 @@ func TestCacheRepository_GetAll(t *testing.T) {
 			},
 			want1: bill.Total{
 				PriceSubtotal: 20000,
+				NetSubtotal:   20000,
 				TaxSubtotal:   2000,
 				GrandTotal:    22000,
 			},
@@ -148,10 +474,11 @@ func TestCacheRepository_GetAll(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &CacheRepository{
 				mutex: tt.fields.mutex,
-				bills: tt.fields.bills,
-				total: tt.fields.total,
+				tenants: map[string]*tenantBill{
+					tenant.Default: {bills: tt.fields.bills, total: tt.fields.total},
+				},
 			}
-			got, got1 := repo.GetAll()
+			got, got1 := repo.GetAll(context.Background())
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("CacheRepository.GetAll() got = %v, want %v", got, tt.want)
 			}
@@ -162,228 +489,555 @@ func TestCacheRepository_GetAll(t *testing.T) {
 	}
 }
 
-func TestCacheRepository_getRefundable(t *testing.T) {
+func TestCacheRepository_Tenant(t *testing.T) {
 	t.Parallel()
-	type fields struct {
-		mutex *sync.Mutex
-		bills []bill.Bill
-		total bill.Total
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{Name: "MACD", TaxCode: 1, Price: 20000, Tenant: "merchant-a"})
+	repo.Add(context.Background(), taxobj.TaxObject{Name: "Movie", TaxCode: 3, Price: 150, Tenant: "merchant-b"})
+
+	bills, total := repo.GetAll(tenant.WithTenant(context.Background(), "merchant-a"))
+	if assert.Len(t, bills, 1) {
+		assert.Equal(t, "MACD", bills[0].Name)
 	}
-	type args struct {
-		taxCode int64
+	assert.Equal(t, bill.Total{
+		PriceSubtotal: 20000,
+		NetSubtotal:   20000,
+		TaxSubtotal:   2000,
+		GrandTotal:    22000,
+		Taxes:         []bill.TaxSummary{{TaxCode: 1, Type: "Food & Beverage", Base: 20000, Tax: 2000}},
+	}, total)
+
+	bills, total = repo.GetAll(tenant.WithTenant(context.Background(), "merchant-b"))
+	if assert.Len(t, bills, 1) {
+		assert.Equal(t, "Movie", bills[0].Name)
 	}
-	defaultFields := fields{
-		mutex: new(sync.Mutex),
-		bills: []bill.Bill{},
-		total: bill.Total{},
+	assert.Equal(t, bill.Total{
+		PriceSubtotal: 150,
+		NetSubtotal:   150,
+		TaxSubtotal:   0.5,
+		GrandTotal:    150.5,
+		Taxes:         []bill.TaxSummary{{TaxCode: 3, Type: "Entertainment", Base: 150, Tax: 0.5}},
+	}, total)
+
+	bills, total = repo.GetAll(context.Background())
+	assert.Empty(t, bills)
+	assert.Equal(t, bill.Total{}, total)
+}
+
+func TestCacheRepository_Update(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "Movie", TaxCode: 3, Price: 150})
+	before, _ := repo.GetAll(context.Background())
+
+	repo.Update(context.Background(), taxobj.TaxObject{ID: 1, Name: "Big MACD", TaxCode: 1, Price: 30000})
+	repo.Update(context.Background(), taxobj.TaxObject{ID: 3, Name: "Unknown", TaxCode: 1, Price: 1000})
+
+	bills, total := repo.GetAll(context.Background())
+	if assert.Len(t, bills, 2) {
+		assert.Equal(t, "Big MACD", bills[0].Name)
+		assert.Equal(t, float64(33000), bills[0].Amount)
+		assert.Equal(t, "Movie", bills[1].Name)
 	}
-	tests := []struct {
-		name           string
-		fields         fields
-		args           args
-		wantRefundable string
-	}{
-		// TODO: Add test cases.
-		{
-			name:   "Food & Beverage",
-			fields: defaultFields,
-			args: args{
-				taxCode: 1,
-			},
-			wantRefundable: "Yes",
-		},
-		{
-			name:   "Tobacco",
-			fields: defaultFields,
-			args: args{
-				taxCode: 2,
-			},
-			wantRefundable: "No",
-		},
-		{
-			name:   "Entertainment",
-			fields: defaultFields,
-			args: args{
-				taxCode: 3,
-			},
-			wantRefundable: "No",
-		},
-		{
-			name:   "Invalid Tax Code",
-			fields: defaultFields,
-			args: args{
-				taxCode: 0,
-			},
-			wantRefundable: "",
-		},
+	assert.Equal(t, bill.Total{
+		PriceSubtotal: 30150,
+		NetSubtotal:   30150,
+		TaxSubtotal:   3000.5,
+		GrandTotal:    33150.5,
+		Taxes:         []bill.TaxSummary{{TaxCode: 1, Type: "Food & Beverage", Base: 30000, Tax: 3000}, {TaxCode: 3, Type: "Entertainment", Base: 150, Tax: 0.5}},
+	}, total)
+	assert.Equal(t, "MACD", before[0].Name)
+}
+
+func TestCacheRepository_Remove(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "Movie", TaxCode: 3, Price: 150})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000, Tenant: "merchant-a"})
+	before, _ := repo.GetAll(context.Background())
+
+	repo.Remove(context.Background(), 1)
+	repo.Remove(context.Background(), 3)
+
+	bills, total := repo.GetAll(context.Background())
+	if assert.Len(t, bills, 1) {
+		assert.Equal(t, "Movie", bills[0].Name)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			repo := &CacheRepository{
-				mutex: tt.fields.mutex,
-				bills: tt.fields.bills,
-				total: tt.fields.total,
-			}
-			if gotRefundable := repo.getRefundable(tt.args.taxCode); gotRefundable != tt.wantRefundable {
-				t.Errorf("CacheRepository.getRefundable() = %v, want %v", gotRefundable, tt.wantRefundable)
-			}
-		})
+	assert.Equal(t, bill.Total{
+		PriceSubtotal: 150,
+		NetSubtotal:   150,
+		TaxSubtotal:   0.5,
+		GrandTotal:    150.5,
+		Taxes:         []bill.TaxSummary{{TaxCode: 3, Type: "Entertainment", Base: 150, Tax: 0.5}},
+	}, total)
+	assert.Len(t, before, 2)
+
+	bills, _ = repo.GetAll(tenant.WithTenant(context.Background(), "merchant-a"))
+	assert.Len(t, bills, 1)
+}
+
+func TestCacheRepository_RemoveBill(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	assert.Equal(t, int64(0), repo.GetID(context.Background()))
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000, BillID: 7})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "Lucky Stretch", TaxCode: 2, Price: 1000, BillID: 7})
+	assert.Equal(t, int64(7), repo.GetID(context.Background()))
+	//The tax object added to the next bill before the finalized bill is removed.
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 3, Name: "Movie", TaxCode: 3, Price: 150, BillID: 8})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 4, Name: "MACD", TaxCode: 1, Price: 20000, BillID: 9, Tenant: "merchant-a"})
+
+	repo.RemoveBill(context.Background(), 7)
+
+	bills, total := repo.GetAll(context.Background())
+	if assert.Len(t, bills, 1) {
+		assert.Equal(t, "Movie", bills[0].Name)
 	}
+	assert.Equal(t, bill.Total{
+		PriceSubtotal: 150,
+		NetSubtotal:   150,
+		TaxSubtotal:   0.5,
+		GrandTotal:    150.5,
+		Taxes:         []bill.TaxSummary{{TaxCode: 3, Type: "Entertainment", Base: 150, Tax: 0.5}},
+	}, total)
+	assert.Equal(t, int64(8), repo.GetID(context.Background()))
+
+	repo.RemoveBill(context.Background(), 8)
+	bills, _ = repo.GetAll(context.Background())
+	assert.Empty(t, bills)
+	assert.Equal(t, int64(0), repo.GetID(context.Background()))
+
+	bills, _ = repo.GetAll(tenant.WithTenant(context.Background(), "merchant-a"))
+	assert.Len(t, bills, 1)
+	assert.Equal(t, int64(9), repo.GetID(tenant.WithTenant(context.Background(), "merchant-a")))
 }
 
-func TestCacheRepository_getType(t *testing.T) {
+func TestCacheRepository_RemoveBill_LateAdd(t *testing.T) {
 	t.Parallel()
-	type fields struct {
-		mutex *sync.Mutex
-		bills []bill.Bill
-		total bill.Total
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000, BillID: 7})
+	repo.RemoveBill(context.Background(), 7)
+	//The tax object committed to the bill before it's finalized is added after the bill is removed.
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "Movie", TaxCode: 3, Price: 150, BillID: 7})
+
+	bills, total := repo.GetAll(context.Background())
+	assert.Empty(t, bills)
+	assert.Equal(t, float64(0), total.GrandTotal)
+	assert.Equal(t, int64(0), repo.GetID(context.Background()))
+
+	//The next open bill is still added.
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 3, Name: "Movie", TaxCode: 3, Price: 150, BillID: 8})
+	bills, _ = repo.GetAll(context.Background())
+	assert.Len(t, bills, 1)
+	assert.Equal(t, int64(8), repo.GetID(context.Background()))
+}
+
+func TestCacheRepository_RemoveBill_Race(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	const lines = 100
+	var wg sync.WaitGroup
+	wg.Add(lines + 1)
+	for id := int64(1); id <= lines; id++ {
+		go func(id int64) {
+			defer wg.Done()
+			repo.Add(context.Background(), taxobj.TaxObject{ID: id, Name: "Movie", TaxCode: 3, Price: 150, BillID: 7})
+		}(id)
 	}
-	type args struct {
-		taxCode int64
+	go func() {
+		defer wg.Done()
+		repo.RemoveBill(context.Background(), 7)
+	}()
+	wg.Wait()
+
+	//Every line of the finalized bill is either removed with it or ignored after it, whatever the order.
+	snapshot := repo.GetSnapshot(context.Background())
+	assert.Empty(t, snapshot.Bills)
+	assert.Equal(t, float64(0), snapshot.Total.GrandTotal)
+	assert.Equal(t, int64(0), snapshot.ID)
+}
+
+func TestCacheRepository_SetCoupons(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{
+		ID:       1,
+		Name:     "MACD",
+		TaxCode:  1,
+		Price:    20000,
+		BillID:   7,
+		Discount: &taxobj.Discount{Type: taxobj.DiscountFixed, Value: 5000},
+	})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "Movie", TaxCode: 3, Price: 5000, BillID: 7})
+	before, _ := repo.GetAll(context.Background())
+
+	//The fixed coupon is shared by the lines in proportion to their price after their discounts,
+	//then the percent coupon is deducted from the remaining price.
+	coupons := []bill.Coupon{
+		{Code: "WELCOME", Discount: taxobj.Discount{Type: taxobj.DiscountFixed, Value: 2000}},
+		{Code: "HAPPYHOUR", Discount: taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10}},
 	}
-	defaultFields := fields{
-		mutex: new(sync.Mutex),
-		bills: []bill.Bill{},
-		total: bill.Total{},
+	repo.SetCoupons(context.Background(), coupons)
+	assert.Equal(t, coupons, repo.GetCoupons(context.Background()))
+
+	bills, total := repo.GetAll(context.Background())
+	if assert.Len(t, bills, 2) {
+		assert.Equal(t, float64(5000), bills[0].Discount)
+		assert.Equal(t, float64(2850), bills[0].Coupon)
+		assert.Equal(t, float64(12150), bills[0].TaxableBase)
+		assert.Equal(t, float64(1215), bills[0].Tax)
+		assert.Equal(t, float64(950), bills[1].Coupon)
+		assert.Equal(t, float64(4050), bills[1].TaxableBase)
+		assert.Equal(t, 39.5, bills[1].Tax)
 	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   args
-		want   string
-	}{
-		// TODO: Add test cases.
-		{
-			name:   "Food & Beverage",
-			fields: defaultFields,
-			args: args{
-				taxCode: 1,
-			},
-			want: "Food & Beverage",
-		},
-		{
-			name:   "Tobacco",
-			fields: defaultFields,
-			args: args{
-				taxCode: 2,
-			},
-			want: "Tobacco",
-		},
-		{
-			name:   "Entertainment",
-			fields: defaultFields,
-			args: args{
-				taxCode: 3,
-			},
-			want: "Entertainment",
-		},
-		{
-			name:   "Invalid Tax Code",
-			fields: defaultFields,
-			args: args{
-				taxCode: 0,
-			},
-			want: "",
-		},
+	assert.Equal(t, bill.Total{
+		PriceSubtotal:    25000,
+		DiscountSubtotal: 5000,
+		CouponSubtotal:   3800,
+		NetSubtotal:      16200,
+		TaxSubtotal:      1254.5,
+		GrandTotal:       17454.5,
+		Taxes:            []bill.TaxSummary{{TaxCode: 1, Type: "Food & Beverage", Base: 12150, Tax: 1215}, {TaxCode: 3, Type: "Entertainment", Base: 4050, Tax: 39.5}},
+	}, total)
+	assert.Equal(t, float64(0), before[0].Coupon)
+
+	//The coupons belong to the finalized bill.
+	repo.RemoveBill(context.Background(), 7)
+	assert.Empty(t, repo.GetCoupons(context.Background()))
+}
+
+func TestCacheRepository_SetCharges(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000, BillID: 7})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "Movie", TaxCode: 3, Price: 5000, BillID: 7})
+
+	//The service charge is taxed as food and beverage, and the tip is not taxed.
+	charges := []bill.Charge{
+		{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 10},
+		{Name: "Tip", Type: bill.ChargeFixed, Value: 1000},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			repo := &CacheRepository{
-				mutex: tt.fields.mutex,
-				bills: tt.fields.bills,
-				total: tt.fields.total,
-			}
-			if got := repo.getType(tt.args.taxCode); got != tt.want {
-				t.Errorf("CacheRepository.getType() = %v, want %v", got, tt.want)
-			}
-		})
+	repo.SetCharges(context.Background(), charges)
+	assert.Equal(t, []bill.ChargeLine{
+		{Charge: charges[0], Price: 2500, Tax: 250, Amount: 2750},
+		{Charge: charges[1], Price: 1000, Amount: 1000},
+	}, repo.GetCharges(context.Background()))
+
+	bills, total := repo.GetAll(context.Background())
+	assert.Len(t, bills, 2)
+	assert.Equal(t, bill.Total{
+		PriceSubtotal:     25000,
+		NetSubtotal:       25000,
+		TaxSubtotal:       2049,
+		ChargeSubtotal:    3500,
+		ChargeTaxSubtotal: 250,
+		GrandTotal:        30799,
+		//The tax of the service charge is summarized with the tax of the food and beverage.
+		Taxes: []bill.TaxSummary{{TaxCode: 1, Type: "Food & Beverage", Base: 22500, Tax: 2250}, {TaxCode: 3, Type: "Entertainment", Base: 5000, Tax: 49}},
+	}, total)
+
+	//The percent charge follows the lines of the bill.
+	repo.Remove(context.Background(), 2)
+	assert.Equal(t, float64(2000), repo.GetCharges(context.Background())[0].Price)
+
+	//The charges belong to the finalized bill.
+	repo.RemoveBill(context.Background(), 7)
+	assert.Empty(t, repo.GetCharges(context.Background()))
+	_, total = repo.GetAll(context.Background())
+	assert.Equal(t, bill.Total{}, total)
+}
+
+func TestCacheRepository_Recalculate(t *testing.T) {
+	t.Parallel()
+	rules := taxcalc.NewJurisdictions(testJurisdiction)
+	repo := NewCacheRepository(rules, logger.Discard())
+	ctx := context.Background()
+	//The operations are random, but the seed is fixed, so the test always runs the same operations.
+	random := rand.New(rand.NewSource(1))
+	newTaxObject := func(id int64) taxobj.TaxObject {
+		taxObject := taxobj.TaxObject{
+			ID:           id,
+			Name:         "Item",
+			TaxCode:      1 + random.Int63n(3),
+			Quantity:     float64(1 + random.Intn(3)),
+			UnitPrice:    float64(50 + random.Intn(2000)),
+			TaxInclusive: random.Intn(4) == 0,
+		}
+		if random.Intn(2) == 0 {
+			taxObject.Jurisdiction = testJurisdiction.Code
+		}
+		if random.Intn(3) == 0 {
+			taxObject.Taxes = []taxobj.Tax{{TaxCode: 1 + random.Int63n(3), Compound: random.Intn(2) == 0}}
+		}
+		if random.Intn(4) == 0 {
+			taxObject.Discount = &taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10}
+		}
+		taxObject.Derive()
+		return taxObject
 	}
+	charges := []bill.Charge{{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 5}}
+	repo.SetCharges(ctx, charges)
+	taxObjects := make([]taxobj.TaxObject, 0)
+	for step := int64(1); step <= 300; step++ {
+		index := 0
+		if len(taxObjects) > 0 {
+			index = random.Intn(len(taxObjects))
+		}
+		switch operation := random.Intn(4); {
+		case operation < 2 || len(taxObjects) == 0:
+			taxObject := newTaxObject(step)
+			taxObjects = append(taxObjects, taxObject)
+			repo.Add(ctx, taxObject)
+		case operation == 2:
+			taxObject := newTaxObject(taxObjects[index].ID)
+			taxObjects[index] = taxObject
+			repo.Update(ctx, taxObject)
+		default:
+			repo.Remove(ctx, taxObjects[index].ID)
+			taxObjects = append(taxObjects[:index], taxObjects[index+1:]...)
+		}
+		//The bill kept line by line is the bill calculated at once.
+		wantBills, wantCharges, wantTotal := taxcalc.Calculate(rules, taxobj.Calculables(taxObjects), nil, charges)
+		snapshot := repo.GetSnapshot(ctx)
+		assert.Equal(t, wantBills, snapshot.Bills)
+		assertChargesInDelta(t, wantCharges, snapshot.Charges)
+		assertTotalInDelta(t, wantTotal, snapshot.Total)
+		assertBreakdownInDelta(t, taxcalc.GroupBreakdown(rules, wantBills), snapshot.Breakdown)
+	}
+
+	//The bill with the coupons is calculated at once.
+	coupons := []bill.Coupon{{Code: "PROMO", Discount: taxobj.Discount{Type: taxobj.DiscountFixed, Value: 100}}}
+	repo.SetCoupons(ctx, coupons)
+	added := newTaxObject(1000)
+	repo.Add(ctx, added)
+	repo.Remove(ctx, taxObjects[0].ID)
+	taxObjects = append(taxObjects[1:], added)
+	wantBills, wantCharges, wantTotal := taxcalc.Calculate(rules, taxobj.Calculables(taxObjects), coupons, charges)
+	snapshot := repo.GetSnapshot(ctx)
+	assert.Equal(t, wantBills, snapshot.Bills)
+	assertChargesInDelta(t, wantCharges, snapshot.Charges)
+	assertTotalInDelta(t, wantTotal, snapshot.Total)
+	assertBreakdownInDelta(t, taxcalc.GroupBreakdown(rules, wantBills), snapshot.Breakdown)
 }
 
-func TestCacheRepository_getTax(t *testing.T) {
+func TestCacheRepository_Drift(t *testing.T) {
 	t.Parallel()
-	type fields struct {
-		mutex *sync.Mutex
-		bills []bill.Bill
-		total bill.Total
+	rules := taxcalc.NewJurisdictions(testJurisdiction)
+	repo := NewCacheRepository(rules, logger.Discard())
+	ctx := context.Background()
+	kept := taxobj.TaxObject{ID: 1, Name: "Movie", TaxCode: 3, Price: 150, TaxInclusive: true}
+	kept.Derive()
+	repo.Add(ctx, kept)
+	//The lines with the long fractions are added and removed many times, so their rounding would accumulate.
+	for step := int64(0); step < 10000; step++ {
+		added := []taxobj.TaxObject{
+			{ID: 2, Name: "Big Mac", TaxCode: 1, Price: 1000.1 + float64(step%7)/3, TaxInclusive: true},
+			{ID: 3, Name: "Lucky Stretch", TaxCode: 2, Jurisdiction: testJurisdiction.Code, Price: 333.3, Taxes: []taxobj.Tax{{TaxCode: 1, Compound: true}}},
+		}
+		for _, taxObject := range added {
+			taxObject.Derive()
+			repo.Add(ctx, taxObject)
+		}
+		repo.Remove(ctx, 3)
+		repo.Remove(ctx, 2)
 	}
-	type args struct {
-		taxCode int64
-		price   float64
+	wantBills, _, wantTotal := taxcalc.Calculate(rules, taxobj.Calculables([]taxobj.TaxObject{kept}), nil, nil)
+	snapshot := repo.GetSnapshot(ctx)
+	assert.Equal(t, wantBills, snapshot.Bills)
+	assert.InDeltaSlice(t, []float64{wantTotal.NetSubtotal, wantTotal.TaxSubtotal, wantTotal.GrandTotal},
+		[]float64{snapshot.Total.NetSubtotal, snapshot.Total.TaxSubtotal, snapshot.Total.GrandTotal}, 1e-9)
+	assertBreakdownInDelta(t, taxcalc.GroupBreakdown(rules, wantBills), snapshot.Breakdown)
+
+	//The bill without any line is exactly empty.
+	repo.Remove(ctx, 1)
+	snapshot = repo.GetSnapshot(ctx)
+	assert.Equal(t, bill.Total{}, snapshot.Total)
+	assert.Empty(t, snapshot.Breakdown)
+}
+
+//assertTotalInDelta assert the total is the wanted total within the rounding of adding and subtracting the lines.
+func assertTotalInDelta(t *testing.T, want bill.Total, got bill.Total) {
+	t.Helper()
+	assert.InDeltaSlice(t, []float64{
+		want.PriceSubtotal, want.DiscountSubtotal, want.CouponSubtotal, want.NetSubtotal,
+		want.TaxSubtotal, want.ChargeSubtotal, want.ChargeTaxSubtotal, want.GrandTotal,
+	}, []float64{
+		got.PriceSubtotal, got.DiscountSubtotal, got.CouponSubtotal, got.NetSubtotal,
+		got.TaxSubtotal, got.ChargeSubtotal, got.ChargeTaxSubtotal, got.GrandTotal,
+	}, 1e-6)
+	if !assert.Len(t, got.Taxes, len(want.Taxes)) {
+		return
 	}
-	defaultFields := fields{
-		mutex: new(sync.Mutex),
-		bills: []bill.Bill{},
-		total: bill.Total{},
+	for index, summary := range want.Taxes {
+		assert.Equal(t, summary.Jurisdiction, got.Taxes[index].Jurisdiction)
+		assert.Equal(t, summary.TaxCode, got.Taxes[index].TaxCode)
+		assert.InDeltaSlice(t, []float64{summary.Base, summary.Tax}, []float64{got.Taxes[index].Base, got.Taxes[index].Tax}, 1e-6)
 	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantTax float64
-	}{
-		// TODO: Add test cases.
-		{
-			name:   "Food & Beverage",
-			fields: defaultFields,
-			args: args{
-				taxCode: 1,
-				price:   10000,
-			},
-			wantTax: 1000,
-		},
-		{
-			name:   "Tobacco",
-			fields: defaultFields,
-			args: args{
-				taxCode: 2,
-				price:   1000,
-			},
-			wantTax: 30,
-		},
-		{
-			name:   "Entertainment Above 100",
-			fields: defaultFields,
-			args: args{
-				taxCode: 3,
-				price:   120,
-			},
-			wantTax: 0.2,
-		},
-		{
-			name:   "Entertainment Below 100",
-			fields: defaultFields,
-			args: args{
-				taxCode: 3,
-				price:   50,
-			},
-			wantTax: 0,
-		},
-		{
-			name:   "Invalid Tax Code",
-			fields: defaultFields,
-			args: args{
-				taxCode: 0,
-			},
-			wantTax: 0,
-		},
+}
+
+//assertChargesInDelta assert the charges are the wanted charges within the rounding of their subtotal.
+func assertChargesInDelta(t *testing.T, want []bill.ChargeLine, got []bill.ChargeLine) {
+	t.Helper()
+	if !assert.Len(t, got, len(want)) {
+		return
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			repo := &CacheRepository{
-				mutex: tt.fields.mutex,
-				bills: tt.fields.bills,
-				total: tt.fields.total,
-			}
-			if gotTax := repo.getTax(tt.args.taxCode, tt.args.price); gotTax != tt.wantTax {
-				t.Errorf("CacheRepository.getTax() = %v, want %v", gotTax, tt.wantTax)
-			}
-		})
+	for index, chargeLine := range want {
+		assert.Equal(t, chargeLine.Charge, got[index].Charge)
+		assert.InDeltaSlice(t, []float64{chargeLine.Price, chargeLine.Tax, chargeLine.Amount}, []float64{got[index].Price, got[index].Tax, got[index].Amount}, 1e-6)
+	}
+}
+
+//assertBreakdownInDelta assert the breakdown is the wanted breakdown within the rounding of adding and subtracting the lines.
+func assertBreakdownInDelta(t *testing.T, want []bill.Breakdown, got []bill.Breakdown) {
+	t.Helper()
+	if !assert.Len(t, got, len(want)) {
+		return
+	}
+	for index, breakdown := range want {
+		assert.Equal(t, breakdown.Jurisdiction, got[index].Jurisdiction)
+		assert.Equal(t, breakdown.TaxCode, got[index].TaxCode)
+		assert.Equal(t, breakdown.Lines, got[index].Lines)
+		assert.InDeltaSlice(t, []float64{breakdown.PriceSubtotal, breakdown.TaxSubtotal, breakdown.RefundableTax},
+			[]float64{got[index].PriceSubtotal, got[index].TaxSubtotal, got[index].RefundableTax}, 1e-6)
+	}
+}
+
+func TestCacheRepository_GetBreakdown(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	assert.Empty(t, repo.GetBreakdown(context.Background()))
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "Movie", TaxCode: 3, Price: 150})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "MACD", TaxCode: 1, Price: 20000})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 3, Name: "KFC", TaxCode: 1, Price: 5000})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 4, Name: "Lucky Stretch", TaxCode: 2, Price: 1000})
+	before := repo.GetBreakdown(context.Background())
+
+	//Only the tax of the food and beverage is refundable.
+	assert.Equal(t, []bill.Breakdown{
+		{TaxCode: 1, Type: "Food & Beverage", Lines: 2, PriceSubtotal: 25000, TaxSubtotal: 2500, RefundableTax: 2500},
+		{TaxCode: 2, Type: "Tobacco", Lines: 1, PriceSubtotal: 1000, TaxSubtotal: 30},
+		{TaxCode: 3, Type: "Entertainment", Lines: 1, PriceSubtotal: 150, TaxSubtotal: 0.5},
+	}, before)
+
+	//The breakdown follows the changes of the bill.
+	repo.Remove(context.Background(), 2)
+	assert.Equal(t, bill.Breakdown{
+		TaxCode:       1,
+		Type:          "Food & Beverage",
+		Lines:         1,
+		PriceSubtotal: 5000,
+		TaxSubtotal:   500,
+		RefundableTax: 500,
+	}, repo.GetBreakdown(context.Background())[0])
+	assert.Equal(t, 2, before[0].Lines)
+	assert.Empty(t, repo.GetBreakdown(tenant.WithTenant(context.Background(), "merchant-a")))
+}
+
+func TestCacheRepository_Jurisdiction(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(testJurisdiction), logger.Discard())
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 1, Name: "Lucky Stretch", TaxCode: 2, Price: 1000})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 2, Name: "Lucky Stretch", TaxCode: 2, Jurisdiction: "TEST", Price: 1000})
+	repo.Add(context.Background(), taxobj.TaxObject{ID: 3, Name: "KFC", TaxCode: 1, Jurisdiction: "TEST", Price: 5000})
+	repo.SetCharges(context.Background(), []bill.Charge{
+		{Name: "Service", TaxCode: 1, Jurisdiction: "TEST", Type: bill.ChargeFixed, Value: 1000},
+	})
+
+	//Every line is taxed by the rules of its jurisdiction.
+	bills, total := repo.GetAll(context.Background())
+	assert.Equal(t, float64(30), bills[0].Tax)
+	assert.Equal(t, "TEST", bills[1].Jurisdiction)
+	assert.Equal(t, float64(70), bills[1].Tax)
+	assert.Equal(t, float64(250), bills[2].Tax)
+	assert.Equal(t, float64(50), repo.GetCharges(context.Background())[0].Tax)
+
+	//The same tax code of different jurisdictions is summarized separately.
+	assert.Equal(t, []bill.TaxSummary{
+		{TaxCode: 2, Type: "Tobacco", Base: 1000, Tax: 30},
+		{Jurisdiction: "TEST", TaxCode: 1, Type: "Food & Beverage", Base: 6000, Tax: 300},
+		{Jurisdiction: "TEST", TaxCode: 2, Type: "Tobacco", Base: 1000, Tax: 70},
+	}, total.Taxes)
+	assert.Equal(t, []bill.Breakdown{
+		{TaxCode: 2, Type: "Tobacco", Lines: 1, PriceSubtotal: 1000, TaxSubtotal: 30},
+		{Jurisdiction: "TEST", TaxCode: 1, Type: "Food & Beverage", Lines: 1, PriceSubtotal: 5000, TaxSubtotal: 250, RefundableTax: 250},
+		{Jurisdiction: "TEST", TaxCode: 2, Type: "Tobacco", Lines: 1, PriceSubtotal: 1000, TaxSubtotal: 70},
+	}, repo.GetBreakdown(context.Background()))
+}
+
+func TestCacheRepository_AddRefund(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	repo.AddRefund(context.Background(), bill.Total{PriceSubtotal: -1000, TaxSubtotal: -100, GrandTotal: -1100})
+	repo.AddRefund(context.Background(), bill.Total{PriceSubtotal: -1000, GrandTotal: -1000})
+	repo.AddRefund(tenant.WithTenant(context.Background(), "merchant-a"), bill.Total{PriceSubtotal: -500, GrandTotal: -500})
+
+	assert.Equal(t, bill.Total{PriceSubtotal: -2000, TaxSubtotal: -100, GrandTotal: -2100}, repo.GetRefunds(context.Background()))
+	assert.Equal(t, bill.Total{PriceSubtotal: -500, GrandTotal: -500}, repo.GetRefunds(tenant.WithTenant(context.Background(), "merchant-a")))
+	//The refunds don't change the open bill.
+	_, total := repo.GetAll(context.Background())
+	assert.Equal(t, bill.Total{}, total)
+}
+
+func TestCacheRepository_GetSnapshot(t *testing.T) {
+	t.Parallel()
+	repo := NewCacheRepository(taxcalc.NewJurisdictions(), logger.Discard())
+	ctx := context.Background()
+	assert.Equal(t, bill.Snapshot{
+		Bills:     []bill.Bill{},
+		Coupons:   []bill.Coupon{},
+		Charges:   []bill.ChargeLine{},
+		Breakdown: []bill.Breakdown{},
+	}, repo.GetSnapshot(ctx))
+
+	repo.Add(ctx, taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000, BillID: 7})
+	repo.SetCoupons(ctx, []bill.Coupon{{Code: "WELCOME", Discount: taxobj.Discount{Type: taxobj.DiscountFixed, Value: 2000}}})
+	repo.SetCharges(ctx, []bill.Charge{{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 10}})
+	repo.AddRefund(ctx, bill.Total{PriceSubtotal: -1000, GrandTotal: -1000})
+	bills, total := repo.GetAll(ctx)
+	assert.Equal(t, bill.Snapshot{
+		ID:        7,
+		Bills:     bills,
+		Coupons:   repo.GetCoupons(ctx),
+		Charges:   repo.GetCharges(ctx),
+		Breakdown: repo.GetBreakdown(ctx),
+		Total:     total,
+		Refunds:   repo.GetRefunds(ctx),
+	}, repo.GetSnapshot(ctx))
+
+	//The snapshot read while the bill changes is always consistent, i.e. its total is the total of its lines.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for id := int64(2); id < 100; id++ {
+			repo.Add(ctx, taxobj.TaxObject{ID: id, Name: "Movie", TaxCode: 3, Price: 150, BillID: 7})
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		snapshot := repo.GetSnapshot(ctx)
+		var priceSubtotal float64
+		for _, line := range snapshot.Bills {
+			priceSubtotal += line.Price
+		}
+		assert.Equal(t, priceSubtotal, snapshot.Total.PriceSubtotal)
+		lines := 0
+		for _, breakdown := range snapshot.Breakdown {
+			lines += breakdown.Lines
+		}
+		assert.Equal(t, len(snapshot.Bills), lines)
 	}
 }
 
 func TestNewCacheRepository(t *testing.T) {
 	t.Parallel()
+	log := logger.Discard()
+	rules := taxcalc.NewJurisdictions()
 	tests := []struct {
 		name string
 		want bill.Repository
@@ -392,15 +1046,17 @@ func TestNewCacheRepository(t *testing.T) {
 		{
 			name: "Init Bill Cache Repository",
 			want: &CacheRepository{
-				mutex: new(sync.Mutex),
-				bills: []bill.Bill{},
-				total: bill.Total{},
+				log:     log,
+				rules:   rules,
+				mutex:   new(sync.Mutex),
+				tenants: map[string]*tenantBill{},
+				total:   bill.Total{},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NewCacheRepository()
+			got := NewCacheRepository(rules, log)
 			assert.EqualValues(t, got, tt.want)
 		})
 	}
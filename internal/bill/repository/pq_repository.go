@@ -0,0 +1,1224 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/metrics"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+	"github.com/sirupsen/logrus"
+)
+
+//PqRepository is the repository for managing the bills and their invoices using postgre.
+//The bills of a tenant are locked in every transaction changing them or their tax objects,
+//so the tax objects are never changed while their bill is finalized.
+type PqRepository struct {
+	pool      *sql.DB
+	rules     taxcalc.Rules
+	recorder  audit.Recorder
+	log       logrus.FieldLogger
+	statement statement
+}
+
+//statement defines the prepared statements of the queries outside the transaction.
+type statement struct {
+	selectInvoice      *sql.Stmt
+	selectCreditNote   *sql.Stmt
+	selectRefundTotals *sql.Stmt
+	selectOpenCoupons  *sql.Stmt
+	selectOpenCharges  *sql.Stmt
+}
+
+//Query names used to label the database metrics.
+const (
+	nameLockBills         = "bill_lock"
+	nameSelectOpen        = "bill_select_open"
+	nameInsertBill        = "bill_insert"
+	nameSelectFinalized   = "bill_select_finalized"
+	nameSelectLines       = "bill_select_lines"
+	nameFinalize          = "bill_finalize"
+	nameSelectNumber      = "invoice_select_number"
+	nameInsertInvoice     = "invoice_insert"
+	nameSelectInvoice     = "invoice_select"
+	nameSelectOne         = "bill_select_one"
+	nameCreateTable       = "bill_create_table"
+	nameBindTaxObjects    = "bill_bind_tax_objects"
+	nameRefund            = "credit_note_refund"
+	nameSelectRefunded    = "credit_note_select_refunded"
+	nameInsertCredit      = "credit_note_insert"
+	nameSelectCredit      = "credit_note_select"
+	nameSelectTotals      = "credit_note_select_totals"
+	nameCreateCredit      = "credit_note_create_table"
+	nameSelectCoupons     = "bill_select_coupons"
+	nameUpdateCoupons     = "bill_update_coupons"
+	nameSelectOpenCoupons = "bill_select_open_coupons"
+	nameAddCoupons        = "bill_add_coupons"
+	nameAddInvoiceCoupons = "invoice_add_coupons"
+	nameSelectCharges     = "bill_select_charges"
+	nameUpdateCharges     = "bill_update_charges"
+	nameSelectOpenCharges = "bill_select_open_charges"
+	nameAddCharges        = "bill_add_charges"
+	nameAddInvoiceCharges = "invoice_add_charges"
+)
+
+const (
+	//queryLockBills serializes the changes to the bills of the tenant until the end of the transaction.
+	queryLockBills = `
+		SELECT pg_advisory_xact_lock(hashtext('bill:' || $1))
+	`
+	querySelectOpen = `
+		SELECT
+			id
+		FROM
+			bill
+		WHERE
+			tenant = $1 AND finalized_at IS NULL
+	`
+	queryInsertBill = `
+		INSERT INTO bill
+			(id, tenant, created_at)
+		VALUES
+			(DEFAULT, $1, DEFAULT)
+		RETURNING id
+	`
+	querySelectFinalized = `
+		SELECT
+			finalized_at IS NOT NULL
+		FROM
+			bill
+		WHERE
+			id = $1 AND tenant = $2
+	`
+	querySelectLines = `
+		SELECT
+			id, name, tax_code, quantity, COALESCE(unit_price, price), price, discount_type, discount_value, tax_inclusive, taxes, jurisdiction, tenant
+		FROM
+			tax_object
+		WHERE
+			bill_id = $1 AND deleted_at IS NULL
+		ORDER BY id
+	`
+	querySelectCoupons = `
+		SELECT
+			coupons
+		FROM
+			bill
+		WHERE
+			id = $1
+	`
+	queryUpdateCoupons = `
+		UPDATE bill
+		SET
+			coupons = $2
+		WHERE
+			id = $1
+	`
+	//querySelectOpenCoupons return the coupons of the open bill of every tenant.
+	querySelectOpenCoupons = `
+		SELECT
+			tenant, coupons
+		FROM
+			bill
+		WHERE
+			finalized_at IS NULL
+	`
+	querySelectCharges = `
+		SELECT
+			charges
+		FROM
+			bill
+		WHERE
+			id = $1
+	`
+	queryUpdateCharges = `
+		UPDATE bill
+		SET
+			charges = $2
+		WHERE
+			id = $1
+	`
+	//querySelectOpenCharges return the charges of the open bill of every tenant.
+	querySelectOpenCharges = `
+		SELECT
+			tenant, charges
+		FROM
+			bill
+		WHERE
+			finalized_at IS NULL
+	`
+	queryFinalize = `
+		UPDATE bill
+		SET
+			finalized_at = $2
+		WHERE
+			id = $1
+	`
+	//querySelectNumber return the next invoice number of the tenant.
+	//The number is taken in the transaction inserting the invoice, so the numbers have no gaps.
+	querySelectNumber = `
+		SELECT
+			COALESCE(MAX(number), 0) + 1
+		FROM
+			invoice
+		WHERE
+			tenant = $1
+	`
+	queryInsertInvoice = `
+		INSERT INTO invoice
+			(tenant, number, bill_id, lines, coupons, charges, total, issued_at)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	querySelectInvoice = `
+		SELECT
+			number, bill_id, lines, coupons, charges, total, issued_at, tenant
+		FROM
+			invoice
+		WHERE
+			tenant = $1 AND number = $2
+	`
+	//querySelectInvoiceLines return the lines of the invoice to refund.
+	querySelectInvoiceLines = `
+		SELECT
+			lines
+		FROM
+			invoice
+		WHERE
+			tenant = $1 AND number = $2
+	`
+	//querySelectRefunded return the lines of the credit notes refunding the invoice.
+	querySelectRefunded = `
+		SELECT
+			lines
+		FROM
+			credit_note
+		WHERE
+			tenant = $1 AND invoice_number = $2
+	`
+	//querySelectCreditNumber return the next credit note number of the tenant.
+	querySelectCreditNumber = `
+		SELECT
+			COALESCE(MAX(number), 0) + 1
+		FROM
+			credit_note
+		WHERE
+			tenant = $1
+	`
+	queryInsertCredit = `
+		INSERT INTO credit_note
+			(tenant, number, invoice_number, lines, total, issued_at)
+		VALUES
+			($1, $2, $3, $4, $5, $6)
+	`
+	querySelectCredit = `
+		SELECT
+			number, invoice_number, lines, total, issued_at, tenant
+		FROM
+			credit_note
+		WHERE
+			tenant = $1 AND number = $2
+	`
+	querySelectTotals = `
+		SELECT
+			tenant, total
+		FROM
+			credit_note
+	`
+	querySelectOne = `
+		SELECT
+			id
+		FROM
+			bill
+		LIMIT 1
+	`
+	//queryCreateTable creates the bills and the invoices.
+	//Every tenant has at most one open bill, and the invoices discard every update and delete.
+	queryCreateTable = `
+		CREATE TABLE bill (
+			id serial PRIMARY KEY,
+			tenant VARCHAR(255) NOT NULL,
+			created_at timestamp with time zone NOT NULL DEFAULT now(),
+			finalized_at timestamp with time zone
+		);
+		CREATE UNIQUE INDEX bill_open_idx ON bill (tenant) WHERE finalized_at IS NULL;
+		CREATE TABLE invoice (
+			tenant VARCHAR(255) NOT NULL,
+			number bigint NOT NULL,
+			bill_id integer NOT NULL UNIQUE REFERENCES bill (id),
+			lines jsonb NOT NULL,
+			total jsonb NOT NULL,
+			issued_at timestamp with time zone NOT NULL,
+			PRIMARY KEY (tenant, number)
+		);
+		CREATE RULE invoice_no_update AS ON UPDATE TO invoice DO INSTEAD NOTHING;
+		CREATE RULE invoice_no_delete AS ON DELETE TO invoice DO INSTEAD NOTHING
+	`
+	//queryCreateCredit creates the credit notes refunding the invoices.
+	//The credit notes discard every update and delete like the invoices.
+	queryCreateCredit = `
+		CREATE TABLE IF NOT EXISTS credit_note (
+			tenant VARCHAR(255) NOT NULL,
+			number bigint NOT NULL,
+			invoice_number bigint NOT NULL,
+			lines jsonb NOT NULL,
+			total jsonb NOT NULL,
+			issued_at timestamp with time zone NOT NULL,
+			PRIMARY KEY (tenant, number),
+			FOREIGN KEY (tenant, invoice_number) REFERENCES invoice (tenant, number)
+		);
+		CREATE OR REPLACE RULE credit_note_no_update AS ON UPDATE TO credit_note DO INSTEAD NOTHING;
+		CREATE OR REPLACE RULE credit_note_no_delete AS ON DELETE TO credit_note DO INSTEAD NOTHING
+	`
+	//queryAddCoupons adds the coupons to the bills created before them.
+	queryAddCoupons = `
+		ALTER TABLE bill
+			ADD COLUMN IF NOT EXISTS coupons jsonb NOT NULL DEFAULT '[]'
+	`
+	//queryAddInvoiceCoupons adds the coupons to the invoices issued before them,
+	//so the invoice is returned with the coupons it was issued with.
+	queryAddInvoiceCoupons = `
+		ALTER TABLE invoice
+			ADD COLUMN IF NOT EXISTS coupons jsonb NOT NULL DEFAULT '[]'
+	`
+	//queryAddCharges adds the charges to the bills created before them.
+	queryAddCharges = `
+		ALTER TABLE bill
+			ADD COLUMN IF NOT EXISTS charges jsonb NOT NULL DEFAULT '[]'
+	`
+	//queryAddInvoiceCharges adds the calculated charges to the invoices issued before them,
+	//so the invoice is returned with the charges it was issued with.
+	queryAddInvoiceCharges = `
+		ALTER TABLE invoice
+			ADD COLUMN IF NOT EXISTS charges jsonb NOT NULL DEFAULT '[]'
+	`
+	//queryBindTaxObjects binds the tax objects created before the bills to the open bill of their tenant.
+	queryBindTaxObjects = `
+		INSERT INTO bill (tenant)
+			SELECT DISTINCT
+				tenant
+			FROM
+				tax_object t
+			WHERE
+				bill_id IS NULL AND NOT EXISTS (
+					SELECT 1 FROM bill b WHERE b.tenant = t.tenant AND b.finalized_at IS NULL
+				);
+		UPDATE tax_object t
+		SET
+			bill_id = b.id
+		FROM
+			bill b
+		WHERE
+			t.bill_id IS NULL AND b.tenant = t.tenant AND b.finalized_at IS NULL
+	`
+)
+
+//NewPqRepository creates the pq repository for the bills and the invoices with postgre connection.
+//Every finalized bill is recorded as the audit event in the same transaction, and its invoice is calculated with the rules.
+func NewPqRepository(pool *sql.DB, rules taxcalc.Rules, recorder audit.Recorder, log logrus.FieldLogger) bill.InvoiceRepository {
+	return &PqRepository{
+		pool:      pool,
+		rules:     rules,
+		recorder:  recorder,
+		log:       log,
+		statement: statement{},
+	}
+}
+
+//OpenBill return the open bill of the tenant in ctx, creating it if it doesn't exist yet.
+//The bills of the tenant are locked until the end of the transaction.
+func (repo *PqRepository) OpenBill(ctx context.Context, tx *sql.Tx) (id int64, err error) {
+	if err = repo.lock(ctx, tx); err != nil {
+		return
+	}
+	begin := time.Now()
+	err = tx.QueryRowContext(ctx, querySelectOpen, tenant.FromContext(ctx)).Scan(&id)
+	if err != sql.ErrNoRows {
+		repo.observe(ctx, nameSelectOpen, begin, err)
+		return
+	}
+	metrics.ObserveQuery(nameSelectOpen, begin, nil)
+	begin = time.Now()
+	err = tx.QueryRowContext(ctx, queryInsertBill, tenant.FromContext(ctx)).Scan(&id)
+	repo.observe(ctx, nameInsertBill, begin, err)
+	return
+}
+
+//CheckOpen return ErrFinalized if the bill of the tenant in ctx has been finalized.
+//The bills of the tenant are locked until the end of the transaction.
+//The tax objects without the bill are not bound yet, so they can be changed.
+func (repo *PqRepository) CheckOpen(ctx context.Context, tx *sql.Tx, id int64) (err error) {
+	if err = repo.lock(ctx, tx); err != nil {
+		return
+	}
+	finalized, err := repo.selectFinalized(ctx, tx, id)
+	if err == bill.ErrNotFound {
+		return nil
+	}
+	if err == nil && finalized {
+		err = bill.ErrFinalized
+	}
+	return
+}
+
+//Finalize freeze the lines and the total of the open bill of the tenant in ctx into the invoice with the next number.
+//The lines are calculated from the tax objects, the coupons, and the charges of the bill in the database, so none of them is missed.
+func (repo *PqRepository) Finalize(ctx context.Context, id int64) (invoice bill.Invoice, err error) {
+	ctx, span := tracing.Start(ctx, "BillPqRepository.Finalize", tracing.Query(nameFinalize, queryFinalize)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameFinalize, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+	invoice = bill.Invoice{
+		BillID: id,
+		Tenant: tenant.FromContext(ctx),
+		//The timestamp is stored in microseconds, so it's truncated to be returned exactly as issued.
+		IssuedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = repo.lockOpen(ctx, tx, id); err != nil {
+			return
+		}
+		taxObjects, err := repo.selectLines(ctx, tx, id)
+		if err != nil {
+			return
+		}
+		if len(taxObjects) == 0 {
+			return bill.ErrEmpty
+		}
+		if invoice.Coupons, err = repo.selectCoupons(ctx, tx, id); err != nil {
+			return
+		}
+		charges, err := repo.selectCharges(ctx, tx, id)
+		if err != nil {
+			return
+		}
+		invoice.Bill, invoice.Charges, invoice.Total = repo.calculate(taxObjects, invoice.Coupons, charges)
+		if _, err = tx.ExecContext(ctx, queryFinalize, id, invoice.IssuedAt); err != nil {
+			return
+		}
+		if err = tx.QueryRowContext(ctx, querySelectNumber, invoice.Tenant).Scan(&invoice.Number); err != nil {
+			return
+		}
+		if err = repo.insertInvoice(ctx, tx, invoice); err != nil {
+			return
+		}
+		event, err := audit.NewEvent(ctx, audit.ActionFinalize, audit.EntityBill, id, nil, invoice)
+		if err != nil {
+			return
+		}
+		return repo.recorder.Record(ctx, tx, &event)
+	})
+	if err != nil {
+		invoice = bill.Invoice{}
+	}
+	return
+}
+
+//GetInvoice return the invoice of the tenant in ctx with the given number as it was issued.
+func (repo *PqRepository) GetInvoice(ctx context.Context, number int64) (invoice bill.Invoice, err error) {
+	ctx, span := tracing.Start(ctx, "BillPqRepository.GetInvoice", tracing.Query(nameSelectInvoice, querySelectInvoice)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectInvoice, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectInvoice, querySelectInvoice)
+	if err != nil {
+		return
+	}
+	var lines, coupons, charges, total []byte
+	err = stmt.QueryRowContext(ctx, tenant.FromContext(ctx), number).Scan(
+		&invoice.Number,
+		&invoice.BillID,
+		&lines,
+		&coupons,
+		&charges,
+		&total,
+		&invoice.IssuedAt,
+		&invoice.Tenant,
+	)
+	if err == sql.ErrNoRows {
+		err = bill.ErrInvoiceNotFound
+	}
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(lines, &invoice.Bill); err != nil {
+		return
+	}
+	if err = json.Unmarshal(coupons, &invoice.Coupons); err != nil {
+		return
+	}
+	if err = json.Unmarshal(charges, &invoice.Charges); err != nil {
+		return
+	}
+	err = json.Unmarshal(total, &invoice.Total)
+	return
+}
+
+//Refund refund the lines of the invoice of the tenant in ctx with the credit note having the next number.
+//All lines that haven't been refunded are refunded if no line is given.
+//The bills of the tenant are locked, so the line is never refunded twice.
+func (repo *PqRepository) Refund(ctx context.Context, number int64, lines []int) (creditNote bill.CreditNote, err error) {
+	ctx, span := tracing.Start(ctx, "BillPqRepository.Refund", tracing.Query(nameRefund, queryInsertCredit)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameRefund, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+	creditNote = bill.CreditNote{
+		InvoiceNumber: number,
+		Tenant:        tenant.FromContext(ctx),
+		IssuedAt:      time.Now().UTC().Truncate(time.Microsecond),
+	}
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = repo.lock(ctx, tx); err != nil {
+			return
+		}
+		var invoiceLines []bill.Bill
+		if err = repo.selectJSON(ctx, tx, querySelectInvoiceLines, &invoiceLines, creditNote.Tenant, number); err != nil {
+			if err == sql.ErrNoRows {
+				err = bill.ErrInvoiceNotFound
+			}
+			return
+		}
+		refunded, err := repo.selectRefunded(ctx, tx, number)
+		if err != nil {
+			return
+		}
+		if creditNote.Lines, creditNote.Total, err = credit(repo.rules, invoiceLines, lines, refunded); err != nil {
+			return
+		}
+		if err = tx.QueryRowContext(ctx, querySelectCreditNumber, creditNote.Tenant).Scan(&creditNote.Number); err != nil {
+			return
+		}
+		if err = repo.insertCredit(ctx, tx, creditNote); err != nil {
+			return
+		}
+		event, err := audit.NewEvent(ctx, audit.ActionRefund, audit.EntityInvoice, number, nil, creditNote)
+		if err != nil {
+			return
+		}
+		return repo.recorder.Record(ctx, tx, &event)
+	})
+	if err != nil {
+		creditNote = bill.CreditNote{}
+	}
+	return
+}
+
+//GetCreditNote return the credit note of the tenant in ctx with the given number as it was issued.
+func (repo *PqRepository) GetCreditNote(ctx context.Context, number int64) (creditNote bill.CreditNote, err error) {
+	ctx, span := tracing.Start(ctx, "BillPqRepository.GetCreditNote", tracing.Query(nameSelectCredit, querySelectCredit)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectCredit, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectCreditNote, querySelectCredit)
+	if err != nil {
+		return
+	}
+	var lines, total []byte
+	err = stmt.QueryRowContext(ctx, tenant.FromContext(ctx), number).Scan(
+		&creditNote.Number,
+		&creditNote.InvoiceNumber,
+		&lines,
+		&total,
+		&creditNote.IssuedAt,
+		&creditNote.Tenant,
+	)
+	if err == sql.ErrNoRows {
+		err = bill.ErrCreditNoteNotFound
+	}
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(lines, &creditNote.Lines); err != nil {
+		return
+	}
+	err = json.Unmarshal(total, &creditNote.Total)
+	return
+}
+
+//GetRefundTotals return the total of the credit notes of every tenant.
+func (repo *PqRepository) GetRefundTotals(ctx context.Context) (totals map[string]bill.Total, err error) {
+	totals = make(map[string]bill.Total)
+	ctx, span := tracing.Start(ctx, "BillPqRepository.GetRefundTotals", tracing.Query(nameSelectTotals, querySelectTotals)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectTotals, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectRefundTotals, querySelectTotals)
+	if err != nil {
+		return
+	}
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name  string
+			data  []byte
+			total bill.Total
+		)
+		if err = rows.Scan(&name, &data); err != nil {
+			return
+		}
+		if err = json.Unmarshal(data, &total); err != nil {
+			return
+		}
+		sum := totals[name]
+		sum.Add(total, 1)
+		totals[name] = sum
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//AddCoupon apply the coupon to the open bill of the tenant in ctx and return the coupons of the bill.
+//The coupon with the same code can only be applied once.
+func (repo *PqRepository) AddCoupon(ctx context.Context, id int64, coupon bill.Coupon) (coupons []bill.Coupon, err error) {
+	return repo.changeCoupons(ctx, "BillPqRepository.AddCoupon", id, func(before []bill.Coupon) (after []bill.Coupon, err error) {
+		if indexCoupon(before, coupon.Code) >= 0 {
+			err = bill.ErrCouponExists
+			return
+		}
+		after = append(before[:len(before):len(before)], coupon)
+		return
+	})
+}
+
+//RemoveCoupon remove the coupon with the given code from the open bill of the tenant in ctx
+//and return the remaining coupons of the bill.
+func (repo *PqRepository) RemoveCoupon(ctx context.Context, id int64, code string) (coupons []bill.Coupon, err error) {
+	return repo.changeCoupons(ctx, "BillPqRepository.RemoveCoupon", id, func(before []bill.Coupon) (after []bill.Coupon, err error) {
+		index := indexCoupon(before, code)
+		if index < 0 {
+			err = bill.ErrCouponNotFound
+			return
+		}
+		after = make([]bill.Coupon, 0, len(before)-1)
+		after = append(append(after, before[:index]...), before[index+1:]...)
+		return
+	})
+}
+
+//GetOpenCoupons return the coupons of the open bill of every tenant.
+func (repo *PqRepository) GetOpenCoupons(ctx context.Context) (coupons map[string][]bill.Coupon, err error) {
+	coupons = make(map[string][]bill.Coupon)
+	ctx, span := tracing.Start(ctx, "BillPqRepository.GetOpenCoupons", tracing.Query(nameSelectOpenCoupons, querySelectOpenCoupons)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectOpenCoupons, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectOpenCoupons, querySelectOpenCoupons)
+	if err != nil {
+		return
+	}
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name    string
+			data    []byte
+			applied []bill.Coupon
+		)
+		if err = rows.Scan(&name, &data); err != nil {
+			return
+		}
+		if err = json.Unmarshal(data, &applied); err != nil {
+			return
+		}
+		if len(applied) > 0 {
+			coupons[name] = applied
+		}
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//AddCharge add the charge to the open bill of the tenant in ctx and return the charges of the bill.
+//The charge with the same name can only be added once.
+func (repo *PqRepository) AddCharge(ctx context.Context, id int64, charge bill.Charge) (charges []bill.Charge, err error) {
+	return repo.changeCharges(ctx, "BillPqRepository.AddCharge", id, func(before []bill.Charge) (after []bill.Charge, err error) {
+		if indexCharge(before, charge.Name) >= 0 {
+			err = bill.ErrChargeExists
+			return
+		}
+		after = append(before[:len(before):len(before)], charge)
+		return
+	})
+}
+
+//RemoveCharge remove the charge with the given name from the open bill of the tenant in ctx
+//and return the remaining charges of the bill.
+func (repo *PqRepository) RemoveCharge(ctx context.Context, id int64, name string) (charges []bill.Charge, err error) {
+	return repo.changeCharges(ctx, "BillPqRepository.RemoveCharge", id, func(before []bill.Charge) (after []bill.Charge, err error) {
+		index := indexCharge(before, name)
+		if index < 0 {
+			err = bill.ErrChargeNotFound
+			return
+		}
+		after = make([]bill.Charge, 0, len(before)-1)
+		after = append(append(after, before[:index]...), before[index+1:]...)
+		return
+	})
+}
+
+//GetOpenCharges return the charges of the open bill of every tenant.
+func (repo *PqRepository) GetOpenCharges(ctx context.Context) (charges map[string][]bill.Charge, err error) {
+	charges = make(map[string][]bill.Charge)
+	ctx, span := tracing.Start(ctx, "BillPqRepository.GetOpenCharges", tracing.Query(nameSelectOpenCharges, querySelectOpenCharges)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectOpenCharges, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	stmt, err := repo.prepare(&repo.statement.selectOpenCharges, querySelectOpenCharges)
+	if err != nil {
+		return
+	}
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name  string
+			data  []byte
+			added []bill.Charge
+		)
+		if err = rows.Scan(&name, &data); err != nil {
+			return
+		}
+		if err = json.Unmarshal(data, &added); err != nil {
+			return
+		}
+		if len(added) > 0 {
+			charges[name] = added
+		}
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//Migrate create the tables in the database if they don't exist,
+//and binds the tax objects created before the bills to the open bill of their tenant.
+func (repo *PqRepository) Migrate() (err error) {
+	ctx, span := tracing.Start(context.Background(), "BillPqRepository.Migrate")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	var id int64
+	begin := time.Now()
+	row := repo.pool.QueryRowContext(ctx, querySelectOne)
+	err = row.Scan(&id)
+	if err == nil || err == sql.ErrNoRows {
+		metrics.ObserveQuery(nameSelectOne, begin, nil)
+	} else {
+		//The select query is expected to fail if the table doesn't exist yet.
+		metrics.ObserveQuery(nameSelectOne, begin, err)
+		repo.log.WithError(err).Info("[BillPqRepository] Creating the bill and invoice tables")
+		begin = time.Now()
+		_, err = repo.pool.ExecContext(ctx, queryCreateTable)
+		repo.observe(ctx, nameCreateTable, begin, err)
+		if err != nil {
+			return
+		}
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryCreateCredit)
+	repo.observe(ctx, nameCreateCredit, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddCoupons)
+	repo.observe(ctx, nameAddCoupons, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddInvoiceCoupons)
+	repo.observe(ctx, nameAddInvoiceCoupons, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddCharges)
+	repo.observe(ctx, nameAddCharges, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddInvoiceCharges)
+	repo.observe(ctx, nameAddInvoiceCharges, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryBindTaxObjects)
+	repo.observe(ctx, nameBindTaxObjects, begin, err)
+	return
+}
+
+//Close close all prepared statements in this repository.
+func (repo *PqRepository) Close() {
+	for _, stmt := range []*sql.Stmt{
+		repo.statement.selectInvoice,
+		repo.statement.selectCreditNote,
+		repo.statement.selectRefundTotals,
+		repo.statement.selectOpenCoupons,
+		repo.statement.selectOpenCharges,
+	} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+//lock locks the bills of the tenant in ctx until the end of the transaction.
+func (repo *PqRepository) lock(ctx context.Context, tx *sql.Tx) (err error) {
+	begin := time.Now()
+	_, err = tx.ExecContext(ctx, queryLockBills, tenant.FromContext(ctx))
+	repo.observe(ctx, nameLockBills, begin, err)
+	return
+}
+
+//lockOpen locks the bills of the tenant in ctx until the end of the transaction,
+//and return ErrFinalized if the bill has been finalized.
+func (repo *PqRepository) lockOpen(ctx context.Context, tx *sql.Tx, id int64) (err error) {
+	if err = repo.lock(ctx, tx); err != nil {
+		return
+	}
+	finalized, err := repo.selectFinalized(ctx, tx, id)
+	if err == nil && finalized {
+		err = bill.ErrFinalized
+	}
+	return
+}
+
+//selectFinalized return whether the bill of the tenant in ctx has been finalized.
+func (repo *PqRepository) selectFinalized(ctx context.Context, tx *sql.Tx, id int64) (finalized bool, err error) {
+	begin := time.Now()
+	defer func() {
+		repo.observe(ctx, nameSelectFinalized, begin, queryError(err))
+	}()
+	err = tx.QueryRowContext(ctx, querySelectFinalized, id, tenant.FromContext(ctx)).Scan(&finalized)
+	if err == sql.ErrNoRows {
+		err = bill.ErrNotFound
+	}
+	return
+}
+
+//selectLines return the tax objects of the bill that are not deleted.
+func (repo *PqRepository) selectLines(ctx context.Context, tx *sql.Tx, id int64) (taxObjects []taxobj.TaxObject, err error) {
+	begin := time.Now()
+	defer func() {
+		repo.observe(ctx, nameSelectLines, begin, err)
+	}()
+	rows, err := tx.QueryContext(ctx, querySelectLines, id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			discount taxobj.Discount
+			taxes    []byte
+		)
+		taxObject := taxobj.TaxObject{BillID: id}
+		err = rows.Scan(
+			&taxObject.ID,
+			&taxObject.Name,
+			&taxObject.TaxCode,
+			&taxObject.Quantity,
+			&taxObject.UnitPrice,
+			&taxObject.Price,
+			&discount.Type,
+			&discount.Value,
+			&taxObject.TaxInclusive,
+			&taxes,
+			&taxObject.Jurisdiction,
+			&taxObject.Tenant,
+		)
+		if err != nil {
+			return
+		}
+		if err = json.Unmarshal(taxes, &taxObject.Taxes); err != nil {
+			return
+		}
+		taxObject.Discount = discount.OrNil()
+		taxObjects = append(taxObjects, taxObject)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//changeCoupons change the coupons of the open bill of the tenant in ctx with the function in the transaction,
+//and record the change as the audit event of the bill.
+func (repo *PqRepository) changeCoupons(ctx context.Context, name string, id int64, change func([]bill.Coupon) ([]bill.Coupon, error)) (coupons []bill.Coupon, err error) {
+	ctx, span := tracing.Start(ctx, name, tracing.Query(nameUpdateCoupons, queryUpdateCoupons)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameUpdateCoupons, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = repo.lockOpen(ctx, tx, id); err != nil {
+			return
+		}
+		before, err := repo.selectCoupons(ctx, tx, id)
+		if err != nil {
+			return
+		}
+		if coupons, err = change(before); err != nil {
+			return
+		}
+		data, err := json.Marshal(coupons)
+		if err != nil {
+			return
+		}
+		if _, err = tx.ExecContext(ctx, queryUpdateCoupons, id, string(data)); err != nil {
+			return
+		}
+		event, err := audit.NewEvent(ctx, audit.ActionUpdate, audit.EntityBill, id, before, coupons)
+		if err != nil {
+			return
+		}
+		return repo.recorder.Record(ctx, tx, &event)
+	})
+	if err != nil {
+		coupons = nil
+	}
+	return
+}
+
+//selectCoupons return the coupons applied to the bill in the order they are applied.
+func (repo *PqRepository) selectCoupons(ctx context.Context, tx *sql.Tx, id int64) (coupons []bill.Coupon, err error) {
+	begin := time.Now()
+	defer func() {
+		repo.observe(ctx, nameSelectCoupons, begin, err)
+	}()
+	coupons = make([]bill.Coupon, 0)
+	err = repo.selectJSON(ctx, tx, querySelectCoupons, &coupons, id)
+	return
+}
+
+//changeCharges change the charges of the open bill of the tenant in ctx with the function in the transaction,
+//and record the change as the audit event of the bill.
+func (repo *PqRepository) changeCharges(ctx context.Context, name string, id int64, change func([]bill.Charge) ([]bill.Charge, error)) (charges []bill.Charge, err error) {
+	ctx, span := tracing.Start(ctx, name, tracing.Query(nameUpdateCharges, queryUpdateCharges)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameUpdateCharges, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = repo.lockOpen(ctx, tx, id); err != nil {
+			return
+		}
+		before, err := repo.selectCharges(ctx, tx, id)
+		if err != nil {
+			return
+		}
+		if charges, err = change(before); err != nil {
+			return
+		}
+		data, err := json.Marshal(charges)
+		if err != nil {
+			return
+		}
+		if _, err = tx.ExecContext(ctx, queryUpdateCharges, id, string(data)); err != nil {
+			return
+		}
+		event, err := audit.NewEvent(ctx, audit.ActionUpdate, audit.EntityBill, id, before, charges)
+		if err != nil {
+			return
+		}
+		return repo.recorder.Record(ctx, tx, &event)
+	})
+	if err != nil {
+		charges = nil
+	}
+	return
+}
+
+//selectCharges return the charges of the bill in the order they are added.
+func (repo *PqRepository) selectCharges(ctx context.Context, tx *sql.Tx, id int64) (charges []bill.Charge, err error) {
+	begin := time.Now()
+	defer func() {
+		repo.observe(ctx, nameSelectCharges, begin, err)
+	}()
+	charges = make([]bill.Charge, 0)
+	err = repo.selectJSON(ctx, tx, querySelectCharges, &charges, id)
+	return
+}
+
+//insertInvoice insert the invoice with its lines, coupons, charges, and total in JSON.
+func (repo *PqRepository) insertInvoice(ctx context.Context, tx *sql.Tx, invoice bill.Invoice) (err error) {
+	begin := time.Now()
+	defer func() {
+		repo.observe(ctx, nameInsertInvoice, begin, err)
+	}()
+	lines, err := json.Marshal(invoice.Bill)
+	if err != nil {
+		return
+	}
+	coupons, err := json.Marshal(invoice.Coupons)
+	if err != nil {
+		return
+	}
+	charges, err := json.Marshal(invoice.Charges)
+	if err != nil {
+		return
+	}
+	total, err := json.Marshal(invoice.Total)
+	if err != nil {
+		return
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		queryInsertInvoice,
+		invoice.Tenant,
+		invoice.Number,
+		invoice.BillID,
+		string(lines),
+		string(coupons),
+		string(charges),
+		string(total),
+		invoice.IssuedAt,
+	)
+	return
+}
+
+//selectRefunded return the indexes of the lines of the invoice that have been refunded.
+func (repo *PqRepository) selectRefunded(ctx context.Context, tx *sql.Tx, number int64) (refunded map[int]bool, err error) {
+	refunded = make(map[int]bool)
+	begin := time.Now()
+	defer func() {
+		repo.observe(ctx, nameSelectRefunded, begin, err)
+	}()
+	rows, err := tx.QueryContext(ctx, querySelectRefunded, tenant.FromContext(ctx), number)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			data  []byte
+			lines []bill.CreditLine
+		)
+		if err = rows.Scan(&data); err != nil {
+			return
+		}
+		if err = json.Unmarshal(data, &lines); err != nil {
+			return
+		}
+		for _, line := range lines {
+			refunded[line.Line] = true
+		}
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//insertCredit insert the credit note with its lines and total in JSON.
+func (repo *PqRepository) insertCredit(ctx context.Context, tx *sql.Tx, creditNote bill.CreditNote) (err error) {
+	begin := time.Now()
+	defer func() {
+		repo.observe(ctx, nameInsertCredit, begin, err)
+	}()
+	lines, err := json.Marshal(creditNote.Lines)
+	if err != nil {
+		return
+	}
+	total, err := json.Marshal(creditNote.Total)
+	if err != nil {
+		return
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		queryInsertCredit,
+		creditNote.Tenant,
+		creditNote.Number,
+		creditNote.InvoiceNumber,
+		string(lines),
+		string(total),
+		creditNote.IssuedAt,
+	)
+	return
+}
+
+//selectJSON select the JSON column of the row of the query and decode it into the value.
+func (repo *PqRepository) selectJSON(ctx context.Context, tx *sql.Tx, query string, value interface{}, args ...interface{}) (err error) {
+	var data []byte
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&data); err != nil {
+		return
+	}
+	return json.Unmarshal(data, value)
+}
+
+//transaction runs the function in the transaction.
+//The transaction is committed if the function succeeds, otherwise it is rolled back.
+func (repo *PqRepository) transaction(ctx context.Context, function func(tx *sql.Tx) error) (err error) {
+	tx, err := repo.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	if err = function(tx); err != nil {
+		tx.Rollback()
+		return
+	}
+	return tx.Commit()
+}
+
+//prepare lazily prepare the statement for the query and store it in the given field.
+func (repo *PqRepository) prepare(field **sql.Stmt, query string) (stmt *sql.Stmt, err error) {
+	if *field != nil {
+		return *field, nil
+	}
+	stmt, err = repo.pool.Prepare(query)
+	if err != nil {
+		return
+	}
+	*field = stmt
+	return
+}
+
+//observe records the metrics of the query started at begin and logs the error if the query failed.
+func (repo *PqRepository) observe(ctx context.Context, query string, begin time.Time, err error) {
+	metrics.ObserveQuery(query, begin, err)
+	if err != nil {
+		logger.FromContext(ctx, repo.log).
+			WithError(err).
+			WithField("query", query).
+			Error("[BillPqRepository] Query failed")
+	}
+}
+
+//queryError return the error of the query itself.
+//The missing or finalized bill, the empty bill, the missing invoice or credit note,
+//the invalid refund, and the duplicate or missing coupon or charge are not the failures of the query.
+func queryError(err error) error {
+	switch err {
+	case bill.ErrNotFound, bill.ErrFinalized, bill.ErrEmpty, bill.ErrInvoiceNotFound,
+		bill.ErrCreditNoteNotFound, bill.ErrLineNotFound, bill.ErrRefunded,
+		bill.ErrCouponExists, bill.ErrCouponNotFound, bill.ErrChargeExists, bill.ErrChargeNotFound:
+		return nil
+	}
+	return err
+}
+
+//calculate calculate the lines, the charges, and the total of the tax objects, the coupons, and the charges
+//with the rules of the repository, i.e. the same rules as the bill cache.
+func (repo *PqRepository) calculate(taxObjects []taxobj.TaxObject, coupons []bill.Coupon, charges []bill.Charge) (lines []bill.Bill, chargeLines []bill.ChargeLine, total bill.Total) {
+	return taxcalc.Calculate(repo.rules, taxobj.Calculables(taxObjects), coupons, charges)
+}
+
+//indexCoupon return the index of the coupon with the given code, or -1 if it's not found.
+func indexCoupon(coupons []bill.Coupon, code string) int {
+	for index, coupon := range coupons {
+		if coupon.Code == code {
+			return index
+		}
+	}
+	return -1
+}
+
+//indexCharge return the index of the charge with the given name, or -1 if it's not found.
+func indexCharge(charges []bill.Charge, name string) int {
+	for index, charge := range charges {
+		if charge.Name == name {
+			return index
+		}
+	}
+	return -1
+}
+
+//credit calculate the credit lines refunding the lines of the invoice with the given indexes and their total.
+//All lines that haven't been refunded are refunded if no index is given.
+//The price after the discounts is refunded for every line, but only the refundable taxes are refunded,
+//i.e. the tax of the tax code of the refundable line, and the stacked taxes whose tax code is refundable by the rules,
+//so the tax of the line is the sum of its refunded taxes.
+//The taxes of the line are refunded with the line, so the refunds are summarized by the tax code too.
+//The charges of the invoice are not refunded.
+func credit(rules taxcalc.Rules, invoiceLines []bill.Bill, indexes []int, refunded map[int]bool) (lines []bill.CreditLine, total bill.Total, err error) {
+	if len(indexes) == 0 {
+		for index := range invoiceLines {
+			if !refunded[index] {
+				indexes = append(indexes, index)
+			}
+		}
+		if len(indexes) == 0 {
+			err = bill.ErrRefunded
+			return
+		}
+	}
+	lines = make([]bill.CreditLine, 0, len(indexes))
+	for _, index := range indexes {
+		if index < 0 || index >= len(invoiceLines) {
+			err = bill.ErrLineNotFound
+			return
+		}
+		if refunded[index] {
+			err = bill.ErrRefunded
+			return
+		}
+		//The line given twice is rejected as it has been refunded by the first one.
+		refunded[index] = true
+		line := bill.CreditLine{
+			Line: index,
+			Bill: invoiceLines[index],
+		}
+		line.UnitPrice = -line.UnitPrice
+		line.Price = -line.Price
+		line.Discount = -line.Discount
+		line.Coupon = -line.Coupon
+		//The amount without the tax is the taxable base, also for the invoices issued before the discounts.
+		line.TaxableBase = -(invoiceLines[index].Amount - invoiceLines[index].Tax)
+		line.Tax = 0
+		line.Taxes = nil
+		for position, component := range invoiceLines[index].Taxes {
+			component.Base = -component.Base
+			component.Tax = -component.Tax
+			if !taxcalc.IsRefundable(rules, line.Bill, position, component) {
+				component.Tax = 0
+			}
+			line.Tax += component.Tax
+			line.Taxes = append(line.Taxes, component)
+		}
+		//The lines of the invoices issued before the taxes were broken down have only their tax.
+		if len(invoiceLines[index].Taxes) == 0 && line.Refundable == Refundable {
+			line.Tax = -invoiceLines[index].Tax
+		}
+		line.Amount = line.TaxableBase + line.Tax
+		total.AddLine(line.Bill)
+		lines = append(lines, line)
+	}
+	return
+}
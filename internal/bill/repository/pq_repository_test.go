@@ -0,0 +1,1432 @@
+// +build unit
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	mocksAudit "github.com/fairyhunter13/tax-calculator/internal/audit/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+	"github.com/stretchr/testify/assert"
+	testify "github.com/stretchr/testify/mock"
+)
+
+const (
+	regexQueryLockBills = `
+		SELECT pg_advisory_xact_lock(.+)
+	`
+	regexQuerySelectOpen = `
+		SELECT
+			id
+		FROM
+			bill
+		WHERE
+			tenant = (.+) AND finalized_at IS NULL
+	`
+	regexQueryInsertBill = `
+		INSERT INTO bill
+			(.+)
+		RETURNING id
+	`
+	regexQuerySelectFinalized = `
+		SELECT
+			finalized_at IS NOT NULL
+		FROM
+			bill
+		WHERE
+			(.+)
+	`
+	regexQuerySelectLines = `
+		SELECT
+			(.+)
+		FROM
+			tax_object
+		WHERE
+			bill_id = (.+)
+	`
+	regexQuerySelectCoupons = `
+		SELECT
+			coupons
+		FROM
+			bill
+		WHERE
+			id = (.+)
+	`
+	regexQueryUpdateCoupons = `
+		UPDATE bill
+		SET
+			coupons = (.+)
+	`
+	regexQuerySelectOpenCoupons = `
+		SELECT
+			tenant, coupons
+		FROM
+			bill
+	`
+	regexQuerySelectCharges = `
+		SELECT
+			charges
+		FROM
+			bill
+		WHERE
+			id = (.+)
+	`
+	regexQueryUpdateCharges = `
+		UPDATE bill
+		SET
+			charges = (.+)
+	`
+	regexQuerySelectOpenCharges = `
+		SELECT
+			tenant, charges
+		FROM
+			bill
+	`
+	regexQueryFinalize = `
+		UPDATE bill
+		SET
+			finalized_at = (.+)
+	`
+	regexQuerySelectNumber = `
+		SELECT
+			COALESCE(.+)
+		FROM
+			invoice
+	`
+	regexQueryInsertInvoice = `
+		INSERT INTO invoice
+			(.+)
+	`
+	regexQuerySelectInvoice = `
+		SELECT
+			(.+)
+		FROM
+			invoice
+		WHERE
+			(.+)
+	`
+	regexQuerySelectInvoiceLines = `
+		SELECT
+			lines
+		FROM
+			invoice
+		WHERE
+			(.+)
+	`
+	regexQuerySelectRefunded = `
+		SELECT
+			lines
+		FROM
+			credit_note
+		WHERE
+			(.+)
+	`
+	regexQuerySelectCreditNumber = `
+		SELECT
+			COALESCE(.+)
+		FROM
+			credit_note
+	`
+	regexQueryInsertCredit = `
+		INSERT INTO credit_note
+			(.+)
+	`
+	regexQuerySelectOne = `
+		SELECT
+			id
+		FROM
+			bill
+		LIMIT 1
+	`
+	regexQueryCreateTable = `
+		CREATE TABLE bill (.+)
+	`
+	regexQueryCreateCredit = `
+		CREATE TABLE IF NOT EXISTS credit_note (.+)
+	`
+	regexQueryAddCoupons = `
+		ALTER TABLE bill
+			ADD COLUMN IF NOT EXISTS coupons (.+)
+	`
+	regexQueryAddInvoiceCoupons = `
+		ALTER TABLE invoice
+			ADD COLUMN IF NOT EXISTS coupons (.+)
+	`
+	regexQueryAddCharges = `
+		ALTER TABLE bill
+			ADD COLUMN IF NOT EXISTS charges (.+)
+	`
+	regexQueryAddInvoiceCharges = `
+		ALTER TABLE invoice
+			ADD COLUMN IF NOT EXISTS charges (.+)
+	`
+	regexQueryBindTaxObjects = `
+		INSERT INTO bill (.+)
+		UPDATE tax_object (.+)
+	`
+)
+
+var (
+	errQuerying         = errors.New("Error in querying rows")
+	errRelationNotExist = errors.New("Relation still doesn't exist")
+	lineColumns         = []string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant"}
+	invoiceColumns      = []string{"number", "bill_id", "lines", "coupons", "charges", "total", "issued_at", "tenant"}
+	issuedAt            = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newPqRepository(t *testing.T, recorder audit.Recorder) (*PqRepository, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error starting the mocker: %s", err)
+	}
+	repo := NewPqRepository(db, taxcalc.NewJurisdictions(), recorder, logger.Discard())
+	return repo.(*PqRepository), mock, db
+}
+
+func TestPqRepository_OpenBill(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		wantID  int64
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Open Bill Exists",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockBills).WithArgs("merchant-a").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectOpen).
+					WithArgs("merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			},
+			wantID: 7,
+		},
+		{
+			name: "First Bill of the Tenant",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectOpen).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexQueryInsertBill).
+					WithArgs("merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+			},
+			wantID: 8,
+		},
+		{
+			name: "Error locking the bills",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockBills).WillReturnError(errQuerying)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newPqRepository(t, nil)
+			defer db.Close()
+			mock.ExpectBegin()
+			tt.expect(mock)
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatalf("Error beginning the transaction: %s", err)
+			}
+			gotID, err := repo.OpenBill(tenant.WithTenant(context.Background(), "merchant-a"), tx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.OpenBill() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantID, gotID)
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.OpenBill() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_CheckOpen(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Open Bill",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockBills).WithArgs("merchant-a").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WithArgs(7, "merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+			},
+		},
+		{
+			name: "Finalized Bill",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(true))
+			},
+			wantErr: bill.ErrFinalized,
+		},
+		{
+			name: "Tax Object Without Bill",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}))
+			},
+		},
+		{
+			name: "Error querying the bill",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).WillReturnError(errQuerying)
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newPqRepository(t, nil)
+			defer db.Close()
+			mock.ExpectBegin()
+			tt.expect(mock)
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatalf("Error beginning the transaction: %s", err)
+			}
+			err = repo.CheckOpen(tenant.WithTenant(context.Background(), "merchant-a"), tx, 7)
+			assert.Equal(t, tt.wantErr, err)
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.CheckOpen() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Finalize(t *testing.T) {
+	t.Parallel()
+	wantInvoice := bill.Invoice{
+		Number: 3,
+		BillID: 7,
+		Bill: []bill.Bill{
+			bill.Bill{
+				Name:        "MACD",
+				TaxCode:     1,
+				Type:        "Food & Beverage",
+				Refundable:  "Yes",
+				Quantity:    1,
+				UnitPrice:   1000,
+				Price:       1000,
+				Discount:    100,
+				Coupon:      90,
+				TaxableBase: 810,
+				Tax:         81,
+				Taxes:       []bill.TaxComponent{{TaxCode: 1, Type: "Food & Beverage", Base: 810, Tax: 81}},
+				Amount:      891,
+			},
+			bill.Bill{
+				Name:        "Lucky Stretch",
+				TaxCode:     2,
+				Type:        "Tobacco",
+				Refundable:  "No",
+				Quantity:    2,
+				UnitPrice:   500,
+				Price:       1000,
+				Coupon:      100,
+				TaxableBase: 900,
+				Tax:         38,
+				Taxes:       []bill.TaxComponent{{TaxCode: 2, Type: "Tobacco", Base: 900, Tax: 38}},
+				Amount:      938,
+			},
+		},
+		Coupons: []bill.Coupon{
+			{Code: "WELCOME", Discount: taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10}},
+		},
+		Charges: []bill.ChargeLine{
+			{
+				Charge: bill.Charge{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 10},
+				Price:  171,
+				Tax:    17.1,
+				Amount: 188.1,
+			},
+			{
+				Charge: bill.Charge{Name: "Tip", Type: bill.ChargeFixed, Value: 100},
+				Price:  100,
+				Amount: 100,
+			},
+		},
+		Total: bill.Total{
+			PriceSubtotal:     2000,
+			DiscountSubtotal:  100,
+			CouponSubtotal:    190,
+			NetSubtotal:       1710,
+			TaxSubtotal:       119,
+			ChargeSubtotal:    271,
+			ChargeTaxSubtotal: 17.1,
+			GrandTotal:        2117.1,
+			Taxes: []bill.TaxSummary{
+				{TaxCode: 1, Type: "Food & Beverage", Base: 981, Tax: 98.1},
+				{TaxCode: 2, Type: "Tobacco", Base: 900, Tax: 38},
+			},
+		},
+		Tenant: "merchant-a",
+	}
+	tests := []struct {
+		name     string
+		expect   func(mock sqlmock.Sqlmock)
+		recorder func() *mocksAudit.Recorder
+		want     bill.Invoice
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WithArgs("merchant-a").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WithArgs(7, "merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectLines).
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows(lineColumns).
+						AddRow(1, "MACD", 1, 1, 1000, 1000, "fixed", 100, false, []byte(`[]`), "", "merchant-a").
+						AddRow(2, "Lucky Stretch", 2, 2, 500, 1000, "", 0, false, []byte(`[]`), "", "merchant-a"))
+				mock.ExpectQuery(regexQuerySelectCoupons).
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows([]string{"coupons"}).AddRow([]byte(`[{"code":"WELCOME","type":"percent","value":10}]`)))
+				mock.ExpectQuery(regexQuerySelectCharges).
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows([]string{"charges"}).AddRow([]byte(
+						`[{"name":"Service","tax_code":1,"type":"percent","value":10},{"name":"Tip","tax_code":0,"type":"fixed","value":100}]`,
+					)))
+				mock.ExpectExec(regexQueryFinalize).
+					WithArgs(7, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexQuerySelectNumber).
+					WithArgs("merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(3))
+				mock.ExpectExec(regexQueryInsertInvoice).
+					WithArgs("merchant-a", 3, 7, sqlmock.AnyArg(), `[{"code":"WELCOME","type":"percent","value":10}]`, sqlmock.AnyArg(), `{"price_subtotal":2000,"discount_subtotal":100,"coupon_subtotal":190,"net_subtotal":1710,"tax_subtotal":119,"charge_subtotal":271,"charge_tax_subtotal":17.1,"grand_total":2117.1,"taxes":[{"tax_code":1,"type":"Food \u0026 Beverage","base":981,"tax":98.1},{"tax_code":2,"type":"Tobacco","base":900,"tax":38}]}`, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionFinalize &&
+						event.Entity == audit.EntityBill &&
+						event.EntityID == 7 &&
+						event.Before == nil
+				})).Return(nil)
+				return recorder
+			},
+			want: wantInvoice,
+		},
+		{
+			name: "Bill has been finalized",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrFinalized,
+		},
+		{
+			name: "Bill of another tenant",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrNotFound,
+		},
+		{
+			name: "Empty bill",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectLines).
+					WillReturnRows(sqlmock.NewRows(lineColumns))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrEmpty,
+		},
+		{
+			name: "Error inserting the invoice",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectLines).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "MACD", 1, 1, 1000, 1000, "", 0, false, []byte(`[]`), "", "merchant-a"))
+				mock.ExpectQuery(regexQuerySelectCoupons).WillReturnRows(sqlmock.NewRows([]string{"coupons"}).AddRow([]byte(`[]`)))
+				mock.ExpectQuery(regexQuerySelectCharges).WillReturnRows(sqlmock.NewRows([]string{"charges"}).AddRow([]byte(`[]`)))
+				mock.ExpectExec(regexQueryFinalize).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexQuerySelectNumber).WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(1))
+				mock.ExpectExec(regexQueryInsertInvoice).WillReturnError(errQuerying)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tt.recorder()
+			repo, mock, db := newPqRepository(t, recorder)
+			defer db.Close()
+			tt.expect(mock)
+			got, err := repo.Finalize(tenant.WithTenant(context.Background(), "merchant-a"), 7)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.False(t, got.IssuedAt.IsZero())
+				got.IssuedAt = time.Time{}
+			}
+			assert.Equal(t, tt.want, got)
+			//The arguments are not asserted because formatting the finished transaction races with its cleanup.
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Finalize() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_GetInvoice(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		want    bill.Invoice
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectInvoice)
+				mock.ExpectQuery(regexQuerySelectInvoice).
+					WithArgs("merchant-a", 3).
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).AddRow(
+						3,
+						7,
+						[]byte(`[{"name":"MACD","tax_code":1,"type":"Food & Beverage","refundable":"Yes","price":1000,"tax":100,"amount":1100}]`),
+						[]byte(`[]`),
+						[]byte(`[]`),
+						[]byte(`{"price_subtotal":1000,"tax_subtotal":100,"grand_total":1100}`),
+						issuedAt,
+						"merchant-a",
+					))
+			},
+			want: bill.Invoice{
+				Number: 3,
+				BillID: 7,
+				Bill: []bill.Bill{
+					bill.Bill{
+						Name:       "MACD",
+						TaxCode:    1,
+						Type:       "Food & Beverage",
+						Refundable: "Yes",
+						Price:      1000,
+						Tax:        100,
+						Amount:     1100,
+					},
+				},
+				Coupons: []bill.Coupon{},
+				Charges: []bill.ChargeLine{},
+				Total: bill.Total{
+					PriceSubtotal: 1000,
+					TaxSubtotal:   100,
+					GrandTotal:    1100,
+				},
+				IssuedAt: issuedAt,
+				Tenant:   "merchant-a",
+			},
+		},
+		{
+			name: "Invoice Not Found",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectInvoice)
+				mock.ExpectQuery(regexQuerySelectInvoice).WillReturnRows(sqlmock.NewRows(invoiceColumns))
+			},
+			wantErr: bill.ErrInvoiceNotFound,
+		},
+		{
+			name: "Error preparing the statement",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectInvoice).WillReturnError(errQuerying)
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newPqRepository(t, nil)
+			defer db.Close()
+			tt.expect(mock)
+			got, err := repo.GetInvoice(tenant.WithTenant(context.Background(), "merchant-a"), 3)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.GetInvoice() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+//captureArg matches any argument and keeps it, so the inserted row can be read back.
+type captureArg struct {
+	value *driver.Value
+}
+
+//Match keeps the argument and matches it.
+func (arg captureArg) Match(value driver.Value) bool {
+	*arg.value = value
+	return true
+}
+
+func TestPqRepository_Finalize_GetInvoice(t *testing.T) {
+	t.Parallel()
+	recorder := &mocksAudit.Recorder{}
+	recorder.On("Record", testify.Anything, testify.Anything, testify.Anything).Return(nil)
+	repo, mock, db := newPqRepository(t, recorder)
+	defer db.Close()
+	ctx := tenant.WithTenant(context.Background(), "merchant-a")
+	var lines, coupons, charges, total, issued driver.Value
+	mock.ExpectBegin()
+	mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexQuerySelectFinalized).
+		WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+	mock.ExpectQuery(regexQuerySelectLines).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "MACD", 1, 1, 1000, 1000, "", 0, false, []byte(`[]`), "", "merchant-a"))
+	mock.ExpectQuery(regexQuerySelectCoupons).
+		WillReturnRows(sqlmock.NewRows([]string{"coupons"}).AddRow([]byte(`[{"code":"WELCOME","type":"fixed","value":100}]`)))
+	mock.ExpectQuery(regexQuerySelectCharges).
+		WillReturnRows(sqlmock.NewRows([]string{"charges"}).AddRow([]byte(`[{"name":"Service","tax_code":1,"type":"percent","value":10}]`)))
+	mock.ExpectExec(regexQueryFinalize).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexQuerySelectNumber).WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(1))
+	mock.ExpectExec(regexQueryInsertInvoice).
+		WithArgs("merchant-a", 1, 7, captureArg{&lines}, captureArg{&coupons}, captureArg{&charges}, captureArg{&total}, captureArg{&issued}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	finalized, err := repo.Finalize(ctx, 7)
+	if err != nil {
+		t.Fatalf("Error finalizing the bill: %s", err)
+	}
+	mock.ExpectPrepare(regexQuerySelectInvoice)
+	mock.ExpectQuery(regexQuerySelectInvoice).
+		WithArgs("merchant-a", 1).
+		WillReturnRows(sqlmock.NewRows(invoiceColumns).AddRow(1, 7, lines, coupons, charges, total, issued, "merchant-a"))
+
+	got, err := repo.GetInvoice(ctx, 1)
+	//The invoice is read back exactly as it was issued, with the coupons and the charges adding up to its total.
+	if assert.NoError(t, err) {
+		assert.Equal(t, finalized, got)
+		if assert.Len(t, got.Coupons, 1) {
+			assert.Equal(t, "WELCOME", got.Coupons[0].Code)
+		}
+		assert.Equal(t, float64(100), got.Total.CouponSubtotal)
+		if assert.Len(t, got.Charges, 1) {
+			assert.Equal(t, "Service", got.Charges[0].Name)
+			assert.Equal(t, float64(90), got.Charges[0].Price)
+			assert.Equal(t, float64(9), got.Charges[0].Tax)
+			assert.Equal(t, got.Total.PriceSubtotal-got.Total.CouponSubtotal+got.Total.TaxSubtotal+
+				got.Charges[0].Price+got.Charges[0].Tax, got.Total.GrandTotal)
+		}
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("PqRepository.GetInvoice() mock expectation were not met: %s", err)
+	}
+}
+
+func TestPqRepository_Refund(t *testing.T) {
+	t.Parallel()
+	invoiceLines := []byte(`[
+		{"name":"MACD","tax_code":1,"type":"Food & Beverage","refundable":"Yes","price":1000,"discount":100,"taxable_base":900,"tax":90,
+			"taxes":[{"tax_code":1,"type":"Food & Beverage","compound":false,"base":900,"tax":90}],"amount":990},
+		{"name":"Lucky Stretch","tax_code":2,"type":"Tobacco","refundable":"No","price":1000,"tax":30,"amount":1030}
+	]`)
+	tests := []struct {
+		name     string
+		lines    []int
+		expect   func(mock sqlmock.Sqlmock)
+		recorder func() *mocksAudit.Recorder
+		want     bill.CreditNote
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Refund the remaining lines",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WithArgs("merchant-a").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectInvoiceLines).
+					WithArgs("merchant-a", 3).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}).AddRow(invoiceLines))
+				mock.ExpectQuery(regexQuerySelectRefunded).
+					WithArgs("merchant-a", 3).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}).AddRow([]byte(`[{"line":0}]`)))
+				mock.ExpectQuery(regexQuerySelectCreditNumber).
+					WithArgs("merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(2))
+				mock.ExpectExec(regexQueryInsertCredit).
+					WithArgs("merchant-a", 2, 3, sqlmock.AnyArg(), `{"price_subtotal":-1000,"discount_subtotal":0,"coupon_subtotal":0,"net_subtotal":-1000,"tax_subtotal":0,"charge_subtotal":0,"charge_tax_subtotal":0,"grand_total":-1000}`, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionRefund &&
+						event.Entity == audit.EntityInvoice &&
+						event.EntityID == 3
+				})).Return(nil)
+				return recorder
+			},
+			want: bill.CreditNote{
+				Number:        2,
+				InvoiceNumber: 3,
+				Lines: []bill.CreditLine{
+					bill.CreditLine{
+						Line: 1,
+						Bill: bill.Bill{
+							Name:        "Lucky Stretch",
+							TaxCode:     2,
+							Type:        "Tobacco",
+							Refundable:  "No",
+							Price:       -1000,
+							TaxableBase: -1000,
+							Amount:      -1000,
+						},
+					},
+				},
+				Total: bill.Total{
+					PriceSubtotal: -1000,
+					NetSubtotal:   -1000,
+					GrandTotal:    -1000,
+				},
+				Tenant: "merchant-a",
+			},
+		},
+		{
+			name:  "Refund the line after its discount",
+			lines: []int{0},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectInvoiceLines).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}).AddRow(invoiceLines))
+				mock.ExpectQuery(regexQuerySelectRefunded).WillReturnRows(sqlmock.NewRows([]string{"lines"}))
+				mock.ExpectQuery(regexQuerySelectCreditNumber).WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(1))
+				mock.ExpectExec(regexQueryInsertCredit).
+					WithArgs("merchant-a", 1, 3, sqlmock.AnyArg(), `{"price_subtotal":-1000,"discount_subtotal":-100,"coupon_subtotal":0,"net_subtotal":-900,"tax_subtotal":-90,"charge_subtotal":0,"charge_tax_subtotal":0,"grand_total":-990,"taxes":[{"tax_code":1,"type":"Food \u0026 Beverage","base":-900,"tax":-90}]}`, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.Anything).Return(nil)
+				return recorder
+			},
+			want: bill.CreditNote{
+				Number:        1,
+				InvoiceNumber: 3,
+				Lines: []bill.CreditLine{
+					bill.CreditLine{
+						Line: 0,
+						Bill: bill.Bill{
+							Name:        "MACD",
+							TaxCode:     1,
+							Type:        "Food & Beverage",
+							Refundable:  "Yes",
+							Price:       -1000,
+							Discount:    -100,
+							TaxableBase: -900,
+							Tax:         -90,
+							Taxes:       []bill.TaxComponent{{TaxCode: 1, Type: "Food & Beverage", Base: -900, Tax: -90}},
+							Amount:      -990,
+						},
+					},
+				},
+				Total: bill.Total{
+					PriceSubtotal:    -1000,
+					DiscountSubtotal: -100,
+					NetSubtotal:      -900,
+					TaxSubtotal:      -90,
+					GrandTotal:       -990,
+					Taxes:            []bill.TaxSummary{{TaxCode: 1, Type: "Food & Beverage", Base: -900, Tax: -90}},
+				},
+				Tenant: "merchant-a",
+			},
+		},
+		{
+			name:  "Refund only the refundable taxes",
+			lines: []int{0},
+			expect: func(mock sqlmock.Sqlmock) {
+				stacked := []byte(`[
+					{"name":"KFC","tax_code":1,"type":"Food & Beverage","refundable":"Yes","price":1000,"taxable_base":1000,"tax":130,
+						"taxes":[{"tax_code":1,"type":"Food & Beverage","base":1000,"tax":100},{"tax_code":2,"type":"Tobacco","base":1000,"tax":30}],"amount":1130}
+				]`)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectInvoiceLines).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}).AddRow(stacked))
+				mock.ExpectQuery(regexQuerySelectRefunded).WillReturnRows(sqlmock.NewRows([]string{"lines"}))
+				mock.ExpectQuery(regexQuerySelectCreditNumber).WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(1))
+				mock.ExpectExec(regexQueryInsertCredit).
+					WithArgs("merchant-a", 1, 3, sqlmock.AnyArg(), `{"price_subtotal":-1000,"discount_subtotal":0,"coupon_subtotal":0,"net_subtotal":-1000,"tax_subtotal":-100,"charge_subtotal":0,"charge_tax_subtotal":0,"grand_total":-1100,"taxes":[{"tax_code":1,"type":"Food \u0026 Beverage","base":-1000,"tax":-100},{"tax_code":2,"type":"Tobacco","base":-1000,"tax":0}]}`, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.Anything).Return(nil)
+				return recorder
+			},
+			//The tobacco tax stacked on the refundable line is not refundable, so only the tax of its tax code is refunded.
+			want: bill.CreditNote{
+				Number:        1,
+				InvoiceNumber: 3,
+				Lines: []bill.CreditLine{
+					bill.CreditLine{
+						Line: 0,
+						Bill: bill.Bill{
+							Name:        "KFC",
+							TaxCode:     1,
+							Type:        "Food & Beverage",
+							Refundable:  "Yes",
+							Price:       -1000,
+							TaxableBase: -1000,
+							Tax:         -100,
+							Taxes: []bill.TaxComponent{
+								{TaxCode: 1, Type: "Food & Beverage", Base: -1000, Tax: -100},
+								{TaxCode: 2, Type: "Tobacco", Base: -1000, Tax: 0},
+							},
+							Amount: -1100,
+						},
+					},
+				},
+				Total: bill.Total{
+					PriceSubtotal: -1000,
+					NetSubtotal:   -1000,
+					TaxSubtotal:   -100,
+					GrandTotal:    -1100,
+					Taxes: []bill.TaxSummary{
+						{TaxCode: 1, Type: "Food & Beverage", Base: -1000, Tax: -100},
+						{TaxCode: 2, Type: "Tobacco", Base: -1000, Tax: 0},
+					},
+				},
+				Tenant: "merchant-a",
+			},
+		},
+		{
+			name:  "Line has been refunded",
+			lines: []int{0},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectInvoiceLines).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}).AddRow(invoiceLines))
+				mock.ExpectQuery(regexQuerySelectRefunded).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}).AddRow([]byte(`[{"line":0}]`)))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrRefunded,
+		},
+		{
+			name:  "Line doesn't exist",
+			lines: []int{2},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectInvoiceLines).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}).AddRow(invoiceLines))
+				mock.ExpectQuery(regexQuerySelectRefunded).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrLineNotFound,
+		},
+		{
+			name: "Invoice Not Found",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectInvoiceLines).WillReturnRows(sqlmock.NewRows([]string{"lines"}))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrInvoiceNotFound,
+		},
+		{
+			name:  "Error inserting the credit note",
+			lines: []int{0},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectInvoiceLines).
+					WillReturnRows(sqlmock.NewRows([]string{"lines"}).AddRow(invoiceLines))
+				mock.ExpectQuery(regexQuerySelectRefunded).WillReturnRows(sqlmock.NewRows([]string{"lines"}))
+				mock.ExpectQuery(regexQuerySelectCreditNumber).WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(1))
+				mock.ExpectExec(regexQueryInsertCredit).WillReturnError(errQuerying)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tt.recorder()
+			repo, mock, db := newPqRepository(t, recorder)
+			defer db.Close()
+			tt.expect(mock)
+			got, err := repo.Refund(tenant.WithTenant(context.Background(), "merchant-a"), 3, tt.lines)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.False(t, got.IssuedAt.IsZero())
+				got.IssuedAt = time.Time{}
+			}
+			assert.Equal(t, tt.want, got)
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Refund() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_AddCoupon(t *testing.T) {
+	t.Parallel()
+	coupon := bill.Coupon{Code: "HAPPYHOUR", Discount: taxobj.Discount{Type: taxobj.DiscountFixed, Value: 500}}
+	tests := []struct {
+		name     string
+		expect   func(mock sqlmock.Sqlmock)
+		recorder func() *mocksAudit.Recorder
+		want     []bill.Coupon
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WithArgs("merchant-a").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WithArgs(7, "merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCoupons).
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows([]string{"coupons"}).AddRow([]byte(`[{"code":"WELCOME","type":"percent","value":10}]`)))
+				mock.ExpectExec(regexQueryUpdateCoupons).
+					WithArgs(7, `[{"code":"WELCOME","type":"percent","value":10},{"code":"HAPPYHOUR","type":"fixed","value":500}]`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionUpdate &&
+						event.Entity == audit.EntityBill &&
+						event.EntityID == 7
+				})).Return(nil)
+				return recorder
+			},
+			want: []bill.Coupon{
+				{Code: "WELCOME", Discount: taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10}},
+				coupon,
+			},
+		},
+		{
+			name: "Coupon has been applied",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCoupons).
+					WillReturnRows(sqlmock.NewRows([]string{"coupons"}).AddRow([]byte(`[{"code":"HAPPYHOUR","type":"percent","value":10}]`)))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrCouponExists,
+		},
+		{
+			name: "Bill has been finalized",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrFinalized,
+		},
+		{
+			name: "Error updating the coupons",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCoupons).
+					WillReturnRows(sqlmock.NewRows([]string{"coupons"}).AddRow([]byte(`[]`)))
+				mock.ExpectExec(regexQueryUpdateCoupons).WillReturnError(errQuerying)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tt.recorder()
+			repo, mock, db := newPqRepository(t, recorder)
+			defer db.Close()
+			tt.expect(mock)
+			got, err := repo.AddCoupon(tenant.WithTenant(context.Background(), "merchant-a"), 7, coupon)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.AddCoupon() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_RemoveCoupon(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		code     string
+		expect   func(mock sqlmock.Sqlmock)
+		recorder func() *mocksAudit.Recorder
+		want     []bill.Coupon
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			code: "WELCOME",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCoupons).
+					WillReturnRows(sqlmock.NewRows([]string{"coupons"}).AddRow([]byte(`[{"code":"WELCOME","type":"percent","value":10}]`)))
+				mock.ExpectExec(regexQueryUpdateCoupons).
+					WithArgs(7, `[]`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.Anything).Return(nil)
+				return recorder
+			},
+			want: []bill.Coupon{},
+		},
+		{
+			name: "Coupon Not Found",
+			code: "HAPPYHOUR",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCoupons).
+					WillReturnRows(sqlmock.NewRows([]string{"coupons"}).AddRow([]byte(`[{"code":"WELCOME","type":"percent","value":10}]`)))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrCouponNotFound,
+		},
+		{
+			name: "Bill of another tenant",
+			code: "WELCOME",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tt.recorder()
+			repo, mock, db := newPqRepository(t, recorder)
+			defer db.Close()
+			tt.expect(mock)
+			got, err := repo.RemoveCoupon(tenant.WithTenant(context.Background(), "merchant-a"), 7, tt.code)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.RemoveCoupon() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_GetOpenCoupons(t *testing.T) {
+	t.Parallel()
+	repo, mock, db := newPqRepository(t, nil)
+	defer db.Close()
+	mock.ExpectPrepare(regexQuerySelectOpenCoupons).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"tenant", "coupons"}).
+			AddRow("merchant-a", []byte(`[{"code":"WELCOME","type":"percent","value":10}]`)).
+			AddRow("merchant-b", []byte(`[]`)))
+	got, err := repo.GetOpenCoupons(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]bill.Coupon{
+		"merchant-a": {{Code: "WELCOME", Discount: taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10}}},
+	}, got)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("PqRepository.GetOpenCoupons() mock expectation were not met: %s", err)
+	}
+}
+
+func TestPqRepository_AddCharge(t *testing.T) {
+	t.Parallel()
+	charge := bill.Charge{Name: "Tip", Type: bill.ChargeFixed, Value: 5000}
+	tests := []struct {
+		name     string
+		expect   func(mock sqlmock.Sqlmock)
+		recorder func() *mocksAudit.Recorder
+		want     []bill.Charge
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WithArgs("merchant-a").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WithArgs(7, "merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCharges).
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows([]string{"charges"}).AddRow([]byte(`[{"name":"Service","tax_code":1,"type":"percent","value":5}]`)))
+				mock.ExpectExec(regexQueryUpdateCharges).
+					WithArgs(7, `[{"name":"Service","tax_code":1,"type":"percent","value":5},{"name":"Tip","tax_code":0,"type":"fixed","value":5000}]`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionUpdate &&
+						event.Entity == audit.EntityBill &&
+						event.EntityID == 7
+				})).Return(nil)
+				return recorder
+			},
+			want: []bill.Charge{
+				{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 5},
+				charge,
+			},
+		},
+		{
+			name: "Charge has been added",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCharges).
+					WillReturnRows(sqlmock.NewRows([]string{"charges"}).AddRow([]byte(`[{"name":"Tip","tax_code":0,"type":"fixed","value":2000}]`)))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrChargeExists,
+		},
+		{
+			name: "Bill has been finalized",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrFinalized,
+		},
+		{
+			name: "Error updating the charges",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCharges).
+					WillReturnRows(sqlmock.NewRows([]string{"charges"}).AddRow([]byte(`[]`)))
+				mock.ExpectExec(regexQueryUpdateCharges).WillReturnError(errQuerying)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tt.recorder()
+			repo, mock, db := newPqRepository(t, recorder)
+			defer db.Close()
+			tt.expect(mock)
+			got, err := repo.AddCharge(tenant.WithTenant(context.Background(), "merchant-a"), 7, charge)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.AddCharge() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_RemoveCharge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		charge   string
+		expect   func(mock sqlmock.Sqlmock)
+		recorder func() *mocksAudit.Recorder
+		want     []bill.Charge
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:   "Positive Case",
+			charge: "Service",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCharges).
+					WillReturnRows(sqlmock.NewRows([]string{"charges"}).AddRow([]byte(`[{"name":"Service","tax_code":1,"type":"percent","value":5}]`)))
+				mock.ExpectExec(regexQueryUpdateCharges).
+					WithArgs(7, `[]`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.Anything).Return(nil)
+				return recorder
+			},
+			want: []bill.Charge{},
+		},
+		{
+			name:   "Charge Not Found",
+			charge: "Tip",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}).AddRow(false))
+				mock.ExpectQuery(regexQuerySelectCharges).
+					WillReturnRows(sqlmock.NewRows([]string{"charges"}).AddRow([]byte(`[{"name":"Service","tax_code":1,"type":"percent","value":5}]`)))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrChargeNotFound,
+		},
+		{
+			name:   "Bill of another tenant",
+			charge: "Service",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexQueryLockBills).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexQuerySelectFinalized).
+					WillReturnRows(sqlmock.NewRows([]string{"finalized"}))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: bill.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tt.recorder()
+			repo, mock, db := newPqRepository(t, recorder)
+			defer db.Close()
+			tt.expect(mock)
+			got, err := repo.RemoveCharge(tenant.WithTenant(context.Background(), "merchant-a"), 7, tt.charge)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.RemoveCharge() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_GetOpenCharges(t *testing.T) {
+	t.Parallel()
+	repo, mock, db := newPqRepository(t, nil)
+	defer db.Close()
+	mock.ExpectPrepare(regexQuerySelectOpenCharges).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"tenant", "charges"}).
+			AddRow("merchant-a", []byte(`[{"name":"Service","tax_code":1,"type":"percent","value":5}]`)).
+			AddRow("merchant-b", []byte(`[]`)))
+	got, err := repo.GetOpenCharges(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]bill.Charge{
+		"merchant-a": {{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 5}},
+	}, got)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("PqRepository.GetOpenCharges() mock expectation were not met: %s", err)
+	}
+}
+
+func TestPqRepository_Migrate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Table has already exist",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexQueryCreateCredit).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddCoupons).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddInvoiceCoupons).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddCharges).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddInvoiceCharges).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryBindTaxObjects).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "Create table for the first time",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnError(errRelationNotExist)
+				mock.ExpectExec(regexQueryCreateTable).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryCreateCredit).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddCoupons).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddInvoiceCoupons).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddCharges).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddInvoiceCharges).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryBindTaxObjects).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "Error creating the table",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexQuerySelectOne).WillReturnError(errRelationNotExist)
+				mock.ExpectExec(regexQueryCreateTable).WillReturnError(errQuerying)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, db := newPqRepository(t, nil)
+			defer db.Close()
+			tt.expect(mock)
+			err := repo.Migrate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.Migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Migrate() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Close(t *testing.T) {
+	t.Parallel()
+	repo, mock, db := newPqRepository(t, nil)
+	defer db.Close()
+	mock.ExpectPrepare(regexQuerySelectInvoice).WillBeClosed()
+	stmt, err := db.Prepare(querySelectInvoice)
+	if err != nil {
+		t.Fatalf("Error preparing the statement: %s", err)
+	}
+	repo.statement.selectInvoice = stmt
+	repo.Close()
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("PqRepository.Close() mock expectation were not met: %s", err)
+	}
+}
@@ -1,7 +1,22 @@
 package bill
 
+import (
+	"context"
+
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+)
+
 //Usecase defines the required behavior for business logic in the bill.
 type Usecase interface {
-	LoadData() error
-	GetBill() ([]Bill, Total)
+	LoadData(context.Context) error
+	GetBill(context.Context) Snapshot
+	PreviewBill(context.Context, []taxobj.TaxObject) ([]Bill, Total)
+	FinalizeBill(context.Context, int64) (Invoice, error)
+	GetInvoice(context.Context, int64) (Invoice, error)
+	RefundInvoice(context.Context, int64, []int) (CreditNote, error)
+	GetCreditNote(context.Context, int64) (CreditNote, error)
+	AddCoupon(context.Context, int64, Coupon) ([]Coupon, error)
+	RemoveCoupon(context.Context, int64, string) error
+	AddCharge(context.Context, int64, Charge) ([]Charge, error)
+	RemoveCharge(context.Context, int64, string) error
 }
@@ -1,39 +1,252 @@
 package usecase
 
 import (
+	"context"
+	"time"
+
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/metrics"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/sirupsen/logrus"
 )
 
 //BillUsecase define the business logic for bill.
+//The tenant is locked from the change of its bill in the database until the change is applied to the cache,
+//so the cache applies the changes in the order they're committed.
 type BillUsecase struct {
-	billRepo bill.Repository
-	taxRepo  taxobj.Repository
+	billRepo    bill.Repository
+	taxRepo     taxobj.Repository
+	invoiceRepo bill.InvoiceRepository
+	locker      *tenant.Locker
+	log         logrus.FieldLogger
 }
 
 //NewBillUsecase creates the new BillUsacase concrete implementation.
-func NewBillUsecase(billRepo bill.Repository, taxRepo taxobj.Repository) bill.Usecase {
+func NewBillUsecase(billRepo bill.Repository, taxRepo taxobj.Repository, invoiceRepo bill.InvoiceRepository, locker *tenant.Locker, log logrus.FieldLogger) bill.Usecase {
 	return &BillUsecase{
 		billRepo,
 		taxRepo,
+		invoiceRepo,
+		locker,
+		log,
 	}
 }
 
 //LoadData init the cache for the first time start of application.
 //It is useful to store already calculated value in the fly,
 //so the performance is good when fetching all tax object in bill.
-func (ucase *BillUsecase) LoadData() (err error) {
-	taxObjects, err := ucase.taxRepo.GetAll()
+//The tax objects are loaded tenant by tenant, followed by the coupons and the charges of the open bills and the refunds of every tenant.
+func (ucase *BillUsecase) LoadData(ctx context.Context) (err error) {
+	defer metrics.ObserveLoadData(time.Now())
+	ctx, span := tracing.Start(ctx, "BillUsecase.LoadData")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	tenants, err := ucase.taxRepo.GetTenants(ctx)
+	if err != nil {
+		logger.FromContext(ctx, ucase.log).WithError(err).Error("[BillUsecase] Failed to load the tenants")
+		return
+	}
+	count := 0
+	for _, name := range tenants {
+		tenantCtx := tenant.WithTenant(ctx, name)
+		var taxObjects []taxobj.TaxObject
+		taxObjects, err = ucase.taxRepo.GetAll(tenantCtx)
+		if err != nil {
+			logger.FromContext(ctx, ucase.log).
+				WithError(err).
+				WithField("tenant", name).
+				Error("[BillUsecase] Failed to load the tax objects")
+			return
+		}
+		for _, taxObject := range taxObjects {
+			ucase.billRepo.Add(tenantCtx, taxObject)
+		}
+		count += len(taxObjects)
+	}
+	coupons, err := ucase.invoiceRepo.GetOpenCoupons(ctx)
+	if err != nil {
+		logger.FromContext(ctx, ucase.log).WithError(err).Error("[BillUsecase] Failed to load the coupons")
+		return
+	}
+	for name, applied := range coupons {
+		ucase.billRepo.SetCoupons(tenant.WithTenant(ctx, name), applied)
+	}
+	charges, err := ucase.invoiceRepo.GetOpenCharges(ctx)
+	if err != nil {
+		logger.FromContext(ctx, ucase.log).WithError(err).Error("[BillUsecase] Failed to load the charges")
+		return
+	}
+	for name, added := range charges {
+		ucase.billRepo.SetCharges(tenant.WithTenant(ctx, name), added)
+	}
+	refunds, err := ucase.invoiceRepo.GetRefundTotals(ctx)
 	if err != nil {
+		logger.FromContext(ctx, ucase.log).WithError(err).Error("[BillUsecase] Failed to load the refunds")
 		return
 	}
-	for _, taxObject := range taxObjects {
-		ucase.billRepo.Add(taxObject)
+	for name, total := range refunds {
+		ucase.billRepo.AddRefund(tenant.WithTenant(ctx, name), total)
 	}
+	logger.FromContext(ctx, ucase.log).
+		WithField("count", count).
+		WithField("tenants", len(tenants)).
+		Info("[BillUsecase] Bill cache loaded")
 	return
 }
 
-//GetBill get the bill and total data.
-func (ucase *BillUsecase) GetBill() ([]bill.Bill, bill.Total) {
-	return ucase.billRepo.GetAll()
+//GetBill get the open bill of the tenant in ctx with its coupons, charges, breakdown, total, and refunds read at once.
+func (ucase *BillUsecase) GetBill(ctx context.Context) bill.Snapshot {
+	ctx, span := tracing.Start(ctx, "BillUsecase.GetBill")
+	defer span.End()
+	return ucase.billRepo.GetSnapshot(ctx)
+}
+
+//PreviewBill get the bill lines of the tax objects and the total the bill of the tenant in ctx would have with them.
+//Nothing is stored, so the tax objects are never added to the bill.
+func (ucase *BillUsecase) PreviewBill(ctx context.Context, taxObjects []taxobj.TaxObject) ([]bill.Bill, bill.Total) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.PreviewBill")
+	defer span.End()
+	return ucase.billRepo.Preview(ctx, taxObjects)
+}
+
+//FinalizeBill finalize the bill of the tenant in ctx into the invoice,
+//and removes its lines from the bill, so the next tax objects are added to a new bill.
+func (ucase *BillUsecase) FinalizeBill(ctx context.Context, id int64) (invoice bill.Invoice, err error) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.FinalizeBill")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	if invoice, err = ucase.invoiceRepo.Finalize(ctx, id); err != nil {
+		return
+	}
+	ucase.billRepo.RemoveBill(ctx, id)
+	logger.FromContext(ctx, ucase.log).
+		WithField("bill_id", id).
+		WithField("invoice_number", invoice.Number).
+		Info("[BillUsecase] Bill finalized")
+	return
+}
+
+//GetInvoice get the invoice of the tenant in ctx with the given number.
+func (ucase *BillUsecase) GetInvoice(ctx context.Context, number int64) (invoice bill.Invoice, err error) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.GetInvoice")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return ucase.invoiceRepo.GetInvoice(ctx, number)
+}
+
+//RefundInvoice refund the lines of the invoice of the tenant in ctx with the credit note,
+//and adds its total to the refunds of the tenant.
+func (ucase *BillUsecase) RefundInvoice(ctx context.Context, number int64, lines []int) (creditNote bill.CreditNote, err error) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.RefundInvoice")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	if creditNote, err = ucase.invoiceRepo.Refund(ctx, number, lines); err != nil {
+		return
+	}
+	ucase.billRepo.AddRefund(ctx, creditNote.Total)
+	logger.FromContext(ctx, ucase.log).
+		WithField("invoice_number", number).
+		WithField("credit_note_number", creditNote.Number).
+		Info("[BillUsecase] Invoice refunded")
+	return
+}
+
+//GetCreditNote get the credit note of the tenant in ctx with the given number.
+func (ucase *BillUsecase) GetCreditNote(ctx context.Context, number int64) (creditNote bill.CreditNote, err error) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.GetCreditNote")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return ucase.invoiceRepo.GetCreditNote(ctx, number)
+}
+
+//AddCoupon apply the coupon to the open bill of the tenant in ctx,
+//and recalculates the bill with the coupons of the bill.
+func (ucase *BillUsecase) AddCoupon(ctx context.Context, id int64, coupon bill.Coupon) (coupons []bill.Coupon, err error) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.AddCoupon")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	if coupons, err = ucase.invoiceRepo.AddCoupon(ctx, id, coupon); err != nil {
+		return
+	}
+	ucase.billRepo.SetCoupons(ctx, coupons)
+	logger.FromContext(ctx, ucase.log).
+		WithField("bill_id", id).
+		WithField("coupon", coupon.Code).
+		Info("[BillUsecase] Coupon applied")
+	return
+}
+
+//RemoveCoupon remove the coupon from the open bill of the tenant in ctx,
+//and recalculates the bill with the remaining coupons of the bill.
+func (ucase *BillUsecase) RemoveCoupon(ctx context.Context, id int64, code string) (err error) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.RemoveCoupon")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	coupons, err := ucase.invoiceRepo.RemoveCoupon(ctx, id, code)
+	if err != nil {
+		return
+	}
+	ucase.billRepo.SetCoupons(ctx, coupons)
+	logger.FromContext(ctx, ucase.log).
+		WithField("bill_id", id).
+		WithField("coupon", code).
+		Info("[BillUsecase] Coupon removed")
+	return
+}
+
+//AddCharge add the charge to the open bill of the tenant in ctx,
+//and recalculates the bill with the charges of the bill.
+func (ucase *BillUsecase) AddCharge(ctx context.Context, id int64, charge bill.Charge) (charges []bill.Charge, err error) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.AddCharge")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	if charges, err = ucase.invoiceRepo.AddCharge(ctx, id, charge); err != nil {
+		return
+	}
+	ucase.billRepo.SetCharges(ctx, charges)
+	logger.FromContext(ctx, ucase.log).
+		WithField("bill_id", id).
+		WithField("charge", charge.Name).
+		Info("[BillUsecase] Charge added")
+	return
+}
+
+//RemoveCharge remove the charge from the open bill of the tenant in ctx,
+//and recalculates the bill with the remaining charges of the bill.
+func (ucase *BillUsecase) RemoveCharge(ctx context.Context, id int64, name string) (err error) {
+	ctx, span := tracing.Start(ctx, "BillUsecase.RemoveCharge")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	charges, err := ucase.invoiceRepo.RemoveCharge(ctx, id, name)
+	if err != nil {
+		return
+	}
+	ucase.billRepo.SetCharges(ctx, charges)
+	logger.FromContext(ctx, ucase.log).
+		WithField("bill_id", id).
+		WithField("charge", name).
+		Info("[BillUsecase] Charge removed")
+	return
 }
@@ -3,86 +3,159 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
 	mocksBill "github.com/fairyhunter13/tax-calculator/internal/bill/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
 	mocksTax "github.com/fairyhunter13/tax-calculator/internal/taxobj/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
 	errDatabaseRepo = errors.New("Error in connecting to the database")
+	coupon          = bill.Coupon{Code: "WELCOME", Discount: taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10}}
+	charge          = bill.Charge{Name: "Service", TaxCode: 1, Type: bill.ChargePercent, Value: 10}
 )
 
 func TestBillUsecase_LoadData(t *testing.T) {
 	t.Parallel()
-	type fields struct {
-		billRepo bill.Repository
-		taxRepo  taxobj.Repository
-	}
 	tests := []struct {
 		name    string
-		fields  func() (bill.Repository, taxobj.Repository)
+		fields  func() (bill.Repository, taxobj.Repository, bill.InvoiceRepository)
 		wantErr bool
 	}{
 		// TODO: Add test cases.
 		{
 			name: "Positive Case",
-			fields: func() (bill.Repository, taxobj.Repository) {
+			fields: func() (bill.Repository, taxobj.Repository, bill.InvoiceRepository) {
 				taxObject := taxobj.TaxObject{
 					Name:    "MACD",
 					TaxCode: 1,
 					Price:   20000,
+					Tenant:  tenant.Default,
 				}
 				taxRepo := &mocksTax.Repository{}
-				taxRepo.On("GetAll").Return([]taxobj.TaxObject{
+				taxRepo.On("GetTenants", mock.Anything).Return([]string{tenant.Default}, nil)
+				taxRepo.On("GetAll", mock.MatchedBy(func(ctx context.Context) bool {
+					return tenant.FromContext(ctx) == tenant.Default
+				})).Return([]taxobj.TaxObject{
 					taxObject,
 				}, nil)
 				billRepo := &mocksBill.Repository{}
-				billRepo.On("Add", taxObject)
-				return billRepo, taxRepo
+				billRepo.On("Add", mock.Anything, taxObject)
+				billRepo.On("AddRefund", mock.MatchedBy(func(ctx context.Context) bool {
+					return tenant.FromContext(ctx) == tenant.Default
+				}), bill.Total{PriceSubtotal: -1000, GrandTotal: -1000})
+				billRepo.On("SetCoupons", mock.MatchedBy(func(ctx context.Context) bool {
+					return tenant.FromContext(ctx) == tenant.Default
+				}), []bill.Coupon{coupon})
+				billRepo.On("SetCharges", mock.MatchedBy(func(ctx context.Context) bool {
+					return tenant.FromContext(ctx) == tenant.Default
+				}), []bill.Charge{charge})
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("GetOpenCoupons", mock.Anything).Return(map[string][]bill.Coupon{
+					tenant.Default: []bill.Coupon{coupon},
+				}, nil)
+				invoiceRepo.On("GetOpenCharges", mock.Anything).Return(map[string][]bill.Charge{
+					tenant.Default: []bill.Charge{charge},
+				}, nil)
+				invoiceRepo.On("GetRefundTotals", mock.Anything).Return(map[string]bill.Total{
+					tenant.Default: bill.Total{PriceSubtotal: -1000, GrandTotal: -1000},
+				}, nil)
+				return billRepo, taxRepo, invoiceRepo
 			},
 			wantErr: false,
 		},
+		{
+			name: "Tax Repo Tenants Error",
+			fields: func() (bill.Repository, taxobj.Repository, bill.InvoiceRepository) {
+				taxRepo := &mocksTax.Repository{}
+				taxRepo.On("GetTenants", mock.Anything).Return([]string{}, errDatabaseRepo)
+				billRepo := &mocksBill.Repository{}
+				return billRepo, taxRepo, &mocksBill.InvoiceRepository{}
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invoice Repo Database Error",
+			fields: func() (bill.Repository, taxobj.Repository, bill.InvoiceRepository) {
+				taxRepo := &mocksTax.Repository{}
+				taxRepo.On("GetTenants", mock.Anything).Return([]string{}, nil)
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("GetOpenCoupons", mock.Anything).Return(map[string][]bill.Coupon{}, nil)
+				invoiceRepo.On("GetOpenCharges", mock.Anything).Return(map[string][]bill.Charge{}, nil)
+				invoiceRepo.On("GetRefundTotals", mock.Anything).Return(nil, errDatabaseRepo)
+				return &mocksBill.Repository{}, taxRepo, invoiceRepo
+			},
+			wantErr: true,
+		},
+		{
+			name: "Coupons Database Error",
+			fields: func() (bill.Repository, taxobj.Repository, bill.InvoiceRepository) {
+				taxRepo := &mocksTax.Repository{}
+				taxRepo.On("GetTenants", mock.Anything).Return([]string{}, nil)
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("GetOpenCoupons", mock.Anything).Return(nil, errDatabaseRepo)
+				return &mocksBill.Repository{}, taxRepo, invoiceRepo
+			},
+			wantErr: true,
+		},
+		{
+			name: "Charges Database Error",
+			fields: func() (bill.Repository, taxobj.Repository, bill.InvoiceRepository) {
+				taxRepo := &mocksTax.Repository{}
+				taxRepo.On("GetTenants", mock.Anything).Return([]string{}, nil)
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("GetOpenCoupons", mock.Anything).Return(map[string][]bill.Coupon{}, nil)
+				invoiceRepo.On("GetOpenCharges", mock.Anything).Return(nil, errDatabaseRepo)
+				return &mocksBill.Repository{}, taxRepo, invoiceRepo
+			},
+			wantErr: true,
+		},
 		{
 			name: "Tax Repo Database Error",
-			fields: func() (bill.Repository, taxobj.Repository) {
+			fields: func() (bill.Repository, taxobj.Repository, bill.InvoiceRepository) {
 				taxRepo := &mocksTax.Repository{}
-				taxRepo.On("GetAll").Return([]taxobj.TaxObject{}, errDatabaseRepo)
+				taxRepo.On("GetTenants", mock.Anything).Return([]string{tenant.Default}, nil)
+				taxRepo.On("GetAll", mock.Anything).Return([]taxobj.TaxObject{}, errDatabaseRepo)
 				billRepo := &mocksBill.Repository{}
-				return billRepo, taxRepo
+				return billRepo, taxRepo, &mocksBill.InvoiceRepository{}
 			},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			billRepo, taxRepo := tt.fields()
+			billRepo, taxRepo, invoiceRepo := tt.fields()
 			ucase := &BillUsecase{
-				billRepo: billRepo,
-				taxRepo:  taxRepo,
+				billRepo:    billRepo,
+				taxRepo:     taxRepo,
+				invoiceRepo: invoiceRepo,
+				log:         logger.Discard(),
 			}
-			if err := ucase.LoadData(); (err != nil) != tt.wantErr {
+			if err := ucase.LoadData(context.Background()); (err != nil) != tt.wantErr {
 				t.Errorf("BillUsecase.LoadData() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			billRepo.(*mocksBill.Repository).AssertExpectations(t)
 		})
 	}
 }
 
 func TestBillUsecase_GetBill(t *testing.T) {
 	t.Parallel()
-	type fields struct {
-		billRepo bill.Repository
-		taxRepo  taxobj.Repository
-	}
 	tests := []struct {
 		name   string
 		fields func() (bill.Repository, taxobj.Repository)
-		want   []bill.Bill
-		want1  bill.Total
+		want   bill.Snapshot
 	}{
 		// TODO: Add test cases.
 		{
@@ -90,18 +163,41 @@ func TestBillUsecase_GetBill(t *testing.T) {
 			fields: func() (bill.Repository, taxobj.Repository) {
 				taxRepo := &mocksTax.Repository{}
 				billRepo := &mocksBill.Repository{}
-				billRepo.On("GetAll").Return([]bill.Bill{}, bill.Total{})
+				billRepo.On("GetSnapshot", mock.Anything).Return(bill.Snapshot{Bills: []bill.Bill{}})
 				return billRepo, taxRepo
 			},
-			want:  []bill.Bill{},
-			want1: bill.Total{},
+			want: bill.Snapshot{Bills: []bill.Bill{}},
 		},
 		{
 			name: "A Data Stored in The Cache",
 			fields: func() (bill.Repository, taxobj.Repository) {
 				taxRepo := &mocksTax.Repository{}
 				billRepo := &mocksBill.Repository{}
-				aBill := []bill.Bill{
+				snapshot := bill.Snapshot{
+					ID: 7,
+					Bills: []bill.Bill{
+						bill.Bill{
+							Name:       "MACD",
+							TaxCode:    1,
+							Price:      20000,
+							Tax:        2000,
+							Type:       "Food & Beverage",
+							Refundable: "Yes",
+							Amount:     22000,
+						},
+					},
+					Total: bill.Total{
+						PriceSubtotal: 20000,
+						TaxSubtotal:   2000,
+						GrandTotal:    22000,
+					},
+				}
+				billRepo.On("GetSnapshot", mock.Anything).Return(snapshot)
+				return billRepo, taxRepo
+			},
+			want: bill.Snapshot{
+				ID: 7,
+				Bills: []bill.Bill{
 					bill.Bill{
 						Name:       "MACD",
 						TaxCode:    1,
@@ -111,54 +207,530 @@ func TestBillUsecase_GetBill(t *testing.T) {
 						Refundable: "Yes",
 						Amount:     22000,
 					},
-				}
-				totalBill := bill.Total{
+				},
+				Total: bill.Total{
 					PriceSubtotal: 20000,
 					TaxSubtotal:   2000,
 					GrandTotal:    22000,
-				}
-				billRepo.On("GetAll").Return(aBill, totalBill)
-				return billRepo, taxRepo
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billRepo, taxRepo := tt.fields()
+			ucase := &BillUsecase{
+				billRepo: billRepo,
+				taxRepo:  taxRepo,
+			}
+			assert.EqualValues(t, tt.want, ucase.GetBill(context.Background()))
+		})
+	}
+}
+
+func TestBillUsecase_PreviewBill(t *testing.T) {
+	t.Parallel()
+	taxObjects := []taxobj.TaxObject{{Name: "MACD", TaxCode: 1, Quantity: 1, UnitPrice: 20000, Price: 20000}}
+	bills := []bill.Bill{{Name: "MACD", TaxCode: 1, Price: 20000, Tax: 2000, Amount: 22000}}
+	total := bill.Total{PriceSubtotal: 20000, TaxSubtotal: 2000, GrandTotal: 22000}
+	billRepo := &mocksBill.Repository{}
+	billRepo.On("Preview", mock.Anything, taxObjects).Return(bills, total)
+	ucase := &BillUsecase{
+		billRepo: billRepo,
+		taxRepo:  &mocksTax.Repository{},
+	}
+	got, got1 := ucase.PreviewBill(context.Background(), taxObjects)
+	assert.Equal(t, bills, got)
+	assert.Equal(t, total, got1)
+	billRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func TestBillUsecase_FinalizeBill(t *testing.T) {
+	t.Parallel()
+	invoice := bill.Invoice{
+		Number: 1,
+		BillID: 7,
+		Bill: []bill.Bill{
+			bill.Bill{
+				Name:       "MACD",
+				TaxCode:    1,
+				Price:      20000,
+				Tax:        2000,
+				Type:       "Food & Beverage",
+				Refundable: "Yes",
+				Amount:     22000,
 			},
-			want: []bill.Bill{
-				bill.Bill{
+		},
+		Total: bill.Total{
+			PriceSubtotal: 20000,
+			TaxSubtotal:   2000,
+			GrandTotal:    22000,
+		},
+	}
+	tests := []struct {
+		name    string
+		fields  func() (bill.Repository, bill.InvoiceRepository)
+		want    bill.Invoice
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("Finalize", mock.Anything, int64(7)).Return(invoice, nil)
+				billRepo := &mocksBill.Repository{}
+				billRepo.On("RemoveBill", mock.Anything, int64(7))
+				return billRepo, invoiceRepo
+			},
+			want: invoice,
+		},
+		{
+			name: "Finalized Bill",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("Finalize", mock.Anything, int64(7)).Return(bill.Invoice{}, bill.ErrFinalized)
+				return &mocksBill.Repository{}, invoiceRepo
+			},
+			wantErr: bill.ErrFinalized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billRepo, invoiceRepo := tt.fields()
+			ucase := &BillUsecase{
+				billRepo:    billRepo,
+				invoiceRepo: invoiceRepo,
+				locker:      tenant.NewLocker(),
+				log:         logger.Discard(),
+			}
+			got, err := ucase.FinalizeBill(context.Background(), 7)
+			assert.Equal(t, tt.wantErr, err)
+			assert.EqualValues(t, tt.want, got)
+			billRepo.(*mocksBill.Repository).AssertExpectations(t)
+		})
+	}
+}
+
+func TestBillUsecase_GetInvoice(t *testing.T) {
+	t.Parallel()
+	invoice := bill.Invoice{
+		Number: 1,
+		BillID: 7,
+	}
+	tests := []struct {
+		name    string
+		fields  func() bill.InvoiceRepository
+		want    bill.Invoice
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			fields: func() bill.InvoiceRepository {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("GetInvoice", mock.Anything, int64(1)).Return(invoice, nil)
+				return invoiceRepo
+			},
+			want: invoice,
+		},
+		{
+			name: "Invoice Not Found",
+			fields: func() bill.InvoiceRepository {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("GetInvoice", mock.Anything, int64(1)).Return(bill.Invoice{}, bill.ErrInvoiceNotFound)
+				return invoiceRepo
+			},
+			wantErr: bill.ErrInvoiceNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ucase := &BillUsecase{
+				invoiceRepo: tt.fields(),
+				log:         logger.Discard(),
+			}
+			got, err := ucase.GetInvoice(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err)
+			assert.EqualValues(t, tt.want, got)
+		})
+	}
+}
+
+func TestBillUsecase_RefundInvoice(t *testing.T) {
+	t.Parallel()
+	creditNote := bill.CreditNote{
+		Number:        1,
+		InvoiceNumber: 3,
+		Lines: []bill.CreditLine{
+			bill.CreditLine{
+				Line: 0,
+				Bill: bill.Bill{
 					Name:       "MACD",
 					TaxCode:    1,
-					Price:      20000,
-					Tax:        2000,
+					Price:      -20000,
+					Tax:        -2000,
 					Type:       "Food & Beverage",
 					Refundable: "Yes",
-					Amount:     22000,
+					Amount:     -22000,
 				},
 			},
-			want1: bill.Total{
-				PriceSubtotal: 20000,
-				TaxSubtotal:   2000,
-				GrandTotal:    22000,
+		},
+		Total: bill.Total{
+			PriceSubtotal: -20000,
+			TaxSubtotal:   -2000,
+			GrandTotal:    -22000,
+		},
+	}
+	tests := []struct {
+		name    string
+		fields  func() (bill.Repository, bill.InvoiceRepository)
+		want    bill.CreditNote
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("Refund", mock.Anything, int64(3), []int{0}).Return(creditNote, nil)
+				billRepo := &mocksBill.Repository{}
+				billRepo.On("AddRefund", mock.Anything, creditNote.Total)
+				return billRepo, invoiceRepo
+			},
+			want: creditNote,
+		},
+		{
+			name: "Refunded Line",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("Refund", mock.Anything, int64(3), []int{0}).Return(bill.CreditNote{}, bill.ErrRefunded)
+				return &mocksBill.Repository{}, invoiceRepo
 			},
+			wantErr: bill.ErrRefunded,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			billRepo, taxRepo := tt.fields()
+			billRepo, invoiceRepo := tt.fields()
 			ucase := &BillUsecase{
-				billRepo: billRepo,
-				taxRepo:  taxRepo,
+				billRepo:    billRepo,
+				invoiceRepo: invoiceRepo,
+				log:         logger.Discard(),
 			}
-			got, got1 := ucase.GetBill()
-			assert.EqualValues(t, got, tt.want)
-			assert.EqualValues(t, got1, tt.want1)
+			got, err := ucase.RefundInvoice(context.Background(), 3, []int{0})
+			assert.Equal(t, tt.wantErr, err)
+			assert.EqualValues(t, tt.want, got)
+			billRepo.(*mocksBill.Repository).AssertExpectations(t)
 		})
 	}
 }
 
+func TestBillUsecase_AddCoupon(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		fields  func() (bill.Repository, bill.InvoiceRepository)
+		want    []bill.Coupon
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("AddCoupon", mock.Anything, int64(7), coupon).Return([]bill.Coupon{coupon}, nil)
+				billRepo := &mocksBill.Repository{}
+				billRepo.On("SetCoupons", mock.Anything, []bill.Coupon{coupon})
+				return billRepo, invoiceRepo
+			},
+			want: []bill.Coupon{coupon},
+		},
+		{
+			name: "Coupon Exists",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("AddCoupon", mock.Anything, int64(7), coupon).Return(nil, bill.ErrCouponExists)
+				return &mocksBill.Repository{}, invoiceRepo
+			},
+			wantErr: bill.ErrCouponExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billRepo, invoiceRepo := tt.fields()
+			ucase := &BillUsecase{
+				billRepo:    billRepo,
+				invoiceRepo: invoiceRepo,
+				locker:      tenant.NewLocker(),
+				log:         logger.Discard(),
+			}
+			got, err := ucase.AddCoupon(context.Background(), 7, coupon)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+			billRepo.(*mocksBill.Repository).AssertExpectations(t)
+		})
+	}
+}
+
+func TestBillUsecase_RemoveCoupon(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		fields  func() (bill.Repository, bill.InvoiceRepository)
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("RemoveCoupon", mock.Anything, int64(7), "WELCOME").Return([]bill.Coupon{}, nil)
+				billRepo := &mocksBill.Repository{}
+				billRepo.On("SetCoupons", mock.Anything, []bill.Coupon{})
+				return billRepo, invoiceRepo
+			},
+		},
+		{
+			name: "Bill Finalized",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("RemoveCoupon", mock.Anything, int64(7), "WELCOME").Return(nil, bill.ErrFinalized)
+				return &mocksBill.Repository{}, invoiceRepo
+			},
+			wantErr: bill.ErrFinalized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billRepo, invoiceRepo := tt.fields()
+			ucase := &BillUsecase{
+				billRepo:    billRepo,
+				invoiceRepo: invoiceRepo,
+				locker:      tenant.NewLocker(),
+				log:         logger.Discard(),
+			}
+			err := ucase.RemoveCoupon(context.Background(), 7, "WELCOME")
+			assert.Equal(t, tt.wantErr, err)
+			billRepo.(*mocksBill.Repository).AssertExpectations(t)
+		})
+	}
+}
+
+func TestBillUsecase_Coupons_Order(t *testing.T) {
+	t.Parallel()
+	var (
+		mutex sync.Mutex
+		calls []string
+	)
+	call := func(name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls = append(calls, name)
+	}
+	entered, release := make(chan struct{}), make(chan struct{})
+	invoiceRepo := &mocksBill.InvoiceRepository{}
+	invoiceRepo.On("AddCoupon", mock.Anything, int64(7), coupon).Return([]bill.Coupon{coupon}, nil).Run(func(mock.Arguments) {
+		call("AddCoupon")
+		close(entered)
+		<-release
+	})
+	invoiceRepo.On("RemoveCoupon", mock.Anything, int64(7), "WELCOME").Return([]bill.Coupon{}, nil).Run(func(mock.Arguments) {
+		call("RemoveCoupon")
+	})
+	billRepo := &mocksBill.Repository{}
+	billRepo.On("SetCoupons", mock.Anything, []bill.Coupon{coupon}).Run(func(mock.Arguments) {
+		call("SetCoupons 1")
+	})
+	billRepo.On("SetCoupons", mock.Anything, []bill.Coupon{}).Run(func(mock.Arguments) {
+		call("SetCoupons 0")
+	})
+	ucase := &BillUsecase{
+		billRepo:    billRepo,
+		invoiceRepo: invoiceRepo,
+		locker:      tenant.NewLocker(),
+		log:         logger.Discard(),
+	}
+	ctx := tenant.WithTenant(context.Background(), "merchant-a")
+	var group sync.WaitGroup
+	group.Add(2)
+	go func() {
+		defer group.Done()
+		_, err := ucase.AddCoupon(ctx, 7, coupon)
+		assert.NoError(t, err)
+	}()
+	<-entered
+	//The coupon is removed while the coupon is being added, so its change waits until the added coupons are cached.
+	go func() {
+		defer group.Done()
+		assert.NoError(t, ucase.RemoveCoupon(ctx, 7, "WELCOME"))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	group.Wait()
+	assert.Equal(t, []string{"AddCoupon", "SetCoupons 1", "RemoveCoupon", "SetCoupons 0"}, calls)
+}
+
+func TestBillUsecase_AddCharge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		fields  func() (bill.Repository, bill.InvoiceRepository)
+		want    []bill.Charge
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("AddCharge", mock.Anything, int64(7), charge).Return([]bill.Charge{charge}, nil)
+				billRepo := &mocksBill.Repository{}
+				billRepo.On("SetCharges", mock.Anything, []bill.Charge{charge})
+				return billRepo, invoiceRepo
+			},
+			want: []bill.Charge{charge},
+		},
+		{
+			name: "Charge Exists",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("AddCharge", mock.Anything, int64(7), charge).Return(nil, bill.ErrChargeExists)
+				return &mocksBill.Repository{}, invoiceRepo
+			},
+			wantErr: bill.ErrChargeExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billRepo, invoiceRepo := tt.fields()
+			ucase := &BillUsecase{
+				billRepo:    billRepo,
+				invoiceRepo: invoiceRepo,
+				locker:      tenant.NewLocker(),
+				log:         logger.Discard(),
+			}
+			got, err := ucase.AddCharge(context.Background(), 7, charge)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+			billRepo.(*mocksBill.Repository).AssertExpectations(t)
+		})
+	}
+}
+
+func TestBillUsecase_RemoveCharge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		fields  func() (bill.Repository, bill.InvoiceRepository)
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("RemoveCharge", mock.Anything, int64(7), "Service").Return([]bill.Charge{}, nil)
+				billRepo := &mocksBill.Repository{}
+				billRepo.On("SetCharges", mock.Anything, []bill.Charge{})
+				return billRepo, invoiceRepo
+			},
+		},
+		{
+			name: "Charge Not Found",
+			fields: func() (bill.Repository, bill.InvoiceRepository) {
+				invoiceRepo := &mocksBill.InvoiceRepository{}
+				invoiceRepo.On("RemoveCharge", mock.Anything, int64(7), "Service").Return(nil, bill.ErrChargeNotFound)
+				return &mocksBill.Repository{}, invoiceRepo
+			},
+			wantErr: bill.ErrChargeNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billRepo, invoiceRepo := tt.fields()
+			ucase := &BillUsecase{
+				billRepo:    billRepo,
+				invoiceRepo: invoiceRepo,
+				locker:      tenant.NewLocker(),
+				log:         logger.Discard(),
+			}
+			err := ucase.RemoveCharge(context.Background(), 7, "Service")
+			assert.Equal(t, tt.wantErr, err)
+			billRepo.(*mocksBill.Repository).AssertExpectations(t)
+		})
+	}
+}
+
+func TestBillUsecase_Charges_Order(t *testing.T) {
+	t.Parallel()
+	var (
+		mutex sync.Mutex
+		calls []string
+	)
+	call := func(name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls = append(calls, name)
+	}
+	entered, release := make(chan struct{}), make(chan struct{})
+	invoiceRepo := &mocksBill.InvoiceRepository{}
+	invoiceRepo.On("AddCharge", mock.Anything, int64(7), charge).Return([]bill.Charge{charge}, nil).Run(func(mock.Arguments) {
+		call("AddCharge")
+		close(entered)
+		<-release
+	})
+	invoiceRepo.On("Finalize", mock.Anything, int64(7)).Return(bill.Invoice{Number: 1}, nil).Run(func(mock.Arguments) {
+		call("Finalize")
+	})
+	billRepo := &mocksBill.Repository{}
+	billRepo.On("SetCharges", mock.Anything, []bill.Charge{charge}).Run(func(mock.Arguments) {
+		call("SetCharges")
+	})
+	billRepo.On("RemoveBill", mock.Anything, int64(7)).Run(func(mock.Arguments) {
+		call("RemoveBill")
+	})
+	ucase := &BillUsecase{
+		billRepo:    billRepo,
+		invoiceRepo: invoiceRepo,
+		locker:      tenant.NewLocker(),
+		log:         logger.Discard(),
+	}
+	ctx := tenant.WithTenant(context.Background(), "merchant-a")
+	var group sync.WaitGroup
+	group.Add(2)
+	go func() {
+		defer group.Done()
+		_, err := ucase.AddCharge(ctx, 7, charge)
+		assert.NoError(t, err)
+	}()
+	<-entered
+	//The bill is finalized while the charge is being added,
+	//so the charges are cached before the bill is removed, and they're never moved to the next bill.
+	go func() {
+		defer group.Done()
+		_, err := ucase.FinalizeBill(ctx, 7)
+		assert.NoError(t, err)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	group.Wait()
+	assert.Equal(t, []string{"AddCharge", "SetCharges", "Finalize", "RemoveBill"}, calls)
+}
+
 func TestNewBillUsecase(t *testing.T) {
 	type args struct {
-		billRepo bill.Repository
-		taxRepo  taxobj.Repository
+		billRepo    bill.Repository
+		taxRepo     taxobj.Repository
+		invoiceRepo bill.InvoiceRepository
+		locker      *tenant.Locker
+		log         logrus.FieldLogger
 	}
 	billRepo := new(mocksBill.Repository)
 	taxRepo := new(mocksTax.Repository)
+	invoiceRepo := new(mocksBill.InvoiceRepository)
+	locker := tenant.NewLocker()
+	log := logger.Discard()
 	tests := []struct {
 		name string
 		args args
@@ -168,18 +740,24 @@ func TestNewBillUsecase(t *testing.T) {
 		{
 			name: "Init Bill Usecase",
 			args: args{
-				billRepo: billRepo,
-				taxRepo:  taxRepo,
+				billRepo:    billRepo,
+				taxRepo:     taxRepo,
+				invoiceRepo: invoiceRepo,
+				locker:      locker,
+				log:         log,
 			},
 			want: &BillUsecase{
-				billRepo: billRepo,
-				taxRepo:  taxRepo,
+				billRepo:    billRepo,
+				taxRepo:     taxRepo,
+				invoiceRepo: invoiceRepo,
+				locker:      locker,
+				log:         log,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.EqualValues(t, NewBillUsecase(tt.args.billRepo, tt.args.taxRepo), tt.want)
+			assert.EqualValues(t, NewBillUsecase(tt.args.billRepo, tt.args.taxRepo, tt.args.invoiceRepo, tt.args.locker, tt.args.log), tt.want)
 		})
 	}
 }
@@ -0,0 +1,23 @@
+//Package httpstatus resolves the status of the response sent for the request handled by echo,
+//so the middlewares observing the requests report the same status as the client receives.
+package httpstatus
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+//Of return the status of the response of the request after its handler returns err.
+//The error isn't sent yet when the middleware observes it, echo's error handler sends it after the middlewares,
+//so the status of the HTTP error is its code and the status of any other error is 500 like echo sends it.
+//The committed response is already sent, so its status is returned even if err isn't nil.
+func Of(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	if httpErr, ok := err.(*echo.HTTPError); ok {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,75 @@
+// +build unit
+
+package httpstatus
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		want    int
+	}{
+		// TODO: Add test cases.
+		{
+			name: "No Error",
+			handler: func(c echo.Context) error {
+				return c.NoContent(http.StatusCreated)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "HTTP Error",
+			handler: func(c echo.Context) error {
+				return echo.NewHTTPError(http.StatusNotFound)
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "Plain Error",
+			handler: func(c echo.Context) error {
+				return errors.New("Database is not online")
+			},
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "Error after the Response",
+			handler: func(c echo.Context) error {
+				c.NoContent(http.StatusAccepted)
+				return errors.New("Failed to write the response")
+			},
+			want: http.StatusAccepted,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got int
+			e := echo.New()
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) (err error) {
+					err = next(c)
+					got = Of(c, err)
+					return
+				}
+			})
+			e.GET("/status", tt.handler)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+			assert.Equal(t, tt.want, got)
+			//The status observed is the status sent to the client.
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package limit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//sweepInterval defines how often the idle buckets are removed from the limiter.
+	sweepInterval = time.Minute
+	//HeaderRetryAfter defines the header telling the client how many seconds to wait before retrying.
+	HeaderRetryAfter = "Retry-After"
+)
+
+var (
+	//ErrTooManyRequests defines the error response returned if the client exceeds its rate limit.
+	ErrTooManyRequests = echo.NewHTTPError(http.StatusTooManyRequests, "Too Many Requests")
+	//ErrBodyTooLarge defines the error response returned if the request body exceeds the maximum size.
+	ErrBodyTooLarge = echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Request Entity Too Large")
+	//ErrUnreadableBody defines the error response returned if the request body can't be read.
+	ErrUnreadableBody = echo.NewHTTPError(http.StatusBadRequest, "Invalid input")
+)
+
+//RateLimiter limit the requests of every client using the token bucket.
+//Every bucket holds up to burst tokens and is refilled with rate tokens per second.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+	mutex *sync.Mutex
+	//mutex here protected the following fields.
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+//bucket define the remaining tokens of a client at the last request.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+//NewRateLimiter return the rate limiter allowing rate requests per second with the given burst.
+//The burst is at least 1, otherwise no request is ever allowed.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		mutex:   new(sync.Mutex),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+//Allow take a token from the bucket of the key.
+//If the bucket is empty, it return false and the duration until the next token is available.
+func (limiter *RateLimiter) Allow(key string) (allowed bool, retryAfter time.Duration) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	now := limiter.now()
+	limiter.sweep(now)
+	owner, ok := limiter.buckets[key]
+	if !ok {
+		owner = &bucket{tokens: limiter.burst, last: now}
+		limiter.buckets[key] = owner
+	}
+	limiter.refill(owner, now)
+	if owner.tokens >= 1 {
+		owner.tokens--
+		return true, 0
+	}
+	retryAfter = time.Duration((1 - owner.tokens) / limiter.rate * float64(time.Second))
+	return
+}
+
+//refill add the tokens accumulated since the last request to the bucket.
+func (limiter *RateLimiter) refill(owner *bucket, now time.Time) {
+	elapsed := now.Sub(owner.last).Seconds()
+	owner.tokens = math.Min(limiter.burst, owner.tokens+elapsed*limiter.rate)
+	owner.last = now
+}
+
+//sweep remove the buckets that are full again, so the idle clients don't grow the limiter unboundedly.
+//A removed bucket is identical to a new one.
+func (limiter *RateLimiter) sweep(now time.Time) {
+	if now.Sub(limiter.swept) < sweepInterval {
+		return
+	}
+	limiter.swept = now
+	for key, owner := range limiter.buckets {
+		limiter.refill(owner, now)
+		if owner.tokens >= limiter.burst {
+			delete(limiter.buckets, key)
+		}
+	}
+}
+
+//KeyFunc return the key identifying the client of the request.
+type KeyFunc func(c echo.Context) string
+
+//ParseTrustedProxies parse the IP addresses or the CIDR ranges of the trusted proxies, e.g. 10.0.0.1 or 10.0.0.0/8.
+func ParseTrustedProxies(proxies []string) (trusted []*net.IPNet, err error) {
+	trusted = make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				err = fmt.Errorf("Invalid trusted proxy %q", proxy)
+				return
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+		var network *net.IPNet
+		_, network, err = net.ParseCIDR(proxy)
+		if err != nil {
+			err = fmt.Errorf("Invalid trusted proxy %q", proxy)
+			return
+		}
+		trusted = append(trusted, network)
+	}
+	return
+}
+
+//ClientIP return the IP address of the client sending the request.
+//The X-Forwarded-For and X-Real-IP headers are only used if the request is sent by a trusted proxy,
+//and the forwarded addresses are read from the last one to the first untrusted one,
+//so the client can't choose its address by sending the headers itself.
+func ClientIP(req *http.Request, trusted []*net.IPNet) string {
+	ip := remoteIP(req.RemoteAddr)
+	if !isTrusted(ip, trusted) {
+		return ip
+	}
+	forwarded := req.Header.Get(echo.HeaderXForwardedFor)
+	if forwarded == "" {
+		if realIP := strings.TrimSpace(req.Header.Get(echo.HeaderXRealIP)); net.ParseIP(realIP) != nil {
+			return realIP
+		}
+		return ip
+	}
+	addresses := strings.Split(forwarded, ",")
+	for index := len(addresses) - 1; index >= 0; index-- {
+		address := strings.TrimSpace(addresses[index])
+		if net.ParseIP(address) == nil {
+			return ip
+		}
+		ip = address
+		if !isTrusted(ip, trusted) {
+			return ip
+		}
+	}
+	return ip
+}
+
+//remoteIP return the IP address of the remote address in the host:port form.
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+//isTrusted return true if the IP address is in any of the trusted networks.
+func isTrusted(address string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+//IPKey return the key function identifying the client by its IP address.
+//The limit keyed by the IP address is applied before the authentication,
+//so the client can't escape it by sending another credential on every request.
+func IPKey(trusted []*net.IPNet) KeyFunc {
+	return func(c echo.Context) string {
+		return "ip:" + ClientIP(c.Request(), trusted)
+	}
+}
+
+//RateLimit return the echo middleware rejecting the requests of the client exceeding its rate limit.
+//The client is identified by the key function, and the rejected requests are responded with 429 and the Retry-After header in seconds.
+func RateLimit(limiter *RateLimiter, key KeyFunc, log logrus.FieldLogger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			client := key(c)
+			allowed, retryAfter := limiter.Allow(client)
+			if allowed {
+				return next(c)
+			}
+			seconds := int64(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			c.Response().Header().Set(HeaderRetryAfter, strconv.FormatInt(seconds, 10))
+			logger.FromContext(c.Request().Context(), log).
+				WithField("client", client).
+				Warn("[RateLimit] Rate limit exceeded")
+			return ErrTooManyRequests
+		}
+	}
+}
+
+//BodyLimit return the echo middleware rejecting the requests with the body larger than max bytes with 413.
+//The body is read before the handler, so the chunked body exceeding the limit is rejected the same way.
+func BodyLimit(max int64, log logrus.FieldLogger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			req := c.Request()
+			if req.ContentLength > max {
+				return bodyTooLarge(c, log)
+			}
+			if req.Body == nil || req.Body == http.NoBody {
+				return next(c)
+			}
+			//Read one more byte than the limit to know if the body exceeds it.
+			body, err := ioutil.ReadAll(io.LimitReader(req.Body, max+1))
+			req.Body.Close()
+			if err != nil {
+				logger.FromContext(req.Context(), log).WithError(err).Warn("[BodyLimit] Failed to read the request body")
+				return ErrUnreadableBody
+			}
+			if int64(len(body)) > max {
+				return bodyTooLarge(c, log)
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			return next(c)
+		}
+	}
+}
+
+//bodyTooLarge log and return the error response of the body exceeding the maximum size.
+func bodyTooLarge(c echo.Context, log logrus.FieldLogger) error {
+	logger.FromContext(c.Request().Context(), log).
+		WithField("content_length", c.Request().ContentLength).
+		Warn("[BodyLimit] Request body too large")
+	return ErrBodyTooLarge
+}
@@ -0,0 +1,286 @@
+// +build unit
+
+package limit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 2)
+	limiter.now = func() time.Time {
+		return now
+	}
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := limiter.Allow("cashier")
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter := limiter.Allow("cashier")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	allowed, _ = limiter.Allow("another cashier")
+	assert.True(t, allowed, "Every client has its own bucket")
+
+	now = now.Add(500 * time.Millisecond)
+	allowed, _ = limiter.Allow("cashier")
+	assert.True(t, allowed, "The bucket is refilled over time")
+
+	now = now.Add(sweepInterval)
+	limiter.Allow("cashier")
+	assert.Len(t, limiter.buckets, 1, "The idle buckets are removed")
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	e.Use(RateLimit(NewRateLimiter(1, 1), IPKey(nil), logger.Discard()))
+	e.GET("/bill", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		apiKey         string
+		forwardedFor   string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		// TODO: Add test cases.
+		{
+			name:           "First Request",
+			remoteAddr:     "192.0.2.1:1234",
+			apiKey:         "cashier",
+			wantStatus:     http.StatusOK,
+			wantRetryAfter: "",
+		},
+		{
+			name:           "Exceeded Rate Limit",
+			remoteAddr:     "192.0.2.1:1234",
+			apiKey:         "cashier",
+			wantStatus:     http.StatusTooManyRequests,
+			wantRetryAfter: "1",
+		},
+		{
+			name:           "Rotated API Key",
+			remoteAddr:     "192.0.2.1:1235",
+			apiKey:         "another cashier",
+			wantStatus:     http.StatusTooManyRequests,
+			wantRetryAfter: "1",
+		},
+		{
+			name:           "Spoofed Forwarded Address",
+			remoteAddr:     "192.0.2.1:1236",
+			forwardedFor:   "198.51.100.1",
+			wantStatus:     http.StatusTooManyRequests,
+			wantRetryAfter: "1",
+		},
+		{
+			name:           "Another IP Address",
+			remoteAddr:     "192.0.2.2:1234",
+			wantStatus:     http.StatusOK,
+			wantRetryAfter: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/bill", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.forwardedFor != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tt.forwardedFor)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantRetryAfter, rec.Header().Get(HeaderRetryAfter))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		proxies     []string
+		wantTrusted []string
+		wantErr     bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:        "Empty",
+			proxies:     []string{""},
+			wantTrusted: []string{},
+		},
+		{
+			name:        "Addresses and Ranges",
+			proxies:     []string{"10.0.0.1", " 172.16.0.0/12", "::1"},
+			wantTrusted: []string{"10.0.0.1/32", "172.16.0.0/12", "::1/128"},
+		},
+		{
+			name:    "Invalid Address",
+			proxies: []string{"proxy"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Range",
+			proxies: []string{"10.0.0.0/33"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotTrusted, err := ParseTrustedProxies(tt.proxies)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTrustedProxies() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make([]string, 0, len(gotTrusted))
+			for _, network := range gotTrusted {
+				got = append(got, network.String())
+			}
+			assert.Equal(t, tt.wantTrusted, got)
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Error parsing the trusted proxies: %s", err)
+	}
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		want         string
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Direct Client",
+			remoteAddr: "192.0.2.1:1234",
+			want:       "192.0.2.1",
+		},
+		{
+			name:         "Untrusted Forwarded Address",
+			remoteAddr:   "192.0.2.1:1234",
+			forwardedFor: "198.51.100.1",
+			realIP:       "198.51.100.2",
+			want:         "192.0.2.1",
+		},
+		{
+			name:         "Trusted Proxy",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: "198.51.100.1",
+			want:         "198.51.100.1",
+		},
+		{
+			name:         "Address Prepended by the Client",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: "203.0.113.1, 198.51.100.1, 10.0.0.2",
+			want:         "198.51.100.1",
+		},
+		{
+			name:       "Real IP from the Trusted Proxy",
+			remoteAddr: "10.0.0.1:1234",
+			realIP:     "198.51.100.2",
+			want:       "198.51.100.2",
+		},
+		{
+			name:         "Invalid Forwarded Address",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: "unknown",
+			want:         "10.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/bill", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				req.Header.Set(echo.HeaderXRealIP, tt.realIP)
+			}
+			assert.Equal(t, tt.want, ClientIP(req, trusted))
+		})
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	e.Use(BodyLimit(16, logger.Discard()))
+	e.POST("/tax", func(c echo.Context) error {
+		body := new(bytes.Buffer)
+		_, err := body.ReadFrom(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusCreated, body.String())
+	})
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		wantStatus    int
+	}{
+		// TODO: Add test cases.
+		{
+			name:          "Body Within Limit",
+			body:          `{"name":"Lucky"}`,
+			contentLength: 16,
+			wantStatus:    http.StatusCreated,
+		},
+		{
+			name:          "Content Length Too Large",
+			body:          `{"name":"Lucky Stretch"}`,
+			contentLength: 24,
+			wantStatus:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "Chunked Body Too Large",
+			body:          `{"name":"Lucky Stretch"}`,
+			contentLength: -1,
+			wantStatus:    http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusCreated {
+				assert.Equal(t, tt.body, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/httpstatus"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//HeaderRequestID defines the header used to propagate the request id.
+	HeaderRequestID = "X-Request-ID"
+	//FieldRequestID defines the field name of the request id in every log line.
+	FieldRequestID = "request_id"
+	//FormatJSON defines the config value for the json log output.
+	FormatJSON = "json"
+	//MaxRequestIDLength defines the maximum length of the request id propagated from the client.
+	MaxRequestIDLength = 64
+)
+
+type contextKey struct{}
+
+var (
+	requestIDKey = contextKey{}
+)
+
+//New creates the logger with the given level and format.
+//The info level is used if the given level is not valid.
+func New(level string, format string) *logrus.Logger {
+	log := logrus.New()
+	log.Out = os.Stdout
+	if format == FormatJSON {
+		log.Formatter = new(logrus.JSONFormatter)
+	}
+	parsedLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		log.WithField("level", level).Warn("[Logger] Invalid log level, using the info level")
+		parsedLevel = logrus.InfoLevel
+	}
+	log.SetLevel(parsedLevel)
+	return log
+}
+
+//Discard creates the logger that writes nothing, e.g. for the tests.
+func Discard() *logrus.Logger {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	return log
+}
+
+//WithRequestID return the copy of ctx carrying the request id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+//RequestID return the request id stored in ctx, or empty string if there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+//FromContext return the logger with the request id field attached if ctx carries one.
+func FromContext(ctx context.Context, log logrus.FieldLogger) logrus.FieldLogger {
+	if requestID := RequestID(ctx); requestID != "" {
+		return log.WithField(FieldRequestID, requestID)
+	}
+	return log
+}
+
+//Middleware return the echo middleware propagating or generating the request id for every request.
+//The request id of the client is only propagated if it's valid, otherwise the new request id is generated,
+//so the request id written to the logs and the response is never injected by the client.
+//The request id is returned in the response header and every request is logged when it's done.
+func Middleware(log logrus.FieldLogger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			begin := time.Now()
+			req := c.Request()
+			requestID := req.Header.Get(HeaderRequestID)
+			if !ValidRequestID(requestID) {
+				requestID = generateRequestID()
+			}
+			c.SetRequest(req.WithContext(WithRequestID(req.Context(), requestID)))
+			c.Response().Header().Set(HeaderRequestID, requestID)
+
+			err = next(c)
+			status := httpstatus.Of(c, err)
+			log.WithFields(logrus.Fields{
+				FieldRequestID: requestID,
+				"method":       req.Method,
+				"uri":          req.RequestURI,
+				"status":       status,
+				"latency":      time.Since(begin).String(),
+			}).Info("[HTTP] Request handled")
+			return
+		}
+	}
+}
+
+//ValidRequestID return true if the request id isn't empty, isn't longer than MaxRequestIDLength,
+//and only has the letters, the digits, and the characters "-", "_", ".", and ":", e.g. the UUID or the trace id.
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > MaxRequestIDLength {
+		return false
+	}
+	for _, char := range requestID {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+		case char == '-', char == '_', char == '.', char == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+//generateRequestID return the random hex string used as the request id.
+func generateRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
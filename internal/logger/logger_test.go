@@ -0,0 +1,172 @@
+// +build unit
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		level         string
+		format        string
+		wantLevel     logrus.Level
+		wantFormatter logrus.Formatter
+	}{
+		// TODO: Add test cases.
+		{
+			name:          "JSON Debug Logger",
+			level:         "debug",
+			format:        FormatJSON,
+			wantLevel:     logrus.DebugLevel,
+			wantFormatter: new(logrus.JSONFormatter),
+		},
+		{
+			name:          "Invalid Level Fallback",
+			level:         "verbose",
+			format:        "text",
+			wantLevel:     logrus.InfoLevel,
+			wantFormatter: new(logrus.TextFormatter),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := New(tt.level, tt.format)
+			assert.Equal(t, tt.wantLevel, log.Level)
+			assert.IsType(t, tt.wantFormatter, log.Formatter)
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+	buffer := new(bytes.Buffer)
+	log := Discard()
+	log.Out = buffer
+	log.Formatter = new(logrus.JSONFormatter)
+
+	FromContext(WithRequestID(context.Background(), "abc"), log).Info("with request id")
+	line := map[string]interface{}{}
+	if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+		t.Fatalf("Error unmarshaling the log line: %s", err)
+	}
+	assert.Equal(t, "abc", line[FieldRequestID])
+
+	assert.Equal(t, log, FromContext(context.Background(), log))
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		requestID     string
+		wantRequestID bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:          "Propagate Request ID",
+			requestID:     "propagated-id",
+			wantRequestID: true,
+		},
+		{
+			name:          "Generate Request ID",
+			requestID:     "",
+			wantRequestID: false,
+		},
+		{
+			name:          "Replace Too Long Request ID",
+			requestID:     strings.Repeat("a", MaxRequestIDLength+1),
+			wantRequestID: false,
+		},
+		{
+			name:          "Replace Request ID Injecting Log Line",
+			requestID:     "id\nlevel=error msg=injected",
+			wantRequestID: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextRequestID string
+			e := echo.New()
+			e.Use(Middleware(Discard()))
+			e.GET("/bill", func(c echo.Context) error {
+				contextRequestID = RequestID(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/bill", nil)
+			if tt.requestID != "" {
+				req.Header.Set(HeaderRequestID, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			responseRequestID := rec.Header().Get(HeaderRequestID)
+			assert.NotEmpty(t, responseRequestID)
+			assert.Equal(t, responseRequestID, contextRequestID)
+			assert.Equal(t, tt.wantRequestID, responseRequestID == tt.requestID)
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		requestID string
+		want      bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:      "UUID",
+			requestID: "123e4567-e89b-12d3-a456-426614174000",
+			want:      true,
+		},
+		{
+			name:      "Maximum Length",
+			requestID: strings.Repeat("a", MaxRequestIDLength),
+			want:      true,
+		},
+		{
+			name:      "Empty",
+			requestID: "",
+			want:      false,
+		},
+		{
+			name:      "Too Long",
+			requestID: strings.Repeat("a", MaxRequestIDLength+1),
+			want:      false,
+		},
+		{
+			name:      "Space",
+			requestID: "request id",
+			want:      false,
+		},
+		{
+			name:      "Line Break",
+			requestID: "id\r\nX-Injected: true",
+			want:      false,
+		},
+		{
+			name:      "Non-ASCII",
+			requestID: "idé",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ValidRequestID(tt.requestID))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/httpstatus"
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "taxcalculator"
+)
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests by method, route and status.",
+		},
+		[]string{"method", "route", "status"},
+	)
+	httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+	dbQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "database",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of the postgre queries by query name.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"query"},
+	)
+	dbQueryErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "database",
+			Name:      "query_errors_total",
+			Help:      "Total number of failed postgre queries by query name.",
+		},
+		[]string{"query"},
+	)
+	billCacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "bill",
+			Name:      "cache_lines",
+			Help:      "Number of bill lines stored in the bill cache.",
+		},
+	)
+	billTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "bill",
+			Name:      "total",
+			Help:      "Current total values of the bill cache.",
+		},
+		[]string{"total"},
+	)
+	loadDataDuration = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "bill",
+			Name:      "load_data_duration_seconds",
+			Help:      "Duration of the last bill cache loading from the database.",
+		},
+	)
+	collectors = []prometheus.Collector{
+		httpRequestsTotal,
+		httpRequestDuration,
+		dbQueryDuration,
+		dbQueryErrors,
+		billCacheSize,
+		billTotal,
+		loadDataDuration,
+	}
+)
+
+//Register registers all collectors of the application to the given registerer.
+func Register(registerer prometheus.Registerer) (err error) {
+	for _, collector := range collectors {
+		if err = registerer.Register(collector); err != nil {
+			return
+		}
+	}
+	return
+}
+
+//Handler return the http handler exposing the metrics gathered by the given gatherer.
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
+
+//Middleware return the echo middleware for counting and timing every request.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			begin := time.Now()
+			err = next(c)
+			status := httpstatus.Of(c, err)
+			labels := prometheus.Labels{
+				"method": c.Request().Method,
+				"route":  c.Path(),
+				"status": strconv.Itoa(status),
+			}
+			httpRequestsTotal.With(labels).Inc()
+			httpRequestDuration.With(labels).Observe(time.Since(begin).Seconds())
+			return
+		}
+	}
+}
+
+//ObserveQuery records the latency of the query started at begin and counts it as failed if err is not nil.
+func ObserveQuery(query string, begin time.Time, err error) {
+	dbQueryDuration.WithLabelValues(query).Observe(time.Since(begin).Seconds())
+	if err != nil {
+		dbQueryErrors.WithLabelValues(query).Inc()
+	}
+}
+
+//SetBillCache records the current size and total values of the bill cache.
+func SetBillCache(size int, total bill.Total) {
+	billCacheSize.Set(float64(size))
+	billTotal.WithLabelValues("price_subtotal").Set(total.PriceSubtotal)
+	billTotal.WithLabelValues("tax_subtotal").Set(total.TaxSubtotal)
+	billTotal.WithLabelValues("grand_total").Set(total.GrandTotal)
+}
+
+//ObserveLoadData records the duration of loading the bill cache started at begin.
+func ObserveLoadData(begin time.Time) {
+	loadDataDuration.Set(time.Since(begin).Seconds())
+}
@@ -0,0 +1,116 @@
+// +build unit
+
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, Register(registry))
+	//Registering the same collectors twice must fail.
+	assert.Error(t, Register(registry))
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/metrics-test/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot)
+	})
+	labels := prometheus.Labels{
+		"method": http.MethodGet,
+		"route":  "/metrics-test/:id",
+		"status": "418",
+	}
+	before := testutil.ToFloat64(httpRequestsTotal.With(labels))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics-test/1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(httpRequestsTotal.With(labels)))
+}
+
+func TestMiddleware_PlainError(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/metrics-plain-error", func(c echo.Context) error {
+		return errors.New("Database is not online")
+	})
+	labels := prometheus.Labels{
+		"method": http.MethodGet,
+		"route":  "/metrics-plain-error",
+		"status": "500",
+	}
+	before := testutil.ToFloat64(httpRequestsTotal.With(labels))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics-plain-error", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	//The plain error is sent as 500 by echo, so it's counted as 500 instead of the status of the unwritten response.
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(httpRequestsTotal.With(labels)))
+}
+
+func TestObserveQuery(t *testing.T) {
+	t.Parallel()
+	const query = "observe_query_test"
+	ObserveQuery(query, time.Now(), nil)
+	assert.Equal(t, float64(0), testutil.ToFloat64(dbQueryErrors.WithLabelValues(query)))
+	ObserveQuery(query, time.Now(), errors.New("Error in querying rows"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(dbQueryErrors.WithLabelValues(query)))
+}
+
+func TestSetBillCache(t *testing.T) {
+	t.Parallel()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(billCacheSize, billTotal)
+	SetBillCache(1, bill.Total{
+		PriceSubtotal: 20000,
+		TaxSubtotal:   2000,
+		GrandTotal:    22000,
+	})
+	const expected = `
+		# HELP taxcalculator_bill_cache_lines Number of bill lines stored in the bill cache.
+		# TYPE taxcalculator_bill_cache_lines gauge
+		taxcalculator_bill_cache_lines 1
+		# HELP taxcalculator_bill_total Current total values of the bill cache.
+		# TYPE taxcalculator_bill_total gauge
+		taxcalculator_bill_total{total="grand_total"} 22000
+		taxcalculator_bill_total{total="price_subtotal"} 20000
+		taxcalculator_bill_total{total="tax_subtotal"} 2000
+	`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected))
+	assert.NoError(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(loadDataDuration)
+	ObserveLoadData(time.Now())
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "taxcalculator_bill_load_data_duration_seconds")
+}
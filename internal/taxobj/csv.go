@@ -0,0 +1,282 @@
+package taxobj
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//The columns of the tax objects in the CSV file, they are named like the JSON fields of the tax object.
+const (
+	ColumnID            = "id"
+	ColumnName          = "name"
+	ColumnTaxCode       = "tax_code"
+	ColumnJurisdiction  = "jurisdiction"
+	ColumnQuantity      = "quantity"
+	ColumnUnitPrice     = "unit_price"
+	ColumnPrice         = "price"
+	ColumnDiscountType  = "discount_type"
+	ColumnDiscountValue = "discount_value"
+	ColumnTaxInclusive  = "tax_inclusive"
+	//ColumnTaxes is the additional taxes in their order separated by ";", e.g. "2;1:compound",
+	//the compound tax is the tax code followed by ":compound".
+	ColumnTaxes = "taxes"
+)
+
+const (
+	//taxSeparator separates the additional taxes in the cell.
+	taxSeparator = ";"
+	//compoundSuffix follows the tax code of the compound tax in the cell.
+	compoundSuffix = ":compound"
+)
+
+//The columns of the lines of the bill exported by taxctl, so the exported bill can be imported again.
+const (
+	//ColumnDiscount is the discount amount of the line, it's read as the fixed discount if the discount type isn't given.
+	ColumnDiscount = "discount"
+)
+
+//CSVColumns are the columns written to the CSV file in their order.
+var CSVColumns = []string{
+	ColumnID,
+	ColumnName,
+	ColumnTaxCode,
+	ColumnJurisdiction,
+	ColumnQuantity,
+	ColumnUnitPrice,
+	ColumnPrice,
+	ColumnDiscountType,
+	ColumnDiscountValue,
+	ColumnTaxInclusive,
+	ColumnTaxes,
+}
+
+//billColumns are the other columns of the exported lines of the bill read by ReadCSV.
+//Only the discount and the taxes are read, the other ones are calculated from the tax objects, so they're ignored.
+var billColumns = []string{ColumnDiscount, "type", "refundable", "coupon", "taxable_base", "tax", "amount"}
+
+//CSVError define the error of the line of the CSV file, the first line is the header.
+//The line is the line of the file where the row starts, so the blank lines and the line breaks in the quoted cells are counted.
+type CSVError struct {
+	Line int
+	Err  error
+}
+
+//Error return the error with its line.
+func (err *CSVError) Error() string {
+	return fmt.Sprintf("Line %d: %s", err.Line, err.Err)
+}
+
+//ReadCSV read the tax objects from the CSV file whose first line is the header naming the columns.
+//The columns can be in any order and only the name and the tax code are required in the header.
+//The columns of the exported lines of the bill are also accepted, the calculated ones are ignored.
+//The empty cell is the zero value, except the quantity which is one unit like in the request without the quantity.
+//The tax objects are only parsed, so they must be validated before they're used.
+func ReadCSV(reader io.Reader) (taxObjects []TaxObject, err error) {
+	taxObjects, _, err = ReadCSVLines(reader)
+	return
+}
+
+//ReadCSVLines read the tax objects from the CSV file like ReadCSV, and return the line where every tax object starts,
+//so the tax object is reported with its line in the file, also after the blank lines and the quoted cells with the line breaks.
+func ReadCSVLines(reader io.Reader) (taxObjects []TaxObject, lines []int, err error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		err = &CSVError{Line: 1, Err: fmt.Errorf("The header is missing")}
+		return
+	}
+	if err != nil {
+		return
+	}
+	headerLine, _ := csvReader.FieldPos(0)
+	columns := make(map[string]int, len(header))
+	for index, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !isCSVColumn(column) {
+			err = &CSVError{Line: headerLine, Err: fmt.Errorf("Unknown column %q", column)}
+			return
+		}
+		columns[column] = index
+	}
+	for _, column := range []string{ColumnName, ColumnTaxCode} {
+		if _, ok := columns[column]; !ok {
+			err = &CSVError{Line: headerLine, Err: fmt.Errorf("The column %q is missing", column)}
+			return
+		}
+	}
+	taxObjects = make([]TaxObject, 0)
+	lines = make([]int, 0)
+	for {
+		var record []string
+		record, err = csvReader.Read()
+		if err == io.EOF {
+			err = nil
+			return
+		}
+		if err != nil {
+			return
+		}
+		line, _ := csvReader.FieldPos(0)
+		var taxObject TaxObject
+		if taxObject, err = parseCSVRecord(columns, record); err != nil {
+			err = &CSVError{Line: line, Err: err}
+			return
+		}
+		taxObjects = append(taxObjects, taxObject)
+		lines = append(lines, line)
+	}
+}
+
+//WriteCSV write the tax objects to the CSV file with the header, in the format read by ReadCSV.
+func WriteCSV(writer io.Writer, taxObjects []TaxObject) (err error) {
+	csvWriter := csv.NewWriter(writer)
+	if err = csvWriter.Write(CSVColumns); err != nil {
+		return
+	}
+	for _, taxObject := range taxObjects {
+		discount := Discount{}
+		if taxObject.Discount != nil {
+			discount = *taxObject.Discount
+		}
+		record := []string{
+			strconv.FormatInt(taxObject.ID, 10),
+			taxObject.Name,
+			strconv.FormatInt(taxObject.TaxCode, 10),
+			taxObject.Jurisdiction,
+			formatFloat(taxObject.Quantity),
+			formatFloat(taxObject.UnitPrice),
+			formatFloat(taxObject.Price),
+			discount.Type,
+			formatFloat(discount.Value),
+			strconv.FormatBool(taxObject.TaxInclusive),
+			FormatTaxes(taxObject.Taxes),
+		}
+		if err = csvWriter.Write(record); err != nil {
+			return
+		}
+	}
+	csvWriter.Flush()
+	err = csvWriter.Error()
+	return
+}
+
+//parseCSVRecord parse the tax object from the record with the columns of the header.
+func parseCSVRecord(columns map[string]int, record []string) (taxObject TaxObject, err error) {
+	cell := func(column string) string {
+		index, ok := columns[column]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+	taxObject = TaxObject{
+		Name:         cell(ColumnName),
+		Jurisdiction: cell(ColumnJurisdiction),
+		Quantity:     1,
+	}
+	discount := Discount{Type: cell(ColumnDiscountType)}
+	var amount float64
+	parsers := []struct {
+		column string
+		parse  func(value string) error
+	}{
+		{ColumnID, func(value string) (err error) {
+			taxObject.ID, err = strconv.ParseInt(value, 10, 64)
+			return
+		}},
+		{ColumnTaxCode, func(value string) (err error) {
+			taxObject.TaxCode, err = strconv.ParseInt(value, 10, 64)
+			return
+		}},
+		{ColumnQuantity, func(value string) (err error) {
+			taxObject.Quantity, err = strconv.ParseFloat(value, 64)
+			return
+		}},
+		{ColumnUnitPrice, func(value string) (err error) {
+			taxObject.UnitPrice, err = strconv.ParseFloat(value, 64)
+			return
+		}},
+		{ColumnPrice, func(value string) (err error) {
+			taxObject.Price, err = strconv.ParseFloat(value, 64)
+			return
+		}},
+		{ColumnDiscountValue, func(value string) (err error) {
+			discount.Value, err = strconv.ParseFloat(value, 64)
+			return
+		}},
+		{ColumnDiscount, func(value string) (err error) {
+			amount, err = strconv.ParseFloat(value, 64)
+			return
+		}},
+		{ColumnTaxInclusive, func(value string) (err error) {
+			taxObject.TaxInclusive, err = strconv.ParseBool(value)
+			return
+		}},
+		{ColumnTaxes, func(value string) (err error) {
+			taxObject.Taxes, err = parseTaxes(value)
+			return
+		}},
+	}
+	for _, parser := range parsers {
+		value := cell(parser.column)
+		if value == "" {
+			continue
+		}
+		if err = parser.parse(value); err != nil {
+			err = fmt.Errorf("Invalid %s %q", parser.column, value)
+			return
+		}
+	}
+	if discount.Type == "" && amount != 0 {
+		discount = Discount{Type: DiscountFixed, Value: amount}
+	}
+	taxObject.Discount = discount.OrNil()
+	return
+}
+
+//parseTaxes parse the additional taxes from the cell, e.g. "2;1:compound".
+func parseTaxes(value string) (taxes []Tax, err error) {
+	for _, cell := range strings.Split(value, taxSeparator) {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		tax := Tax{Compound: strings.HasSuffix(cell, compoundSuffix)}
+		if tax.TaxCode, err = strconv.ParseInt(strings.TrimSuffix(cell, compoundSuffix), 10, 64); err != nil {
+			return
+		}
+		taxes = append(taxes, tax)
+	}
+	return
+}
+
+//FormatTaxes format the additional taxes in the cell of the taxes column, e.g. "2;1:compound".
+func FormatTaxes(taxes []Tax) string {
+	cells := make([]string, 0, len(taxes))
+	for _, tax := range taxes {
+		cell := strconv.FormatInt(tax.TaxCode, 10)
+		if tax.Compound {
+			cell += compoundSuffix
+		}
+		cells = append(cells, cell)
+	}
+	return strings.Join(cells, taxSeparator)
+}
+
+//isCSVColumn return true if the column is one of the columns of the tax objects or the exported lines of the bill.
+func isCSVColumn(column string) bool {
+	for _, columns := range [][]string{CSVColumns, billColumns} {
+		for _, other := range columns {
+			if column == other {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//formatFloat format the number without the trailing zeros.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,179 @@
+// +build unit
+
+package taxobj
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCSV(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		input          string
+		wantTaxObjects []TaxObject
+		wantErr        string
+	}{
+		// TODO: Add test cases.
+		{
+			name:  "Positive Case",
+			input: "name,tax_code,price\nBig Mac,1,1000\nLucky Stretch,2,1000\n",
+			wantTaxObjects: []TaxObject{
+				{Name: "Big Mac", TaxCode: 1, Quantity: 1, Price: 1000},
+				{Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, Price: 1000},
+			},
+		},
+		{
+			name: "All Columns in Another Order",
+			input: "Tax_Inclusive, Discount_Value, Discount_Type, Price, Unit_Price, Quantity, Jurisdiction, Tax_Code, Name, ID\n" +
+				"true,10,percent,,500,2,TEST,1,Big Mac,7\n",
+			wantTaxObjects: []TaxObject{
+				{
+					ID:           7,
+					Name:         "Big Mac",
+					TaxCode:      1,
+					Jurisdiction: "TEST",
+					Quantity:     2,
+					UnitPrice:    500,
+					Discount:     &Discount{Type: DiscountPercent, Value: 10},
+					TaxInclusive: true,
+				},
+			},
+		},
+		{
+			name: "Exported Bill Lines",
+			input: "name,tax_code,jurisdiction,type,refundable,quantity,unit_price,price,discount,coupon,tax_inclusive,taxable_base,tax,amount\n" +
+				"Big Mac,1,,Food & Beverage,Yes,2,1000,2000,100,0,false,1900,190,2090\n" +
+				"Movie,3,TEST,Entertainment,No,1.5,150,225,0,10,true,213.02,1.98,215\n",
+			wantTaxObjects: []TaxObject{
+				{
+					Name:      "Big Mac",
+					TaxCode:   1,
+					Quantity:  2,
+					UnitPrice: 1000,
+					Price:     2000,
+					Discount:  &Discount{Type: DiscountFixed, Value: 100},
+				},
+				{
+					Name:         "Movie",
+					TaxCode:      3,
+					Jurisdiction: "TEST",
+					Quantity:     1.5,
+					UnitPrice:    150,
+					Price:        225,
+					TaxInclusive: true,
+				},
+			},
+		},
+		{
+			name:  "Stacked Taxes",
+			input: "name,tax_code,price,taxes\nLucky Stretch,2,1000,\"3; 1:Compound\"\nBig Mac,1,1000,\n",
+			wantTaxObjects: []TaxObject{
+				{Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, Price: 1000, Taxes: []Tax{{TaxCode: 3}, {TaxCode: 1, Compound: true}}},
+				{Name: "Big Mac", TaxCode: 1, Quantity: 1, Price: 1000},
+			},
+		},
+		{
+			name:           "Only Header",
+			input:          "name,tax_code\n",
+			wantTaxObjects: []TaxObject{},
+		},
+		{
+			name:    "Empty",
+			input:   "",
+			wantErr: "Line 1: The header is missing",
+		},
+		{
+			name:    "Unknown Column",
+			input:   "name,tax_code,vat\n",
+			wantErr: `Line 1: Unknown column "vat"`,
+		},
+		{
+			name:    "Missing Column",
+			input:   "name,price\n",
+			wantErr: `Line 1: The column "tax_code" is missing`,
+		},
+		{
+			name:    "Invalid Number",
+			input:   "name,tax_code,price\nBig Mac,1,1000\nLucky Stretch,two,1000\n",
+			wantErr: `Line 3: Invalid tax_code "two"`,
+		},
+		{
+			name:    "Invalid Number after Blank Lines",
+			input:   "name,tax_code,price\n\nBig Mac,1,1000\n\n\nLucky Stretch,two,1000\n",
+			wantErr: `Line 6: Invalid tax_code "two"`,
+		},
+		{
+			name:    "Invalid Number after Quoted Line Breaks",
+			input:   "name,tax_code,price\n\"Big Mac,\nLarge\",1,1000\nLucky Stretch,two,1000\n",
+			wantErr: `Line 4: Invalid tax_code "two"`,
+		},
+		{
+			name:    "Invalid Taxes",
+			input:   "name,tax_code,taxes\nLucky Stretch,2,1 compound\n",
+			wantErr: `Line 2: Invalid taxes "1 compound"`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotTaxObjects, err := ReadCSV(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantTaxObjects, gotTaxObjects)
+			}
+		})
+	}
+}
+
+func TestReadCSVLines(t *testing.T) {
+	t.Parallel()
+	input := "\nname,tax_code,price\nBig Mac,1,1000\n\n\"Lucky\nStretch\",2,1000\nMovie,3,150\n"
+	taxObjects, lines, err := ReadCSVLines(strings.NewReader(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, taxObjects, 3)
+	//The blank lines and the line breaks in the quoted cells are counted.
+	assert.Equal(t, []int{3, 5, 7}, lines)
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+	taxObjects := []TaxObject{
+		{ID: 1, Name: "Big Mac", TaxCode: 1, Quantity: 1, UnitPrice: 1000, Price: 1000},
+		{
+			ID:           2,
+			Name:         "Movie, 3D",
+			TaxCode:      3,
+			Jurisdiction: "TEST",
+			Quantity:     1.5,
+			UnitPrice:    150,
+			Price:        225,
+			Discount:     &Discount{Type: DiscountFixed, Value: 25},
+			TaxInclusive: true,
+			Taxes:        []Tax{{TaxCode: 2}, {TaxCode: 1, Compound: true}},
+		},
+	}
+	buffer := new(bytes.Buffer)
+	if !assert.NoError(t, WriteCSV(buffer, taxObjects)) {
+		return
+	}
+	assert.Equal(t, "id,name,tax_code,jurisdiction,quantity,unit_price,price,discount_type,discount_value,tax_inclusive,taxes\n"+
+		"1,Big Mac,1,,1,1000,1000,,0,false,\n"+
+		"2,\"Movie, 3D\",3,TEST,1.5,150,225,fixed,25,true,2;1:compound\n", buffer.String())
+
+	//The written tax objects are read back the same.
+	got, err := ReadCSV(buffer)
+	if assert.NoError(t, err) {
+		assert.Equal(t, taxObjects, got)
+	}
+}
@@ -3,6 +3,9 @@
 package delivery
 
 import (
+	"context"
+	"time"
+
 	taxobj "github.com/fairyhunter13/tax-calculator/internal/taxobj"
 )
 
@@ -11,7 +14,32 @@ type usecase struct {
 }
 
 // CreateTaxObject provides a mock function with given fields: _a0
-func (ucase *usecase) CreateTaxObject(taxObj *taxobj.TaxObject) error {
+func (ucase *usecase) CreateTaxObject(ctx context.Context, taxObj *taxobj.TaxObject) error {
 	taxObj.ID = 1
 	return nil
 }
+
+// UpdateTaxObject provides a mock function with given fields: _a0, _a1
+func (ucase *usecase) UpdateTaxObject(ctx context.Context, taxObj *taxobj.TaxObject) error {
+	return nil
+}
+
+// DeleteTaxObject provides a mock function with given fields: _a0, _a1
+func (ucase *usecase) DeleteTaxObject(ctx context.Context, id int64) error {
+	return nil
+}
+
+// RestoreTaxObject provides a mock function with given fields: _a0, _a1
+func (ucase *usecase) RestoreTaxObject(ctx context.Context, id int64) (taxobj.TaxObject, error) {
+	return taxobj.TaxObject{ID: id}, nil
+}
+
+// PurgeTaxObjects provides a mock function with given fields: _a0, _a1
+func (ucase *usecase) PurgeTaxObjects(ctx context.Context, retention time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// CheckBillLines provides a mock function with given fields: _a0, _a1
+func (ucase *usecase) CheckBillLines(ctx context.Context, lines int) error {
+	return nil
+}
@@ -1,12 +1,22 @@
 package delivery
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"sync"
 
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	authDelivery "github.com/fairyhunter13/tax-calculator/internal/auth/delivery"
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	billDelivery "github.com/fairyhunter13/tax-calculator/internal/bill/delivery"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
 	"github.com/labstack/echo"
 	"github.com/microcosm-cc/bluemonday"
+	"github.com/sirupsen/logrus"
 	validator "gopkg.in/go-playground/validator.v9"
 )
 
@@ -14,8 +24,24 @@ var (
 	//ErrInvalidInput defines the error response returned by the handler
 	//if the request is not valid JSON or have any invalid value.
 	ErrInvalidInput = echo.NewHTTPError(http.StatusBadRequest, "Invalid input")
+	//ErrJurisdictionNotFound defines the error response returned if the jurisdiction of the tax object isn't in the rules.
+	ErrJurisdictionNotFound = echo.NewHTTPError(http.StatusBadRequest, "Jurisdiction not found")
+	//ErrPriceMismatch defines the error response returned if the price of the tax object isn't its quantity times its unit price.
+	ErrPriceMismatch = echo.NewHTTPError(http.StatusBadRequest, "The price doesn't equal the quantity times the unit price")
+	//ErrNotFound defines the error response returned if the tax object doesn't exist in the tenant.
+	ErrNotFound = echo.NewHTTPError(http.StatusNotFound, "Tax object not found")
+	//ErrDeletedNotFound defines the error response returned if the deleted tax object doesn't exist in the tenant,
+	//e.g. it's not deleted or it has been purged.
+	ErrDeletedNotFound = echo.NewHTTPError(http.StatusNotFound, "Deleted tax object not found")
+	//ErrBillFull defines the error response returned if the bill has reached the maximum lines.
+	ErrBillFull = echo.NewHTTPError(http.StatusRequestEntityTooLarge, "The bill has reached the maximum lines")
+	//ErrFinalized defines the error response returned if the bill of the tax object has been finalized.
+	ErrFinalized = echo.NewHTTPError(http.StatusConflict, "The bill has been finalized")
 )
 
+//maxPreviewTaxObjects defines the maximum tax objects previewed in one request.
+const maxPreviewTaxObjects = 100
+
 var (
 	httpHandler      *HTTPTaxObjectHandler
 	once             sync.Once
@@ -32,33 +58,268 @@ func init() {
 }
 
 //HTTPTaxObjectHandler defines the http delivery layer for the tax object.
+//The bill usecase previews the bill lines of the tax objects that aren't created,
+//and the rules validate the jurisdictions and explain the lines, so they must be the rules calculating them.
 type HTTPTaxObjectHandler struct {
 	taxObjUcase taxobj.Usecase
+	billUcase   bill.Usecase
+	rules       taxcalc.Rules
+	log         logrus.FieldLogger
+}
+
+//PreviewResponse define the response of the preview,
+//i.e. the bill lines of the previewed tax objects and the total of the bill with them.
+type PreviewResponse struct {
+	Bills []bill.Bill `json:"bills"`
+	Total bill.Total  `json:"total"`
 }
 
 //NewTaxObjectHandler create the HTTPTaxObjectHandler with customed routing for echo.
-func NewTaxObjectHandler(e *echo.Echo, taxObjUcase taxobj.Usecase) {
+//Every route is protected by the guard with the permission of the route.
+func NewTaxObjectHandler(e *echo.Echo, taxObjUcase taxobj.Usecase, billUcase bill.Usecase, rules taxcalc.Rules, log logrus.FieldLogger, guard authDelivery.Guard) {
 	httpHandler = &HTTPTaxObjectHandler{
 		taxObjUcase,
+		billUcase,
+		rules,
+		log,
 	}
-	e.POST("/tax", httpHandler.CreateTaxObject)
+	e.POST("/tax", httpHandler.CreateTaxObject, guard.Protect(auth.PermissionCreateTax)...)
+	e.POST("/tax/preview", httpHandler.PreviewTaxObjects, guard.Protect(auth.PermissionPreviewTax)...)
+	e.PUT("/tax/:id", httpHandler.UpdateTaxObject, guard.Protect(auth.PermissionUpdateTax)...)
+	e.DELETE("/tax/:id", httpHandler.DeleteTaxObject, guard.Protect(auth.PermissionDeleteTax)...)
+	//Restoring undoes the deletion, so it requires the same permission.
+	e.POST("/tax/:id/restore", httpHandler.RestoreTaxObject, guard.Protect(auth.PermissionDeleteTax)...)
 }
 
 //CreateTaxObject handle request for creating the tax object.
 func (handler *HTTPTaxObjectHandler) CreateTaxObject(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPTaxObjectHandler.CreateTaxObject")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	taxObject := taxobj.TaxObject{}
+	if err = handler.bindAndValidate(ctx, c, &taxObject); err != nil {
+		return
+	}
+	handler.sanitize(ctx, &taxObject)
+	err = handler.taxObjUcase.CreateTaxObject(ctx, &taxObject)
+	if err == taxobj.ErrBillFull {
+		err = ErrBillFull
+		return
+	}
+	if err != nil {
+		return
+	}
+	err = c.JSON(http.StatusCreated, &taxObject)
+	return
+}
+
+//UpdateTaxObject handle request for correcting the tax object.
+func (handler *HTTPTaxObjectHandler) UpdateTaxObject(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPTaxObjectHandler.UpdateTaxObject")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
 	taxObject := taxobj.TaxObject{}
-	if err = c.Bind(&taxObject); err != nil {
+	if err = handler.bindAndValidate(ctx, c, &taxObject); err != nil {
+		return
+	}
+	handler.sanitize(ctx, &taxObject)
+	taxObject.ID = id
+	err = handler.taxObjUcase.UpdateTaxObject(ctx, &taxObject)
+	switch err {
+	case nil:
+	case taxobj.ErrNotFound:
+		err = ErrNotFound
+		return
+	case bill.ErrFinalized:
+		err = ErrFinalized
+		return
+	default:
+		return
+	}
+	err = c.JSON(http.StatusOK, &taxObject)
+	return
+}
+
+//DeleteTaxObject handle request for deleting the tax object.
+func (handler *HTTPTaxObjectHandler) DeleteTaxObject(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPTaxObjectHandler.DeleteTaxObject")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
 		err = ErrInvalidInput
 		return
 	}
-	if err = requestValidator.Struct(&taxObject); err != nil {
+	err = handler.taxObjUcase.DeleteTaxObject(ctx, id)
+	switch err {
+	case nil:
+	case taxobj.ErrNotFound:
+		err = ErrNotFound
+		return
+	case bill.ErrFinalized:
+		err = ErrFinalized
+		return
+	default:
+		return
+	}
+	err = c.NoContent(http.StatusNoContent)
+	return
+}
+
+//RestoreTaxObject handle request for restoring the deleted tax object.
+func (handler *HTTPTaxObjectHandler) RestoreTaxObject(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPTaxObjectHandler.RestoreTaxObject")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
 		err = ErrInvalidInput
 		return
 	}
-	taxObject.Name = sanitizer.Sanitize(taxObject.Name)
-	if err = handler.taxObjUcase.CreateTaxObject(&taxObject); err != nil {
+	taxObject, err := handler.taxObjUcase.RestoreTaxObject(ctx, id)
+	switch err {
+	case nil:
+	case taxobj.ErrNotFound:
+		err = ErrDeletedNotFound
+		return
+	case taxobj.ErrBillFull:
+		err = ErrBillFull
+		return
+	case bill.ErrFinalized:
+		err = ErrFinalized
+		return
+	default:
 		return
 	}
-	err = c.JSON(http.StatusCreated, &taxObject)
+	err = c.JSON(http.StatusOK, &taxObject)
+	return
+}
+
+//PreviewTaxObjects handle request for previewing the bill lines of the tax object or the list of the tax objects.
+//The tax objects are validated like the created tax objects, and rejected if the bill can't have them all,
+//but they are never stored.
+//The lines are explained if the explain query parameter is true.
+func (handler *HTTPTaxObjectHandler) PreviewTaxObjects(c echo.Context) (err error) {
+	ctx, span := tracing.Start(c.Request().Context(), "HTTPTaxObjectHandler.PreviewTaxObjects")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	explain, err := billDelivery.ParseExplain(c)
+	if err != nil {
+		err = ErrInvalidInput
+		return
+	}
+	taxObjects, err := handler.bindPreview(ctx, c)
+	if err != nil {
+		return
+	}
+	for index := range taxObjects {
+		if err = handler.validate(ctx, &taxObjects[index]); err != nil {
+			return
+		}
+		handler.sanitize(ctx, &taxObjects[index])
+	}
+	err = handler.taxObjUcase.CheckBillLines(ctx, len(taxObjects))
+	if err == taxobj.ErrBillFull {
+		err = ErrBillFull
+	}
+	if err != nil {
+		return
+	}
+	bills, total := handler.billUcase.PreviewBill(ctx, taxObjects)
+	if explain {
+		bills = taxcalc.ExplainAll(handler.rules, bills)
+	}
+	err = c.JSON(http.StatusOK, PreviewResponse{
+		Bills: bills,
+		Total: total,
+	})
+	return
+}
+
+//bindPreview bind the request body of the tax object or the list of the tax objects.
+//The quantity of every tax object is one unit if it's not given.
+func (handler *HTTPTaxObjectHandler) bindPreview(ctx context.Context, c echo.Context) (taxObjects []taxobj.TaxObject, err error) {
+	log := logger.FromContext(ctx, handler.log)
+	taxObjects, err = taxobj.ReadJSON(c.Request().Body)
+	if err != nil || len(taxObjects) == 0 || len(taxObjects) > maxPreviewTaxObjects {
+		log.WithError(err).WithField("count", len(taxObjects)).Warn("[HTTPTaxObjectHandler] Failed to bind the request")
+		err = ErrInvalidInput
+		return
+	}
+	return
+}
+
+//bindAndValidate bind the request body to the tax object, validate it, and derive its price.
+func (handler *HTTPTaxObjectHandler) bindAndValidate(ctx context.Context, c echo.Context, taxObject *taxobj.TaxObject) (err error) {
+	ctx, span := tracing.Start(ctx, "HTTPTaxObjectHandler.bindAndValidate")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	log := logger.FromContext(ctx, handler.log)
+	//The quantity is one unit if it's not given, so only the given quantity is validated.
+	taxObject.Quantity = 1
+	if err = c.Bind(taxObject); err != nil {
+		log.WithError(err).Warn("[HTTPTaxObjectHandler] Failed to bind the request")
+		err = ErrInvalidInput
+		return
+	}
+	return handler.validate(ctx, taxObject)
+}
+
+//validate validate the bound tax object and derive its price.
+func (handler *HTTPTaxObjectHandler) validate(ctx context.Context, taxObject *taxobj.TaxObject) (err error) {
+	log := logger.FromContext(ctx, handler.log)
+	err = Validate(taxObject, handler.rules)
+	switch {
+	case err == taxobj.ErrJurisdictionNotFound:
+		log.WithField("jurisdiction", taxObject.Jurisdiction).Warn("[HTTPTaxObjectHandler] Jurisdiction not found")
+		err = ErrJurisdictionNotFound
+	case err == taxobj.ErrPriceMismatch:
+		log.WithField("price", taxObject.Price).
+			WithField("quantity", taxObject.Quantity).
+			WithField("unit_price", taxObject.UnitPrice).
+			Warn("[HTTPTaxObjectHandler] Price mismatch")
+		err = ErrPriceMismatch
+	case err != nil:
+		log.WithError(err).Warn("[HTTPTaxObjectHandler] Failed to validate the request")
+		err = ErrInvalidInput
+	}
+	return
+}
+
+//Validate validate the tax object like the request creating it, and derive its price.
+//It returns the error of the validator, taxobj.ErrJurisdictionNotFound if the jurisdiction isn't in the rules,
+//or taxobj.ErrPriceMismatch if the price isn't the quantity times the unit price,
+//so the tax objects not sent to the handler, e.g. the tax objects calculated offline, are checked the same.
+//The rules must be the rules calculating the tax object, e.g. the jurisdictions loaded offline.
+func Validate(taxObject *taxobj.TaxObject, rules taxcalc.Rules) (err error) {
+	if err = requestValidator.Struct(taxObject); err != nil {
+		return
+	}
+	if !rules.HasJurisdiction(taxObject.Jurisdiction) {
+		err = taxobj.ErrJurisdictionNotFound
+		return
+	}
+	if err = taxObject.CheckPrice(); err != nil {
+		return
+	}
+	taxObject.Derive()
 	return
 }
+
+//sanitize sanitize the user input in the tax object.
+func (handler *HTTPTaxObjectHandler) sanitize(ctx context.Context, taxObject *taxobj.TaxObject) {
+	_, span := tracing.Start(ctx, "HTTPTaxObjectHandler.sanitize")
+	defer span.End()
+	taxObject.Name = sanitizer.Sanitize(taxObject.Name)
+}
@@ -11,10 +11,15 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	mocksBill "github.com/fairyhunter13/tax-calculator/internal/bill/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj/mocks"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 const (
@@ -51,10 +56,12 @@ func TestHTTPTaxObjectHandler_CreateTaxObject_Positive(t *testing.T) {
 	t.Parallel()
 	const logFail = `[TestHTTPTaxObjectHandler_CreateTaxObject_Positive] %s: %s`
 	expectedResp := taxobj.TaxObject{
-		ID:      1,
-		Name:    "MACD",
-		TaxCode: 1,
-		Price:   20000,
+		ID:        1,
+		Name:      "MACD",
+		TaxCode:   1,
+		Quantity:  1,
+		UnitPrice: 20000,
+		Price:     20000,
 	}
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(validJSON))
@@ -64,6 +71,8 @@ func TestHTTPTaxObjectHandler_CreateTaxObject_Positive(t *testing.T) {
 	taxUcase := &usecase{}
 	h := &HTTPTaxObjectHandler{
 		taxObjUcase: taxUcase,
+		rules:       taxcalc.NewJurisdictions(),
+		log:         logger.Discard(),
 	}
 
 	// Assertions using testify framework
@@ -86,9 +95,11 @@ func TestHTTPTaxObjectHandler_CreateTaxObject_InvalidJSONSyntax(t *testing.T) {
 	rec := httptest.NewRecorder()
 	ctx := e.NewContext(req, rec)
 	taxUcase := &mocks.Usecase{}
-	taxUcase.On("CreateTaxObject", new(taxobj.TaxObject)).Return(nil)
+	taxUcase.On("CreateTaxObject", mock.Anything, new(taxobj.TaxObject)).Return(nil)
 	h := &HTTPTaxObjectHandler{
 		taxObjUcase: taxUcase,
+		rules:       taxcalc.NewJurisdictions(),
+		log:         logger.Discard(),
 	}
 
 	// Assertions using testify framework
@@ -108,9 +119,11 @@ func TestHTTPTaxObjectHandler_CreateTaxObject_InvalidInput(t *testing.T) {
 	rec := httptest.NewRecorder()
 	ctx := e.NewContext(req, rec)
 	taxUcase := &mocks.Usecase{}
-	taxUcase.On("CreateTaxObject", new(taxobj.TaxObject)).Return(nil)
+	taxUcase.On("CreateTaxObject", mock.Anything, new(taxobj.TaxObject)).Return(nil)
 	h := &HTTPTaxObjectHandler{
 		taxObjUcase: taxUcase,
+		rules:       taxcalc.NewJurisdictions(),
+		log:         logger.Discard(),
 	}
 
 	// Assertions using testify framework
@@ -122,22 +135,88 @@ func TestHTTPTaxObjectHandler_CreateTaxObject_InvalidInput(t *testing.T) {
 	}
 }
 
+func TestHTTPTaxObjectHandler_CreateTaxObject_InvalidTaxes(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	body := `{"name":"Cigar","tax_code":2,"price":1000,"taxes":[{"tax_code":4,"compound":true}]}`
+	req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	taxUcase := &mocks.Usecase{}
+	h := &HTTPTaxObjectHandler{
+		taxObjUcase: taxUcase,
+		rules:       taxcalc.NewJurisdictions(),
+		log:         logger.Discard(),
+	}
+
+	err := h.CreateTaxObject(ctx)
+	if assert.Error(t, err) {
+		assert.Equal(t, fmt.Sprintf("%s", ErrInvalidInput), fmt.Sprintf("%s", err))
+	}
+	taxUcase.AssertNotCalled(t, "CreateTaxObject", mock.Anything, mock.Anything)
+}
+
+func TestHTTPTaxObjectHandler_CreateTaxObject_JurisdictionNotFound(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	body := `{"name":"Cigar","tax_code":2,"jurisdiction":"UNKNOWN","price":1000}`
+	req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	taxUcase := &mocks.Usecase{}
+	h := &HTTPTaxObjectHandler{
+		taxObjUcase: taxUcase,
+		rules:       taxcalc.NewJurisdictions(),
+		log:         logger.Discard(),
+	}
+
+	err := h.CreateTaxObject(ctx)
+	assert.Equal(t, ErrJurisdictionNotFound, err)
+	taxUcase.AssertNotCalled(t, "CreateTaxObject", mock.Anything, mock.Anything)
+}
+
+func TestHTTPTaxObjectHandler_CreateTaxObject_PriceMismatch(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	body := `{"name":"Movie","tax_code":3,"quantity":2,"unit_price":150,"price":150}`
+	req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	taxUcase := &mocks.Usecase{}
+	h := &HTTPTaxObjectHandler{
+		taxObjUcase: taxUcase,
+		rules:       taxcalc.NewJurisdictions(),
+		log:         logger.Discard(),
+	}
+
+	err := h.CreateTaxObject(ctx)
+	assert.Equal(t, ErrPriceMismatch, err)
+	taxUcase.AssertNotCalled(t, "CreateTaxObject", mock.Anything, mock.Anything)
+}
+
 func TestHTTPTaxObjectHandler_CreateTaxObject_InternalServerError(t *testing.T) {
 	t.Parallel()
 	e := echo.New()
 	arg := &taxobj.TaxObject{
-		Name:    "MACD",
-		TaxCode: 1,
-		Price:   20000,
+		Name:      "MACD",
+		TaxCode:   1,
+		Quantity:  1,
+		UnitPrice: 20000,
+		Price:     20000,
 	}
 	req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(validJSON))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	ctx := e.NewContext(req, rec)
 	taxUcase := &mocks.Usecase{}
-	taxUcase.On("CreateTaxObject", arg).Return(errDatabaseNotOnline)
+	taxUcase.On("CreateTaxObject", mock.Anything, arg).Return(errDatabaseNotOnline)
 	h := &HTTPTaxObjectHandler{
 		taxObjUcase: taxUcase,
+		rules:       taxcalc.NewJurisdictions(),
+		log:         logger.Discard(),
 	}
 
 	// Assertions using testify framework
@@ -149,11 +228,537 @@ func TestHTTPTaxObjectHandler_CreateTaxObject_InternalServerError(t *testing.T)
 	}
 }
 
+func TestHTTPTaxObjectHandler_CreateTaxObject_BillFull(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	arg := &taxobj.TaxObject{
+		Name:      "MACD",
+		TaxCode:   1,
+		Quantity:  1,
+		UnitPrice: 20000,
+		Price:     20000,
+	}
+	req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(validJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	taxUcase := &mocks.Usecase{}
+	taxUcase.On("CreateTaxObject", mock.Anything, arg).Return(taxobj.ErrBillFull)
+	h := &HTTPTaxObjectHandler{
+		taxObjUcase: taxUcase,
+		rules:       taxcalc.NewJurisdictions(),
+		log:         logger.Discard(),
+	}
+
+	err := h.CreateTaxObject(ctx)
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrBillFull, err)
+	}
+}
+
+func TestHTTPTaxObjectHandler_UpdateTaxObject(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		ucaseErr   error
+		wantStatus int
+		wantErr    error
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Positive Case",
+			id:         "1",
+			body:       validJSON,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:     "Tax object not found",
+			id:       "1",
+			body:     validJSON,
+			ucaseErr: taxobj.ErrNotFound,
+			wantErr:  ErrNotFound,
+		},
+		{
+			name:     "Bill has been finalized",
+			id:       "1",
+			body:     validJSON,
+			ucaseErr: bill.ErrFinalized,
+			wantErr:  ErrFinalized,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "abc",
+			body:    validJSON,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Invalid Input",
+			id:      "1",
+			body:    invalidInput,
+			wantErr: ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/tax/"+tt.id, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(tt.id)
+			taxUcase := &mocks.Usecase{}
+			taxUcase.On("UpdateTaxObject", mock.Anything, &taxobj.TaxObject{
+				ID:        1,
+				Name:      "MACD",
+				TaxCode:   1,
+				Quantity:  1,
+				UnitPrice: 20000,
+				Price:     20000,
+			}).Return(tt.ucaseErr)
+			h := &HTTPTaxObjectHandler{
+				taxObjUcase: taxUcase,
+				rules:       taxcalc.NewJurisdictions(),
+				log:         logger.Discard(),
+			}
+
+			err := h.UpdateTaxObject(ctx)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHTTPTaxObjectHandler_DeleteTaxObject(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		id         string
+		ucaseErr   error
+		wantStatus int
+		wantErr    error
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Positive Case",
+			id:         "1",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:     "Tax object not found",
+			id:       "1",
+			ucaseErr: taxobj.ErrNotFound,
+			wantErr:  ErrNotFound,
+		},
+		{
+			name:     "Bill has been finalized",
+			id:       "1",
+			ucaseErr: bill.ErrFinalized,
+			wantErr:  ErrFinalized,
+		},
+		{
+			name:     "Internal Server Error",
+			id:       "1",
+			ucaseErr: errDatabaseNotOnline,
+			wantErr:  errDatabaseNotOnline,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/tax/"+tt.id, nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(tt.id)
+			taxUcase := &mocks.Usecase{}
+			taxUcase.On("DeleteTaxObject", mock.Anything, int64(1)).Return(tt.ucaseErr)
+			h := &HTTPTaxObjectHandler{
+				taxObjUcase: taxUcase,
+				rules:       taxcalc.NewJurisdictions(),
+				log:         logger.Discard(),
+			}
+
+			err := h.DeleteTaxObject(ctx)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHTTPTaxObjectHandler_RestoreTaxObject(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		id         string
+		ucaseErr   error
+		wantStatus int
+		wantBody   string
+		wantErr    error
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Positive Case",
+			id:         "1",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":1,"name":"MACD","tax_code":1,"quantity":1,"unit_price":20000,"price":20000}`,
+		},
+		{
+			name:    "Invalid id",
+			id:      "one",
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:     "Deleted tax object not found",
+			id:       "1",
+			ucaseErr: taxobj.ErrNotFound,
+			wantErr:  ErrDeletedNotFound,
+		},
+		{
+			name:     "Bill is full",
+			id:       "1",
+			ucaseErr: taxobj.ErrBillFull,
+			wantErr:  ErrBillFull,
+		},
+		{
+			name:     "Internal Server Error",
+			id:       "1",
+			ucaseErr: errDatabaseNotOnline,
+			wantErr:  errDatabaseNotOnline,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/tax/"+tt.id+"/restore", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(tt.id)
+			taxUcase := &mocks.Usecase{}
+			taxUcase.On("RestoreTaxObject", mock.Anything, int64(1)).
+				Return(taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Quantity: 1, UnitPrice: 20000, Price: 20000}, tt.ucaseErr)
+			h := &HTTPTaxObjectHandler{
+				taxObjUcase: taxUcase,
+				rules:       taxcalc.NewJurisdictions(),
+				log:         logger.Discard(),
+			}
+
+			err := h.RestoreTaxObject(ctx)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantStatus, rec.Code)
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHTTPTaxObjectHandler_PreviewTaxObjects(t *testing.T) {
+	t.Parallel()
+	macd := taxobj.TaxObject{Name: "MACD", TaxCode: 1, Quantity: 1, UnitPrice: 20000, Price: 20000}
+	movie := taxobj.TaxObject{Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150, Price: 300}
+	tests := []struct {
+		name     string
+		body     string
+		want     []taxobj.TaxObject
+		ucaseErr error
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Single Tax Object",
+			body: `{"name":"MACD","tax_code":1,"price":20000}`,
+			want: []taxobj.TaxObject{macd},
+		},
+		{
+			name:     "Bill Full",
+			body:     `[{"name":"MACD","tax_code":1,"price":20000},{"name":"Movie","tax_code":3,"quantity":2,"unit_price":150}]`,
+			want:     []taxobj.TaxObject{macd, movie},
+			ucaseErr: taxobj.ErrBillFull,
+			wantErr:  ErrBillFull,
+		},
+		{
+			name: "Batch of Tax Objects",
+			body: `[{"name":"MACD","tax_code":1,"price":20000},{"name":"Movie","tax_code":3,"quantity":2,"unit_price":150}]`,
+			want: []taxobj.TaxObject{macd, movie},
+		},
+		{
+			name:    "Invalid JSON Syntax",
+			body:    `{"name":"MACD",`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Empty Batch",
+			body:    `[]`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Invalid Tax Object in the Batch",
+			body:    `[{"name":"MACD","tax_code":1,"price":20000},{"name":"Movie","tax_code":4,"price":150}]`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Jurisdiction Not Found",
+			body:    `{"name":"MACD","tax_code":1,"jurisdiction":"UNKNOWN","price":20000}`,
+			wantErr: ErrJurisdictionNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/tax/preview", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			taxUcase := &mocks.Usecase{}
+			taxUcase.On("CheckBillLines", mock.Anything, len(tt.want)).Return(tt.ucaseErr)
+			billUcase := &mocksBill.Usecase{}
+			bills := make([]bill.Bill, len(tt.want))
+			total := bill.Total{PriceSubtotal: 20000}
+			billUcase.On("PreviewBill", mock.Anything, tt.want).Return(bills, total)
+			h := &HTTPTaxObjectHandler{
+				taxObjUcase: taxUcase,
+				billUcase:   billUcase,
+				rules:       taxcalc.NewJurisdictions(),
+				log:         logger.Discard(),
+			}
+
+			err := h.PreviewTaxObjects(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			//Nothing is created by the preview.
+			taxUcase.AssertNotCalled(t, "CreateTaxObject", mock.Anything, mock.Anything)
+			if tt.wantErr != nil {
+				billUcase.AssertNotCalled(t, "PreviewBill", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(t, http.StatusOK, rec.Code)
+			got := PreviewResponse{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Error unmarshaling preview response: %s", err)
+			}
+			assert.Equal(t, PreviewResponse{Bills: bills, Total: total}, got)
+		})
+	}
+}
+
+func TestHTTPTaxObjectHandler_PreviewTaxObjects_Explain(t *testing.T) {
+	t.Parallel()
+	macd := taxobj.TaxObject{Name: "MACD", TaxCode: 1, Quantity: 1, UnitPrice: 20000, Price: 20000}
+	bills := []bill.Bill{
+		{
+			Name:     "MACD",
+			TaxCode:  1,
+			Quantity: 1,
+			Price:    20000,
+			Taxes:    []bill.TaxComponent{{TaxCode: 1, Type: "Food & Beverage", Base: 20000, Tax: 2000}},
+		},
+	}
+	for _, query := range []string{"?explain=true", "?explain=maybe"} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/tax/preview"+query, strings.NewReader(`{"name":"MACD","tax_code":1,"price":20000}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		taxUcase := &mocks.Usecase{}
+		taxUcase.On("CheckBillLines", mock.Anything, 1).Return(nil)
+		billUcase := &mocksBill.Usecase{}
+		billUcase.On("PreviewBill", mock.Anything, []taxobj.TaxObject{macd}).Return(bills, bill.Total{})
+		h := &HTTPTaxObjectHandler{
+			taxObjUcase: taxUcase,
+			billUcase:   billUcase,
+			rules:       taxcalc.NewJurisdictions(),
+			log:         logger.Discard(),
+		}
+		err := h.PreviewTaxObjects(ctx)
+		if query == "?explain=maybe" {
+			assert.Equal(t, ErrInvalidInput, err)
+			continue
+		}
+		got := PreviewResponse{}
+		if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Error unmarshaling preview response: %s", err)
+		}
+		if assert.NotNil(t, got.Bills[0].Explanation) {
+			assert.Equal(t, "1 * (0 + 10% * (20000 - 0)) = 2000", got.Bills[0].Explanation.Taxes[0].Formula)
+		}
+	}
+}
+
+func TestHTTPTaxObjectHandler_bindAndValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		body    string
+		want    taxobj.TaxObject
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Price Only",
+			body: `{"name":"MACD","tax_code":1,"price":20000}`,
+			want: taxobj.TaxObject{Name: "MACD", TaxCode: 1, Quantity: 1, UnitPrice: 20000, Price: 20000},
+		},
+		{
+			name: "Quantity and Unit Price",
+			body: `{"name":"MACD","tax_code":1,"quantity":3,"unit_price":5000}`,
+			want: taxobj.TaxObject{Name: "MACD", TaxCode: 1, Quantity: 3, UnitPrice: 5000, Price: 15000},
+		},
+		{
+			name: "Fractional Quantity",
+			body: `{"name":"Beef","tax_code":1,"quantity":1.5,"unit_price":100000}`,
+			want: taxobj.TaxObject{Name: "Beef", TaxCode: 1, Quantity: 1.5, UnitPrice: 100000, Price: 150000},
+		},
+		{
+			name: "Quantity of the Price",
+			body: `{"name":"MACD","tax_code":1,"quantity":2,"price":5000}`,
+			want: taxobj.TaxObject{Name: "MACD", TaxCode: 1, Quantity: 2, UnitPrice: 5000, Price: 10000},
+		},
+		{
+			name:    "Zero Quantity",
+			body:    `{"name":"MACD","tax_code":1,"quantity":0,"unit_price":5000}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Negative Quantity",
+			body:    `{"name":"MACD","tax_code":1,"quantity":-1,"unit_price":5000}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Negative Unit Price",
+			body:    `{"name":"MACD","tax_code":1,"quantity":1,"unit_price":-5000}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "No Price",
+			body:    `{"name":"MACD","tax_code":1,"quantity":1}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name: "Percent Discount",
+			body: `{"name":"MACD","tax_code":1,"price":20000,"discount":{"type":"percent","value":10}}`,
+			want: taxobj.TaxObject{
+				Name:      "MACD",
+				TaxCode:   1,
+				Quantity:  1,
+				UnitPrice: 20000,
+				Price:     20000,
+				Discount:  &taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10},
+			},
+		},
+		{
+			name:    "Invalid Discount Type",
+			body:    `{"name":"MACD","tax_code":1,"price":20000,"discount":{"type":"free","value":10}}`,
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "Negative Discount",
+			body:    `{"name":"MACD","tax_code":1,"price":20000,"discount":{"type":"fixed","value":-10}}`,
+			wantErr: ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/tax", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			ctx := e.NewContext(req, httptest.NewRecorder())
+			h := &HTTPTaxObjectHandler{
+				rules: taxcalc.NewJurisdictions(),
+				log:   logger.Discard(),
+			}
+			got := taxobj.TaxObject{}
+			err := h.bindAndValidate(req.Context(), ctx, &got)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	//The unit price is a variable, so its product is rounded like the product of the floats sent by the client.
+	unitPrice := 0.1
+	tests := []struct {
+		name          string
+		taxObject     taxobj.TaxObject
+		wantTaxObject taxobj.TaxObject
+		wantErr       error
+		wantInvalid   bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:          "Positive Case",
+			taxObject:     taxobj.TaxObject{Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150},
+			wantTaxObject: taxobj.TaxObject{Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150, Price: 300},
+		},
+		{
+			name:        "Invalid Tax Code",
+			taxObject:   taxobj.TaxObject{Name: "Movie", TaxCode: 4, Quantity: 1, Price: 150},
+			wantInvalid: true,
+		},
+		{
+			name:        "Zero Quantity",
+			taxObject:   taxobj.TaxObject{Name: "Movie", TaxCode: 3, Price: 150},
+			wantInvalid: true,
+		},
+		{
+			name:      "Jurisdiction Not Found",
+			taxObject: taxobj.TaxObject{Name: "Movie", TaxCode: 3, Quantity: 1, Price: 150, Jurisdiction: "UNKNOWN"},
+			wantErr:   taxobj.ErrJurisdictionNotFound,
+		},
+		{
+			name:          "Matching Price",
+			taxObject:     taxobj.TaxObject{Name: "Movie", TaxCode: 3, Quantity: 3, UnitPrice: unitPrice, Price: 0.3},
+			wantTaxObject: taxobj.TaxObject{Name: "Movie", TaxCode: 3, Quantity: 3, UnitPrice: unitPrice, Price: 3 * unitPrice},
+		},
+		{
+			name:      "Price Mismatch",
+			taxObject: taxobj.TaxObject{Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150, Price: 150},
+			wantErr:   taxobj.ErrPriceMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.taxObject, taxcalc.NewJurisdictions())
+			switch {
+			case tt.wantInvalid:
+				assert.Error(t, err)
+				assert.NotEqual(t, taxobj.ErrJurisdictionNotFound, err)
+			case tt.wantErr != nil:
+				assert.Equal(t, tt.wantErr, err)
+			default:
+				if assert.NoError(t, err) {
+					assert.Equal(t, tt.wantTaxObject, tt.taxObject)
+				}
+			}
+		})
+	}
+}
+
 func TestNewTaxObjectHandler(t *testing.T) {
 	t.Parallel()
 	type args struct {
 		e           *echo.Echo
 		taxObjUcase taxobj.Usecase
+		billUcase   bill.Usecase
 	}
 	tests := []struct {
 		name string
@@ -165,12 +770,13 @@ func TestNewTaxObjectHandler(t *testing.T) {
 			args: args{
 				e:           echo.New(),
 				taxObjUcase: &mocks.Usecase{},
+				billUcase:   &mocksBill.Usecase{},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			NewTaxObjectHandler(tt.args.e, tt.args.taxObjUcase)
+			NewTaxObjectHandler(tt.args.e, tt.args.taxObjUcase, tt.args.billUcase, taxcalc.NewJurisdictions(), logger.Discard(), nil)
 		})
 	}
 }
@@ -0,0 +1,36 @@
+package taxobj
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+//ReadJSON read the tax object or the list of the tax objects from the JSON document.
+//The quantity of every tax object is one unit if it's not given, like in the request without the quantity.
+//The tax objects are only parsed, so they must be validated before they're used.
+func ReadJSON(reader io.Reader) (taxObjects []TaxObject, err error) {
+	var (
+		body  json.RawMessage
+		items []json.RawMessage
+	)
+	if err = json.NewDecoder(reader).Decode(&body); err != nil {
+		return
+	}
+	if len(body) > 0 && body[0] == '[' {
+		if err = json.Unmarshal(body, &items); err != nil {
+			return
+		}
+	} else {
+		items = append(items, body)
+	}
+	taxObjects = make([]TaxObject, len(items))
+	for index, item := range items {
+		taxObjects[index].Quantity = 1
+		if err = json.Unmarshal(item, &taxObjects[index]); err != nil {
+			err = fmt.Errorf("Tax object %d: %s", index+1, err)
+			return nil, err
+		}
+	}
+	return
+}
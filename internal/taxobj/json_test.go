@@ -0,0 +1,64 @@
+// +build unit
+
+package taxobj
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadJSON(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		input          string
+		wantTaxObjects []TaxObject
+		wantErr        bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:  "Single Tax Object",
+			input: `{"name": "Big Mac", "tax_code": 1, "price": 1000}`,
+			wantTaxObjects: []TaxObject{
+				{Name: "Big Mac", TaxCode: 1, Quantity: 1, Price: 1000},
+			},
+		},
+		{
+			name:  "List of Tax Objects",
+			input: `[{"name": "Big Mac", "tax_code": 1, "price": 1000}, {"name": "Movie", "tax_code": 3, "quantity": 2, "unit_price": 150}]`,
+			wantTaxObjects: []TaxObject{
+				{Name: "Big Mac", TaxCode: 1, Quantity: 1, Price: 1000},
+				{Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150},
+			},
+		},
+		{
+			name:           "Empty List",
+			input:          `[]`,
+			wantTaxObjects: []TaxObject{},
+		},
+		{
+			name:    "Invalid JSON",
+			input:   `[{"name": "Big Mac"`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Tax Object",
+			input:   `[{"name": "Big Mac", "tax_code": 1}, {"name": "Movie", "tax_code": "3"}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotTaxObjects, err := ReadJSON(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantTaxObjects, gotTaxObjects)
+		})
+	}
+}
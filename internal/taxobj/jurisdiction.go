@@ -0,0 +1,21 @@
+package taxobj
+
+import (
+	"errors"
+
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+)
+
+//DefaultJurisdiction defines the jurisdiction of the tax objects without the jurisdiction.
+const DefaultJurisdiction = taxcalc.DefaultJurisdiction
+
+//ErrJurisdictionNotFound defines the error if the jurisdiction of the tax object or the charge isn't in the rules.
+var ErrJurisdictionNotFound = errors.New("Jurisdiction not found")
+
+//The rules of the jurisdictions are the rules of the calculation.
+type (
+	//Rule define the tax of the tax code in the jurisdiction.
+	Rule = taxcalc.Rule
+	//Jurisdiction define the rules of the tax codes in the country or the region.
+	Jurisdiction = taxcalc.Jurisdiction
+)
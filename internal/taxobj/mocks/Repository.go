@@ -2,8 +2,10 @@
 
 package mocks
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 import taxobj "github.com/fairyhunter13/tax-calculator/internal/taxobj"
+import time "time"
 
 // Repository is an autogenerated mock type for the Repository type
 type Repository struct {
@@ -15,13 +17,13 @@ func (_m *Repository) Close() {
 	_m.Called()
 }
 
-// Create provides a mock function with given fields: _a0
-func (_m *Repository) Create(_a0 *taxobj.TaxObject) error {
-	ret := _m.Called(_a0)
+// Create provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Create(_a0 context.Context, _a1 *taxobj.TaxObject) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*taxobj.TaxObject) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, *taxobj.TaxObject) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -29,13 +31,27 @@ func (_m *Repository) Create(_a0 *taxobj.TaxObject) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *Repository) GetAll() ([]taxobj.TaxObject, error) {
-	ret := _m.Called()
+// Delete provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Delete(_a0 context.Context, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: _a0
+func (_m *Repository) GetAll(_a0 context.Context) ([]taxobj.TaxObject, error) {
+	ret := _m.Called(_a0)
 
 	var r0 []taxobj.TaxObject
-	if rf, ok := ret.Get(0).(func() []taxobj.TaxObject); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []taxobj.TaxObject); ok {
+		r0 = rf(_a0)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]taxobj.TaxObject)
@@ -43,8 +59,31 @@ func (_m *Repository) GetAll() ([]taxobj.TaxObject, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTenants provides a mock function with given fields: _a0
+func (_m *Repository) GetTenants(_a0 context.Context) ([]string, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -65,3 +104,59 @@ func (_m *Repository) Migrate() error {
 
 	return r0
 }
+
+// Purge provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Purge(_a0 context.Context, _a1 time.Time) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Restore(_a0 context.Context, _a1 int64) (taxobj.TaxObject, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 taxobj.TaxObject
+	if rf, ok := ret.Get(0).(func(context.Context, int64) taxobj.TaxObject); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(taxobj.TaxObject)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: _a0, _a1
+func (_m *Repository) Update(_a0 context.Context, _a1 *taxobj.TaxObject) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *taxobj.TaxObject) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -2,21 +2,107 @@
 
 package mocks
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 import taxobj "github.com/fairyhunter13/tax-calculator/internal/taxobj"
+import time "time"
 
 // Usecase is an autogenerated mock type for the Usecase type
 type Usecase struct {
 	mock.Mock
 }
 
-// CreateTaxObject provides a mock function with given fields: _a0
-func (_m *Usecase) CreateTaxObject(_a0 *taxobj.TaxObject) error {
-	ret := _m.Called(_a0)
+// CheckBillLines provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) CheckBillLines(_a0 context.Context, _a1 int) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*taxobj.TaxObject) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateTaxObject provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) CreateTaxObject(_a0 context.Context, _a1 *taxobj.TaxObject) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *taxobj.TaxObject) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTaxObject provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) DeleteTaxObject(_a0 context.Context, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeTaxObjects provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) PurgeTaxObjects(_a0 context.Context, _a1 time.Duration) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreTaxObject provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) RestoreTaxObject(_a0 context.Context, _a1 int64) (taxobj.TaxObject, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 taxobj.TaxObject
+	if rf, ok := ret.Get(0).(func(context.Context, int64) taxobj.TaxObject); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(taxobj.TaxObject)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTaxObject provides a mock function with given fields: _a0, _a1
+func (_m *Usecase) UpdateTaxObject(_a0 context.Context, _a1 *taxobj.TaxObject) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *taxobj.TaxObject) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1,9 +1,21 @@
 package taxobj
 
+import (
+	"context"
+	"time"
+)
+
 //Repository define the required behavior of data management in the tax object.
+//All tax objects are scoped to the tenant in the context, except the purge of all tenants.
+//The deleted tax objects are soft deleted, so they can be restored until they are purged.
 type Repository interface {
-	GetAll() ([]TaxObject, error)
-	Create(*TaxObject) error
+	GetAll(context.Context) ([]TaxObject, error)
+	GetTenants(context.Context) ([]string, error)
+	Create(context.Context, *TaxObject) error
+	Update(context.Context, *TaxObject) error
+	Delete(context.Context, int64) error
+	Restore(context.Context, int64) (TaxObject, error)
+	Purge(context.Context, time.Time) (int64, error)
 	Close()
 	Migrate() error
 }
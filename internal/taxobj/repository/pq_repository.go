@@ -1,35 +1,138 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/metrics"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/sirupsen/logrus"
 )
 
 //PqRepository is the repository for managing the data using postgre.
+//Every change is recorded as the audit event in the same transaction,
+//and is bound to the open bill by the binder, so the tax objects of the finalized bill are never changed.
 type PqRepository struct {
 	pool      *sql.DB
+	binder    bill.Binder
+	recorder  audit.Recorder
+	log       logrus.FieldLogger
 	statement statement
 }
 
+//statement defines the prepared statements of the queries outside the transaction.
 type statement struct {
-	insert    *sql.Stmt
-	selectAll *sql.Stmt
+	selectAll     *sql.Stmt
+	selectTenants *sql.Stmt
 }
 
+//Query names used to label the database metrics.
+const (
+	nameInsert          = "insert"
+	nameUpdate          = "update"
+	nameDelete          = "delete"
+	nameRestore         = "restore"
+	namePurge           = "purge"
+	nameSelectForUpdate = "select_for_update"
+	nameSelectDeleted   = "select_deleted_for_update"
+	nameSelectAll       = "select_all"
+	nameSelectTenants   = "select_tenants"
+	nameSelectOne       = "select_one"
+	nameCreateTable     = "create_table"
+	nameAddTenant       = "add_tenant"
+	nameAddDeletedAt    = "add_deleted_at"
+	nameAddBillID       = "add_bill_id"
+	nameAddQuantity     = "add_quantity"
+	nameAddDiscount     = "add_discount"
+	nameAddTaxInclusive = "add_tax_inclusive"
+	nameAddTaxes        = "add_taxes"
+	nameAddJurisdiction = "add_jurisdiction"
+)
+
 const (
 	queryInsert = `
 		INSERT INTO tax_object
-			(id, name, tax_code, price)
+			(id, name, tax_code, quantity, unit_price, price, discount_type, discount_value, tax_inclusive, taxes, jurisdiction, tenant, bill_id)
 		VALUES
-			(DEFAULT, $1, $2, $3)
+			(DEFAULT, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 	`
+	queryUpdate = `
+		UPDATE tax_object
+		SET
+			name = $1, tax_code = $2, quantity = $3, unit_price = $4, price = $5, discount_type = $6, discount_value = $7,
+			tax_inclusive = $8, taxes = $9, jurisdiction = $10
+		WHERE
+			id = $11 AND tenant = $12 AND deleted_at IS NULL
+	`
+	//queryDelete soft deletes the tax object, so it's kept for the audit until it's purged.
+	queryDelete = `
+		UPDATE tax_object
+		SET
+			deleted_at = now()
+		WHERE
+			id = $1 AND tenant = $2 AND deleted_at IS NULL
+	`
+	queryRestore = `
+		UPDATE tax_object
+		SET
+			deleted_at = NULL
+		WHERE
+			id = $1 AND tenant = $2 AND deleted_at IS NOT NULL
+	`
+	//queryPurge return the purged tax objects, so every one of them is recorded as the audit event.
+	queryPurge = `
+		DELETE FROM tax_object
+		WHERE
+			deleted_at < $1
+		RETURNING
+			id, name, tax_code, quantity, COALESCE(unit_price, price), price, discount_type, discount_value, tax_inclusive, taxes, jurisdiction, tenant, COALESCE(bill_id, 0)
+	`
+	querySelectForUpdate = `
+		SELECT
+			id, name, tax_code, quantity, COALESCE(unit_price, price), price, discount_type, discount_value, tax_inclusive, taxes, jurisdiction, tenant, COALESCE(bill_id, 0)
+		FROM
+			tax_object
+		WHERE
+			id = $1 AND tenant = $2 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	querySelectDeleted = `
+		SELECT
+			id, name, tax_code, quantity, COALESCE(unit_price, price), price, discount_type, discount_value, tax_inclusive, taxes, jurisdiction, tenant, COALESCE(bill_id, 0)
+		FROM
+			tax_object
+		WHERE
+			id = $1 AND tenant = $2 AND deleted_at IS NOT NULL
+		FOR UPDATE
+	`
+	//querySelectAll return the tax objects of the open bill of the tenant.
 	querySelectAll = `
-		SELECT 
-			*
+		SELECT
+			id, name, tax_code, quantity, COALESCE(unit_price, price), price, discount_type, discount_value, tax_inclusive, taxes, jurisdiction, tenant, COALESCE(bill_id, 0)
 		FROM
 			tax_object
+		WHERE
+			tenant = $1 AND deleted_at IS NULL AND bill_id IN (
+				SELECT id FROM bill WHERE tenant = $1 AND finalized_at IS NULL
+			)
+		ORDER BY id
+	`
+	querySelectTenants = `
+		SELECT DISTINCT
+			tenant
+		FROM
+			tax_object
+		WHERE
+			deleted_at IS NULL
+		ORDER BY tenant
 	`
 	querySelectOne = `
 		SELECT
@@ -46,22 +149,83 @@ const (
 			price double precision NOT NULL
 		)
 	`
+	//queryAddTenant adds the tenant to the table created before the multi-tenancy.
+	//The existing tax objects belong to the default tenant.
+	queryAddTenant = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS tenant VARCHAR(255) NOT NULL DEFAULT 'default';
+		CREATE INDEX IF NOT EXISTS tax_object_tenant_idx ON tax_object (tenant)
+	`
+	//queryAddDeletedAt adds the soft delete to the table created before it.
+	//The partial index only covers the deleted tax objects waiting to be purged.
+	queryAddDeletedAt = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS deleted_at timestamp with time zone;
+		CREATE INDEX IF NOT EXISTS tax_object_deleted_at_idx ON tax_object (deleted_at) WHERE deleted_at IS NOT NULL
+	`
+	//queryAddBillID adds the bill to the table created before the bills.
+	//The existing tax objects are bound to the open bill of their tenant by the bill migration.
+	queryAddBillID = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS bill_id integer;
+		CREATE INDEX IF NOT EXISTS tax_object_bill_id_idx ON tax_object (bill_id)
+	`
+	//queryAddQuantity adds the quantity and the unit price to the table created before them.
+	//The existing tax objects are one unit of their price, so their unit price is read as their price.
+	queryAddQuantity = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS quantity double precision NOT NULL DEFAULT 1,
+			ADD COLUMN IF NOT EXISTS unit_price double precision
+	`
+	//queryAddDiscount adds the discount to the table created before it.
+	//The tax object without the discount has the empty discount type.
+	queryAddDiscount = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS discount_type VARCHAR(16) NOT NULL DEFAULT '',
+			ADD COLUMN IF NOT EXISTS discount_value double precision NOT NULL DEFAULT 0
+	`
+	//queryAddTaxInclusive adds the tax-inclusive flag to the table created before it.
+	//The existing tax objects have the price without the tax.
+	queryAddTaxInclusive = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS tax_inclusive boolean NOT NULL DEFAULT false
+	`
+	//queryAddTaxes adds the additional taxes to the table created before them.
+	//The existing tax objects only have the tax of their tax code.
+	queryAddTaxes = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS taxes jsonb NOT NULL DEFAULT '[]'
+	`
+	//queryAddJurisdiction adds the jurisdiction to the table created before it.
+	//The existing tax objects have the default jurisdiction.
+	queryAddJurisdiction = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS jurisdiction VARCHAR(32) NOT NULL DEFAULT ''
+	`
 )
 
 //NewPqRepository creates the pq repository for tax object with postgre connection.
-func NewPqRepository(pool *sql.DB) taxobj.Repository {
+func NewPqRepository(pool *sql.DB, binder bill.Binder, recorder audit.Recorder, log logrus.FieldLogger) taxobj.Repository {
 	return &PqRepository{
 		pool:      pool,
+		binder:    binder,
+		recorder:  recorder,
+		log:       log,
 		statement: statement{},
 	}
 }
 
-//GetAll return all tax objects in postgre.
-func (repo *PqRepository) GetAll() (taxObjects []taxobj.TaxObject, err error) {
+//GetAll return all tax objects of the tenant in ctx.
+func (repo *PqRepository) GetAll(ctx context.Context) (taxObjects []taxobj.TaxObject, err error) {
 	var (
 		taxObject taxobj.TaxObject
 	)
 	taxObjects = make([]taxobj.TaxObject, 0)
+	ctx, span := tracing.Start(ctx, "PqRepository.GetAll", tracing.Query(nameSelectAll, querySelectAll)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectAll, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
 
 	//Lazy init for preparing statement
 	if repo.statement.selectAll == nil {
@@ -72,20 +236,13 @@ func (repo *PqRepository) GetAll() (taxObjects []taxobj.TaxObject, err error) {
 		repo.statement.selectAll = stmt
 	}
 
-	rows, err := repo.statement.selectAll.Query()
+	rows, err := repo.statement.selectAll.QueryContext(ctx, tenant.FromContext(ctx))
 	if err != nil {
 		return
 	}
 	defer rows.Close()
 	for rows.Next() {
-		taxObject = taxobj.TaxObject{}
-		err = rows.Scan(
-			&taxObject.ID,
-			&taxObject.Name,
-			&taxObject.TaxCode,
-			&taxObject.Price,
-		)
-		if err != nil {
+		if taxObject, err = scan(rows); err != nil {
 			return
 		}
 		taxObjects = append(taxObjects, taxObject)
@@ -96,43 +253,458 @@ func (repo *PqRepository) GetAll() (taxObjects []taxobj.TaxObject, err error) {
 	return
 }
 
-//Create create a new tax object in the database.
-func (repo *PqRepository) Create(taxObj *taxobj.TaxObject) (err error) {
+//GetTenants return all tenants having the tax objects.
+func (repo *PqRepository) GetTenants(ctx context.Context) (tenants []string, err error) {
+	tenants = make([]string, 0)
+	ctx, span := tracing.Start(ctx, "PqRepository.GetTenants", tracing.Query(nameSelectTenants, querySelectTenants)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameSelectTenants, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+
 	//Lazy init for preparing statement
-	if repo.statement.insert == nil {
-		stmt, err := repo.pool.Prepare(queryInsert)
+	if repo.statement.selectTenants == nil {
+		stmt, err := repo.pool.Prepare(querySelectTenants)
 		if err != nil {
-			return err
+			return tenants, err
+		}
+		repo.statement.selectTenants = stmt
+	}
+
+	rows, err := repo.statement.selectTenants.QueryContext(ctx)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return
+		}
+		tenants = append(tenants, name)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//Create create a new tax object of the tenant in ctx in the open bill of the tenant in the database.
+func (repo *PqRepository) Create(ctx context.Context, taxObj *taxobj.TaxObject) (err error) {
+	ctx, span := tracing.Start(ctx, "PqRepository.Create", tracing.Query(nameInsert, queryInsert)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameInsert, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+	taxObj.Tenant = tenant.FromContext(ctx)
+	discount := discountOf(taxObj)
+	taxes, err := taxesOf(taxObj)
+	if err != nil {
+		return
+	}
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		if taxObj.BillID, err = repo.binder.OpenBill(ctx, tx); err != nil {
+			return
 		}
-		repo.statement.insert = stmt
+		row := tx.QueryRowContext(
+			ctx,
+			queryInsert,
+			taxObj.Name,
+			taxObj.TaxCode,
+			taxObj.Quantity,
+			taxObj.UnitPrice,
+			taxObj.Price,
+			discount.Type,
+			discount.Value,
+			taxObj.TaxInclusive,
+			taxes,
+			taxObj.Jurisdiction,
+			taxObj.Tenant,
+			taxObj.BillID,
+		)
+		if err = row.Scan(&taxObj.ID); err != nil {
+			return
+		}
+		return repo.record(ctx, tx, audit.ActionCreate, taxObj.ID, nil, *taxObj)
+	})
+	return
+}
+
+//Update update the tax object of the tenant in ctx in the database.
+func (repo *PqRepository) Update(ctx context.Context, taxObj *taxobj.TaxObject) (err error) {
+	ctx, span := tracing.Start(ctx, "PqRepository.Update", tracing.Query(nameUpdate, queryUpdate)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameUpdate, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+	taxObj.Tenant = tenant.FromContext(ctx)
+	discount := discountOf(taxObj)
+	taxes, err := taxesOf(taxObj)
+	if err != nil {
+		return
 	}
-	row := repo.statement.insert.QueryRow(taxObj.Name, taxObj.TaxCode, taxObj.Price)
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		before, err := repo.selectForUpdate(ctx, tx, taxObj.ID)
+		if err != nil {
+			return
+		}
+		if err = repo.binder.CheckOpen(ctx, tx, before.BillID); err != nil {
+			return
+		}
+		taxObj.BillID = before.BillID
+		result, err := tx.ExecContext(
+			ctx,
+			queryUpdate,
+			taxObj.Name,
+			taxObj.TaxCode,
+			taxObj.Quantity,
+			taxObj.UnitPrice,
+			taxObj.Price,
+			discount.Type,
+			discount.Value,
+			taxObj.TaxInclusive,
+			taxes,
+			taxObj.Jurisdiction,
+			taxObj.ID,
+			taxObj.Tenant,
+		)
+		if err != nil {
+			return
+		}
+		if err = affected(result); err != nil {
+			return
+		}
+		return repo.record(ctx, tx, audit.ActionUpdate, taxObj.ID, before, *taxObj)
+	})
+	return
+}
 
+//Delete soft delete the tax object of the tenant in ctx in the database.
+//The deleted tax object is hidden until it's restored or purged.
+func (repo *PqRepository) Delete(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "PqRepository.Delete", tracing.Query(nameDelete, queryDelete)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameDelete, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		before, err := repo.selectForUpdate(ctx, tx, id)
+		if err != nil {
+			return
+		}
+		if err = repo.binder.CheckOpen(ctx, tx, before.BillID); err != nil {
+			return
+		}
+		result, err := tx.ExecContext(ctx, queryDelete, id, tenant.FromContext(ctx))
+		if err != nil {
+			return
+		}
+		if err = affected(result); err != nil {
+			return
+		}
+		return repo.record(ctx, tx, audit.ActionDelete, id, before, nil)
+	})
+	return
+}
+
+//Restore restore the soft deleted tax object of the tenant in ctx and return it.
+func (repo *PqRepository) Restore(ctx context.Context, id int64) (taxObj taxobj.TaxObject, err error) {
+	ctx, span := tracing.Start(ctx, "PqRepository.Restore", tracing.Query(nameRestore, queryRestore)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, nameRestore, begin, queryError(err))
+		tracing.End(span, queryError(err))
+	}(time.Now())
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		taxObj, err = repo.selectDeletedForUpdate(ctx, tx, id)
+		if err != nil {
+			return
+		}
+		if err = repo.binder.CheckOpen(ctx, tx, taxObj.BillID); err != nil {
+			return
+		}
+		result, err := tx.ExecContext(ctx, queryRestore, id, tenant.FromContext(ctx))
+		if err != nil {
+			return
+		}
+		if err = affected(result); err != nil {
+			return
+		}
+		return repo.record(ctx, tx, audit.ActionRestore, id, nil, taxObj)
+	})
+	return
+}
+
+//Purge permanently delete the tax objects of all tenants soft deleted before the given time.
+//Every purged tax object is recorded as the audit event of its tenant in the same transaction,
+//and their previous audit events are kept, so the purged tax objects can still be reviewed.
+func (repo *PqRepository) Purge(ctx context.Context, before time.Time) (purged int64, err error) {
+	ctx, span := tracing.Start(ctx, "PqRepository.Purge", tracing.Query(namePurge, queryPurge)...)
+	defer func(begin time.Time) {
+		repo.observe(ctx, namePurge, begin, err)
+		tracing.End(span, err)
+	}(time.Now())
+	err = repo.transaction(ctx, func(tx *sql.Tx) (err error) {
+		taxObjects, err := repo.purge(ctx, tx, before)
+		if err != nil {
+			return
+		}
+		for _, taxObj := range taxObjects {
+			if err = repo.record(tenant.WithTenant(ctx, taxObj.Tenant), tx, audit.ActionPurge, taxObj.ID, taxObj, nil); err != nil {
+				return
+			}
+		}
+		purged = int64(len(taxObjects))
+		return
+	})
+	if err != nil {
+		purged = 0
+	}
+	return
+}
+
+//purge delete the tax objects soft deleted before the given time in the transaction and return them.
+//The rows are read before the audit events are recorded, so the connection is free for their queries.
+func (repo *PqRepository) purge(ctx context.Context, tx *sql.Tx, before time.Time) (taxObjects []taxobj.TaxObject, err error) {
+	rows, err := tx.QueryContext(ctx, queryPurge, before)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var taxObj taxobj.TaxObject
+		if taxObj, err = scan(rows); err != nil {
+			return
+		}
+		taxObjects = append(taxObjects, taxObj)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+//selectForUpdate return the tax object of the tenant in ctx and locks it until the end of the transaction.
+//The soft deleted tax object is not found.
+func (repo *PqRepository) selectForUpdate(ctx context.Context, tx *sql.Tx, id int64) (taxObj taxobj.TaxObject, err error) {
+	return repo.selectRowForUpdate(ctx, tx, nameSelectForUpdate, querySelectForUpdate, id)
+}
+
+//selectDeletedForUpdate return the soft deleted tax object of the tenant in ctx
+//and locks it until the end of the transaction.
+func (repo *PqRepository) selectDeletedForUpdate(ctx context.Context, tx *sql.Tx, id int64) (taxObj taxobj.TaxObject, err error) {
+	return repo.selectRowForUpdate(ctx, tx, nameSelectDeleted, querySelectDeleted, id)
+}
+
+//selectRowForUpdate return the tax object of the tenant in ctx selected by the query
+//and locks it until the end of the transaction.
+func (repo *PqRepository) selectRowForUpdate(ctx context.Context, tx *sql.Tx, name string, query string, id int64) (taxObj taxobj.TaxObject, err error) {
+	begin := time.Now()
+	defer func() {
+		repo.observe(ctx, name, begin, queryError(err))
+	}()
+	taxObj, err = scan(tx.QueryRowContext(ctx, query, id, tenant.FromContext(ctx)))
+	if err == sql.ErrNoRows {
+		err = taxobj.ErrNotFound
+	}
+	return
+}
+
+//scanner defines the row of the tax object, either from a single row or from the rows of the query.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//scan return the tax object in the row with all its columns.
+func scan(row scanner) (taxObj taxobj.TaxObject, err error) {
+	var (
+		discount taxobj.Discount
+		taxes    []byte
+	)
 	err = row.Scan(
 		&taxObj.ID,
+		&taxObj.Name,
+		&taxObj.TaxCode,
+		&taxObj.Quantity,
+		&taxObj.UnitPrice,
+		&taxObj.Price,
+		&discount.Type,
+		&discount.Value,
+		&taxObj.TaxInclusive,
+		&taxes,
+		&taxObj.Jurisdiction,
+		&taxObj.Tenant,
+		&taxObj.BillID,
 	)
-
+	if err != nil {
+		return
+	}
+	taxObj.Discount = discount.OrNil()
+	taxObj.Taxes, err = taxesFrom(taxes)
 	return
 }
 
+//record records the audit event of the change to the tax object in the transaction.
+func (repo *PqRepository) record(ctx context.Context, tx *sql.Tx, action string, id int64, before interface{}, after interface{}) (err error) {
+	event, err := audit.NewEvent(ctx, action, audit.EntityTaxObject, id, before, after)
+	if err != nil {
+		return
+	}
+	return repo.recorder.Record(ctx, tx, &event)
+}
+
+//transaction runs the function in the transaction.
+//The transaction is committed if the function succeeds, otherwise it is rolled back.
+func (repo *PqRepository) transaction(ctx context.Context, function func(tx *sql.Tx) error) (err error) {
+	tx, err := repo.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	if err = function(tx); err != nil {
+		tx.Rollback()
+		return
+	}
+	return tx.Commit()
+}
+
 //Migrate create the table in the database if it doesn't exist.
 func (repo *PqRepository) Migrate() (err error) {
+	ctx, span := tracing.Start(context.Background(), "PqRepository.Migrate")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	var id int64
-	row := repo.pool.QueryRow(querySelectOne)
+	begin := time.Now()
+	row := repo.pool.QueryRowContext(ctx, querySelectOne)
 	err = row.Scan(&id)
 	if err == nil || err == sql.ErrNoRows {
-		return nil
+		metrics.ObserveQuery(nameSelectOne, begin, nil)
+	} else {
+		//The select query is expected to fail if the table doesn't exist yet.
+		metrics.ObserveQuery(nameSelectOne, begin, err)
+		repo.log.WithError(err).Info("[PqRepository] Creating the tax_object table")
+		begin = time.Now()
+		_, err = repo.pool.ExecContext(ctx, queryCreateTable)
+		repo.observe(ctx, nameCreateTable, begin, err)
+		if err != nil {
+			return
+		}
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddTenant)
+	repo.observe(ctx, nameAddTenant, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddDeletedAt)
+	repo.observe(ctx, nameAddDeletedAt, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddBillID)
+	repo.observe(ctx, nameAddBillID, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddQuantity)
+	repo.observe(ctx, nameAddQuantity, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddDiscount)
+	repo.observe(ctx, nameAddDiscount, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddTaxInclusive)
+	repo.observe(ctx, nameAddTaxInclusive, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddTaxes)
+	repo.observe(ctx, nameAddTaxes, begin, err)
+	if err != nil {
+		return
+	}
+	begin = time.Now()
+	_, err = repo.pool.ExecContext(ctx, queryAddJurisdiction)
+	repo.observe(ctx, nameAddJurisdiction, begin, err)
+	return
+}
+
+//observe records the metrics of the query started at begin and logs the error if the query failed.
+func (repo *PqRepository) observe(ctx context.Context, query string, begin time.Time, err error) {
+	metrics.ObserveQuery(query, begin, err)
+	if err != nil {
+		logger.FromContext(ctx, repo.log).
+			WithError(err).
+			WithField("query", query).
+			Error("[PqRepository] Query failed")
+	}
+}
+
+//discountOf return the discount of the tax object stored in the columns,
+//the tax object without the discount is stored with the empty discount type.
+func discountOf(taxObj *taxobj.TaxObject) (discount taxobj.Discount) {
+	if taxObj.Discount != nil {
+		discount = *taxObj.Discount
 	}
-	_, err = repo.pool.Exec(queryCreateTable)
 	return
 }
 
+//taxesOf return the additional taxes of the tax object stored in the JSON column,
+//the tax object without the additional taxes is stored with the empty list.
+func taxesOf(taxObj *taxobj.TaxObject) (taxes string, err error) {
+	list := taxObj.Taxes
+	if list == nil {
+		list = make([]taxobj.Tax, 0)
+	}
+	data, err := json.Marshal(list)
+	return string(data), err
+}
+
+//taxesFrom return the additional taxes stored in the JSON column,
+//or nil if the tax object only has the tax of its tax code.
+func taxesFrom(data []byte) (taxes []taxobj.Tax, err error) {
+	if err = json.Unmarshal(data, &taxes); err != nil || len(taxes) == 0 {
+		taxes = nil
+	}
+	return
+}
+
+//affected return ErrNotFound if the query doesn't affect any tax object,
+//i.e. the tax object doesn't exist in the tenant.
+func affected(result sql.Result) (err error) {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if rows == 0 {
+		err = taxobj.ErrNotFound
+	}
+	return
+}
+
+//queryError return the error of the query itself.
+//The missing tax object and the finalized bill are not the failures of the query.
+func queryError(err error) error {
+	if err == taxobj.ErrNotFound || err == bill.ErrFinalized {
+		return nil
+	}
+	return err
+}
+
 //Close close all prepared statements in this repository.
 func (repo *PqRepository) Close() {
-	if repo.statement.insert != nil {
-		repo.statement.insert.Close()
-	}
 	if repo.statement.selectAll != nil {
 		repo.statement.selectAll.Close()
 	}
+	if repo.statement.selectTenants != nil {
+		repo.statement.selectTenants.Close()
+	}
 }
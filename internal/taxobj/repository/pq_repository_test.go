@@ -3,16 +3,25 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"log"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	testify "github.com/stretchr/testify/mock"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	mocksAudit "github.com/fairyhunter13/tax-calculator/internal/audit/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	mocksBill "github.com/fairyhunter13/tax-calculator/internal/bill/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
 )
 
 const (
@@ -24,8 +33,56 @@ const (
 		RETURNING id
 	`
 	regexQuerySelectAll = `
-		SELECT 
-			\*
+		SELECT
+			(.+)
+		FROM
+			tax_object
+		WHERE
+			tenant = (.+)
+	`
+	regexQueryUpdate = `
+		UPDATE tax_object
+		SET
+			(.+)
+	`
+	regexQueryDelete = `
+		UPDATE tax_object
+		SET
+			deleted_at = now(.+)
+	`
+	regexQueryRestore = `
+		UPDATE tax_object
+		SET
+			deleted_at = NULL
+		WHERE
+			(.+)
+	`
+	regexQueryPurge = `
+		DELETE FROM tax_object
+		WHERE
+			deleted_at < (.+)
+	`
+	regexQuerySelectForUpdate = `
+		SELECT
+			(.+)
+		FROM
+			tax_object
+		WHERE
+			id = (.+)
+		FOR UPDATE
+	`
+	regexQuerySelectDeleted = `
+		SELECT
+			(.+)
+		FROM
+			tax_object
+		WHERE
+			id = (.+) AND deleted_at IS NOT NULL
+		FOR UPDATE
+	`
+	regexQuerySelectTenants = `
+		SELECT DISTINCT
+			tenant
 		FROM
 			tax_object
 	`
@@ -39,12 +96,45 @@ const (
 	regexQueryCreateTable = `
 		CREATE TABLE tax_object (.+)
 	`
+	regexQueryAddTenant = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS tenant (.+)
+	`
+	regexQueryAddDeletedAt = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS deleted_at (.+)
+	`
+	regexQueryAddBillID = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS bill_id (.+)
+	`
+	regexQueryAddQuantity = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS quantity (.+)
+	`
+	regexQueryAddDiscount = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS discount_type (.+)
+	`
+	regexQueryAddTaxInclusive = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS tax_inclusive (.+)
+	`
+	regexQueryAddTaxes = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS taxes (.+)
+	`
+	regexQueryAddJurisdiction = `
+		ALTER TABLE tax_object
+			ADD COLUMN IF NOT EXISTS jurisdiction (.+)
+	`
 )
 
 var (
 	errPreparingStatement = errors.New("Error preparing the statement")
 	errQuerying           = errors.New("Error in querying rows")
 	errRelationNotExist   = errors.New("Relation still doesn't exist")
+	errBeginning          = errors.New("Error beginning the transaction")
 )
 
 func TestPqRepository_GetAll(t *testing.T) {
@@ -64,22 +154,27 @@ func TestPqRepository_GetAll(t *testing.T) {
 				if err != nil {
 					t.Errorf(logFail, "Error starting the mocker", err)
 				}
-				resultRow := sqlmock.NewRows([]string{"id", "name", "tax_code", "price"})
-				resultRow.AddRow(1, "MACD", 1, 20000)
+				resultRow := sqlmock.NewRows([]string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant", "bill_id"})
+				resultRow.AddRow(1, "MACD", 1, 1, 20000, 20000, "", 0, false, []byte(`[]`), "", tenant.Default, 7)
 				//Init the mock!
 				mock.ExpectPrepare(regexQuerySelectAll)
 				mock.ExpectQuery(regexQuerySelectAll).
+					WithArgs(tenant.Default).
 					WillReturnRows(resultRow)
 
-				repo := NewPqRepository(db)
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
 				return repo.(*PqRepository), mock, db
 			},
 			wantTaxObjects: []taxobj.TaxObject{
 				taxobj.TaxObject{
-					ID:      1,
-					Name:    "MACD",
-					TaxCode: 1,
-					Price:   20000,
+					ID:        1,
+					Name:      "MACD",
+					TaxCode:   1,
+					Quantity:  1,
+					UnitPrice: 20000,
+					Price:     20000,
+					BillID:    7,
+					Tenant:    tenant.Default,
 				},
 			},
 			wantErr: false,
@@ -94,7 +189,7 @@ func TestPqRepository_GetAll(t *testing.T) {
 				//Init the mock!
 				mock.ExpectPrepare(regexQuerySelectAll).WillReturnError(errPreparingStatement)
 
-				repo := NewPqRepository(db)
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
 				return repo.(*PqRepository), mock, db
 			},
 			wantTaxObjects: []taxobj.TaxObject{},
@@ -112,7 +207,7 @@ func TestPqRepository_GetAll(t *testing.T) {
 				mock.ExpectQuery(regexQuerySelectAll).
 					WillReturnError(errQuerying)
 
-				repo := NewPqRepository(db)
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
 				return repo.(*PqRepository), mock, db
 			},
 			wantTaxObjects: []taxobj.TaxObject{},
@@ -132,7 +227,7 @@ func TestPqRepository_GetAll(t *testing.T) {
 				mock.ExpectQuery(regexQuerySelectAll).
 					WillReturnRows(resultRow)
 
-				repo := NewPqRepository(db)
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
 				return repo.(*PqRepository), mock, db
 			},
 			wantTaxObjects: []taxobj.TaxObject{},
@@ -143,7 +238,7 @@ func TestPqRepository_GetAll(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo, mock, db := tt.customFunc()
 			defer db.Close()
-			gotTaxObjects, err := repo.GetAll()
+			gotTaxObjects, err := repo.GetAll(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("PqRepository.GetAll() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -158,6 +253,87 @@ func TestPqRepository_GetAll(t *testing.T) {
 	}
 }
 
+func TestPqRepository_GetAll_Tenant(t *testing.T) {
+	t.Parallel()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error starting the mocker: %s", err)
+	}
+	defer db.Close()
+	resultRow := sqlmock.NewRows([]string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant", "bill_id"})
+	resultRow.AddRow(2, "Lucky Stretch", 2, 1, 1000, 1000, "", 0, false, []byte(`[]`), "", "merchant-a", 7)
+	mock.ExpectPrepare(regexQuerySelectAll)
+	mock.ExpectQuery(regexQuerySelectAll).
+		WithArgs("merchant-a").
+		WillReturnRows(resultRow)
+	repo := NewPqRepository(db, nil, nil, logger.Discard())
+
+	taxObjects, err := repo.GetAll(tenant.WithTenant(context.Background(), "merchant-a"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []taxobj.TaxObject{
+			{ID: 2, Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, UnitPrice: 1000, Price: 1000, BillID: 7, Tenant: "merchant-a"},
+		}, taxObjects)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("PqRepository.GetAll() mock expectation were not met: %s", err)
+	}
+}
+
+func TestPqRepository_GetTenants(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		expect      func(mock sqlmock.Sqlmock)
+		wantTenants []string
+		wantErr     bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				resultRow := sqlmock.NewRows([]string{"tenant"})
+				resultRow.AddRow(tenant.Default)
+				resultRow.AddRow("merchant-a")
+				mock.ExpectPrepare(regexQuerySelectTenants)
+				mock.ExpectQuery(regexQuerySelectTenants).
+					WillReturnRows(resultRow)
+			},
+			wantTenants: []string{tenant.Default, "merchant-a"},
+			wantErr:     false,
+		},
+		{
+			name: "Error querying rows",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(regexQuerySelectTenants)
+				mock.ExpectQuery(regexQuerySelectTenants).
+					WillReturnError(errQuerying)
+			},
+			wantTenants: []string{},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error starting the mocker: %s", err)
+			}
+			defer db.Close()
+			tt.expect(mock)
+			repo := NewPqRepository(db, nil, nil, logger.Discard())
+			gotTenants, err := repo.GetTenants(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.GetTenants() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantTenants, gotTenants)
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.GetTenants() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
 func TestPqRepository_Create(t *testing.T) {
 	t.Parallel()
 	const logFail = `[TestPqRepository_Create] %s: %s`
@@ -181,42 +357,89 @@ func TestPqRepository_Create(t *testing.T) {
 				resultRow := sqlmock.NewRows([]string{"id"})
 				resultRow.AddRow(1)
 				//Init the mock!
-				mock.ExpectPrepare(regexQueryInsert)
+				mock.ExpectBegin()
 				mock.ExpectQuery(regexQueryInsert).
-					WithArgs("MACD", 1, float64(20000)).
+					WithArgs("MACD", 1, float64(2), float64(10000), float64(20000), "", float64(0), false, "[]", "", tenant.Default, int64(7)).
 					WillReturnRows(resultRow)
+				mock.ExpectCommit()
+				binder := &mocksBill.Binder{}
+				binder.On("OpenBill", testify.Anything, testify.Anything).Return(int64(7), nil)
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionCreate &&
+						event.EntityID == 1 &&
+						event.Before == nil &&
+						string(event.After) == `{"id":1,"name":"MACD","tax_code":1,"quantity":2,"unit_price":10000,"price":20000,"bill_id":7}`
+				})).Return(nil)
 
-				repo := NewPqRepository(db)
+				repo := NewPqRepository(db, binder, recorder, logger.Discard())
 				return repo.(*PqRepository), mock, db
 			},
 			args: args{
 				taxObj: &taxobj.TaxObject{
-					Name:    "MACD",
-					TaxCode: 1,
-					Price:   20000,
+					Name:      "MACD",
+					TaxCode:   1,
+					Quantity:  2,
+					UnitPrice: 10000,
+					Price:     20000,
 				},
 			},
 			wantErr: false,
 		},
 		{
-			name: "Error in preparing statement",
+			name: "Error in recording the audit event",
 			customFunc: func() (*PqRepository, sqlmock.Sqlmock, *sql.DB) {
 				db, mock, err := sqlmock.New()
 				if err != nil {
 					t.Errorf(logFail, "Error starting the mocker", err)
 				}
+				resultRow := sqlmock.NewRows([]string{"id"})
+				resultRow.AddRow(1)
 				//Init the mock!
-				mock.ExpectPrepare(regexQueryInsert).
-					WillReturnError(errPreparingStatement)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQueryInsert).
+					WillReturnRows(resultRow)
+				mock.ExpectRollback()
+				binder := &mocksBill.Binder{}
+				binder.On("OpenBill", testify.Anything, testify.Anything).Return(int64(7), nil)
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.Anything).Return(errQuerying)
 
-				repo := NewPqRepository(db)
+				repo := NewPqRepository(db, binder, recorder, logger.Discard())
 				return repo.(*PqRepository), mock, db
 			},
 			args: args{
 				taxObj: &taxobj.TaxObject{
-					Name:    "MACD",
-					TaxCode: 1,
-					Price:   20000,
+					Name:      "MACD",
+					TaxCode:   1,
+					Quantity:  2,
+					UnitPrice: 10000,
+					Price:     20000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error in beginning the transaction",
+			customFunc: func() (*PqRepository, sqlmock.Sqlmock, *sql.DB) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Errorf(logFail, "Error starting the mocker", err)
+				}
+				//Init the mock!
+				mock.ExpectBegin().
+					WillReturnError(errBeginning)
+
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
+				return repo.(*PqRepository), mock, db
+			},
+			args: args{
+				taxObj: &taxobj.TaxObject{
+					Name:      "MACD",
+					TaxCode:   1,
+					Quantity:  2,
+					UnitPrice: 10000,
+					Price:     20000,
 				},
 			},
 			wantErr: true,
@@ -226,7 +449,7 @@ func TestPqRepository_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo, mock, db := tt.customFunc()
 			defer db.Close()
-			err := repo.Create(tt.args.taxObj)
+			err := repo.Create(context.Background(), tt.args.taxObj)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("PqRepository.Create() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -237,6 +460,435 @@ func TestPqRepository_Create(t *testing.T) {
 	}
 }
 
+func TestPqRepository_Update(t *testing.T) {
+	t.Parallel()
+	before := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant", "bill_id"}).
+			AddRow(1, "MACD", 1, 1, 20000, 20000, "", 0, false, []byte(`[]`), "", "merchant-a", 7)
+	}
+	tests := []struct {
+		name     string
+		expect   func(mock sqlmock.Sqlmock)
+		recorder func() *mocksAudit.Recorder
+		checkErr error
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectForUpdate).
+					WithArgs(1, "merchant-a").
+					WillReturnRows(before())
+				mock.ExpectExec(regexQueryUpdate).
+					WithArgs("MACD", 1, float64(1), float64(25000), float64(25000), "percent", float64(10), true, `[{"tax_code":3,"compound":true}]`, "TEST", 1, "merchant-a").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionUpdate &&
+						event.Tenant == "merchant-a" &&
+						string(event.Before) == `{"id":1,"name":"MACD","tax_code":1,"quantity":1,"unit_price":20000,"price":20000,"bill_id":7}` &&
+						string(event.After) == `{"id":1,"name":"MACD","tax_code":1,"jurisdiction":"TEST","taxes":[{"tax_code":3,"compound":true}],"quantity":1,"unit_price":25000,"price":25000,"discount":{"type":"percent","value":10},"tax_inclusive":true,"bill_id":7}`
+				})).Return(nil)
+				return recorder
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Bill has been finalized",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectForUpdate).
+					WithArgs(1, "merchant-a").
+					WillReturnRows(before())
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			checkErr: bill.ErrFinalized,
+			wantErr:  bill.ErrFinalized,
+		},
+		{
+			name: "Tax object of another tenant",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectForUpdate).
+					WithArgs(1, "merchant-a").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: taxobj.ErrNotFound,
+		},
+		{
+			name: "Error executing the query",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectForUpdate).
+					WillReturnRows(before())
+				mock.ExpectExec(regexQueryUpdate).
+					WillReturnError(errQuerying)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error starting the mocker: %s", err)
+			}
+			defer db.Close()
+			tt.expect(mock)
+			recorder := tt.recorder()
+			binder := &mocksBill.Binder{}
+			binder.On("CheckOpen", testify.Anything, testify.Anything, int64(7)).Return(tt.checkErr)
+			repo := NewPqRepository(db, binder, recorder, logger.Discard())
+			taxObj := &taxobj.TaxObject{
+				ID:           1,
+				Name:         "MACD",
+				TaxCode:      1,
+				Jurisdiction: "TEST",
+				Quantity:     1,
+				UnitPrice:    25000,
+				Price:        25000,
+				Taxes:        []taxobj.Tax{{TaxCode: 3, Compound: true}},
+				Discount:     &taxobj.Discount{Type: taxobj.DiscountPercent, Value: 10},
+				TaxInclusive: true,
+			}
+			err = repo.Update(tenant.WithTenant(context.Background(), "merchant-a"), taxObj)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, "merchant-a", taxObj.Tenant)
+			//The arguments are not asserted because formatting the finished transaction races with its cleanup.
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Update() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Delete(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		expect   func(mock sqlmock.Sqlmock)
+		recorder func() *mocksAudit.Recorder
+		checkErr error
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectForUpdate).
+					WithArgs(1, "merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant", "bill_id"}).
+						AddRow(1, "MACD", 1, 1, 20000, 20000, "", 0, false, []byte(`[]`), "", "merchant-a", 7))
+				mock.ExpectExec(regexQueryDelete).
+					WithArgs(1, "merchant-a").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionDelete &&
+						string(event.Before) == `{"id":1,"name":"MACD","tax_code":1,"quantity":1,"unit_price":20000,"price":20000,"bill_id":7}` &&
+						event.After == nil
+				})).Return(nil)
+				return recorder
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Bill has been finalized",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectForUpdate).
+					WithArgs(1, "merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant", "bill_id"}).
+						AddRow(1, "MACD", 1, 1, 20000, 20000, "", 0, false, []byte(`[]`), "", "merchant-a", 7))
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			checkErr: bill.ErrFinalized,
+			wantErr:  bill.ErrFinalized,
+		},
+		{
+			name: "Tax object of another tenant",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectForUpdate).
+					WithArgs(1, "merchant-a").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: taxobj.ErrNotFound,
+		},
+		{
+			name: "Error beginning the transaction",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin().
+					WillReturnError(errBeginning)
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: errBeginning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error starting the mocker: %s", err)
+			}
+			defer db.Close()
+			tt.expect(mock)
+			recorder := tt.recorder()
+			binder := &mocksBill.Binder{}
+			binder.On("CheckOpen", testify.Anything, testify.Anything, int64(7)).Return(tt.checkErr)
+			repo := NewPqRepository(db, binder, recorder, logger.Discard())
+			err = repo.Delete(tenant.WithTenant(context.Background(), "merchant-a"), 1)
+			assert.Equal(t, tt.wantErr, err)
+			//The arguments are not asserted because formatting the finished transaction races with its cleanup.
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Delete() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Restore(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		recorder   func() *mocksAudit.Recorder
+		checkErr   error
+		wantTaxObj taxobj.TaxObject
+		wantErr    error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectDeleted).
+					WithArgs(1, "merchant-a").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant", "bill_id"}).
+						AddRow(1, "MACD", 1, 1, 20000, 20000, "", 0, false, []byte(`[]`), "", "merchant-a", 7))
+				mock.ExpectExec(regexQueryRestore).
+					WithArgs(1, "merchant-a").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionRestore &&
+						event.Before == nil &&
+						string(event.After) == `{"id":1,"name":"MACD","tax_code":1,"quantity":1,"unit_price":20000,"price":20000,"bill_id":7}`
+				})).Return(nil)
+				return recorder
+			},
+			wantTaxObj: taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Quantity: 1, UnitPrice: 20000, Price: 20000, BillID: 7, Tenant: "merchant-a"},
+			wantErr:    nil,
+		},
+		{
+			name: "Tax object is not deleted",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectDeleted).
+					WithArgs(1, "merchant-a").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: taxobj.ErrNotFound,
+		},
+		{
+			name: "Error executing the query",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQuerySelectDeleted).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant", "bill_id"}).
+						AddRow(1, "MACD", 1, 1, 20000, 20000, "", 0, false, []byte(`[]`), "", "merchant-a", 7))
+				mock.ExpectExec(regexQueryRestore).
+					WillReturnError(errQuerying)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantErr: errQuerying,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error starting the mocker: %s", err)
+			}
+			defer db.Close()
+			tt.expect(mock)
+			recorder := tt.recorder()
+			binder := &mocksBill.Binder{}
+			binder.On("CheckOpen", testify.Anything, testify.Anything, int64(7)).Return(tt.checkErr)
+			repo := NewPqRepository(db, binder, recorder, logger.Discard())
+			gotTaxObj, err := repo.Restore(tenant.WithTenant(context.Background(), "merchant-a"), 1)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.wantTaxObj, gotTaxObj)
+			}
+			//The arguments are not asserted because formatting the finished transaction races with its cleanup.
+			recorder.AssertNumberOfCalls(t, "Record", len(recorder.ExpectedCalls))
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Restore() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
+func TestPqRepository_Purge(t *testing.T) {
+	t.Parallel()
+	before := time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)
+	purgedRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "tax_code", "quantity", "unit_price", "price", "discount_type", "discount_value", "tax_inclusive", "taxes", "jurisdiction", "tenant", "bill_id"}).
+			AddRow(1, "MACD", 1, 1, 20000, 20000, "", 0, false, []byte(`[]`), "", "merchant-a", 7).
+			AddRow(2, "Movie", 3, 1, 150, 150, "", 0, false, []byte(`[]`), "", "merchant-b", 8)
+	}
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		recorder   func() *mocksAudit.Recorder
+		wantPurged int64
+		wantErr    bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQueryPurge).
+					WithArgs(before).
+					WillReturnRows(purgedRows())
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionPurge &&
+						event.Entity == audit.EntityTaxObject &&
+						event.EntityID == 1 &&
+						event.Tenant == "merchant-a" &&
+						string(event.Before) == `{"id":1,"name":"MACD","tax_code":1,"quantity":1,"unit_price":20000,"price":20000,"bill_id":7}` &&
+						event.After == nil
+				})).Return(nil).Once()
+				recorder.On("Record", testify.Anything, testify.Anything, testify.MatchedBy(func(event *audit.Event) bool {
+					return event.Action == audit.ActionPurge &&
+						event.EntityID == 2 &&
+						event.Tenant == "merchant-b"
+				})).Return(nil).Once()
+				return recorder
+			},
+			wantPurged: 2,
+			wantErr:    false,
+		},
+		{
+			name: "Nothing to purge",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQueryPurge).
+					WithArgs(before).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectCommit()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantPurged: 0,
+			wantErr:    false,
+		},
+		{
+			name: "Error recording the audit event",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQueryPurge).
+					WithArgs(before).
+					WillReturnRows(purgedRows())
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				recorder := &mocksAudit.Recorder{}
+				recorder.On("Record", testify.Anything, testify.Anything, testify.Anything).Return(errQuerying)
+				return recorder
+			},
+			wantPurged: 0,
+			wantErr:    true,
+		},
+		{
+			name: "Error executing the query",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexQueryPurge).
+					WillReturnError(errQuerying)
+				mock.ExpectRollback()
+			},
+			recorder: func() *mocksAudit.Recorder {
+				return &mocksAudit.Recorder{}
+			},
+			wantPurged: 0,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error starting the mocker: %s", err)
+			}
+			defer db.Close()
+			tt.expect(mock)
+			recorder := tt.recorder()
+			repo := NewPqRepository(db, nil, recorder, logger.Discard())
+			gotPurged, err := repo.Purge(context.Background(), before)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PqRepository.Purge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantPurged, gotPurged)
+			//The purged tax objects are committed only with their audit events.
+			if !tt.wantErr {
+				recorder.AssertNumberOfCalls(t, "Record", int(tt.wantPurged))
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PqRepository.Purge() mock expectation were not met: %s", err)
+			}
+		})
+	}
+}
+
 func TestPqRepository_Migrate(t *testing.T) {
 	t.Parallel()
 	const logFail = `[TestPqRepository_Migrate] %s: %s`
@@ -258,8 +910,96 @@ func TestPqRepository_Migrate(t *testing.T) {
 				//Init the mock!
 				mock.ExpectQuery(regexQuerySelectOne).
 					WillReturnRows(resultRow)
+				mock.ExpectExec(regexQueryAddTenant).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddDeletedAt).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddBillID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddQuantity).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddDiscount).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddTaxInclusive).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddTaxes).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddJurisdiction).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
+				return repo.(*PqRepository), mock, db
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error adding the tenant to the existing table",
+			customFunc: func() (*PqRepository, sqlmock.Sqlmock, *sql.DB) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Errorf(logFail, "Error starting the mocker", err)
+				}
+				//Init the mock!
+				mock.ExpectQuery(regexQuerySelectOne).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexQueryAddTenant).
+					WillReturnError(errQuerying)
+
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
+				return repo.(*PqRepository), mock, db
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error adding the soft delete to the existing table",
+			customFunc: func() (*PqRepository, sqlmock.Sqlmock, *sql.DB) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Errorf(logFail, "Error starting the mocker", err)
+				}
+				//Init the mock!
+				mock.ExpectQuery(regexQuerySelectOne).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexQueryAddTenant).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddDeletedAt).
+					WillReturnError(errQuerying)
+
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
+				return repo.(*PqRepository), mock, db
+			},
+			wantErr: true,
+		},
+		{
+			name: "Create table with the tenant",
+			customFunc: func() (*PqRepository, sqlmock.Sqlmock, *sql.DB) {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Errorf(logFail, "Error starting the mocker", err)
+				}
+				//Init the mock!
+				mock.ExpectQuery(regexQuerySelectOne).
+					WillReturnError(errRelationNotExist)
+				mock.ExpectExec(regexQueryCreateTable).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddTenant).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddDeletedAt).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddBillID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddQuantity).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddDiscount).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddTaxInclusive).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddTaxes).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexQueryAddJurisdiction).
+					WillReturnResult(sqlmock.NewResult(0, 0))
 
-				repo := NewPqRepository(db)
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
 				return repo.(*PqRepository), mock, db
 			},
 			wantErr: false,
@@ -276,7 +1016,7 @@ func TestPqRepository_Migrate(t *testing.T) {
 					WillReturnError(errRelationNotExist)
 				mock.ExpectExec(regexQueryCreateTable)
 
-				repo := NewPqRepository(db)
+				repo := NewPqRepository(db, nil, nil, logger.Discard())
 				return repo.(*PqRepository), mock, db
 			},
 			wantErr: true,
@@ -313,15 +1053,15 @@ func TestPqRepository_Close(t *testing.T) {
 					t.Errorf(logFail, "Error starting the mocker", err)
 				}
 				//Init the mock!
-				mock.ExpectPrepare(regexQueryInsert)
+				mock.ExpectPrepare(regexQuerySelectTenants)
 				mock.ExpectPrepare(regexQuerySelectAll)
 
-				repo := NewPqRepository(db).(*PqRepository)
-				stmt, err := db.Prepare(queryInsert)
+				repo := NewPqRepository(db, nil, nil, logger.Discard()).(*PqRepository)
+				stmt, err := db.Prepare(querySelectTenants)
 				if err != nil {
 					t.Errorf(logFail, "Error preparing the statement", err)
 				}
-				repo.statement.insert = stmt
+				repo.statement.selectTenants = stmt
 				stmt, err = db.Prepare(querySelectAll)
 				if err != nil {
 					t.Errorf(logFail, "Error preparing the statement", err)
@@ -353,6 +1093,7 @@ func TestNewPqRepository(t *testing.T) {
 		t.Fatalf("Error starting the mock: %s", err)
 	}
 	defer db.Close()
+	log := logger.Discard()
 	tests := []struct {
 		name string
 		args args
@@ -366,12 +1107,13 @@ func TestNewPqRepository(t *testing.T) {
 			},
 			want: &PqRepository{
 				pool: db,
+				log:  log,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.EqualValues(t, NewPqRepository(tt.args.pool), tt.want)
+			assert.EqualValues(t, NewPqRepository(tt.args.pool, nil, nil, log), tt.want)
 		})
 	}
 }
@@ -1,11 +1,114 @@
 package taxobj
 
+import (
+	"errors"
+	"math"
+
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+)
+
+var (
+	//ErrNotFound defines the error if the tax object doesn't exist in the tenant.
+	ErrNotFound = errors.New("Tax object not found")
+	//ErrBillFull defines the error if the bill of the tenant has reached the maximum lines.
+	ErrBillFull = errors.New("The bill has reached the maximum lines")
+	//ErrPriceMismatch defines the error if the price of the tax object isn't its quantity times its unit price.
+	ErrPriceMismatch = errors.New("The price doesn't equal the quantity times the unit price")
+)
+
+//priceTolerance defines the relative difference allowed between the price and the quantity times the unit price,
+//so the rounding of the floating point numbers sent by the client isn't a mismatch.
+const priceTolerance = 1e-9
+
+const (
+	//DiscountPercent defines the discount of the percentage of the price.
+	DiscountPercent = taxcalc.DiscountPercent
+	//DiscountFixed defines the discount of the fixed amount.
+	DiscountFixed = taxcalc.DiscountFixed
+)
+
 //TaxObject define the model for tax object.
 //This is the data that the user will input.
 //Tax objects are also used to calculate bills.
+//The price is derived from the quantity and the unit price, the quantity can be fractional, e.g. 1.5 kg.
+//The discount is deducted from the price before the tax is calculated.
+//The taxes are the additional taxes applied after the tax of the tax code in their order, e.g. the VAT after the excise.
+//The price of the tax-inclusive tax object includes its tax, so the price without the tax is calculated from it.
+//The jurisdiction selects the rules of the tax codes, the tax object without the jurisdiction uses the default rules.
+//The tenant is derived from the authenticated principal, so it can't be set by the user.
+//The bill id is the open bill of the tenant when the tax object is created, so it's also not set by the user.
 type TaxObject struct {
-	ID      int64   `json:"id"`
-	Name    string  `json:"name" validate:"required"`
-	TaxCode int64   `json:"tax_code" validate:"required,gte=1,lte=3"`
-	Price   float64 `json:"price" validate:"required,gt=0"`
+	ID           int64     `json:"id"`
+	Name         string    `json:"name" validate:"required"`
+	TaxCode      int64     `json:"tax_code" validate:"required,gte=1,lte=3"`
+	Jurisdiction string    `json:"jurisdiction,omitempty" validate:"max=32"`
+	Taxes        []Tax     `json:"taxes,omitempty" validate:"max=3,dive"`
+	Quantity     float64   `json:"quantity" validate:"gt=0"`
+	UnitPrice    float64   `json:"unit_price" validate:"required_without=Price,omitempty,gt=0"`
+	Price        float64   `json:"price" validate:"required_without=UnitPrice,omitempty,gt=0"`
+	Discount     *Discount `json:"discount,omitempty"`
+	TaxInclusive bool      `json:"tax_inclusive,omitempty"`
+	BillID       int64     `json:"bill_id,omitempty"`
+	Tenant       string    `json:"-"`
+}
+
+//The discount and the taxes of the tax object are the models of the calculation.
+type (
+	//Discount define the percentage or the fixed discount of the price.
+	Discount = taxcalc.Discount
+	//Tax define the additional tax of the tax object calculated by the rule of its tax code.
+	Tax = taxcalc.Tax
+)
+
+//Derive derive the price of the tax object from its quantity and unit price.
+//The tax object without the quantity is one unit, and the tax object without the unit price
+//has the price as its unit price, so the tax objects having only the price keep their price.
+func (taxObject *TaxObject) Derive() {
+	calculable := taxObject.Calculable()
+	calculable.Derive()
+	taxObject.Quantity = calculable.Quantity
+	taxObject.UnitPrice = calculable.UnitPrice
+	taxObject.Price = calculable.Price
+}
+
+//Calculable return the tax object calculated by the taxcalc package.
+//The taxes and the discount are shared with the tax object, as the calculation never changes them.
+func (taxObject TaxObject) Calculable() taxcalc.TaxObject {
+	return taxcalc.TaxObject{
+		Name:         taxObject.Name,
+		TaxCode:      taxObject.TaxCode,
+		Jurisdiction: taxObject.Jurisdiction,
+		Taxes:        taxObject.Taxes,
+		Quantity:     taxObject.Quantity,
+		UnitPrice:    taxObject.UnitPrice,
+		Price:        taxObject.Price,
+		Discount:     taxObject.Discount,
+		TaxInclusive: taxObject.TaxInclusive,
+	}
+}
+
+//Calculables return the tax objects calculated by the taxcalc package in their order.
+func Calculables(taxObjects []TaxObject) []taxcalc.TaxObject {
+	calculables := make([]taxcalc.TaxObject, 0, len(taxObjects))
+	for _, taxObject := range taxObjects {
+		calculables = append(calculables, taxObject.Calculable())
+	}
+	return calculables
+}
+
+//CheckPrice return ErrPriceMismatch if the tax object has both the price and the unit price,
+//but its price isn't its quantity times its unit price, so Derive never silently replaces the price sent by the user.
+func (taxObject TaxObject) CheckPrice() (err error) {
+	if taxObject.Price == 0 || taxObject.UnitPrice == 0 {
+		return
+	}
+	quantity := taxObject.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	derived := quantity * taxObject.UnitPrice
+	if math.Abs(taxObject.Price-derived) > priceTolerance*math.Max(1, math.Abs(derived)) {
+		err = ErrPriceMismatch
+	}
+	return
 }
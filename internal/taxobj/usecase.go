@@ -1,6 +1,16 @@
 package taxobj
 
+import (
+	"context"
+	"time"
+)
+
 //Usecase defines the required behavior for business logic in the tax object.
 type Usecase interface {
-	CreateTaxObject(*TaxObject) error
+	CreateTaxObject(context.Context, *TaxObject) error
+	UpdateTaxObject(context.Context, *TaxObject) error
+	DeleteTaxObject(context.Context, int64) error
+	RestoreTaxObject(context.Context, int64) (TaxObject, error)
+	PurgeTaxObjects(context.Context, time.Duration) (int64, error)
+	CheckBillLines(context.Context, int) error
 }
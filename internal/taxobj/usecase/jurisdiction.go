@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+)
+
+//ErrInvalidJurisdiction defines the error if the jurisdiction in the jurisdictions file is not valid.
+var ErrInvalidJurisdiction = errors.New("Invalid jurisdiction")
+
+//LoadJurisdictions load the jurisdictions in the JSON file.
+//Every jurisdiction must have its code and the rules of all tax codes, so the tax objects of any tax code can be taxed.
+//The default jurisdiction can't be replaced, so the existing tax objects keep their tax.
+func LoadJurisdictions(path string) (jurisdictions []taxobj.Jurisdiction, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(content, &jurisdictions); err != nil {
+		return
+	}
+	for _, jurisdiction := range jurisdictions {
+		if err = validateJurisdiction(jurisdiction); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+//validateJurisdiction return ErrInvalidJurisdiction if the jurisdiction has no code, is longer than the stored code,
+//misses the rule of any tax code, or has any negative rule.
+func validateJurisdiction(jurisdiction taxobj.Jurisdiction) (err error) {
+	if jurisdiction.Code == taxobj.DefaultJurisdiction || len(jurisdiction.Code) > 32 {
+		return ErrInvalidJurisdiction
+	}
+	for taxCode := int64(1); taxCode <= 3; taxCode++ {
+		rule, ok := jurisdiction.Rules[taxCode]
+		if !ok || rule.Type == "" || rule.Fixed < 0 || rule.Rate < 0 || rule.Threshold < 0 {
+			return ErrInvalidJurisdiction
+		}
+	}
+	return
+}
@@ -0,0 +1,89 @@
+// +build unit
+
+package usecase
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadJurisdictions(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "jurisdictions")
+	if err != nil {
+		t.Fatalf("Error creating the temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	tests := []struct {
+		name    string
+		content string
+		want    []taxobj.Jurisdiction
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			content: `[{"code": "TEST", "name": "Test", "rules": {
+				"1": {"type": "Food & Beverage", "refundable": true, "rate": 5},
+				"2": {"type": "Tobacco", "fixed": 20, "rate": 5},
+				"3": {"type": "Entertainment", "rate": 1, "threshold": 100}
+			}}]`,
+			want: []taxobj.Jurisdiction{
+				{
+					Code: "TEST",
+					Name: "Test",
+					Rules: map[int64]taxobj.Rule{
+						1: {Type: "Food & Beverage", Refundable: true, Rate: 5},
+						2: {Type: "Tobacco", Fixed: 20, Rate: 5},
+						3: {Type: "Entertainment", Rate: 1, Threshold: 100},
+					},
+				},
+			},
+		},
+		{
+			name: "Default Jurisdiction",
+			content: `[{"code": "", "rules": {
+				"1": {"type": "Food & Beverage", "rate": 5},
+				"2": {"type": "Tobacco", "rate": 5},
+				"3": {"type": "Entertainment", "rate": 5}
+			}}]`,
+			wantErr: ErrInvalidJurisdiction,
+		},
+		{
+			name: "Missing Rule",
+			content: `[{"code": "TEST", "rules": {
+				"1": {"type": "Food & Beverage", "rate": 5},
+				"2": {"type": "Tobacco", "rate": 5}
+			}}]`,
+			wantErr: ErrInvalidJurisdiction,
+		},
+		{
+			name: "Negative Rate",
+			content: `[{"code": "TEST", "rules": {
+				"1": {"type": "Food & Beverage", "rate": -5},
+				"2": {"type": "Tobacco", "rate": 5},
+				"3": {"type": "Entertainment", "rate": 5}
+			}}]`,
+			wantErr: ErrInvalidJurisdiction,
+		},
+	}
+	for index, tt := range tests {
+		path := filepath.Join(dir, string(rune('a'+index))+".json")
+		if err = ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatalf("Error writing the jurisdictions file: %s", err)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadJurisdictions(path)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err = LoadJurisdictions(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
@@ -1,30 +1,168 @@
 package usecase
 
 import (
+	"context"
+	"time"
+
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/fairyhunter13/tax-calculator/internal/tracing"
+	"github.com/sirupsen/logrus"
 )
 
 //TaxObjectUsecase defines all the business logic for the tax object.
+//The bill of every tenant has at most maxLines lines, or unlimited if maxLines is 0.
+//The tenant is locked from the change of the tax object in the database until the change is applied to its bill,
+//so the bill applies the changes in the order they're committed.
 type TaxObjectUsecase struct {
 	taxObjRepo taxobj.Repository
 	billRepo   bill.Repository
+	maxLines   int
+	locker     *tenant.Locker
+	log        logrus.FieldLogger
 }
 
 //NewTaxObjectUsecase return the tax object usecase.
-func NewTaxObjectUsecase(taxObjRepo taxobj.Repository, billRepo bill.Repository) taxobj.Usecase {
+func NewTaxObjectUsecase(taxObjRepo taxobj.Repository, billRepo bill.Repository, maxLines int, locker *tenant.Locker, log logrus.FieldLogger) taxobj.Usecase {
 	return &TaxObjectUsecase{
 		taxObjRepo,
 		billRepo,
+		maxLines,
+		locker,
+		log,
 	}
 }
 
 //CreateTaxObject create a new tax object and store it into the database.
-func (ucase *TaxObjectUsecase) CreateTaxObject(taxObject *taxobj.TaxObject) (err error) {
-	err = ucase.taxObjRepo.Create(taxObject)
+func (ucase *TaxObjectUsecase) CreateTaxObject(ctx context.Context, taxObject *taxobj.TaxObject) (err error) {
+	ctx, span := tracing.Start(ctx, "TaxObjectUsecase.CreateTaxObject")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	if err = ucase.CheckBillLines(ctx, 1); err != nil {
+		return
+	}
+	err = ucase.taxObjRepo.Create(ctx, taxObject)
+	if err != nil {
+		logger.FromContext(ctx, ucase.log).WithError(err).Error("[TaxObjectUsecase] Failed to create the tax object")
+		return
+	}
+	logger.FromContext(ctx, ucase.log).
+		WithField("tax_object_id", taxObject.ID).
+		Info("[TaxObjectUsecase] Tax object created")
+	//The bill is updated synchronously so it's consistent with the later corrections and deletions.
+	ucase.billRepo.Add(ctx, *taxObject)
+	return
+}
+
+//UpdateTaxObject correct the tax object in the database and its bill.
+func (ucase *TaxObjectUsecase) UpdateTaxObject(ctx context.Context, taxObject *taxobj.TaxObject) (err error) {
+	ctx, span := tracing.Start(ctx, "TaxObjectUsecase.UpdateTaxObject")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	err = ucase.taxObjRepo.Update(ctx, taxObject)
 	if err != nil {
+		logger.FromContext(ctx, ucase.log).
+			WithError(err).
+			WithField("tax_object_id", taxObject.ID).
+			Warn("[TaxObjectUsecase] Failed to update the tax object")
 		return
 	}
-	go ucase.billRepo.Add(*taxObject)
+	logger.FromContext(ctx, ucase.log).
+		WithField("tax_object_id", taxObject.ID).
+		Info("[TaxObjectUsecase] Tax object updated")
+	ucase.billRepo.Update(ctx, *taxObject)
 	return
 }
+
+//DeleteTaxObject delete the tax object from the database and its bill.
+//The tax object is soft deleted, so it can be restored until it's purged.
+func (ucase *TaxObjectUsecase) DeleteTaxObject(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "TaxObjectUsecase.DeleteTaxObject")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	err = ucase.taxObjRepo.Delete(ctx, id)
+	if err != nil {
+		logger.FromContext(ctx, ucase.log).
+			WithError(err).
+			WithField("tax_object_id", id).
+			Warn("[TaxObjectUsecase] Failed to delete the tax object")
+		return
+	}
+	logger.FromContext(ctx, ucase.log).
+		WithField("tax_object_id", id).
+		Info("[TaxObjectUsecase] Tax object deleted")
+	ucase.billRepo.Remove(ctx, id)
+	return
+}
+
+//RestoreTaxObject restore the deleted tax object in the database and add it back to its bill.
+func (ucase *TaxObjectUsecase) RestoreTaxObject(ctx context.Context, id int64) (taxObject taxobj.TaxObject, err error) {
+	ctx, span := tracing.Start(ctx, "TaxObjectUsecase.RestoreTaxObject")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	unlock := ucase.locker.Lock(ctx)
+	defer unlock()
+	if err = ucase.CheckBillLines(ctx, 1); err != nil {
+		return
+	}
+	taxObject, err = ucase.taxObjRepo.Restore(ctx, id)
+	if err != nil {
+		logger.FromContext(ctx, ucase.log).
+			WithError(err).
+			WithField("tax_object_id", id).
+			Warn("[TaxObjectUsecase] Failed to restore the tax object")
+		return
+	}
+	logger.FromContext(ctx, ucase.log).
+		WithField("tax_object_id", id).
+		Info("[TaxObjectUsecase] Tax object restored")
+	ucase.billRepo.Add(ctx, taxObject)
+	return
+}
+
+//PurgeTaxObjects permanently delete the tax objects of all tenants deleted longer than the retention ago.
+func (ucase *TaxObjectUsecase) PurgeTaxObjects(ctx context.Context, retention time.Duration) (purged int64, err error) {
+	ctx, span := tracing.Start(ctx, "TaxObjectUsecase.PurgeTaxObjects")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	purged, err = ucase.taxObjRepo.Purge(ctx, time.Now().Add(-retention))
+	if err != nil {
+		logger.FromContext(ctx, ucase.log).WithError(err).Error("[TaxObjectUsecase] Failed to purge the deleted tax objects")
+		return
+	}
+	logger.FromContext(ctx, ucase.log).
+		WithField("count", purged).
+		Info("[TaxObjectUsecase] Deleted tax objects purged")
+	return
+}
+
+//CheckBillLines return ErrBillFull if the bill of the tenant in ctx can't have the given lines more without exceeding the maximum lines,
+//so the created tax objects and the previewed tax objects are checked the same.
+//The tax objects are created and restored while the tenant is locked, so they never exceed the maximum together.
+func (ucase *TaxObjectUsecase) CheckBillLines(ctx context.Context, lines int) (err error) {
+	if ucase.maxLines <= 0 {
+		return
+	}
+	bills, _ := ucase.billRepo.GetAll(ctx)
+	if len(bills)+lines <= ucase.maxLines {
+		return
+	}
+	logger.FromContext(ctx, ucase.log).
+		WithField("lines", lines).
+		WithField("max_lines", ucase.maxLines).
+		Warn("[TaxObjectUsecase] The bill has reached the maximum lines")
+	return taxobj.ErrBillFull
+}
@@ -3,14 +3,21 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/fairyhunter13/tax-calculator/internal/bill"
 	mocksBill "github.com/fairyhunter13/tax-calculator/internal/bill/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
 	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
 	mocksTax "github.com/fairyhunter13/tax-calculator/internal/taxobj/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/tenant"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestTaxObjectUsecase_CreateTaxObject(t *testing.T) {
@@ -34,10 +41,10 @@ func TestTaxObjectUsecase_CreateTaxObject(t *testing.T) {
 					Price:   20000,
 				}
 				taxRepo := &mocksTax.Repository{}
-				taxRepo.On("Create", taxObj).Return(nil)
+				taxRepo.On("Create", mock.Anything, taxObj).Return(nil)
 				billRepo := &mocksBill.Repository{}
-				billRepo.On("Add", *taxObj).Return()
-				ucase := NewTaxObjectUsecase(taxRepo, billRepo)
+				billRepo.On("Add", mock.Anything, *taxObj).Return()
+				ucase := NewTaxObjectUsecase(taxRepo, billRepo, 0, tenant.NewLocker(), logger.Discard())
 				return ucase.(*TaxObjectUsecase)
 			},
 			args: args{
@@ -58,9 +65,27 @@ func TestTaxObjectUsecase_CreateTaxObject(t *testing.T) {
 					Price:   20000,
 				}
 				taxRepo := &mocksTax.Repository{}
-				taxRepo.On("Create", taxObj).Return(errors.New("Error in storing to the database"))
+				taxRepo.On("Create", mock.Anything, taxObj).Return(errors.New("Error in storing to the database"))
 				billRepo := &mocksBill.Repository{}
-				ucase := NewTaxObjectUsecase(taxRepo, billRepo)
+				ucase := NewTaxObjectUsecase(taxRepo, billRepo, 0, tenant.NewLocker(), logger.Discard())
+				return ucase.(*TaxObjectUsecase)
+			},
+			args: args{
+				taxObject: &taxobj.TaxObject{
+					Name:    "MACD",
+					TaxCode: 1,
+					Price:   20000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Bill Reached Maximum Lines",
+			ucase: func() *TaxObjectUsecase {
+				taxRepo := &mocksTax.Repository{}
+				billRepo := &mocksBill.Repository{}
+				billRepo.On("GetAll", mock.Anything).Return([]bill.Bill{{Name: "KFC Burger"}}, bill.Total{})
+				ucase := NewTaxObjectUsecase(taxRepo, billRepo, 1, tenant.NewLocker(), logger.Discard())
 				return ucase.(*TaxObjectUsecase)
 			},
 			args: args{
@@ -75,20 +100,324 @@ func TestTaxObjectUsecase_CreateTaxObject(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.ucase().CreateTaxObject(tt.args.taxObject); (err != nil) != tt.wantErr {
+			if err := tt.ucase().CreateTaxObject(context.Background(), tt.args.taxObject); (err != nil) != tt.wantErr {
 				t.Errorf("TaxObjectUsecase.CreateTaxObject() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func TestTaxObjectUsecase_UpdateTaxObject(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		repoErr error
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:    "Positive Case",
+			repoErr: nil,
+			wantErr: false,
+		},
+		{
+			name:    "Tax object not found",
+			repoErr: taxobj.ErrNotFound,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taxObj := &taxobj.TaxObject{
+				ID:      1,
+				Name:    "MACD",
+				TaxCode: 1,
+				Price:   25000,
+			}
+			taxRepo := &mocksTax.Repository{}
+			taxRepo.On("Update", mock.Anything, taxObj).Return(tt.repoErr)
+			billRepo := &mocksBill.Repository{}
+			billRepo.On("Update", mock.Anything, *taxObj).Return()
+			ucase := NewTaxObjectUsecase(taxRepo, billRepo, 0, tenant.NewLocker(), logger.Discard())
+			if err := ucase.UpdateTaxObject(context.Background(), taxObj); (err != nil) != tt.wantErr {
+				t.Errorf("TaxObjectUsecase.UpdateTaxObject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				billRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			} else {
+				billRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestTaxObjectUsecase_DeleteTaxObject(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		repoErr error
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:    "Positive Case",
+			repoErr: nil,
+			wantErr: false,
+		},
+		{
+			name:    "Tax object not found",
+			repoErr: taxobj.ErrNotFound,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taxRepo := &mocksTax.Repository{}
+			taxRepo.On("Delete", mock.Anything, int64(1)).Return(tt.repoErr)
+			billRepo := &mocksBill.Repository{}
+			billRepo.On("Remove", mock.Anything, int64(1)).Return()
+			ucase := NewTaxObjectUsecase(taxRepo, billRepo, 0, tenant.NewLocker(), logger.Discard())
+			if err := ucase.DeleteTaxObject(context.Background(), 1); (err != nil) != tt.wantErr {
+				t.Errorf("TaxObjectUsecase.DeleteTaxObject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				billRepo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything)
+			} else {
+				billRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestTaxObjectUsecase_RestoreTaxObject(t *testing.T) {
+	t.Parallel()
+	taxObj := taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000}
+	tests := []struct {
+		name     string
+		maxLines int
+		repoErr  error
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:    "Positive Case",
+			repoErr: nil,
+			wantErr: nil,
+		},
+		{
+			name:    "Deleted tax object not found",
+			repoErr: taxobj.ErrNotFound,
+			wantErr: taxobj.ErrNotFound,
+		},
+		{
+			name:     "Bill is full",
+			maxLines: 1,
+			wantErr:  taxobj.ErrBillFull,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taxRepo := &mocksTax.Repository{}
+			taxRepo.On("Restore", mock.Anything, int64(1)).Return(taxObj, tt.repoErr)
+			billRepo := &mocksBill.Repository{}
+			billRepo.On("GetAll", mock.Anything).Return([]bill.Bill{{Name: "Lucky Stretch"}}, bill.Total{})
+			billRepo.On("Add", mock.Anything, taxObj).Return()
+			ucase := NewTaxObjectUsecase(taxRepo, billRepo, tt.maxLines, tenant.NewLocker(), logger.Discard())
+			gotTaxObj, err := ucase.RestoreTaxObject(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				billRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(t, taxObj, gotTaxObj)
+			billRepo.AssertCalled(t, "Add", mock.Anything, taxObj)
+		})
+	}
+}
+
+func TestTaxObjectUsecase_CheckBillLines(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		maxLines int
+		lines    int
+		wantErr  error
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Unlimited Lines",
+			maxLines: 0,
+			lines:    100,
+		},
+		{
+			name:     "Lines Within the Maximum",
+			maxLines: 3,
+			lines:    2,
+		},
+		{
+			name:     "Lines Exceeding the Maximum",
+			maxLines: 3,
+			lines:    3,
+			wantErr:  taxobj.ErrBillFull,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			billRepo := &mocksBill.Repository{}
+			billRepo.On("GetAll", mock.Anything).Return([]bill.Bill{{Name: "Lucky Stretch"}}, bill.Total{})
+			ucase := NewTaxObjectUsecase(&mocksTax.Repository{}, billRepo, tt.maxLines, tenant.NewLocker(), logger.Discard())
+			assert.Equal(t, tt.wantErr, ucase.CheckBillLines(context.Background(), tt.lines))
+		})
+	}
+}
+
+func TestTaxObjectUsecase_PurgeTaxObjects(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		purged     int64
+		repoErr    error
+		wantPurged int64
+		wantErr    bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Positive Case",
+			purged:     2,
+			wantPurged: 2,
+			wantErr:    false,
+		},
+		{
+			name:    "Database is not online",
+			repoErr: errors.New("Database is not online"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retention := 24 * time.Hour
+			begin := time.Now()
+			taxRepo := &mocksTax.Repository{}
+			taxRepo.On("Purge", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+				return !before.Before(begin.Add(-retention)) && !before.After(time.Now().Add(-retention))
+			})).Return(tt.purged, tt.repoErr)
+			ucase := NewTaxObjectUsecase(taxRepo, &mocksBill.Repository{}, 0, tenant.NewLocker(), logger.Discard())
+			gotPurged, err := ucase.PurgeTaxObjects(context.Background(), retention)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TaxObjectUsecase.PurgeTaxObjects() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantPurged, gotPurged)
+			taxRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaxObjectUsecase_Order(t *testing.T) {
+	t.Parallel()
+	var (
+		mutex sync.Mutex
+		calls []string
+	)
+	call := func(name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls = append(calls, name)
+	}
+	taxObj := taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000}
+	entered, release := make(chan struct{}), make(chan struct{})
+	taxRepo := &mocksTax.Repository{}
+	taxRepo.On("Delete", mock.Anything, int64(1)).Return(nil).Run(func(mock.Arguments) {
+		call("Delete")
+		close(entered)
+		<-release
+	})
+	taxRepo.On("Restore", mock.Anything, int64(1)).Return(taxObj, nil).Run(func(mock.Arguments) {
+		call("Restore")
+	})
+	billRepo := &mocksBill.Repository{}
+	billRepo.On("Remove", mock.Anything, int64(1)).Run(func(mock.Arguments) {
+		call("Remove")
+	})
+	billRepo.On("Add", mock.Anything, taxObj).Run(func(mock.Arguments) {
+		call("Add")
+	})
+	ucase := NewTaxObjectUsecase(taxRepo, billRepo, 0, tenant.NewLocker(), logger.Discard())
+	ctx := tenant.WithTenant(context.Background(), "merchant-a")
+	var group sync.WaitGroup
+	group.Add(2)
+	go func() {
+		defer group.Done()
+		assert.NoError(t, ucase.DeleteTaxObject(ctx, 1))
+	}()
+	<-entered
+	//The tax object is restored while it's being deleted,
+	//so it's added back to the bill after it's removed, like it's live in the database.
+	go func() {
+		defer group.Done()
+		_, err := ucase.RestoreTaxObject(ctx, 1)
+		assert.NoError(t, err)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	group.Wait()
+	assert.Equal(t, []string{"Delete", "Remove", "Restore", "Add"}, calls)
+}
+
+func TestTaxObjectUsecase_UpdateTaxObject_Order(t *testing.T) {
+	t.Parallel()
+	var (
+		mutex   sync.Mutex
+		updated []string
+	)
+	first := taxobj.TaxObject{ID: 1, Name: "MACD", TaxCode: 1, Price: 20000}
+	second := taxobj.TaxObject{ID: 1, Name: "KFC", TaxCode: 1, Price: 5000}
+	entered, release := make(chan struct{}), make(chan struct{})
+	taxRepo := &mocksTax.Repository{}
+	taxRepo.On("Update", mock.Anything, &first).Return(nil).Run(func(mock.Arguments) {
+		close(entered)
+		<-release
+	})
+	taxRepo.On("Update", mock.Anything, &second).Return(nil)
+	billRepo := &mocksBill.Repository{}
+	billRepo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		updated = append(updated, args.Get(1).(taxobj.TaxObject).Name)
+	})
+	ucase := NewTaxObjectUsecase(taxRepo, billRepo, 0, tenant.NewLocker(), logger.Discard())
+	ctx := tenant.WithTenant(context.Background(), "merchant-a")
+	var group sync.WaitGroup
+	group.Add(2)
+	go func() {
+		defer group.Done()
+		assert.NoError(t, ucase.UpdateTaxObject(ctx, &first))
+	}()
+	<-entered
+	//The second correction is committed after the first one, so the bill keeps it like the database.
+	go func() {
+		defer group.Done()
+		assert.NoError(t, ucase.UpdateTaxObject(ctx, &second))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	group.Wait()
+	assert.Equal(t, []string{"MACD", "KFC"}, updated)
+}
+
 func TestNewTaxObjectUsecase(t *testing.T) {
 	type args struct {
 		taxObjRepo taxobj.Repository
 		billRepo   bill.Repository
+		maxLines   int
+		locker     *tenant.Locker
+		log        logrus.FieldLogger
 	}
 	taxObjRepo := &mocksTax.Repository{}
 	billRepo := &mocksBill.Repository{}
+	locker := tenant.NewLocker()
+	log := logger.Discard()
 	tests := []struct {
 		name string
 		args args
@@ -100,16 +429,22 @@ func TestNewTaxObjectUsecase(t *testing.T) {
 			args: args{
 				taxObjRepo: taxObjRepo,
 				billRepo:   billRepo,
+				maxLines:   100,
+				locker:     locker,
+				log:        log,
 			},
 			want: &TaxObjectUsecase{
 				taxObjRepo: taxObjRepo,
 				billRepo:   billRepo,
+				maxLines:   100,
+				locker:     locker,
+				log:        log,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.EqualValues(t, NewTaxObjectUsecase(taxObjRepo, billRepo), tt.want)
+			assert.EqualValues(t, NewTaxObjectUsecase(tt.args.taxObjRepo, tt.args.billRepo, tt.args.maxLines, tt.args.locker, tt.args.log), tt.want)
 		})
 	}
 }
@@ -0,0 +1,36 @@
+package tenant
+
+import (
+	"context"
+	"sync"
+)
+
+//Locker serializes the changes of every tenant in the process,
+//so the change stored in the database and applied to the cache is never interleaved with another change of the tenant.
+//The changes of the tenant are already serialized by the lock of its bills in the database,
+//so holding the lock of the tenant across them doesn't slow them down.
+type Locker struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+//NewLocker return the locker of the tenants.
+func NewLocker() *Locker {
+	return &Locker{
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+//Lock locks the tenant in ctx and return the function unlocking it.
+func (locker *Locker) Lock(ctx context.Context) (unlock func()) {
+	name := FromContext(ctx)
+	locker.mutex.Lock()
+	lock, ok := locker.locks[name]
+	if !ok {
+		lock = new(sync.Mutex)
+		locker.locks[name] = lock
+	}
+	locker.mutex.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
@@ -0,0 +1,28 @@
+package tenant
+
+import (
+	"context"
+)
+
+//Default defines the tenant of the requests without the authenticated principal,
+//e.g. if the authentication is disabled, and of the rows created before the tenant existed.
+const Default = "default"
+
+type contextKey struct{}
+
+var (
+	tenantKey = contextKey{}
+)
+
+//WithTenant return the copy of ctx carrying the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+//FromContext return the tenant stored in ctx, or the default tenant if there is none.
+func FromContext(ctx context.Context) string {
+	if tenant, _ := ctx.Value(tenantKey).(string); tenant != "" {
+		return tenant
+	}
+	return Default
+}
@@ -0,0 +1,41 @@
+// +build unit
+
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Tenant In Context",
+			ctx:  WithTenant(context.Background(), "merchant-a"),
+			want: "merchant-a",
+		},
+		{
+			name: "Empty Tenant",
+			ctx:  WithTenant(context.Background(), ""),
+			want: Default,
+		},
+		{
+			name: "No Tenant",
+			ctx:  context.Background(),
+			want: Default,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FromContext(tt.ctx))
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/fairyhunter13/tax-calculator/internal/httpstatus"
+	"github.com/labstack/echo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	//ExporterNone disables the tracing.
+	ExporterNone = "none"
+	//ExporterStdout exports the spans to the standard output, e.g. for the local use.
+	ExporterStdout = "stdout"
+	//ExporterOTLP exports the spans to the OTLP collector through http.
+	ExporterOTLP = "otlp"
+
+	instrumentationName = "github.com/fairyhunter13/tax-calculator"
+)
+
+var (
+	//ErrUnknownExporter defines the error returned if the configured exporter is not supported.
+	ErrUnknownExporter = errors.New("Unknown tracing exporter")
+)
+
+//Options define the options to create the tracer provider.
+type Options struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+}
+
+//NewProvider creates the tracer provider with the configured exporter.
+//The returned provider is nil if the tracing is disabled.
+func NewProvider(ctx context.Context, options Options) (provider *sdktrace.TracerProvider, err error) {
+	var exporter sdktrace.SpanExporter
+	switch options.Exporter {
+	case "", ExporterNone:
+		return
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		exporterOptions := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(options.Endpoint),
+		}
+		if options.Insecure {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, exporterOptions...)
+	default:
+		err = ErrUnknownExporter
+	}
+	if err != nil {
+		return
+	}
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(options.ServiceName),
+		)),
+	)
+	return
+}
+
+//Register sets the provider and the W3C trace-context propagator as the global ones.
+func Register(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+//Start starts the span with the given name as the child of the span in ctx.
+func Start(ctx context.Context, spanName string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, spanName, trace.WithAttributes(attributes...))
+}
+
+//End records the error in the span if any and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+//Query return the attributes describing the postgre query.
+func Query(operation string, statement string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation(operation),
+		semconv.DBStatement(statement),
+	}
+}
+
+//Middleware return the echo middleware starting the server span for every request.
+//The trace context sent by the client is extracted from the W3C trace-context headers.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := otel.Tracer(instrumentationName).Start(
+				ctx,
+				req.Method+" "+c.Path(),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.HTTPRoute(c.Path()),
+					semconv.URLPath(req.URL.Path),
+				),
+			)
+			defer span.End()
+			c.SetRequest(req.WithContext(ctx))
+
+			err = next(c)
+			status := httpstatus.Of(c, err)
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if err != nil {
+				span.RecordError(err)
+			}
+			if status >= 500 {
+				span.SetStatus(codes.Error, "")
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// +build unit
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+const (
+	traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		options      Options
+		wantProvider bool
+		wantErr      bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:         "Tracing Disabled",
+			options:      Options{Exporter: ExporterNone},
+			wantProvider: false,
+			wantErr:      false,
+		},
+		{
+			name:         "Stdout Exporter",
+			options:      Options{Exporter: ExporterStdout, ServiceName: "tax-calculator"},
+			wantProvider: true,
+			wantErr:      false,
+		},
+		{
+			name:         "OTLP Exporter",
+			options:      Options{Exporter: ExporterOTLP, Endpoint: "localhost:4318", Insecure: true},
+			wantProvider: true,
+			wantErr:      false,
+		},
+		{
+			name:         "Unknown Exporter",
+			options:      Options{Exporter: "zipkin"},
+			wantProvider: false,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(context.Background(), tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantProvider, provider != nil)
+			if provider != nil {
+				provider.Shutdown(context.Background())
+			}
+		})
+	}
+}
+
+//TestMiddleware can't run in parallel because it sets the global tracer provider.
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer provider.Shutdown(context.Background())
+	Register(provider)
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/bill", func(c echo.Context) (err error) {
+		_, span := Start(c.Request().Context(), "handler")
+		End(span, errors.New("Database is not online"))
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/bill", nil)
+	req.Header.Set("traceparent", traceParent)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	handlerSpan, serverSpan := spans[0], spans[1]
+	assert.Equal(t, "GET /bill", serverSpan.Name())
+	assert.Equal(t, traceID, serverSpan.SpanContext().TraceID().String())
+	assert.Equal(t, serverSpan.SpanContext().SpanID(), handlerSpan.Parent().SpanID())
+	assert.Contains(t, serverSpan.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+	assert.Equal(t, codes.Error, serverSpan.Status().Code)
+	assert.Equal(t, codes.Error, handlerSpan.Status().Code)
+}
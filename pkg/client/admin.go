@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+//The api keys are managed by the administrator, so these requests are authenticated with the admin key.
+
+//CreateAPIKey create the api key and return it with its plain key, the plain key is only returned once.
+func (client *Client) CreateAPIKey(ctx context.Context, apiKey APIKey) (created CreatedAPIKey, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/admin/apikeys",
+		body:   apiKey,
+		admin:  true,
+	}, &created)
+	return
+}
+
+//GetAPIKeys return all api keys.
+func (client *Client) GetAPIKeys(ctx context.Context) (apiKeys []APIKey, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/admin/apikeys",
+		admin:  true,
+	}, &apiKeys)
+	return
+}
+
+//RevokeAPIKey revoke the api key with the id.
+func (client *Client) RevokeAPIKey(ctx context.Context, id int64) (err error) {
+	err = client.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/admin/apikeys/" + strconv.FormatInt(id, 10),
+		admin:  true,
+	}, nil)
+	return
+}
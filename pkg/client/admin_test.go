@@ -0,0 +1,45 @@
+// +build unit
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClient_APIKeys(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	createdAt := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	apiKey := APIKey{ID: 1, Name: "POS", Tenant: "tenant", Role: "clerk", Prefix: "tc_1234", CreatedAt: createdAt}
+	serverKey := auth.APIKey{ID: 1, Name: "POS", Tenant: "tenant", Role: "clerk", Prefix: "tc_1234", Hash: "hash", CreatedAt: createdAt}
+	server.authUcase.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*auth.APIKey")).Return("tc_1234secret", nil).Run(func(args mock.Arguments) {
+		created := args.Get(1).(*auth.APIKey)
+		created.ID = serverKey.ID
+		created.Prefix = serverKey.Prefix
+		created.CreatedAt = serverKey.CreatedAt
+	})
+	server.authUcase.On("GetAPIKeys", mock.Anything).Return([]auth.APIKey{serverKey}, nil)
+	server.authUcase.On("RevokeAPIKey", mock.Anything, int64(1)).Return(nil)
+	server.authUcase.On("RevokeAPIKey", mock.Anything, int64(2)).Return(auth.ErrNotFound)
+
+	client := server.newClient("")
+	created, err := client.CreateAPIKey(context.Background(), APIKey{Name: "POS", Tenant: "tenant", Role: "clerk"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, CreatedAPIKey{APIKey: apiKey, Key: "tc_1234secret"}, created)
+	}
+	_, err = client.CreateAPIKey(context.Background(), APIKey{Name: "POS"})
+	assertError(t, ErrInvalidInput, err)
+	apiKeys, err := client.GetAPIKeys(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, []APIKey{apiKey}, apiKeys)
+	}
+	assert.NoError(t, client.RevokeAPIKey(context.Background(), 1))
+	assertError(t, ErrAPIKeyNotFound, client.RevokeAPIKey(context.Background(), 2))
+}
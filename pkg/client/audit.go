@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+//GetEvents return the audit events of the tenant matching the filter.
+func (client *Client) GetEvents(ctx context.Context, filter Filter) (events []Event, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/audit",
+		query:  filterQuery(filter),
+	}, &events)
+	return
+}
+
+//GetHead return the head of the hash chain of the audit events of the tenant.
+func (client *Client) GetHead(ctx context.Context) (head ChainHead, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/audit/head",
+	}, &head)
+	return
+}
+
+//filterQuery return the query parameters of the filter, the empty fields are omitted.
+func filterQuery(filter Filter) url.Values {
+	query := url.Values{}
+	if filter.Actor != "" {
+		query.Set("actor", filter.Actor)
+	}
+	if filter.Action != "" {
+		query.Set("action", filter.Action)
+	}
+	if filter.Entity != "" {
+		query.Set("entity", filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		query.Set("entity_id", strconv.FormatInt(filter.EntityID, 10))
+	}
+	if !filter.From.IsZero() {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	return query
+}
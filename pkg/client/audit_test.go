@@ -0,0 +1,86 @@
+// +build unit
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testHead   = ChainHead{EventID: 2, Hash: "hash"}
+	serverHead = audit.ChainHead{EventID: 2, Hash: "hash"}
+)
+
+func TestClient_GetEvents(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	from := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	filter := Filter{
+		Actor:    "clerk",
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityTaxObject,
+		EntityID: 1,
+		From:     from,
+		To:       from.Add(24 * time.Hour),
+		Limit:    10,
+	}
+	events := []Event{{ID: 1, Actor: "clerk", Action: audit.ActionCreate, Entity: audit.EntityTaxObject, EntityID: 1, CreatedAt: from}}
+	server.auditUcase.On("GetEvents", mock.Anything, audit.Filter{
+		Actor:    "clerk",
+		Action:   audit.ActionCreate,
+		Entity:   audit.EntityTaxObject,
+		EntityID: 1,
+		From:     from,
+		To:       from.Add(24 * time.Hour),
+		Limit:    10,
+	}).Return([]audit.Event{
+		{ID: 1, Tenant: "tenant", Actor: "clerk", Action: audit.ActionCreate, Entity: audit.EntityTaxObject, EntityID: 1, CreatedAt: from},
+	}, nil)
+	server.auditUcase.On("GetEvents", mock.Anything, audit.Filter{}).Return([]audit.Event{}, nil)
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []Event
+	}{
+		// TODO: Add test cases.
+		{
+			name:   "Filtered",
+			filter: filter,
+			want:   events,
+		},
+		{
+			name: "Not Filtered",
+			want: []Event{},
+		},
+	}
+	client := server.newClient(testAPIKey)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.GetEvents(context.Background(), tt.filter)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_GetHead(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	server.auditUcase.On("GetHead", mock.Anything).Return(serverHead, nil)
+
+	got, err := server.newClient(testAPIKey).GetHead(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, testHead, got)
+	}
+	_, err = server.newClient(testViewerKey).GetHead(context.Background())
+	assertError(t, ErrForbidden, err)
+}
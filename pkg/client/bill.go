@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+//GetBill return the open bill of the tenant.
+//The lines are explained if explain is true.
+func (client *Client) GetBill(ctx context.Context, explain bool) (billResp BillResponse, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/bill",
+		query:  explainQuery(explain),
+	}, &billResp)
+	return
+}
+
+//FinalizeBill finalize the open bill with the id into the invoice.
+func (client *Client) FinalizeBill(ctx context.Context, id int64) (invoice Invoice, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodPost,
+		path:   billPath(id) + "/finalize",
+	}, &invoice)
+	return
+}
+
+//AddCoupon apply the coupon to the open bill with the id and return the applied coupons.
+func (client *Client) AddCoupon(ctx context.Context, id int64, coupon Coupon) (coupons []Coupon, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodPost,
+		path:   billPath(id) + "/coupons",
+		body:   coupon,
+	}, &coupons)
+	return
+}
+
+//RemoveCoupon remove the coupon with the code from the open bill with the id.
+func (client *Client) RemoveCoupon(ctx context.Context, id int64, code string) (err error) {
+	err = client.do(ctx, request{
+		method: http.MethodDelete,
+		path:   billPath(id) + "/coupons/" + url.PathEscape(code),
+	}, nil)
+	return
+}
+
+//AddCharge add the charge to the open bill with the id and return the added charges.
+func (client *Client) AddCharge(ctx context.Context, id int64, charge Charge) (charges []Charge, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodPost,
+		path:   billPath(id) + "/charges",
+		body:   charge,
+	}, &charges)
+	return
+}
+
+//RemoveCharge remove the charge with the name from the open bill with the id.
+func (client *Client) RemoveCharge(ctx context.Context, id int64, name string) (err error) {
+	err = client.do(ctx, request{
+		method: http.MethodDelete,
+		path:   billPath(id) + "/charges/" + url.PathEscape(name),
+	}, nil)
+	return
+}
+
+//GetInvoice return the invoice with the number as it was issued.
+func (client *Client) GetInvoice(ctx context.Context, number int64) (invoice Invoice, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/invoices/" + strconv.FormatInt(number, 10),
+	}, &invoice)
+	return
+}
+
+//RefundInvoice refund the lines of the invoice with the number and return the issued credit note.
+//All lines that haven't been refunded are refunded if lines is empty.
+func (client *Client) RefundInvoice(ctx context.Context, number int64, lines []int) (creditNote CreditNote, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/invoices/" + strconv.FormatInt(number, 10) + "/refunds",
+		body:   refundRequest{Lines: lines},
+	}, &creditNote)
+	return
+}
+
+//GetCreditNote return the credit note with the number as it was issued.
+func (client *Client) GetCreditNote(ctx context.Context, number int64) (creditNote CreditNote, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/credit-notes/" + strconv.FormatInt(number, 10),
+	}, &creditNote)
+	return
+}
+
+//billPath return the path of the bill with the id.
+func billPath(id int64) string {
+	return "/bills/" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,264 @@
+// +build unit
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testLines = []Bill{
+		{
+			Name:        "Big Mac",
+			TaxCode:     1,
+			Type:        "Food & Beverage",
+			Refundable:  "Yes",
+			Quantity:    1,
+			UnitPrice:   1000,
+			Price:       1000,
+			TaxableBase: 1000,
+			Tax:         100,
+			Taxes:       []TaxComponent{{TaxCode: 1, Type: "Food & Beverage", Base: 1000, Tax: 100}},
+			Amount:      1100,
+		},
+	}
+	testTotal = Total{PriceSubtotal: 1000, NetSubtotal: 1000, TaxSubtotal: 100, GrandTotal: 1100}
+	testIssue = time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	//serverLines and serverTotal are the same lines and total returned by the usecases of the server.
+	serverLines = []bill.Bill{
+		{
+			Name:        "Big Mac",
+			TaxCode:     1,
+			Type:        "Food & Beverage",
+			Refundable:  "Yes",
+			Quantity:    1,
+			UnitPrice:   1000,
+			Price:       1000,
+			TaxableBase: 1000,
+			Tax:         100,
+			Taxes:       []bill.TaxComponent{{TaxCode: 1, Type: "Food & Beverage", Base: 1000, Tax: 100}},
+			Amount:      1100,
+		},
+	}
+	serverTotal = bill.Total{PriceSubtotal: 1000, NetSubtotal: 1000, TaxSubtotal: 100, GrandTotal: 1100}
+)
+
+func TestClient_GetBill(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	server.billUcase.On("GetBill", mock.Anything).Return(bill.Snapshot{
+		ID:        1,
+		Bills:     serverLines,
+		Coupons:   []bill.Coupon{{Code: "PROMO", Discount: taxobj.Discount{Type: "percent", Value: 10}}},
+		Charges:   []bill.ChargeLine{{Charge: bill.Charge{Name: "Service", Type: "percent", Value: 5}, Price: 50, Amount: 50}},
+		Breakdown: []bill.Breakdown{{TaxCode: 1, Type: "Food & Beverage"}},
+		Total:     serverTotal,
+	})
+	coupons := []Coupon{{Code: "PROMO", Discount: Discount{Type: "percent", Value: 10}}}
+	charges := []ChargeLine{{Charge: Charge{Name: "Service", Type: "percent", Value: 5}, Price: 50, Amount: 50}}
+	breakdown := []Breakdown{{TaxCode: 1, Type: "Food & Beverage"}}
+	var explained []Bill
+	fromServer(t, taxcalc.ExplainAll(testRules, serverLines), &explained)
+	tests := []struct {
+		name    string
+		apiKey  string
+		explain bool
+		want    BillResponse
+	}{
+		// TODO: Add test cases.
+		{
+			name:   "Positive Case",
+			apiKey: testAPIKey,
+			want: BillResponse{
+				ID:        1,
+				Bill:      testLines,
+				Coupons:   coupons,
+				Charges:   charges,
+				Breakdown: breakdown,
+				Total:     testTotal,
+			},
+		},
+		{
+			name:    "Explained",
+			apiKey:  testViewerKey,
+			explain: true,
+			want: BillResponse{
+				ID:        1,
+				Bill:      explained,
+				Coupons:   coupons,
+				Charges:   charges,
+				Breakdown: breakdown,
+				Total:     testTotal,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.newClient(tt.apiKey).GetBill(context.Background(), tt.explain)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_FinalizeBill(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	invoice := Invoice{Number: 1, BillID: 1, Bill: testLines, Total: testTotal, IssuedAt: testIssue}
+	server.billUcase.On("FinalizeBill", mock.Anything, int64(1)).Return(bill.Invoice{
+		Number:   1,
+		BillID:   1,
+		Bill:     serverLines,
+		Total:    serverTotal,
+		IssuedAt: testIssue,
+		Tenant:   "tenant",
+	}, nil)
+	server.billUcase.On("FinalizeBill", mock.Anything, int64(2)).Return(bill.Invoice{}, bill.ErrEmpty)
+	server.billUcase.On("FinalizeBill", mock.Anything, int64(3)).Return(bill.Invoice{}, bill.ErrNotFound)
+
+	client := server.newClient(testAPIKey)
+	got, err := client.FinalizeBill(context.Background(), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, invoice, got)
+	}
+	_, err = client.FinalizeBill(context.Background(), 2)
+	assertError(t, ErrBillEmpty, err)
+	_, err = client.FinalizeBill(context.Background(), 3)
+	assertError(t, ErrBillNotFound, err)
+}
+
+func TestClient_Coupons(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	coupon := Coupon{Code: "PROMO 10", Discount: Discount{Type: "percent", Value: 10}}
+	serverCoupon := bill.Coupon{Code: "PROMO 10", Discount: taxobj.Discount{Type: "percent", Value: 10}}
+	server.billUcase.On("AddCoupon", mock.Anything, int64(1), serverCoupon).Return([]bill.Coupon{serverCoupon}, nil)
+	server.billUcase.On("AddCoupon", mock.Anything, int64(2), serverCoupon).Return(nil, bill.ErrCouponExists)
+	server.billUcase.On("RemoveCoupon", mock.Anything, int64(1), "PROMO 10").Return(nil)
+	server.billUcase.On("RemoveCoupon", mock.Anything, int64(2), "PROMO 10").Return(bill.ErrCouponNotFound)
+
+	client := server.newClient(testAPIKey)
+	got, err := client.AddCoupon(context.Background(), 1, coupon)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Coupon{coupon}, got)
+	}
+	_, err = client.AddCoupon(context.Background(), 2, coupon)
+	assertError(t, ErrCouponExists, err)
+	_, err = client.AddCoupon(context.Background(), 1, Coupon{Code: "INVALID"})
+	assertError(t, ErrInvalidInput, err)
+	assert.NoError(t, client.RemoveCoupon(context.Background(), 1, "PROMO 10"))
+	assertError(t, ErrCouponNotFound, client.RemoveCoupon(context.Background(), 2, "PROMO 10"))
+}
+
+func TestClient_Charges(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	charge := Charge{Name: "Service Tip", TaxCode: 1, Type: "percent", Value: 5}
+	serverCharge := bill.Charge{Name: "Service Tip", TaxCode: 1, Type: "percent", Value: 5}
+	server.billUcase.On("AddCharge", mock.Anything, int64(1), serverCharge).Return([]bill.Charge{serverCharge}, nil)
+	server.billUcase.On("AddCharge", mock.Anything, int64(2), serverCharge).Return(nil, bill.ErrFinalized)
+	server.billUcase.On("RemoveCharge", mock.Anything, int64(1), "Service Tip").Return(nil)
+	server.billUcase.On("RemoveCharge", mock.Anything, int64(2), "Service Tip").Return(bill.ErrChargeNotFound)
+
+	client := server.newClient(testAPIKey)
+	got, err := client.AddCharge(context.Background(), 1, charge)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Charge{charge}, got)
+	}
+	_, err = client.AddCharge(context.Background(), 2, charge)
+	assertError(t, ErrFinalized, err)
+	_, err = client.AddCharge(context.Background(), 1, Charge{Name: "Service", Type: "percent", Value: 5, Jurisdiction: "UNKNOWN"})
+	assertError(t, ErrJurisdictionNotFound, err)
+	assert.NoError(t, client.RemoveCharge(context.Background(), 1, "Service Tip"))
+	assertError(t, ErrChargeNotFound, client.RemoveCharge(context.Background(), 2, "Service Tip"))
+}
+
+func TestClient_GetInvoice(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	invoice := Invoice{Number: 1, BillID: 1, Bill: testLines, Total: testTotal, IssuedAt: testIssue}
+	server.billUcase.On("GetInvoice", mock.Anything, int64(1)).Return(bill.Invoice{
+		Number:   1,
+		BillID:   1,
+		Bill:     serverLines,
+		Total:    serverTotal,
+		IssuedAt: testIssue,
+	}, nil)
+	server.billUcase.On("GetInvoice", mock.Anything, int64(2)).Return(bill.Invoice{}, bill.ErrInvoiceNotFound)
+
+	client := server.newClient(testViewerKey)
+	got, err := client.GetInvoice(context.Background(), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, invoice, got)
+	}
+	_, err = client.GetInvoice(context.Background(), 2)
+	assertError(t, ErrInvoiceNotFound, err)
+}
+
+func TestClient_RefundInvoice(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	creditNote := CreditNote{
+		Number:        1,
+		InvoiceNumber: 1,
+		Lines:         []CreditLine{{Line: 0, Bill: testLines[0]}},
+		Total:         testTotal,
+		IssuedAt:      testIssue,
+	}
+	server.billUcase.On("RefundInvoice", mock.Anything, int64(1), []int{0}).Return(bill.CreditNote{
+		Number:        1,
+		InvoiceNumber: 1,
+		Lines:         []bill.CreditLine{{Line: 0, Bill: serverLines[0]}},
+		Total:         serverTotal,
+		IssuedAt:      testIssue,
+	}, nil)
+	server.billUcase.On("RefundInvoice", mock.Anything, int64(1), []int(nil)).Return(bill.CreditNote{}, bill.ErrRefunded)
+	server.billUcase.On("RefundInvoice", mock.Anything, int64(1), []int{5}).Return(bill.CreditNote{}, bill.ErrLineNotFound)
+
+	client := server.newClient(testAPIKey)
+	got, err := client.RefundInvoice(context.Background(), 1, []int{0})
+	if assert.NoError(t, err) {
+		assert.Equal(t, creditNote, got)
+	}
+	_, err = client.RefundInvoice(context.Background(), 1, nil)
+	assertError(t, ErrRefunded, err)
+	_, err = client.RefundInvoice(context.Background(), 1, []int{5})
+	assertError(t, ErrLineNotFound, err)
+}
+
+func TestClient_GetCreditNote(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	creditNote := CreditNote{Number: 1, InvoiceNumber: 1, Total: testTotal, IssuedAt: testIssue}
+	server.billUcase.On("GetCreditNote", mock.Anything, int64(1)).Return(bill.CreditNote{
+		Number:        1,
+		InvoiceNumber: 1,
+		Total:         serverTotal,
+		IssuedAt:      testIssue,
+	}, nil)
+	server.billUcase.On("GetCreditNote", mock.Anything, int64(2)).Return(bill.CreditNote{}, bill.ErrCreditNoteNotFound)
+
+	client := server.newClient(testViewerKey)
+	got, err := client.GetCreditNote(context.Background(), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, creditNote, got)
+	}
+	_, err = client.GetCreditNote(context.Background(), 2)
+	assertError(t, ErrCreditNoteNotFound, err)
+}
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	//DefaultMaxRetries defines the maximum retries of the idempotent request if it's not configured.
+	DefaultMaxRetries = 3
+	//DefaultBackoff defines the wait before the first retry if it's not configured.
+	DefaultBackoff = 100 * time.Millisecond
+	//DefaultMaxBackoff defines the maximum wait between the retries if it's not configured.
+	DefaultMaxBackoff = 2 * time.Second
+)
+
+//Headers of the requests and the responses of the API.
+const (
+	//HeaderAPIKey defines the header sending the api key.
+	HeaderAPIKey = "X-API-Key"
+	//HeaderAdminKey defines the header sending the admin key.
+	HeaderAdminKey = "X-Admin-Key"
+	//HeaderRequestID defines the header propagating the request id.
+	HeaderRequestID = "X-Request-ID"
+	//HeaderRetryAfter defines the header telling how many seconds to wait before retrying.
+	HeaderRetryAfter = "Retry-After"
+	//mimeJSON defines the content type of the request and the response.
+	mimeJSON = "application/json"
+)
+
+//contextKey define the type of the keys of the values stored in the context by the client.
+type contextKey int
+
+//requestIDKey is the key of the request id stored in the context.
+const requestIDKey contextKey = 0
+
+//Options define the options of the client.
+type Options struct {
+	//APIKey is sent in the X-API-Key header to authenticate the client.
+	APIKey string
+	//Token is sent as the bearer token if the api key is empty.
+	Token string
+	//AdminKey is sent in the X-Admin-Key header of the requests managing the api keys.
+	AdminKey string
+	//HTTPClient sends the requests, http.DefaultClient is used if it's nil.
+	HTTPClient *http.Client
+	//MaxRetries is the maximum retries of the idempotent request, DefaultMaxRetries is used if it's zero
+	//and the request is never retried if it's negative.
+	MaxRetries int
+	//Backoff is the wait before the first retry, it's doubled on every next retry up to MaxBackoff.
+	Backoff time.Duration
+	//MaxBackoff is the maximum wait between the retries.
+	MaxBackoff time.Duration
+}
+
+//Client define the client of the tax calculator API.
+//Every method is bound to the context, so the request is canceled with the context.
+//The idempotent requests are retried with the exponential backoff
+//if the request fails to be sent or the server is temporarily unavailable.
+type Client struct {
+	baseURL string
+	options Options
+}
+
+//request define the request sent by the client.
+//The request is idempotent if it can be sent many times with the same effect.
+type request struct {
+	method     string
+	path       string
+	query      url.Values
+	body       interface{}
+	admin      bool
+	idempotent bool
+}
+
+//WithRequestID return the copy of ctx carrying the request id, it's sent with every request bound to the context,
+//so the request can be found in the logs of the server.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+//New create the client of the API served in the base url, e.g. http://localhost:8080.
+func New(baseURL string, options Options) *Client {
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = DefaultMaxRetries
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = DefaultBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = DefaultMaxBackoff
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		options: options,
+	}
+}
+
+//do send the request and decode the response body to the result.
+//The error response is returned as *Error.
+func (client *Client) do(ctx context.Context, req request, result interface{}) (err error) {
+	var body []byte
+	if req.body != nil {
+		if body, err = json.Marshal(req.body); err != nil {
+			return
+		}
+	}
+	retries := 0
+	if req.idempotent || isIdempotent(req.method) {
+		retries = client.options.MaxRetries
+	}
+	//unanswered is true if an earlier attempt may have reached the handler, i.e. it got no response or the server error.
+	unanswered := false
+	for attempt := 0; ; attempt++ {
+		var (
+			resp       *http.Response
+			retryAfter time.Duration
+		)
+		resp, err = client.send(ctx, req, body)
+		if err == nil {
+			if unanswered && isDeleted(req.method, resp.StatusCode) {
+				drain(resp)
+				return nil
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get(HeaderRetryAfter))
+			//The server asking to wait longer than the maximum backoff isn't retried, so the caller is never blocked longer than it.
+			if !isTemporary(resp.StatusCode) || attempt >= retries || retryAfter > client.options.MaxBackoff {
+				return decode(resp, result)
+			}
+			unanswered = unanswered || resp.StatusCode >= http.StatusInternalServerError
+			drain(resp)
+		} else {
+			unanswered = true
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= retries {
+			return
+		}
+		if err = client.wait(ctx, attempt, retryAfter); err != nil {
+			return
+		}
+	}
+}
+
+//send send the request once with the encoded body.
+func (client *Client) send(ctx context.Context, req request, body []byte) (resp *http.Response, err error) {
+	target := client.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequest(req.method, target, reader)
+	if err != nil {
+		return
+	}
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header.Set("Accept", mimeJSON)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", mimeJSON)
+	}
+	if requestID, _ := ctx.Value(requestIDKey).(string); requestID != "" {
+		httpReq.Header.Set(HeaderRequestID, requestID)
+	}
+	switch {
+	case req.admin:
+		httpReq.Header.Set(HeaderAdminKey, client.options.AdminKey)
+	case client.options.APIKey != "":
+		httpReq.Header.Set(HeaderAPIKey, client.options.APIKey)
+	case client.options.Token != "":
+		httpReq.Header.Set("Authorization", "Bearer "+client.options.Token)
+	}
+	return client.options.HTTPClient.Do(httpReq)
+}
+
+//wait wait before the next retry or until the context is done.
+//The wait is the exponential backoff with the jitter, or the wait requested by the server if it's longer,
+//which is at most the maximum backoff.
+func (client *Client) wait(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	backoff := client.options.Backoff << uint(attempt)
+	if backoff <= 0 || backoff > client.options.MaxBackoff {
+		backoff = client.options.MaxBackoff
+	}
+	backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	if retryAfter > backoff {
+		backoff = retryAfter
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+//decode decode the response body to the result, or return the error response as *Error.
+func decode(resp *http.Response, result interface{}) (err error) {
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newError(resp, content)
+	}
+	if result == nil || len(content) == 0 {
+		return
+	}
+	return json.Unmarshal(content, result)
+}
+
+//drain discard the response body of the retried request, so the connection can be reused.
+func drain(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+//isIdempotent return true if the request with the method can be retried.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+//isDeleted return true if the retried request deleting the resource finds nothing,
+//because the unanswered attempt may have deleted it, e.g. the response was lost after the tax object was soft deleted.
+//It's only checked after the attempt without the response or with the server error,
+//the rate limited attempt never reaches the handler, so the resource not found after it is never deleted by the request.
+func isDeleted(method string, statusCode int) bool {
+	return method == http.MethodDelete && statusCode == http.StatusNotFound
+}
+
+//isTemporary return true if the request failing with the status code may succeed if it's retried.
+func isTemporary(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+//parseRetryAfter parse the Retry-After header in seconds, it's zero if the header is not valid.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,388 @@
+// +build unit
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	auditDelivery "github.com/fairyhunter13/tax-calculator/internal/audit/delivery"
+	mocksAudit "github.com/fairyhunter13/tax-calculator/internal/audit/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/auth"
+	authDelivery "github.com/fairyhunter13/tax-calculator/internal/auth/delivery"
+	mocksAuth "github.com/fairyhunter13/tax-calculator/internal/auth/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	billDelivery "github.com/fairyhunter13/tax-calculator/internal/bill/delivery"
+	mocksBill "github.com/fairyhunter13/tax-calculator/internal/bill/mocks"
+	"github.com/fairyhunter13/tax-calculator/internal/limit"
+	"github.com/fairyhunter13/tax-calculator/internal/logger"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	taxobjDelivery "github.com/fairyhunter13/tax-calculator/internal/taxobj/delivery"
+	mocksTax "github.com/fairyhunter13/tax-calculator/internal/taxobj/mocks"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testAPIKey    = "clerk-key"
+	testViewerKey = "viewer-key"
+	testAdminKey  = "admin-key"
+)
+
+var (
+	//testRules are the rules of the server explaining the bill lines.
+	testRules = taxcalc.NewJurisdictions()
+	//handlerMutex serializes the registration of the handlers, they are stored in the package variables.
+	handlerMutex sync.Mutex
+	testPolicy   = auth.Policy{
+		auth.PermissionCreateTax:    {"clerk"},
+		auth.PermissionPreviewTax:   {"clerk", "viewer"},
+		auth.PermissionUpdateTax:    {"clerk"},
+		auth.PermissionDeleteTax:    {"clerk"},
+		auth.PermissionReadBill:     {"clerk", "viewer"},
+		auth.PermissionFinalizeBill: {"clerk"},
+		auth.PermissionRefundBill:   {"clerk"},
+		auth.PermissionDiscountBill: {"clerk"},
+		auth.PermissionChargeBill:   {"clerk"},
+		auth.PermissionReadAudit:    {"clerk"},
+	}
+)
+
+//testServer define the httptest server running the real handlers with the mocked usecases.
+//The first failures requests are responded with the failure status before they reach the handlers.
+type testServer struct {
+	*httptest.Server
+	taxUcase   *mocksTax.Usecase
+	billUcase  *mocksBill.Usecase
+	auditUcase *mocksAudit.Usecase
+	authUcase  *mocksAuth.Usecase
+	failures   int32
+	failure    int
+	requests   int32
+}
+
+func newTestServer(failures int32, failure int) *testServer {
+	server := &testServer{
+		taxUcase:   &mocksTax.Usecase{},
+		billUcase:  &mocksBill.Usecase{},
+		auditUcase: &mocksAudit.Usecase{},
+		authUcase:  &mocksAuth.Usecase{},
+		failures:   failures,
+		failure:    failure,
+	}
+	server.authUcase.On("AuthenticateAPIKey", mock.Anything, testAPIKey).Return(auth.Principal{
+		Subject: "clerk",
+		Method:  auth.MethodAPIKey,
+		Tenant:  "tenant",
+		Roles:   []string{"clerk"},
+	}, nil)
+	server.authUcase.On("AuthenticateAPIKey", mock.Anything, testViewerKey).Return(auth.Principal{
+		Subject: "viewer",
+		Method:  auth.MethodAPIKey,
+		Tenant:  "tenant",
+		Roles:   []string{"viewer"},
+	}, nil)
+	server.authUcase.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return(auth.Principal{}, auth.ErrInvalidCredentials)
+
+	log := logger.Discard()
+	e := echo.New()
+	e.Use(logger.Middleware(log))
+	guard := authDelivery.NewGuard(server.authUcase, testPolicy, log)
+	handlerMutex.Lock()
+	billDelivery.NewHTTPBillHandler(e, server.billUcase, testRules, log, guard)
+	taxobjDelivery.NewTaxObjectHandler(e, server.taxUcase, server.billUcase, testRules, log, guard)
+	auditDelivery.NewHTTPAuditHandler(e, server.auditUcase, log, guard)
+	authDelivery.NewAPIKeyHandler(e, server.authUcase, log, authDelivery.AdminMiddleware(auth.HashKey(testAdminKey), log))
+	handlerMutex.Unlock()
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&server.requests, 1)
+		if atomic.AddInt32(&server.failures, -1) >= 0 {
+			w.Header().Set(limit.HeaderRetryAfter, "0")
+			w.WriteHeader(server.failure)
+			return
+		}
+		e.ServeHTTP(w, r)
+	}))
+	return server
+}
+
+//newClient return the client of the server authenticated with the api key, it retries without waiting long.
+func (server *testServer) newClient(apiKey string) *Client {
+	return New(server.URL+"/", Options{
+		APIKey:     apiKey,
+		AdminKey:   testAdminKey,
+		Backoff:    time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+	})
+}
+
+func TestClient_Retry(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		failures     int32
+		failure      int
+		maxRetries   int
+		call         func(*Client) error
+		wantErr      error
+		wantRequests int32
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "Idempotent Request Retried",
+			failures: 2,
+			failure:  http.StatusServiceUnavailable,
+			call: func(client *Client) error {
+				_, err := client.GetHead(context.Background())
+				return err
+			},
+			wantRequests: 3,
+		},
+		{
+			name:     "Rate Limited Request Retried",
+			failures: 1,
+			failure:  http.StatusTooManyRequests,
+			call: func(client *Client) error {
+				return client.DeleteTaxObject(context.Background(), 1)
+			},
+			wantRequests: 2,
+		},
+		{
+			name:     "Retried Delete Not Found",
+			failures: 1,
+			failure:  http.StatusBadGateway,
+			call: func(client *Client) error {
+				return client.DeleteTaxObject(context.Background(), 404)
+			},
+			wantRequests: 2,
+		},
+		{
+			name:     "Rate Limited Delete Not Found",
+			failures: 1,
+			failure:  http.StatusTooManyRequests,
+			call: func(client *Client) error {
+				return client.DeleteTaxObject(context.Background(), 404)
+			},
+			wantErr:      ErrTaxObjectNotFound,
+			wantRequests: 2,
+		},
+		{
+			name:     "Preview Retried",
+			failures: 1,
+			failure:  http.StatusBadGateway,
+			call: func(client *Client) error {
+				_, err := client.PreviewTaxObjects(context.Background(), []TaxObject{{Name: "Lucky Stretch", TaxCode: 2, UnitPrice: 1000}}, false)
+				return err
+			},
+			wantRequests: 2,
+		},
+		{
+			name:       "Retries Exhausted",
+			failures:   5,
+			failure:    http.StatusServiceUnavailable,
+			maxRetries: 2,
+			call: func(client *Client) error {
+				_, err := client.GetHead(context.Background())
+				return err
+			},
+			wantErr:      &Error{StatusCode: http.StatusServiceUnavailable, Message: "Service Unavailable"},
+			wantRequests: 3,
+		},
+		{
+			name:       "Retries Disabled",
+			failures:   1,
+			failure:    http.StatusServiceUnavailable,
+			maxRetries: -1,
+			call: func(client *Client) error {
+				_, err := client.GetHead(context.Background())
+				return err
+			},
+			wantErr:      &Error{StatusCode: http.StatusServiceUnavailable, Message: "Service Unavailable"},
+			wantRequests: 1,
+		},
+		{
+			name:     "Not Idempotent Request",
+			failures: 1,
+			failure:  http.StatusServiceUnavailable,
+			call: func(client *Client) error {
+				_, err := client.CreateTaxObject(context.Background(), TaxObject{Name: "Big Mac", TaxCode: 1, UnitPrice: 1000})
+				return err
+			},
+			wantErr:      &Error{StatusCode: http.StatusServiceUnavailable, Message: "Service Unavailable"},
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := newTestServer(tt.failures, tt.failure)
+			defer server.Close()
+			server.auditUcase.On("GetHead", mock.Anything).Return(serverHead, nil)
+			server.taxUcase.On("DeleteTaxObject", mock.Anything, int64(1)).Return(nil)
+			server.taxUcase.On("DeleteTaxObject", mock.Anything, int64(404)).Return(taxobj.ErrNotFound)
+			server.taxUcase.On("CheckBillLines", mock.Anything, 1).Return(nil)
+			server.billUcase.On("PreviewBill", mock.Anything, mock.Anything).Return(nil, bill.Total{})
+			client := server.newClient(testAPIKey)
+			client.options.MaxRetries = tt.maxRetries
+			if tt.maxRetries == 0 {
+				client.options.MaxRetries = DefaultMaxRetries
+			}
+			err := tt.call(client)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+			}
+			assert.Equal(t, tt.wantRequests, atomic.LoadInt32(&server.requests))
+		})
+	}
+}
+
+//roundTripFunc define the transport of the http client by the function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestClient_Retry_NoResponse(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	server.taxUcase.On("DeleteTaxObject", mock.Anything, int64(404)).Return(taxobj.ErrNotFound)
+	client := server.newClient(testAPIKey)
+	//The response of the first attempt is lost, so it may have deleted the tax object.
+	var lost int32
+	client.options.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&lost, 1) == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	assert.NoError(t, client.DeleteTaxObject(context.Background(), 404))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lost))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.requests))
+}
+
+func TestClient_Retry_LongRetryAfter(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	client := server.newClient(testAPIKey)
+	client.options.MaxBackoff = 10 * time.Millisecond
+	//The server asks to wait an hour, which is longer than the maximum backoff.
+	var requests int32
+	client.options.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		recorder := httptest.NewRecorder()
+		recorder.Header().Set(HeaderRetryAfter, "3600")
+		recorder.WriteHeader(http.StatusTooManyRequests)
+		return recorder.Result(), nil
+	})}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := client.GetHead(ctx)
+	assert.True(t, errors.Is(err, ErrTooManyRequests), "unexpected error: %v", err)
+	assert.NoError(t, ctx.Err())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestClient_Error(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	server.taxUcase.On("DeleteTaxObject", mock.Anything, int64(404)).Return(taxobj.ErrNotFound)
+	tests := []struct {
+		name       string
+		apiKey     string
+		call       func(*Client) error
+		wantErr    error
+		wantReason bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:   "Unauthorized",
+			apiKey: "unknown",
+			call: func(client *Client) error {
+				_, err := client.GetBill(context.Background(), false)
+				return err
+			},
+			wantErr: ErrUnauthorized,
+		},
+		{
+			name:   "Forbidden",
+			apiKey: testViewerKey,
+			call: func(client *Client) error {
+				return client.DeleteTaxObject(context.Background(), 1)
+			},
+			wantErr:    ErrForbidden,
+			wantReason: true,
+		},
+		{
+			name:   "Not Found",
+			apiKey: testAPIKey,
+			call: func(client *Client) error {
+				return client.DeleteTaxObject(context.Background(), 404)
+			},
+			wantErr: ErrTaxObjectNotFound,
+		},
+		{
+			name:   "Admin Unauthorized",
+			apiKey: testAPIKey,
+			call: func(client *Client) error {
+				client.options.AdminKey = "unknown"
+				_, err := client.GetAPIKeys(context.Background())
+				return err
+			},
+			wantErr: ErrUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(server.newClient(tt.apiKey))
+			assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+			var apiErr *Error
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.NotEmpty(t, apiErr.RequestID)
+				assert.Equal(t, tt.wantReason, apiErr.Reason != "")
+			}
+		})
+	}
+}
+
+func TestClient_Context(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(100, http.StatusServiceUnavailable)
+	defer server.Close()
+	client := server.newClient(testAPIKey)
+	client.options.Backoff = time.Hour
+	client.options.MaxBackoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.GetBill(ctx, false)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.requests))
+}
+
+func TestClient_RequestID(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	server.taxUcase.On("DeleteTaxObject", mock.Anything, int64(404)).Return(taxobj.ErrNotFound)
+	ctx := WithRequestID(context.Background(), "request-id")
+	err := server.newClient(testAPIKey).DeleteTaxObject(ctx, 404)
+	var apiErr *Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "request-id", apiErr.RequestID)
+	}
+}
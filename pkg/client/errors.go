@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+//Error define the error response of the API.
+//The reason is only returned if the request is forbidden.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
+	Reason     string `json:"reason,omitempty"`
+	RequestID  string `json:"-"`
+}
+
+//Errors returned by the API, compare them with the returned error using errors.Is.
+//They have the status codes and the messages of the errors returned by the handlers.
+var (
+	//ErrUnauthorized defines the error if the api key, the token, or the admin key is not valid.
+	ErrUnauthorized = &Error{StatusCode: http.StatusUnauthorized, Message: "Unauthorized"}
+	//ErrForbidden defines the error if the role of the client is not allowed to do the request.
+	ErrForbidden = &Error{StatusCode: http.StatusForbidden, Message: "Forbidden"}
+	//ErrTooManyRequests defines the error if the client exceeds its rate limit.
+	ErrTooManyRequests = &Error{StatusCode: http.StatusTooManyRequests, Message: "Too Many Requests"}
+	//ErrInvalidInput defines the error if the request has any invalid value.
+	ErrInvalidInput = &Error{StatusCode: http.StatusBadRequest, Message: "Invalid input"}
+	//ErrJurisdictionNotFound defines the error if the jurisdiction of the tax object or the charge isn't registered.
+	ErrJurisdictionNotFound = &Error{StatusCode: http.StatusBadRequest, Message: "Jurisdiction not found"}
+	//ErrPriceMismatch defines the error if the price of the tax object isn't its quantity times its unit price.
+	ErrPriceMismatch = &Error{StatusCode: http.StatusBadRequest, Message: "The price doesn't equal the quantity times the unit price"}
+	//ErrTaxObjectNotFound defines the error if the tax object doesn't exist.
+	ErrTaxObjectNotFound = &Error{StatusCode: http.StatusNotFound, Message: "Tax object not found"}
+	//ErrDeletedNotFound defines the error if the deleted tax object doesn't exist.
+	ErrDeletedNotFound = &Error{StatusCode: http.StatusNotFound, Message: "Deleted tax object not found"}
+	//ErrBillFull defines the error if the bill has reached the maximum lines.
+	ErrBillFull = &Error{StatusCode: http.StatusRequestEntityTooLarge, Message: "The bill has reached the maximum lines"}
+	//ErrFinalized defines the error if the bill has been finalized.
+	ErrFinalized = &Error{StatusCode: http.StatusConflict, Message: "The bill has been finalized"}
+	//ErrBillNotFound defines the error if the bill doesn't exist.
+	ErrBillNotFound = &Error{StatusCode: http.StatusNotFound, Message: "Bill not found"}
+	//ErrBillEmpty defines the error if the bill to finalize has no lines.
+	ErrBillEmpty = &Error{StatusCode: http.StatusConflict, Message: "The bill is empty"}
+	//ErrInvoiceNotFound defines the error if the invoice doesn't exist.
+	ErrInvoiceNotFound = &Error{StatusCode: http.StatusNotFound, Message: "Invoice not found"}
+	//ErrLineNotFound defines the error if the line to refund doesn't exist in the invoice.
+	ErrLineNotFound = &Error{StatusCode: http.StatusBadRequest, Message: "Invoice line not found"}
+	//ErrRefunded defines the error if the line to refund has been refunded.
+	ErrRefunded = &Error{StatusCode: http.StatusConflict, Message: "The line has been refunded"}
+	//ErrCreditNoteNotFound defines the error if the credit note doesn't exist.
+	ErrCreditNoteNotFound = &Error{StatusCode: http.StatusNotFound, Message: "Credit note not found"}
+	//ErrCouponExists defines the error if the coupon with the same code has been applied to the bill.
+	ErrCouponExists = &Error{StatusCode: http.StatusConflict, Message: "The coupon has been applied"}
+	//ErrCouponNotFound defines the error if the coupon hasn't been applied to the bill.
+	ErrCouponNotFound = &Error{StatusCode: http.StatusNotFound, Message: "Coupon not found"}
+	//ErrChargeExists defines the error if the charge with the same name has been added to the bill.
+	ErrChargeExists = &Error{StatusCode: http.StatusConflict, Message: "The charge has been added"}
+	//ErrChargeNotFound defines the error if the charge hasn't been added to the bill.
+	ErrChargeNotFound = &Error{StatusCode: http.StatusNotFound, Message: "Charge not found"}
+	//ErrInvalidFilter defines the error if the filter of the audit events is not valid.
+	ErrInvalidFilter = &Error{StatusCode: http.StatusBadRequest, Message: "Invalid filter"}
+	//ErrAPIKeyNotFound defines the error if the api key doesn't exist.
+	ErrAPIKeyNotFound = &Error{StatusCode: http.StatusNotFound, Message: "API key not found"}
+)
+
+//Error return the status code and the message of the error response.
+func (err *Error) Error() string {
+	if err.Reason != "" {
+		return fmt.Sprintf("%d %s: %s", err.StatusCode, err.Message, err.Reason)
+	}
+	return fmt.Sprintf("%d %s", err.StatusCode, err.Message)
+}
+
+//Is return true if the target is the error with the same status code and message,
+//so the denial is the ErrForbidden whatever its reason is.
+func (err *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.StatusCode == err.StatusCode && other.Message == err.Message
+}
+
+//newError return the error of the error response.
+//The message is the status text if the body is not the JSON error, e.g. if it's returned by the proxy.
+func newError(resp *http.Response, content []byte) *Error {
+	err := &Error{}
+	if json.Unmarshal(content, err) != nil || err.Message == "" {
+		err.Message = http.StatusText(resp.StatusCode)
+	}
+	err.StatusCode = resp.StatusCode
+	err.RequestID = resp.Header.Get(HeaderRequestID)
+	return err
+}
@@ -0,0 +1,61 @@
+// +build unit
+
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	auditDelivery "github.com/fairyhunter13/tax-calculator/internal/audit/delivery"
+	authDelivery "github.com/fairyhunter13/tax-calculator/internal/auth/delivery"
+	billDelivery "github.com/fairyhunter13/tax-calculator/internal/bill/delivery"
+	"github.com/fairyhunter13/tax-calculator/internal/limit"
+	taxobjDelivery "github.com/fairyhunter13/tax-calculator/internal/taxobj/delivery"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		err     *Error
+		httpErr *echo.HTTPError
+	}{
+		// TODO: Add test cases.
+		{name: "Unauthorized", err: ErrUnauthorized, httpErr: authDelivery.ErrUnauthorized},
+		{name: "Too Many Requests", err: ErrTooManyRequests, httpErr: limit.ErrTooManyRequests},
+		{name: "Invalid Input", err: ErrInvalidInput, httpErr: taxobjDelivery.ErrInvalidInput},
+		{name: "Invalid Bill Input", err: ErrInvalidInput, httpErr: billDelivery.ErrInvalidInput},
+		{name: "Invalid API Key Input", err: ErrInvalidInput, httpErr: authDelivery.ErrInvalidInput},
+		{name: "Jurisdiction Not Found", err: ErrJurisdictionNotFound, httpErr: taxobjDelivery.ErrJurisdictionNotFound},
+		{name: "Charge Jurisdiction Not Found", err: ErrJurisdictionNotFound, httpErr: billDelivery.ErrJurisdictionNotFound},
+		{name: "Price Mismatch", err: ErrPriceMismatch, httpErr: taxobjDelivery.ErrPriceMismatch},
+		{name: "Tax Object Not Found", err: ErrTaxObjectNotFound, httpErr: taxobjDelivery.ErrNotFound},
+		{name: "Deleted Not Found", err: ErrDeletedNotFound, httpErr: taxobjDelivery.ErrDeletedNotFound},
+		{name: "Bill Full", err: ErrBillFull, httpErr: taxobjDelivery.ErrBillFull},
+		{name: "Finalized", err: ErrFinalized, httpErr: billDelivery.ErrFinalized},
+		{name: "Tax Object Finalized", err: ErrFinalized, httpErr: taxobjDelivery.ErrFinalized},
+		{name: "Bill Not Found", err: ErrBillNotFound, httpErr: billDelivery.ErrNotFound},
+		{name: "Bill Empty", err: ErrBillEmpty, httpErr: billDelivery.ErrEmpty},
+		{name: "Invoice Not Found", err: ErrInvoiceNotFound, httpErr: billDelivery.ErrInvoiceNotFound},
+		{name: "Line Not Found", err: ErrLineNotFound, httpErr: billDelivery.ErrLineNotFound},
+		{name: "Refunded", err: ErrRefunded, httpErr: billDelivery.ErrRefunded},
+		{name: "Credit Note Not Found", err: ErrCreditNoteNotFound, httpErr: billDelivery.ErrCreditNoteNotFound},
+		{name: "Coupon Exists", err: ErrCouponExists, httpErr: billDelivery.ErrCouponExists},
+		{name: "Coupon Not Found", err: ErrCouponNotFound, httpErr: billDelivery.ErrCouponNotFound},
+		{name: "Charge Exists", err: ErrChargeExists, httpErr: billDelivery.ErrChargeExists},
+		{name: "Charge Not Found", err: ErrChargeNotFound, httpErr: billDelivery.ErrChargeNotFound},
+		{name: "Invalid Filter", err: ErrInvalidFilter, httpErr: auditDelivery.ErrInvalidFilter},
+		{name: "API Key Not Found", err: ErrAPIKeyNotFound, httpErr: authDelivery.ErrNotFound},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			//The client doesn't import the handlers, so its errors are checked against them here.
+			assert.Equal(t, tt.httpErr.Code, tt.err.StatusCode)
+			assert.Equal(t, fmt.Sprint(tt.httpErr.Message), tt.err.Message)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+//CreateTaxObject add the tax object to the open bill and return the created tax object.
+//The tax object without the quantity is one unit, like in the request without the quantity.
+func (client *Client) CreateTaxObject(ctx context.Context, taxObject TaxObject) (created TaxObject, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/tax",
+		body:   withQuantity(taxObject),
+	}, &created)
+	return
+}
+
+//PreviewTaxObjects return the lines of the tax objects and the total of the bill with them without adding them.
+//The lines are explained if explain is true.
+//The preview changes nothing, so it's retried like the idempotent requests.
+func (client *Client) PreviewTaxObjects(ctx context.Context, taxObjects []TaxObject, explain bool) (preview PreviewResponse, err error) {
+	body := make([]TaxObject, len(taxObjects))
+	for index, taxObject := range taxObjects {
+		body[index] = withQuantity(taxObject)
+	}
+	err = client.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/tax/preview",
+		query:      explainQuery(explain),
+		body:       body,
+		idempotent: true,
+	}, &preview)
+	return
+}
+
+//UpdateTaxObject correct the tax object with the id and return the corrected tax object.
+//The tax object without the quantity is one unit, like in the request without the quantity.
+func (client *Client) UpdateTaxObject(ctx context.Context, id int64, taxObject TaxObject) (updated TaxObject, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/tax/" + strconv.FormatInt(id, 10),
+		body:   withQuantity(taxObject),
+	}, &updated)
+	return
+}
+
+//DeleteTaxObject delete the tax object with the id.
+func (client *Client) DeleteTaxObject(ctx context.Context, id int64) (err error) {
+	err = client.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/tax/" + strconv.FormatInt(id, 10),
+	}, nil)
+	return
+}
+
+//RestoreTaxObject restore the deleted tax object with the id and return the restored tax object.
+func (client *Client) RestoreTaxObject(ctx context.Context, id int64) (restored TaxObject, err error) {
+	err = client.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/tax/" + strconv.FormatInt(id, 10) + "/restore",
+	}, &restored)
+	return
+}
+
+//withQuantity return the tax object with one unit if it has no quantity,
+//because the zero quantity is sent in the request and it's not valid.
+func withQuantity(taxObject TaxObject) TaxObject {
+	if taxObject.Quantity == 0 {
+		taxObject.Quantity = 1
+	}
+	return taxObject
+}
+
+//explainQuery return the query explaining the lines if explain is true.
+func explainQuery(explain bool) url.Values {
+	if !explain {
+		return nil
+	}
+	return url.Values{"explain": []string{"true"}}
+}
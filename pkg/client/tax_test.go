@@ -0,0 +1,214 @@
+// +build unit
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/fairyhunter13/tax-calculator/internal/bill"
+	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
+	"github.com/fairyhunter13/tax-calculator/pkg/taxcalc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClient_CreateTaxObject(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	server.taxUcase.On("CreateTaxObject", mock.Anything, mock.MatchedBy(func(taxObject *taxobj.TaxObject) bool {
+		return taxObject.Name == "Big Mac"
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*taxobj.TaxObject).ID = 1
+	})
+	server.taxUcase.On("CreateTaxObject", mock.Anything, mock.Anything).Return(taxobj.ErrBillFull)
+	tests := []struct {
+		name      string
+		taxObject TaxObject
+		want      TaxObject
+		wantErr   error
+	}{
+		// TODO: Add test cases.
+		{
+			name:      "Positive Case",
+			taxObject: TaxObject{Name: "Big Mac", TaxCode: 1, UnitPrice: 1000},
+			want:      TaxObject{ID: 1, Name: "Big Mac", TaxCode: 1, Quantity: 1, UnitPrice: 1000, Price: 1000},
+		},
+		{
+			name:      "Invalid Input",
+			taxObject: TaxObject{Name: "Big Mac", TaxCode: 4, UnitPrice: 1000},
+			wantErr:   ErrInvalidInput,
+		},
+		{
+			name:      "Jurisdiction Not Found",
+			taxObject: TaxObject{Name: "Big Mac", TaxCode: 1, UnitPrice: 1000, Jurisdiction: "UNKNOWN"},
+			wantErr:   ErrJurisdictionNotFound,
+		},
+		{
+			name:      "Bill Full",
+			taxObject: TaxObject{Name: "Whopper", TaxCode: 1, UnitPrice: 1000},
+			wantErr:   ErrBillFull,
+		},
+	}
+	client := server.newClient(testAPIKey)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.CreateTaxObject(context.Background(), tt.taxObject)
+			assertError(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_PreviewTaxObjects(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	serverLines := []bill.Bill{
+		{
+			Name:        "Lucky Stretch",
+			TaxCode:     2,
+			Type:        "Tobacco",
+			Refundable:  "No",
+			Quantity:    1,
+			UnitPrice:   1000,
+			Price:       1000,
+			TaxableBase: 1000,
+			Tax:         30,
+			Taxes:       []bill.TaxComponent{{TaxCode: 2, Type: "Tobacco", Base: 1000, Tax: 30}},
+			Amount:      1030,
+		},
+	}
+	lines := []Bill{
+		{
+			Name:        "Lucky Stretch",
+			TaxCode:     2,
+			Type:        "Tobacco",
+			Refundable:  "No",
+			Quantity:    1,
+			UnitPrice:   1000,
+			Price:       1000,
+			TaxableBase: 1000,
+			Tax:         30,
+			Taxes:       []TaxComponent{{TaxCode: 2, Type: "Tobacco", Base: 1000, Tax: 30}},
+			Amount:      1030,
+		},
+	}
+	server.taxUcase.On("CheckBillLines", mock.Anything, 1).Return(nil)
+	server.billUcase.On("PreviewBill", mock.Anything, mock.Anything).Return(serverLines, bill.Total{PriceSubtotal: 1000, TaxSubtotal: 30, GrandTotal: 1030})
+
+	client := server.newClient(testAPIKey)
+	got, err := client.PreviewTaxObjects(context.Background(), []TaxObject{{Name: "Lucky Stretch", TaxCode: 2, UnitPrice: 1000}}, false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, PreviewResponse{Bills: lines, Total: Total{PriceSubtotal: 1000, TaxSubtotal: 30, GrandTotal: 1030}}, got)
+	}
+	got, err = client.PreviewTaxObjects(context.Background(), []TaxObject{{Name: "Lucky Stretch", TaxCode: 2, UnitPrice: 1000}}, true)
+	if assert.NoError(t, err) && assert.Len(t, got.Bills, 1) {
+		var want Explanation
+		fromServer(t, taxcalc.ExplainAll(testRules, serverLines)[0].Explanation, &want)
+		assert.Equal(t, &want, got.Bills[0].Explanation)
+	}
+	_, err = client.PreviewTaxObjects(context.Background(), nil, false)
+	assertError(t, ErrInvalidInput, err)
+}
+
+func TestClient_UpdateTaxObject(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	server.taxUcase.On("UpdateTaxObject", mock.Anything, mock.MatchedBy(func(taxObject *taxobj.TaxObject) bool {
+		return taxObject.ID == 1
+	})).Return(nil)
+	server.taxUcase.On("UpdateTaxObject", mock.Anything, mock.MatchedBy(func(taxObject *taxobj.TaxObject) bool {
+		return taxObject.ID == 2
+	})).Return(bill.ErrFinalized)
+	server.taxUcase.On("UpdateTaxObject", mock.Anything, mock.Anything).Return(taxobj.ErrNotFound)
+	tests := []struct {
+		name    string
+		id      int64
+		want    TaxObject
+		wantErr error
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Positive Case",
+			id:   1,
+			want: TaxObject{ID: 1, Name: "Big Mac", TaxCode: 1, Quantity: 2, UnitPrice: 1000, Price: 2000},
+		},
+		{
+			name:    "Finalized",
+			id:      2,
+			wantErr: ErrFinalized,
+		},
+		{
+			name:    "Not Found",
+			id:      3,
+			wantErr: ErrTaxObjectNotFound,
+		},
+	}
+	client := server.newClient(testAPIKey)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.UpdateTaxObject(context.Background(), tt.id, TaxObject{Name: "Big Mac", TaxCode: 1, Quantity: 2, UnitPrice: 1000})
+			assertError(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_DeleteTaxObject(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	server.taxUcase.On("DeleteTaxObject", mock.Anything, int64(1)).Return(nil)
+	server.taxUcase.On("DeleteTaxObject", mock.Anything, int64(2)).Return(taxobj.ErrNotFound)
+
+	client := server.newClient(testAPIKey)
+	assert.NoError(t, client.DeleteTaxObject(context.Background(), 1))
+	assertError(t, ErrTaxObjectNotFound, client.DeleteTaxObject(context.Background(), 2))
+}
+
+func TestClient_RestoreTaxObject(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(0, 0)
+	defer server.Close()
+	restored := TaxObject{ID: 1, Name: "Big Mac", TaxCode: 1, Quantity: 1, UnitPrice: 1000, Price: 1000}
+	server.taxUcase.On("RestoreTaxObject", mock.Anything, int64(1)).Return(taxobj.TaxObject{
+		ID:        1,
+		Name:      "Big Mac",
+		TaxCode:   1,
+		Quantity:  1,
+		UnitPrice: 1000,
+		Price:     1000,
+		Tenant:    "tenant",
+	}, nil)
+	server.taxUcase.On("RestoreTaxObject", mock.Anything, int64(2)).Return(taxobj.TaxObject{}, taxobj.ErrNotFound)
+
+	client := server.newClient(testAPIKey)
+	got, err := client.RestoreTaxObject(context.Background(), 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, restored, got)
+	}
+	_, err = client.RestoreTaxObject(context.Background(), 2)
+	assertError(t, ErrDeletedNotFound, err)
+}
+
+//fromServer convert the value returned by the server to the value of the client, like the client decodes the response.
+func fromServer(t *testing.T, value interface{}, result interface{}) {
+	content, err := json.Marshal(value)
+	if assert.NoError(t, err) {
+		assert.NoError(t, json.Unmarshal(content, result))
+	}
+}
+
+//assertError assert that the error is the expected error, or nil if the expected error is nil.
+func assertError(t *testing.T, want error, err error) {
+	if want == nil {
+		assert.NoError(t, err)
+		return
+	}
+	assert.True(t, errors.Is(err, want), "unexpected error: %v", err)
+}
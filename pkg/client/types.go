@@ -0,0 +1,226 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+//The models of the API, they have the fields of the request and the response of the handlers,
+//so the client can be used without importing the packages of the server.
+type (
+	//TaxObject define the item added to the bill.
+	//The price is the unit price times the quantity, and the discount is deducted from it before the tax.
+	TaxObject struct {
+		ID           int64     `json:"id"`
+		Name         string    `json:"name"`
+		TaxCode      int64     `json:"tax_code"`
+		Jurisdiction string    `json:"jurisdiction,omitempty"`
+		Taxes        []Tax     `json:"taxes,omitempty"`
+		Quantity     float64   `json:"quantity"`
+		UnitPrice    float64   `json:"unit_price"`
+		Price        float64   `json:"price"`
+		Discount     *Discount `json:"discount,omitempty"`
+		TaxInclusive bool      `json:"tax_inclusive,omitempty"`
+		BillID       int64     `json:"bill_id,omitempty"`
+	}
+	//Tax define the other tax stacked on the tax object, it's compounded on the taxes before it if compound is true.
+	Tax struct {
+		TaxCode  int64 `json:"tax_code"`
+		Compound bool  `json:"compound"`
+	}
+	//Discount define the discount of the tax object or the coupon, its type is either percent or fixed.
+	Discount struct {
+		Type  string  `json:"type"`
+		Value float64 `json:"value"`
+	}
+	//Bill define the calculated line of the bill.
+	Bill struct {
+		Name         string         `json:"name"`
+		TaxCode      int64          `json:"tax_code"`
+		Jurisdiction string         `json:"jurisdiction,omitempty"`
+		Type         string         `json:"type"`
+		Refundable   string         `json:"refundable"`
+		Quantity     float64        `json:"quantity"`
+		UnitPrice    float64        `json:"unit_price"`
+		Price        float64        `json:"price"`
+		Discount     float64        `json:"discount"`
+		Coupon       float64        `json:"coupon"`
+		TaxInclusive bool           `json:"tax_inclusive"`
+		TaxableBase  float64        `json:"taxable_base"`
+		Tax          float64        `json:"tax"`
+		Taxes        []TaxComponent `json:"taxes,omitempty"`
+		Amount       float64        `json:"amount"`
+		Explanation  *Explanation   `json:"explanation,omitempty"`
+	}
+	//TaxComponent define the tax of the tax code calculated in the line.
+	TaxComponent struct {
+		TaxCode  int64   `json:"tax_code"`
+		Type     string  `json:"type"`
+		Compound bool    `json:"compound"`
+		Base     float64 `json:"base"`
+		Tax      float64 `json:"tax"`
+	}
+	//Explanation define how the taxes of the line are calculated with the rules of its jurisdiction.
+	Explanation struct {
+		Jurisdiction string           `json:"jurisdiction"`
+		Version      string           `json:"version"`
+		Taxes        []TaxExplanation `json:"taxes"`
+	}
+	//TaxExplanation define the rule, the formula, and the values of the tax of the tax code.
+	TaxExplanation struct {
+		TaxCode   int64   `json:"tax_code"`
+		Rule      Rule    `json:"rule"`
+		Formula   string  `json:"formula"`
+		Quantity  float64 `json:"quantity"`
+		UnitBase  float64 `json:"unit_base"`
+		TaxedBase float64 `json:"taxed_base"`
+		UnitTax   float64 `json:"unit_tax"`
+		Tax       float64 `json:"tax"`
+		Rounding  float64 `json:"rounding"`
+	}
+	//Rule define the rule of the tax code in the jurisdiction.
+	Rule struct {
+		Type       string  `json:"type"`
+		Refundable bool    `json:"refundable"`
+		Fixed      float64 `json:"fixed"`
+		Rate       float64 `json:"rate"`
+		Threshold  float64 `json:"threshold"`
+	}
+	//Total define the total of the bill.
+	Total struct {
+		PriceSubtotal     float64      `json:"price_subtotal"`
+		DiscountSubtotal  float64      `json:"discount_subtotal"`
+		CouponSubtotal    float64      `json:"coupon_subtotal"`
+		NetSubtotal       float64      `json:"net_subtotal"`
+		TaxSubtotal       float64      `json:"tax_subtotal"`
+		ChargeSubtotal    float64      `json:"charge_subtotal"`
+		ChargeTaxSubtotal float64      `json:"charge_tax_subtotal"`
+		GrandTotal        float64      `json:"grand_total"`
+		Taxes             []TaxSummary `json:"taxes,omitempty"`
+	}
+	//TaxSummary define the base and the tax of the tax code in the total.
+	TaxSummary struct {
+		Jurisdiction string  `json:"jurisdiction,omitempty"`
+		TaxCode      int64   `json:"tax_code"`
+		Type         string  `json:"type"`
+		Base         float64 `json:"base"`
+		Tax          float64 `json:"tax"`
+	}
+	//Breakdown define the lines, the price, and the tax of the tax code in the bill.
+	Breakdown struct {
+		Jurisdiction  string  `json:"jurisdiction,omitempty"`
+		TaxCode       int64   `json:"tax_code"`
+		Type          string  `json:"type"`
+		Lines         int     `json:"lines"`
+		PriceSubtotal float64 `json:"price_subtotal"`
+		TaxSubtotal   float64 `json:"tax_subtotal"`
+		RefundableTax float64 `json:"refundable_tax"`
+	}
+	//Coupon define the discount of the bill applied to all its lines.
+	Coupon struct {
+		Code string `json:"code"`
+		Discount
+	}
+	//Charge define the charge of the bill, e.g. the service charge or the tip.
+	//The charge is taxed with the tax code if it's not zero.
+	Charge struct {
+		Name         string  `json:"name"`
+		TaxCode      int64   `json:"tax_code"`
+		Jurisdiction string  `json:"jurisdiction,omitempty"`
+		Type         string  `json:"type"`
+		Value        float64 `json:"value"`
+	}
+	//ChargeLine define the calculated charge of the bill.
+	ChargeLine struct {
+		Charge
+		Price  float64 `json:"price"`
+		Tax    float64 `json:"tax"`
+		Amount float64 `json:"amount"`
+	}
+	//Invoice define the finalized bill.
+	Invoice struct {
+		Number   int64        `json:"number"`
+		BillID   int64        `json:"bill_id"`
+		Bill     []Bill       `json:"bill"`
+		Coupons  []Coupon     `json:"coupons,omitempty"`
+		Charges  []ChargeLine `json:"charges,omitempty"`
+		Total    Total        `json:"total"`
+		IssuedAt time.Time    `json:"issued_at"`
+	}
+	//CreditLine define the refunded line of the invoice with its index.
+	CreditLine struct {
+		Line int `json:"line"`
+		Bill
+	}
+	//CreditNote define the document refunding the lines of the invoice.
+	CreditNote struct {
+		Number        int64        `json:"number"`
+		InvoiceNumber int64        `json:"invoice_number"`
+		Lines         []CreditLine `json:"lines"`
+		Total         Total        `json:"total"`
+		IssuedAt      time.Time    `json:"issued_at"`
+	}
+	//BillResponse define the open bill with its coupons, charges, breakdown, total, and refunds.
+	BillResponse struct {
+		ID        int64        `json:"id,omitempty"`
+		Bill      []Bill       `json:"bill"`
+		Coupons   []Coupon     `json:"coupons"`
+		Charges   []ChargeLine `json:"charges"`
+		Breakdown []Breakdown  `json:"breakdown"`
+		Total     Total        `json:"total"`
+		Refunds   Total        `json:"refunds"`
+	}
+	//PreviewResponse define the previewed lines of the tax objects and the total of the bill with them.
+	PreviewResponse struct {
+		Bills []Bill `json:"bills"`
+		Total Total  `json:"total"`
+	}
+	//Event define the audit event.
+	Event struct {
+		ID        int64           `json:"id"`
+		Actor     string          `json:"actor"`
+		Action    string          `json:"action"`
+		Entity    string          `json:"entity"`
+		EntityID  int64           `json:"entity_id"`
+		Before    json.RawMessage `json:"before,omitempty"`
+		After     json.RawMessage `json:"after,omitempty"`
+		RequestID string          `json:"request_id"`
+		CreatedAt time.Time       `json:"created_at"`
+		PrevHash  string          `json:"prev_hash"`
+		Hash      string          `json:"hash"`
+	}
+	//ChainHead define the head of the hash chain of the audit events.
+	ChainHead struct {
+		EventID int64  `json:"event_id"`
+		Hash    string `json:"hash"`
+	}
+	//Filter define the filter of the audit events, the empty fields match every event.
+	Filter struct {
+		Actor    string
+		Action   string
+		Entity   string
+		EntityID int64
+		From     time.Time
+		To       time.Time
+		Limit    int
+	}
+	//APIKey define the api key used by the client to authenticate.
+	APIKey struct {
+		ID        int64      `json:"id"`
+		Name      string     `json:"name"`
+		Tenant    string     `json:"tenant"`
+		Role      string     `json:"role"`
+		Prefix    string     `json:"prefix"`
+		CreatedAt time.Time  `json:"created_at"`
+		RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	}
+	//CreatedAPIKey define the created api key with its plain key.
+	CreatedAPIKey struct {
+		APIKey
+		Key string `json:"key"`
+	}
+	//refundRequest define the request refunding the lines of the invoice.
+	refundRequest struct {
+		Lines []int `json:"lines"`
+	}
+)
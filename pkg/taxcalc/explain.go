@@ -0,0 +1,95 @@
+package taxcalc
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//Explanation define how the taxes of the line are derived from the rules of its jurisdiction.
+//The version is the version of the rules, so the tax can be checked against the rules in force.
+type Explanation struct {
+	Jurisdiction string           `json:"jurisdiction"`
+	Version      string           `json:"version"`
+	Taxes        []TaxExplanation `json:"taxes"`
+}
+
+//TaxExplanation define how the tax of one tax component of the line is derived from its rule.
+//The taxed base is the unit base above the threshold, and the unit tax is the fixed tax plus the rate of the taxed base,
+//or 0 if the unit base is under the threshold.
+//The rounding is the difference between the tax and the formula, i.e. the difference the tax-inclusive line assigns to its first tax.
+type TaxExplanation struct {
+	TaxCode   int64   `json:"tax_code"`
+	Rule      Rule    `json:"rule"`
+	Formula   string  `json:"formula"`
+	Quantity  float64 `json:"quantity"`
+	UnitBase  float64 `json:"unit_base"`
+	TaxedBase float64 `json:"taxed_base"`
+	UnitTax   float64 `json:"unit_tax"`
+	Tax       float64 `json:"tax"`
+	Rounding  float64 `json:"rounding"`
+}
+
+//Explain return the explanation of the taxes of the line by the rules of its jurisdiction.
+//The rules must be the rules calculating the line, otherwise the explanation doesn't match its taxes.
+func Explain(rules Rules, line Line) (explanation Explanation) {
+	explanation = Explanation{
+		Jurisdiction: line.Jurisdiction,
+		Version:      rules.VersionOf(line.Jurisdiction),
+		Taxes:        make([]TaxExplanation, 0, len(line.Taxes)),
+	}
+	for _, component := range line.Taxes {
+		rule, _ := rules.RuleOf(line.Jurisdiction, component.TaxCode)
+		taxExplanation := TaxExplanation{
+			TaxCode:  component.TaxCode,
+			Rule:     rule,
+			Quantity: line.Quantity,
+			Tax:      component.Tax,
+		}
+		if line.Quantity > 0 {
+			taxExplanation.UnitBase = component.Base / line.Quantity
+		}
+		taxExplanation.UnitTax = rule.Tax(taxExplanation.UnitBase)
+		if taxExplanation.UnitBase > 0 && taxExplanation.UnitBase >= rule.Threshold {
+			taxExplanation.TaxedBase = taxExplanation.UnitBase - rule.Threshold
+		}
+		taxExplanation.Rounding = component.Tax - line.Quantity*taxExplanation.UnitTax
+		taxExplanation.Formula = taxExplanation.formula()
+		explanation.Taxes = append(explanation.Taxes, taxExplanation)
+	}
+	return
+}
+
+//ExplainAll return the copies of the lines with their explanations by the rules.
+//The lines are copied, so the lines shared with the bill are not changed.
+func ExplainAll(rules Rules, lines []Line) (explained []Line) {
+	explained = make([]Line, 0, len(lines))
+	for _, line := range lines {
+		explanation := Explain(rules, line)
+		line.Explanation = &explanation
+		explained = append(explained, line)
+	}
+	return
+}
+
+//formula return the formula of the tax with its values, e.g. 2 * (10 + 2% * (1000 - 0)) = 60.
+func (taxExplanation TaxExplanation) formula() string {
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	rule := taxExplanation.Rule
+	if taxExplanation.UnitBase <= 0 {
+		return fmt.Sprintf("%s * 0 = 0, the unit base is not positive", format(taxExplanation.Quantity))
+	}
+	if taxExplanation.UnitBase < rule.Threshold {
+		return fmt.Sprintf("%s * 0 = 0, the unit base %s is under the threshold %s",
+			format(taxExplanation.Quantity), format(taxExplanation.UnitBase), format(rule.Threshold))
+	}
+	return fmt.Sprintf("%s * (%s + %s%% * (%s - %s)) = %s",
+		format(taxExplanation.Quantity),
+		format(rule.Fixed),
+		format(rule.Rate),
+		format(taxExplanation.UnitBase),
+		format(rule.Threshold),
+		format(taxExplanation.Quantity*taxExplanation.UnitTax),
+	)
+}
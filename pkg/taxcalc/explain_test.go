@@ -0,0 +1,45 @@
+// +build unit
+
+package taxcalc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainAll(t *testing.T) {
+	t.Parallel()
+	versioned := testJurisdiction
+	versioned.Version = "2024-1"
+	rules := NewJurisdictions(versioned)
+	lines, _, _ := Calculate(rules, []TaxObject{
+		{Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150},
+		{Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, UnitPrice: 1000, Jurisdiction: "TEST"},
+	}, nil, nil)
+	explained := ExplainAll(rules, lines)
+
+	assert.Equal(t, Explanation{
+		Version: "1",
+		Taxes: []TaxExplanation{
+			{
+				TaxCode:   3,
+				Rule:      Rule{Type: "Entertainment", Rate: 1, Threshold: 100},
+				Formula:   "2 * (0 + 1% * (150 - 100)) = 1",
+				Quantity:  2,
+				UnitBase:  150,
+				TaxedBase: 50,
+				UnitTax:   0.5,
+				Tax:       1,
+			},
+		},
+	}, *explained[0].Explanation)
+	//The line of the jurisdiction is explained by the rules of the jurisdiction and their version.
+	assert.Equal(t, "TEST", explained[1].Explanation.Jurisdiction)
+	assert.Equal(t, "2024-1", explained[1].Explanation.Version)
+	assert.Equal(t, "1 * (20 + 5% * (1000 - 0)) = 70", explained[1].Explanation.Taxes[0].Formula)
+	//The lines are copied, so the calculated lines are not explained.
+	for _, line := range lines {
+		assert.Nil(t, line.Explanation)
+	}
+}
@@ -0,0 +1,155 @@
+package taxcalc
+
+//DefaultJurisdiction defines the jurisdiction of the tax objects without the jurisdiction.
+//Its rules are the rules used before the jurisdictions, so the existing tax objects keep their tax.
+const DefaultJurisdiction = ""
+
+//Rule define the tax of the tax code in the jurisdiction.
+//The tax of a unit is the fixed tax plus the rate in percent of the price above the threshold,
+//and the unit under the threshold is not taxed, e.g. the entertainment ticket under 100.
+type Rule struct {
+	Type       string  `json:"type"`
+	Refundable bool    `json:"refundable"`
+	Fixed      float64 `json:"fixed"`
+	Rate       float64 `json:"rate"`
+	Threshold  float64 `json:"threshold"`
+}
+
+//Jurisdiction define the rules of the tax codes in the country or the region.
+//The same tax code has the same type in every jurisdiction, but its rule may differ,
+//e.g. the tobacco may have another fixed tax in another region.
+//The version identifies the rules in force, so the explained tax can be checked against them.
+type Jurisdiction struct {
+	Code    string         `json:"code"`
+	Name    string         `json:"name"`
+	Version string         `json:"version"`
+	Rules   map[int64]Rule `json:"rules"`
+}
+
+//Rules define the rules of the tax codes in the jurisdictions used to calculate and explain the bill.
+type Rules interface {
+	//HasJurisdiction return true if the rules have the jurisdiction.
+	HasJurisdiction(jurisdiction string) bool
+	//RuleOf return the rule of the tax code in the jurisdiction,
+	//or the empty rule not taxing anything and false if either of them doesn't exist.
+	RuleOf(jurisdiction string, taxCode int64) (Rule, bool)
+	//VersionOf return the version of the rules of the jurisdiction, or empty if it doesn't exist.
+	VersionOf(jurisdiction string) string
+}
+
+//Jurisdictions define the rules of the jurisdictions by their code.
+//The rules are given to every calculation, so the calculation never depends on the global state.
+type Jurisdictions map[string]Jurisdiction
+
+//NewJurisdictions return the rules of the default jurisdiction and the jurisdictions,
+//so the tax objects without the jurisdiction are taxed like in the application.
+//The jurisdiction with the same code as the previous one replaces it.
+func NewJurisdictions(jurisdictions ...Jurisdiction) Jurisdictions {
+	rules := Jurisdictions{
+		DefaultJurisdiction: NewDefaultJurisdiction(),
+	}
+	for _, jurisdiction := range jurisdictions {
+		rules[jurisdiction.Code] = jurisdiction
+	}
+	return rules
+}
+
+//NewDefaultJurisdiction return the default jurisdiction with the rules used before the jurisdictions.
+//Every call returns the new rules, so changing them doesn't change the rules of the other calculations.
+func NewDefaultJurisdiction() Jurisdiction {
+	return Jurisdiction{
+		Code:    DefaultJurisdiction,
+		Name:    "Default",
+		Version: "1",
+		Rules: map[int64]Rule{
+			1: {Type: "Food & Beverage", Refundable: true, Rate: 10},
+			2: {Type: "Tobacco", Fixed: 10, Rate: 2},
+			3: {Type: "Entertainment", Rate: 1, Threshold: 100},
+		},
+	}
+}
+
+//HasJurisdiction return true if the jurisdiction is one of the jurisdictions.
+func (jurisdictions Jurisdictions) HasJurisdiction(jurisdiction string) (ok bool) {
+	_, ok = jurisdictions[jurisdiction]
+	return
+}
+
+//RuleOf return the rule of the tax code in the jurisdiction.
+func (jurisdictions Jurisdictions) RuleOf(jurisdiction string, taxCode int64) (rule Rule, ok bool) {
+	rule, ok = jurisdictions[jurisdiction].Rules[taxCode]
+	return
+}
+
+//VersionOf return the version of the rules of the jurisdiction, or empty if it doesn't exist.
+func (jurisdictions Jurisdictions) VersionOf(jurisdiction string) string {
+	return jurisdictions[jurisdiction].Version
+}
+
+//UnitTax return the tax of the price of a unit by the rule of the tax code in the jurisdiction.
+func UnitTax(rules Rules, jurisdiction string, taxCode int64, price float64) float64 {
+	rule, _ := rules.RuleOf(jurisdiction, taxCode)
+	return rule.Tax(price)
+}
+
+//UnitNet return the price of a unit without the tax of the given price including the tax
+//by the rule of the tax code in the jurisdiction, i.e. the price whose price plus its tax is the given price.
+//The price not covering the fixed tax, e.g. the fixed tobacco tax, is all tax.
+func UnitNet(rules Rules, jurisdiction string, taxCode int64, price float64) float64 {
+	rule, _ := rules.RuleOf(jurisdiction, taxCode)
+	return rule.Net(price)
+}
+
+//IsRefundable return true if the tax component at the position of the line is refundable, i.e. the credit notes refund it.
+//The first component is the tax of the tax code of the line, so it's refundable if the line is refundable,
+//and the stacked taxes are refundable if the rules of their tax code in the jurisdiction of the line are refundable.
+func IsRefundable(rules Rules, line Line, position int, component TaxComponent) bool {
+	if position == 0 && component.TaxCode == line.TaxCode {
+		return line.Refundable == Refundable
+	}
+	rule, ok := rules.RuleOf(line.Jurisdiction, component.TaxCode)
+	return ok && rule.Refundable
+}
+
+//getRefundable return the refundable text to display based on the rule of the tax code in the jurisdiction.
+func getRefundable(rules Rules, jurisdiction string, taxCode int64) (refundable string) {
+	rule, ok := rules.RuleOf(jurisdiction, taxCode)
+	if !ok {
+		return
+	}
+	refundable = NotRefundable
+	if rule.Refundable {
+		refundable = Refundable
+	}
+	return
+}
+
+//getType return the type text for the given tax code in the jurisdiction.
+func getType(rules Rules, jurisdiction string, taxCode int64) string {
+	rule, _ := rules.RuleOf(jurisdiction, taxCode)
+	return rule.Type
+}
+
+//Tax return the tax of the price of a unit.
+func (rule Rule) Tax(price float64) (tax float64) {
+	if price <= 0 || price < rule.Threshold {
+		return
+	}
+	tax = rule.Fixed + rule.Rate/float64(100)*(price-rule.Threshold)
+	return
+}
+
+//Net return the price of a unit without the tax of the given price including the tax,
+//i.e. the price whose price plus its tax is the given price.
+//The price not covering the fixed tax is all tax above the threshold.
+func (rule Rule) Net(price float64) (net float64) {
+	net = price
+	if price <= 0 || price < rule.Threshold || (rule.Fixed == 0 && rule.Rate == 0) {
+		return
+	}
+	net = (price - rule.Fixed + rule.Rate/float64(100)*rule.Threshold) * float64(100) / (float64(100) + rule.Rate)
+	if net < rule.Threshold {
+		net = rule.Threshold
+	}
+	return
+}
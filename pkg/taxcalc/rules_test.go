@@ -0,0 +1,300 @@
+// +build unit
+
+package taxcalc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	//testJurisdiction is the jurisdiction with the other rates of the food and beverage and the tobacco.
+	testJurisdiction = Jurisdiction{
+		Code: "TEST",
+		Name: "Test",
+		Rules: map[int64]Rule{
+			1: {Type: "Food & Beverage", Refundable: true, Rate: 5},
+			2: {Type: "Tobacco", Fixed: 20, Rate: 5},
+			3: {Type: "Entertainment", Rate: 1, Threshold: 100},
+		},
+	}
+	//testRules is the rules of the default jurisdiction and the test jurisdiction, they aren't registered in the application.
+	testRules = NewJurisdictions(testJurisdiction)
+)
+
+func TestNewJurisdictions(t *testing.T) {
+	t.Parallel()
+	replaced := Jurisdiction{Code: DefaultJurisdiction, Rules: map[int64]Rule{1: {Type: "Food & Beverage", Rate: 5}}}
+	tests := []struct {
+		name          string
+		jurisdictions []Jurisdiction
+		want          Jurisdictions
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Default Jurisdiction",
+			want: Jurisdictions{DefaultJurisdiction: NewDefaultJurisdiction()},
+		},
+		{
+			name:          "Other Jurisdiction",
+			jurisdictions: []Jurisdiction{testJurisdiction},
+			want: Jurisdictions{
+				DefaultJurisdiction:   NewDefaultJurisdiction(),
+				testJurisdiction.Code: testJurisdiction,
+			},
+		},
+		{
+			name:          "Replaced Default Jurisdiction",
+			jurisdictions: []Jurisdiction{replaced},
+			want:          Jurisdictions{DefaultJurisdiction: replaced},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, NewJurisdictions(tt.jurisdictions...))
+		})
+	}
+}
+
+func Test_getRefundable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		rules          Rules
+		taxCode        int64
+		wantRefundable string
+	}{
+		// TODO: Add test cases.
+		{
+			name:           "Food & Beverage",
+			rules:          testRules,
+			taxCode:        1,
+			wantRefundable: "Yes",
+		},
+		{
+			name:           "Tobacco",
+			rules:          testRules,
+			taxCode:        2,
+			wantRefundable: "No",
+		},
+		{
+			name:           "Entertainment",
+			rules:          testRules,
+			taxCode:        3,
+			wantRefundable: "No",
+		},
+		{
+			name:           "Invalid Tax Code",
+			rules:          testRules,
+			taxCode:        0,
+			wantRefundable: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if gotRefundable := getRefundable(tt.rules, DefaultJurisdiction, tt.taxCode); gotRefundable != tt.wantRefundable {
+				t.Errorf("getRefundable() = %v, want %v", gotRefundable, tt.wantRefundable)
+			}
+		})
+	}
+}
+
+func Test_getType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		rules   Rules
+		taxCode int64
+		want    string
+	}{
+		// TODO: Add test cases.
+		{
+			name:    "Food & Beverage",
+			rules:   testRules,
+			taxCode: 1,
+			want:    "Food & Beverage",
+		},
+		{
+			name:    "Tobacco",
+			rules:   testRules,
+			taxCode: 2,
+			want:    "Tobacco",
+		},
+		{
+			name:    "Entertainment",
+			rules:   testRules,
+			taxCode: 3,
+			want:    "Entertainment",
+		},
+		{
+			name:    "Invalid Tax Code",
+			rules:   testRules,
+			taxCode: 0,
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := getType(tt.rules, DefaultJurisdiction, tt.taxCode); got != tt.want {
+				t.Errorf("getType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnitTax(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		jurisdiction string
+		taxCode      int64
+		price        float64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantTax float64
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Food & Beverage",
+			args: args{
+				taxCode: 1,
+				price:   10000,
+			},
+			wantTax: 1000,
+		},
+		{
+			name: "Tobacco",
+			args: args{
+				taxCode: 2,
+				price:   1000,
+			},
+			wantTax: 30,
+		},
+		{
+			name: "Entertainment Above 100",
+			args: args{
+				taxCode: 3,
+				price:   120,
+			},
+			wantTax: 0.2,
+		},
+		{
+			name: "Entertainment Below 100",
+			args: args{
+				taxCode: 3,
+				price:   50,
+			},
+			wantTax: 0,
+		},
+		{
+			name: "Tobacco in Another Jurisdiction",
+			args: args{
+				jurisdiction: "TEST",
+				taxCode:      2,
+				price:        1000,
+			},
+			wantTax: 70,
+		},
+		{
+			name: "Unknown Jurisdiction",
+			args: args{
+				jurisdiction: "UNKNOWN",
+				taxCode:      1,
+				price:        10000,
+			},
+			wantTax: 0,
+		},
+		{
+			name: "Invalid Tax Code",
+			args: args{
+				taxCode: 0,
+			},
+			wantTax: 0,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if gotTax := UnitTax(testRules, tt.args.jurisdiction, tt.args.taxCode, tt.args.price); gotTax != tt.wantTax {
+				t.Errorf("UnitTax() = %v, want %v", gotTax, tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestUnitNet(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		jurisdiction string
+		taxCode      int64
+		price        float64
+		wantNet      float64
+	}{
+		// TODO: Add test cases.
+		{
+			name:    "Food & Beverage",
+			taxCode: 1,
+			price:   11000,
+			wantNet: 10000,
+		},
+		{
+			name:    "Tobacco",
+			taxCode: 2,
+			price:   1030,
+			wantNet: 1000,
+		},
+		{
+			name:    "Tobacco Below the Fixed Tax",
+			taxCode: 2,
+			price:   5,
+			wantNet: 0,
+		},
+		{
+			name:    "Entertainment Above 100",
+			taxCode: 3,
+			price:   120.2,
+			wantNet: 120,
+		},
+		{
+			name:    "Entertainment Below 100",
+			taxCode: 3,
+			price:   50,
+			wantNet: 50,
+		},
+		{
+			name:    "Invalid Tax Code",
+			taxCode: 0,
+			price:   100,
+			wantNet: 100,
+		},
+		{
+			name:         "Tobacco in Another Jurisdiction",
+			jurisdiction: "TEST",
+			taxCode:      2,
+			price:        1070,
+			wantNet:      1000,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotNet := UnitNet(testRules, tt.jurisdiction, tt.taxCode, tt.price)
+			assert.InDelta(t, tt.wantNet, gotNet, 1e-9)
+			//The price without the tax plus its tax is the price including the tax.
+			if gotNet > 0 {
+				assert.InDelta(t, tt.price, gotNet+UnitTax(testRules, tt.jurisdiction, tt.taxCode, gotNet), 1e-9)
+			}
+		})
+	}
+}
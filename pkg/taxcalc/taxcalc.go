@@ -0,0 +1,241 @@
+//Package taxcalc calculates the bill of the tax objects without the HTTP server and the storage,
+//so the other services compute the same numbers as the application, e.g. offline.
+//The functions are pure, they only depend on their arguments and the rules given to them.
+//The models of the calculation are defined here and the application uses them too,
+//so the values built by the other modules are the values calculated by the application.
+package taxcalc
+
+import "sort"
+
+//Calculate calculate the bill lines of the tax objects, the charges, and their total by the rules.
+//Every line is taxed by the rules of the jurisdiction of its tax object.
+//The discount of every line is deducted first, then the coupons are deducted in the order they are applied,
+//and the taxes are calculated from the remaining taxable base in their order.
+//The remaining price of the tax-inclusive line includes the taxes, so its taxable base is solved from it instead.
+//The rules apply to every unit, so the tax is the tax of the taxable base of a unit multiplied by the quantity,
+//e.g. the fixed tobacco tax is charged for every pack and the entertainment threshold applies to every ticket.
+//The charges are calculated last from the subtotal of the taxable bases, and every charge is taxed by its own tax code.
+func Calculate(rules Rules, taxObjects []TaxObject, coupons []Coupon, charges []Charge) (bills []Line, chargeLines []ChargeLine, total Total) {
+	bills = make([]Line, 0, len(taxObjects))
+	for _, taxObject := range taxObjects {
+		bills = append(bills, newBill(rules, taxObject))
+	}
+	for _, coupon := range coupons {
+		applyCoupon(bills, coupon)
+	}
+	for index := range bills {
+		applyLine(rules, &bills[index])
+		total.AddLine(bills[index])
+	}
+	chargeLines = CalculateCharges(rules, charges, &total)
+	return
+}
+
+//CalculateLine calculate the bill line of the tax object by the rules like Calculate, but without the coupons.
+//The line without the coupons doesn't depend on the other lines,
+//so the bill without the coupons can be kept line by line instead of calculating all lines again.
+func CalculateLine(rules Rules, taxObject TaxObject) (line Line) {
+	line = newBill(rules, taxObject)
+	applyLine(rules, &line)
+	return
+}
+
+//CalculateCharges calculate the charges from the net subtotal of the total of the lines by the rules like Calculate,
+//and add them to the total.
+func CalculateCharges(rules Rules, charges []Charge, total *Total) (chargeLines []ChargeLine) {
+	chargeLines = make([]ChargeLine, 0, len(charges))
+	for _, charge := range charges {
+		chargeLine := newChargeLine(rules, charge, total.NetSubtotal)
+		chargeLines = append(chargeLines, chargeLine)
+		total.AddCharge(chargeLine)
+		if charge.TaxCode != 0 {
+			total.AddTax(TaxSummary{
+				TaxCode:      charge.TaxCode,
+				Jurisdiction: charge.Jurisdiction,
+				Type:         getType(rules, charge.Jurisdiction, charge.TaxCode),
+				Base:         chargeLine.Price,
+				Tax:          chargeLine.Tax,
+			})
+		}
+	}
+	return
+}
+
+//GroupBreakdown return the lines of the bill list grouped by their jurisdiction and tax code, and ordered by them.
+//The lines and the price are counted in the tax code of the line, but every tax component is summed in its own tax code,
+//so the tax stacked on the line of another tax code is in the subtotal of its tax code.
+//The refundable tax components are the refundable tax, as the credit notes refund only them.
+func GroupBreakdown(rules Rules, bills []Line) (breakdowns []Breakdown) {
+	breakdowns = make([]Breakdown, 0)
+	for _, billObject := range bills {
+		breakdowns = AddBreakdown(rules, breakdowns, billObject, 1)
+	}
+	return
+}
+
+//AddBreakdown add the line multiplied by the sign to the breakdowns grouped like GroupBreakdown, and return them.
+//The sign is -1 to subtract the line, e.g. the line removed from the bill, but its empty groups are kept.
+//The breakdowns are changed, so they must not be shared.
+func AddBreakdown(rules Rules, breakdowns []Breakdown, line Line, sign float64) []Breakdown {
+	var index int
+	breakdowns, index = breakdownOf(breakdowns, line.Jurisdiction, line.TaxCode, line.Type)
+	breakdowns[index].Lines += int(sign)
+	breakdowns[index].PriceSubtotal += sign * line.Price
+	components := line.Taxes
+	if len(components) == 0 {
+		//The lines calculated before the tax components have the tax of their tax code only.
+		components = []TaxComponent{{TaxCode: line.TaxCode, Type: line.Type, Tax: line.Tax}}
+	}
+	for position, component := range components {
+		breakdowns, index = breakdownOf(breakdowns, line.Jurisdiction, component.TaxCode, component.Type)
+		breakdowns[index].TaxSubtotal += sign * component.Tax
+		if IsRefundable(rules, line, position, component) {
+			breakdowns[index].RefundableTax += sign * component.Tax
+		}
+	}
+	return breakdowns
+}
+
+//breakdownOf return the index of the breakdown of the jurisdiction and the tax code in the ordered breakdowns,
+//inserting the empty breakdown if it doesn't exist yet.
+func breakdownOf(breakdowns []Breakdown, jurisdiction string, taxCode int64, taxType string) ([]Breakdown, int) {
+	index := sort.Search(len(breakdowns), func(index int) bool {
+		other := breakdowns[index]
+		return other.Jurisdiction > jurisdiction ||
+			(other.Jurisdiction == jurisdiction && other.TaxCode >= taxCode)
+	})
+	if index < len(breakdowns) && breakdowns[index].Jurisdiction == jurisdiction && breakdowns[index].TaxCode == taxCode {
+		return breakdowns, index
+	}
+	breakdowns = append(breakdowns, Breakdown{})
+	copy(breakdowns[index+1:], breakdowns[index:])
+	breakdowns[index] = Breakdown{
+		Jurisdiction: jurisdiction,
+		TaxCode:      taxCode,
+		Type:         taxType,
+	}
+	return breakdowns, index
+}
+
+//newChargeLine return the calculated charge of the subtotal.
+//The charge is taxed by the rule of its tax code in its jurisdiction, and the charge with the tax code 0 is not taxed.
+func newChargeLine(rules Rules, charge Charge, subtotal float64) (chargeLine ChargeLine) {
+	chargeLine = ChargeLine{
+		Charge: charge,
+		Price:  charge.Amount(subtotal),
+	}
+	chargeLine.Tax = UnitTax(rules, charge.Jurisdiction, charge.TaxCode, chargeLine.Price)
+	chargeLine.Amount = chargeLine.Price + chargeLine.Tax
+	return
+}
+
+//applyLine calculate the taxable base, the taxes, and the amount of the bill from its price after the discount and the coupons.
+func applyLine(rules Rules, billObject *Line) {
+	remaining := billObject.Price - billObject.Discount - billObject.Coupon
+	if billObject.TaxInclusive {
+		billObject.Amount = remaining
+		billObject.TaxableBase = billObject.Quantity * solveNet(rules, billObject.Jurisdiction, billObject.Taxes, remaining/billObject.Quantity)
+		billObject.Tax = billObject.Amount - billObject.TaxableBase
+		//The tax of the tax code takes the difference, i.e. the rounding or the price not covering the fixed tax.
+		tax := applyTaxes(rules, billObject.Jurisdiction, billObject.Taxes, billObject.TaxableBase/billObject.Quantity, billObject.Quantity)
+		billObject.Taxes[0].Tax += billObject.Tax - tax
+		return
+	}
+	billObject.TaxableBase = remaining
+	billObject.Tax = applyTaxes(rules, billObject.Jurisdiction, billObject.Taxes, billObject.TaxableBase/billObject.Quantity, billObject.Quantity)
+	billObject.Amount = billObject.TaxableBase + billObject.Tax
+}
+
+//newBill return the bill of the tax object with its discount and its taxes, but without the coupons and the tax yet.
+//The tax of the tax code is the first tax, followed by the additional taxes of the tax object.
+func newBill(rules Rules, taxObject TaxObject) (billObject Line) {
+	taxObject.Derive()
+	billObject = Line{
+		Name:         taxObject.Name,
+		Quantity:     taxObject.Quantity,
+		UnitPrice:    taxObject.UnitPrice,
+		Price:        taxObject.Price,
+		TaxInclusive: taxObject.TaxInclusive,
+		TaxCode:      taxObject.TaxCode,
+		Jurisdiction: taxObject.Jurisdiction,
+		Refundable:   getRefundable(rules, taxObject.Jurisdiction, taxObject.TaxCode),
+		Type:         getType(rules, taxObject.Jurisdiction, taxObject.TaxCode),
+		Taxes:        make([]TaxComponent, 0, len(taxObject.Taxes)+1),
+	}
+	billObject.Taxes = append(billObject.Taxes, TaxComponent{
+		TaxCode: taxObject.TaxCode,
+		Type:    getType(rules, taxObject.Jurisdiction, taxObject.TaxCode),
+	})
+	for _, tax := range taxObject.Taxes {
+		billObject.Taxes = append(billObject.Taxes, TaxComponent{
+			TaxCode:  tax.TaxCode,
+			Type:     getType(rules, taxObject.Jurisdiction, tax.TaxCode),
+			Compound: tax.Compound,
+		})
+	}
+	if taxObject.Discount != nil {
+		billObject.Discount = taxObject.Discount.Amount(taxObject.Price)
+	}
+	return
+}
+
+//applyCoupon deduct the coupon from the price of the bills remaining after their discounts and the previous coupons.
+//The fixed coupon is shared in proportion to the remaining price of every bill.
+func applyCoupon(bills []Line, coupon Coupon) {
+	remaining := float64(0)
+	for _, billObject := range bills {
+		remaining += billObject.Price - billObject.Discount - billObject.Coupon
+	}
+	if remaining <= 0 {
+		return
+	}
+	amount := coupon.Amount(remaining)
+	for index := range bills {
+		billObject := &bills[index]
+		billObject.Coupon += amount * (billObject.Price - billObject.Discount - billObject.Coupon) / remaining
+	}
+}
+
+//applyTaxes calculate the taxes of the line from the taxable base of a unit in their order and return their sum.
+//The compound tax is calculated from the taxable base plus the taxes before it,
+//and every tax is the tax of a unit multiplied by the quantity.
+func applyTaxes(rules Rules, jurisdiction string, components []TaxComponent, price float64, quantity float64) (tax float64) {
+	unitTax := float64(0)
+	for index := range components {
+		component := &components[index]
+		base := price
+		if component.Compound {
+			base += unitTax
+		}
+		componentTax := UnitTax(rules, jurisdiction, component.TaxCode, base)
+		unitTax += componentTax
+		component.Base = quantity * base
+		component.Tax = quantity * componentTax
+		tax += component.Tax
+	}
+	return
+}
+
+//solveNet return the price without the taxes of the given price of a unit including them.
+//The single tax is solved by UnitNet, and several taxes are solved by the bisection,
+//because the price including the taxes increases with the price without them.
+func solveNet(rules Rules, jurisdiction string, components []TaxComponent, price float64) (net float64) {
+	if len(components) == 1 || price <= 0 {
+		return UnitNet(rules, jurisdiction, components[0].TaxCode, price)
+	}
+	//The taxes are calculated on the copy, so the components are only calculated from the solved price.
+	scratch := make([]TaxComponent, len(components))
+	copy(scratch, components)
+	high := price
+	for {
+		middle := net + (high-net)/2
+		if middle <= net || middle >= high {
+			return
+		}
+		if middle+applyTaxes(rules, jurisdiction, scratch, middle, 1) > price {
+			high = middle
+		} else {
+			net = middle
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// +build unit
+
+package taxcalc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculate(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		taxObjects []TaxObject
+		coupons    []Coupon
+		charges    []Charge
+	}
+	tests := []struct {
+		name            string
+		args            args
+		wantBills       []Line
+		wantChargeLines []ChargeLine
+		wantGrandTotal  float64
+	}{
+		// TODO: Add test cases.
+		{
+			name: "Empty",
+			args: args{
+				taxObjects: []TaxObject{},
+			},
+			wantBills:       []Line{},
+			wantChargeLines: []ChargeLine{},
+		},
+		{
+			name: "Tax Objects",
+			args: args{
+				taxObjects: []TaxObject{
+					{Name: "Big Mac", TaxCode: 1, Quantity: 1, UnitPrice: 1000},
+					{Name: "Lucky Stretch", TaxCode: 2, Quantity: 2, UnitPrice: 1000, Jurisdiction: "TEST"},
+				},
+			},
+			wantBills: []Line{
+				{
+					Name:        "Big Mac",
+					TaxCode:     1,
+					Type:        "Food & Beverage",
+					Refundable:  Refundable,
+					Quantity:    1,
+					UnitPrice:   1000,
+					Price:       1000,
+					TaxableBase: 1000,
+					Tax:         100,
+					Taxes:       []TaxComponent{{TaxCode: 1, Type: "Food & Beverage", Base: 1000, Tax: 100}},
+					Amount:      1100,
+				},
+				{
+					Name:         "Lucky Stretch",
+					TaxCode:      2,
+					Jurisdiction: "TEST",
+					Type:         "Tobacco",
+					Refundable:   NotRefundable,
+					Quantity:     2,
+					UnitPrice:    1000,
+					Price:        2000,
+					TaxableBase:  2000,
+					Tax:          140,
+					Taxes:        []TaxComponent{{TaxCode: 2, Type: "Tobacco", Base: 2000, Tax: 140}},
+					Amount:       2140,
+				},
+			},
+			wantChargeLines: []ChargeLine{},
+			wantGrandTotal:  3240,
+		},
+		{
+			name: "Coupons and Charges",
+			args: args{
+				taxObjects: []TaxObject{
+					{Name: "Big Mac", TaxCode: 1, Quantity: 1, UnitPrice: 1000, TaxInclusive: true},
+				},
+				coupons: []Coupon{{Code: "PROMO", Discount: Discount{Type: DiscountPercent, Value: 10}}},
+				charges: []Charge{{Name: "Service", TaxCode: 1, Type: ChargePercent, Value: 10}},
+			},
+			wantBills: []Line{
+				{
+					Name:         "Big Mac",
+					TaxCode:      1,
+					Type:         "Food & Beverage",
+					Refundable:   Refundable,
+					Quantity:     1,
+					UnitPrice:    1000,
+					Price:        1000,
+					TaxInclusive: true,
+					Coupon:       100,
+					TaxableBase:  900 / 1.1,
+					Tax:          900 - 900/1.1,
+					Taxes:        []TaxComponent{{TaxCode: 1, Type: "Food & Beverage", Base: 900 / 1.1, Tax: 900 - 900/1.1}},
+					Amount:       900,
+				},
+			},
+			wantChargeLines: []ChargeLine{
+				{
+					Charge: Charge{Name: "Service", TaxCode: 1, Type: ChargePercent, Value: 10},
+					Price:  90 / 1.1,
+					Tax:    9 / 1.1,
+					Amount: 99 / 1.1,
+				},
+			},
+			wantGrandTotal: 990,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotBills, gotChargeLines, gotTotal := Calculate(testRules, tt.args.taxObjects, tt.args.coupons, tt.args.charges)
+			if assert.Len(t, gotBills, len(tt.wantBills)) {
+				for index, want := range tt.wantBills {
+					got := gotBills[index]
+					assert.InDelta(t, want.TaxableBase, got.TaxableBase, 1e-9)
+					assert.InDelta(t, want.Tax, got.Tax, 1e-9)
+					if assert.Len(t, got.Taxes, len(want.Taxes)) {
+						for component := range want.Taxes {
+							assert.InDelta(t, want.Taxes[component].Base, got.Taxes[component].Base, 1e-9)
+							assert.InDelta(t, want.Taxes[component].Tax, got.Taxes[component].Tax, 1e-9)
+							got.Taxes[component].Base, got.Taxes[component].Tax = want.Taxes[component].Base, want.Taxes[component].Tax
+						}
+					}
+					got.TaxableBase, got.Tax = want.TaxableBase, want.Tax
+					assert.Equal(t, want, got)
+				}
+			}
+			if assert.Len(t, gotChargeLines, len(tt.wantChargeLines)) {
+				for index, want := range tt.wantChargeLines {
+					got := gotChargeLines[index]
+					assert.Equal(t, want.Charge, got.Charge)
+					assert.InDelta(t, want.Price, got.Price, 1e-9)
+					assert.InDelta(t, want.Tax, got.Tax, 1e-9)
+					assert.InDelta(t, want.Amount, got.Amount, 1e-9)
+				}
+			}
+			assert.InDelta(t, tt.wantGrandTotal, gotTotal.GrandTotal, 1e-9)
+		})
+	}
+}
+
+func TestCalculate_Rules(t *testing.T) {
+	t.Parallel()
+	//The tax objects are taxed by the given rules, so the same tax objects are taxed differently by other rules.
+	taxObjects := []TaxObject{
+		{Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150},
+		{Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, UnitPrice: 1000},
+	}
+	replaced := testJurisdiction
+	replaced.Code = DefaultJurisdiction
+	_, _, total := Calculate(NewJurisdictions(), taxObjects, nil, nil)
+	_, _, replacedTotal := Calculate(NewJurisdictions(replaced), taxObjects, nil, nil)
+	assert.Equal(t, float64(1331), total.GrandTotal)
+	assert.Equal(t, float64(1371), replacedTotal.GrandTotal)
+}
+
+func TestGroupBreakdown(t *testing.T) {
+	t.Parallel()
+	bills, _, _ := Calculate(testRules, []TaxObject{
+		{Name: "Movie", TaxCode: 3, Quantity: 1, UnitPrice: 150},
+		{Name: "MACD", TaxCode: 1, Quantity: 1, UnitPrice: 20000},
+		{Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, UnitPrice: 1000, Jurisdiction: "TEST"},
+		{Name: "KFC", TaxCode: 1, Quantity: 1, UnitPrice: 5000},
+	}, nil, nil)
+	//The taxes stacked on the line are summed in their own tax code,
+	//and only the taxes of the refundable tax codes are refundable, also if they're stacked on the other lines.
+	stacked, _, _ := Calculate(testRules, []TaxObject{
+		{Name: "KFC", TaxCode: 1, Quantity: 1, UnitPrice: 5000, Taxes: []Tax{{TaxCode: 3}}},
+		{Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, UnitPrice: 1000, Taxes: []Tax{{TaxCode: 1}}},
+	}, nil, nil)
+	tests := []struct {
+		name  string
+		bills []Line
+		want  []Breakdown
+	}{
+		// TODO: Add test cases.
+		{
+			name:  "Empty",
+			bills: []Line{},
+			want:  []Breakdown{},
+		},
+		{
+			name:  "Grouped",
+			bills: bills,
+			want: []Breakdown{
+				{TaxCode: 1, Type: "Food & Beverage", Lines: 2, PriceSubtotal: 25000, TaxSubtotal: 2500, RefundableTax: 2500},
+				{TaxCode: 3, Type: "Entertainment", Lines: 1, PriceSubtotal: 150, TaxSubtotal: 0.5},
+				{Jurisdiction: "TEST", TaxCode: 2, Type: "Tobacco", Lines: 1, PriceSubtotal: 1000, TaxSubtotal: 70},
+			},
+		},
+		{
+			name:  "Stacked Taxes",
+			bills: stacked,
+			want: []Breakdown{
+				{TaxCode: 1, Type: "Food & Beverage", Lines: 1, PriceSubtotal: 5000, TaxSubtotal: 500 + 100, RefundableTax: 500 + 100},
+				{TaxCode: 2, Type: "Tobacco", Lines: 1, PriceSubtotal: 1000, TaxSubtotal: 30},
+				{TaxCode: 3, Type: "Entertainment", TaxSubtotal: 49},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, GroupBreakdown(testRules, tt.bills))
+		})
+	}
+}
+
+func TestCalculateLine(t *testing.T) {
+	t.Parallel()
+	taxObjects := []TaxObject{
+		{Name: "Movie", TaxCode: 3, Quantity: 2, UnitPrice: 150},
+		{Name: "Lucky Stretch", TaxCode: 2, Quantity: 1, UnitPrice: 1030, TaxInclusive: true, Jurisdiction: "TEST"},
+		{Name: "KFC", TaxCode: 1, Quantity: 1, UnitPrice: 5000, Taxes: []Tax{{TaxCode: 3, Compound: true}}, Discount: &Discount{Type: DiscountPercent, Value: 10}},
+	}
+	charges := []Charge{{Name: "Service", TaxCode: 1, Type: ChargePercent, Value: 10}}
+	bills, chargeLines, total := Calculate(testRules, taxObjects, nil, charges)
+
+	//The lines without the coupons are calculated one by one, and the charges are calculated from their total.
+	lineTotal := Total{}
+	for index, taxObject := range taxObjects {
+		line := CalculateLine(testRules, taxObject)
+		assert.Equal(t, bills[index], line)
+		lineTotal.AddLine(line)
+	}
+	assert.Equal(t, chargeLines, CalculateCharges(testRules, charges, &lineTotal))
+	assert.Equal(t, total, lineTotal)
+}
+
+func TestAddBreakdown(t *testing.T) {
+	t.Parallel()
+	bills, _, _ := Calculate(testRules, []TaxObject{
+		{Name: "MACD", TaxCode: 1, Quantity: 1, UnitPrice: 20000},
+		{Name: "KFC", TaxCode: 1, Quantity: 1, UnitPrice: 5000, Taxes: []Tax{{TaxCode: 3}}},
+	}, nil, nil)
+	breakdowns := AddBreakdown(testRules, GroupBreakdown(testRules, bills), bills[1], -1)
+	//The group of the stacked tax is kept empty, the caller knows if any other line is summed in it.
+	assert.Equal(t, []Breakdown{
+		{TaxCode: 1, Type: "Food & Beverage", Lines: 1, PriceSubtotal: 20000, TaxSubtotal: 2000, RefundableTax: 2000},
+		{TaxCode: 3, Type: "Entertainment"},
+	}, breakdowns)
+}
+
+func Test_solveNet(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		components []TaxComponent
+		price      float64
+		wantNet    float64
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "Single Tax",
+			components: []TaxComponent{{TaxCode: 1}},
+			price:      11000,
+			wantNet:    10000,
+		},
+		{
+			name:       "Compound Taxes",
+			components: []TaxComponent{{TaxCode: 2}, {TaxCode: 1, Compound: true}},
+			price:      1133,
+			wantNet:    1000,
+		},
+		{
+			name:       "Stacked Taxes",
+			components: []TaxComponent{{TaxCode: 2}, {TaxCode: 1}},
+			price:      1130,
+			wantNet:    1000,
+		},
+		{
+			name:       "Price below the Fixed Tax",
+			components: []TaxComponent{{TaxCode: 2}, {TaxCode: 1}},
+			price:      5,
+			wantNet:    0,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.InDelta(t, tt.wantNet, solveNet(testRules, DefaultJurisdiction, tt.components, tt.price), 1e-9)
+			//The components are only calculated from the solved price.
+			for _, component := range tt.components {
+				assert.Equal(t, float64(0), component.Base)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package taxcalc
+
+import "sort"
+
+const (
+	//Refundable defines the text to show if it's refundable.
+	Refundable = "Yes"
+	//NotRefundable defines the text to show if it's not refundable.
+	NotRefundable = "No"
+)
+
+const (
+	//DiscountPercent defines the discount of the percentage of the price.
+	DiscountPercent = "percent"
+	//DiscountFixed defines the discount of the fixed amount.
+	DiscountFixed = "fixed"
+)
+
+const (
+	//ChargePercent defines the charge of the percentage of the subtotal of the bill.
+	ChargePercent = "percent"
+	//ChargeFixed defines the charge of the fixed amount.
+	ChargeFixed = "fixed"
+)
+
+//TaxObject define the item of the bill calculated into the line of the bill.
+//The price is derived from the quantity and the unit price, the quantity can be fractional, e.g. 1.5 kg.
+//The discount is deducted from the price before the tax is calculated.
+//The taxes are the additional taxes applied after the tax of the tax code in their order, e.g. the VAT after the excise.
+//The price of the tax-inclusive tax object includes its tax, so the price without the tax is calculated from it.
+//The jurisdiction selects the rules of the tax codes, the tax object without the jurisdiction uses the default rules.
+type TaxObject struct {
+	Name         string    `json:"name"`
+	TaxCode      int64     `json:"tax_code"`
+	Jurisdiction string    `json:"jurisdiction,omitempty"`
+	Taxes        []Tax     `json:"taxes,omitempty"`
+	Quantity     float64   `json:"quantity"`
+	UnitPrice    float64   `json:"unit_price"`
+	Price        float64   `json:"price"`
+	Discount     *Discount `json:"discount,omitempty"`
+	TaxInclusive bool      `json:"tax_inclusive,omitempty"`
+}
+
+//Discount define the percentage or the fixed discount of the price.
+//The fixed discount is the amount deducted from the whole price, not from every unit.
+type Discount struct {
+	Type  string  `json:"type" validate:"required,oneof=percent fixed"`
+	Value float64 `json:"value" validate:"required,gt=0"`
+}
+
+//Tax define the additional tax of the tax object calculated by the rule of its tax code.
+//The compound tax is calculated from the price plus the taxes before it, e.g. the VAT including the excise in its base,
+//otherwise it's calculated from the price only.
+type Tax struct {
+	TaxCode  int64 `json:"tax_code" validate:"required,gte=1,lte=3"`
+	Compound bool  `json:"compound"`
+}
+
+//Line define the calculated line of the bill.
+//The price is the gross price of the quantity, and the tax is applied to the taxable base,
+//i.e. the price after the discount of the line and its share of the coupons of the bill.
+//The price, the discount, and the coupon of the tax-inclusive line include the tax,
+//so its taxable base is the price after them without the tax, and its amount is the price after them.
+//The taxes break the tax down by the tax of the tax code and the additional taxes of the tax object in their order.
+//The jurisdiction is the jurisdiction of the tax object whose rules calculate the taxes.
+//The explanation is only given if it's requested, and it's never stored.
+type Line struct {
+	Name         string         `json:"name"`
+	TaxCode      int64          `json:"tax_code"`
+	Jurisdiction string         `json:"jurisdiction,omitempty"`
+	Type         string         `json:"type"`
+	Refundable   string         `json:"refundable"`
+	Quantity     float64        `json:"quantity"`
+	UnitPrice    float64        `json:"unit_price"`
+	Price        float64        `json:"price"`
+	Discount     float64        `json:"discount"`
+	Coupon       float64        `json:"coupon"`
+	TaxInclusive bool           `json:"tax_inclusive"`
+	TaxableBase  float64        `json:"taxable_base"`
+	Tax          float64        `json:"tax"`
+	Taxes        []TaxComponent `json:"taxes,omitempty"`
+	Amount       float64        `json:"amount"`
+	Explanation  *Explanation   `json:"explanation,omitempty"`
+}
+
+//TaxComponent define the tax of the line calculated by the rule of one tax code.
+//The base is the price the tax is calculated from, which includes the taxes before it for the compound tax.
+type TaxComponent struct {
+	TaxCode  int64   `json:"tax_code"`
+	Type     string  `json:"type"`
+	Compound bool    `json:"compound"`
+	Base     float64 `json:"base"`
+	Tax      float64 `json:"tax"`
+}
+
+//TaxSummary define the base and the tax of all taxes of one tax code of one jurisdiction in the bill.
+type TaxSummary struct {
+	Jurisdiction string  `json:"jurisdiction,omitempty"`
+	TaxCode      int64   `json:"tax_code"`
+	Type         string  `json:"type"`
+	Base         float64 `json:"base"`
+	Tax          float64 `json:"tax"`
+}
+
+//Total define the total calculation for each price, discount, tax, and amount.
+//The net subtotal is the subtotal of the taxable bases, i.e. the lines without their tax.
+//The charges and their tax are shown separately from the lines, and the grand total includes them.
+//The taxes summarize the taxes of the lines and the charges by their tax code.
+type Total struct {
+	PriceSubtotal     float64      `json:"price_subtotal"`
+	DiscountSubtotal  float64      `json:"discount_subtotal"`
+	CouponSubtotal    float64      `json:"coupon_subtotal"`
+	NetSubtotal       float64      `json:"net_subtotal"`
+	TaxSubtotal       float64      `json:"tax_subtotal"`
+	ChargeSubtotal    float64      `json:"charge_subtotal"`
+	ChargeTaxSubtotal float64      `json:"charge_tax_subtotal"`
+	GrandTotal        float64      `json:"grand_total"`
+	Taxes             []TaxSummary `json:"taxes,omitempty"`
+}
+
+//Breakdown define the lines of one tax code of one jurisdiction in the bill with their subtotals.
+//The refundable tax is the tax of the refundable tax components, i.e. the tax the credit notes refund.
+type Breakdown struct {
+	Jurisdiction  string  `json:"jurisdiction,omitempty"`
+	TaxCode       int64   `json:"tax_code"`
+	Type          string  `json:"type"`
+	Lines         int     `json:"lines"`
+	PriceSubtotal float64 `json:"price_subtotal"`
+	TaxSubtotal   float64 `json:"tax_subtotal"`
+	RefundableTax float64 `json:"refundable_tax"`
+}
+
+//Coupon define the discount of the bill applied to all its lines after the discounts of the lines.
+//The fixed coupon is shared by the lines in proportion to their price after their discounts.
+type Coupon struct {
+	Code string `json:"code" validate:"required,max=64"`
+	Discount
+}
+
+//Charge define the charge of the bill, e.g. the service charge or the tip.
+//The percent charge is the percentage of the subtotal of the lines after their discounts and the coupons.
+//The tax code in the jurisdiction determines the tax of the charge, and the charge with the tax code 0 is not taxed.
+type Charge struct {
+	Name         string  `json:"name" validate:"required,max=64"`
+	TaxCode      int64   `json:"tax_code" validate:"gte=0,lte=3"`
+	Jurisdiction string  `json:"jurisdiction,omitempty" validate:"max=32"`
+	Type         string  `json:"type" validate:"required,oneof=percent fixed"`
+	Value        float64 `json:"value" validate:"required,gt=0"`
+}
+
+//ChargeLine define the calculated charge of the bill.
+type ChargeLine struct {
+	Charge
+	Price  float64 `json:"price"`
+	Tax    float64 `json:"tax"`
+	Amount float64 `json:"amount"`
+}
+
+//Derive derive the price of the tax object from its quantity and unit price.
+//The tax object without the quantity is one unit, and the tax object without the unit price
+//has the price as its unit price, so the tax objects having only the price keep their price.
+func (taxObject *TaxObject) Derive() {
+	if taxObject.Quantity == 0 {
+		taxObject.Quantity = 1
+	}
+	if taxObject.UnitPrice == 0 {
+		taxObject.UnitPrice = taxObject.Price
+	}
+	taxObject.Price = taxObject.Quantity * taxObject.UnitPrice
+}
+
+//OrNil return the discount, or nil if it has no type, i.e. the tax object has no discount.
+func (discount Discount) OrNil() *Discount {
+	if discount.Type == "" {
+		return nil
+	}
+	return &discount
+}
+
+//Amount return the discount of the price, which never exceeds the price.
+func (discount Discount) Amount(price float64) (amount float64) {
+	switch discount.Type {
+	case DiscountPercent:
+		amount = discount.Value / 100 * price
+	case DiscountFixed:
+		amount = discount.Value
+	}
+	if amount > price {
+		amount = price
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	return
+}
+
+//Amount return the charge of the subtotal.
+func (charge Charge) Amount(subtotal float64) (amount float64) {
+	switch charge.Type {
+	case ChargePercent:
+		amount = charge.Value / 100 * subtotal
+	case ChargeFixed:
+		amount = charge.Value
+	}
+	return
+}
+
+//Add add the other total multiplied by the sign to the total.
+//The sign is -1 to subtract the other total.
+func (total *Total) Add(other Total, sign float64) {
+	total.PriceSubtotal += sign * other.PriceSubtotal
+	total.DiscountSubtotal += sign * other.DiscountSubtotal
+	total.CouponSubtotal += sign * other.CouponSubtotal
+	total.NetSubtotal += sign * other.NetSubtotal
+	total.TaxSubtotal += sign * other.TaxSubtotal
+	total.ChargeSubtotal += sign * other.ChargeSubtotal
+	total.ChargeTaxSubtotal += sign * other.ChargeTaxSubtotal
+	total.GrandTotal += sign * other.GrandTotal
+	for _, summary := range other.Taxes {
+		summary.Base *= sign
+		summary.Tax *= sign
+		total.AddTax(summary)
+	}
+}
+
+//AddLine add the price, the discounts, the tax, and the amount of the line to the total.
+func (total *Total) AddLine(line Line) {
+	total.PriceSubtotal += line.Price
+	total.DiscountSubtotal += line.Discount
+	total.CouponSubtotal += line.Coupon
+	total.NetSubtotal += line.TaxableBase
+	total.TaxSubtotal += line.Tax
+	total.GrandTotal += line.Amount
+	for _, component := range line.Taxes {
+		total.AddTax(TaxSummary{
+			Jurisdiction: line.Jurisdiction,
+			TaxCode:      component.TaxCode,
+			Type:         component.Type,
+			Base:         component.Base,
+			Tax:          component.Tax,
+		})
+	}
+}
+
+//AddCharge add the price, the tax, and the amount of the charge to the total.
+func (total *Total) AddCharge(chargeLine ChargeLine) {
+	total.ChargeSubtotal += chargeLine.Price
+	total.ChargeTaxSubtotal += chargeLine.Tax
+	total.GrandTotal += chargeLine.Amount
+}
+
+//AddTax add the base and the tax of the tax code to the summary of the taxes ordered by the jurisdiction and the tax code.
+//The summary is copied, so the totals sharing it are not changed.
+func (total *Total) AddTax(summary TaxSummary) {
+	index := sort.Search(len(total.Taxes), func(index int) bool {
+		other := total.Taxes[index]
+		return other.Jurisdiction > summary.Jurisdiction ||
+			(other.Jurisdiction == summary.Jurisdiction && other.TaxCode >= summary.TaxCode)
+	})
+	taxes := make([]TaxSummary, 0, len(total.Taxes)+1)
+	taxes = append(taxes, total.Taxes[:index]...)
+	if index < len(total.Taxes) &&
+		total.Taxes[index].Jurisdiction == summary.Jurisdiction &&
+		total.Taxes[index].TaxCode == summary.TaxCode {
+		summary.Base += total.Taxes[index].Base
+		summary.Tax += total.Taxes[index].Tax
+		index++
+	}
+	taxes = append(taxes, summary)
+	total.Taxes = append(taxes, total.Taxes[index:]...)
+}
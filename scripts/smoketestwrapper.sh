@@ -1,5 +1,5 @@
 #!/bin/bash
-until $(curl --output /dev/null --silent --get --fail 'http://'"$SERVICE_HOST"':'"$SERVICE_PORT$SERVICE_ENDPOINT"'');
+until $(curl --output /dev/null --silent --get 'http://'"$SERVICE_HOST"':'"$SERVICE_PORT$SERVICE_ENDPOINT"'');
 do
   echo 'Waiting for the TaxCalculator to start'
   sleep 1
@@ -3,32 +3,24 @@
 package test
 
 import (
-	"encoding/json"
-	"io/ioutil"
+	"context"
 	"net/http"
 	"os"
-	"strings"
 	"testing"
 	"time"
 
-	"github.com/fairyhunter13/tax-calculator/internal/bill"
-	billDelivery "github.com/fairyhunter13/tax-calculator/internal/bill/delivery"
-
-	"github.com/fairyhunter13/tax-calculator/internal/taxobj"
-
 	"github.com/fairyhunter13/tax-calculator/internal/app"
-	"github.com/labstack/echo"
+	"github.com/fairyhunter13/tax-calculator/pkg/client"
 	"github.com/stretchr/testify/assert"
 	ini "gopkg.in/ini.v1"
 )
 
 var (
-	id     int64
-	config *app.Config
-	client = &http.Client{
-		Timeout: 5 * time.Second,
-	}
-	host string
+	id       int64
+	apiKeyID int64
+	config   *app.Config
+	api      *client.Client
+	admin    *client.Client
 )
 
 const (
@@ -46,7 +38,39 @@ func setup(t *testing.T) {
 	if hostname == "" {
 		hostname = "taxcalculator"
 	}
-	host = "http://" + hostname + config.Server.Port
+	baseURL := "http://" + hostname + config.Server.Port
+	httpClient := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	options := client.Options{
+		HTTPClient: httpClient,
+	}
+	if config.Auth.Enabled {
+		options.APIKey = createAPIKey(t, baseURL, httpClient)
+	}
+	api = client.New(baseURL, options)
+}
+
+//createAPIKey create the api key of the smoke test with the admin key in the SERVICE_ADMIN_KEY environment variable.
+func createAPIKey(t *testing.T, baseURL string, httpClient *http.Client) string {
+	adminKey := os.Getenv("SERVICE_ADMIN_KEY")
+	if adminKey == "" {
+		t.Fatal("The SERVICE_ADMIN_KEY is required if the authentication is enabled")
+	}
+	admin = client.New(baseURL, client.Options{
+		AdminKey:   adminKey,
+		HTTPClient: httpClient,
+	})
+	created, err := admin.CreateAPIKey(context.Background(), client.APIKey{
+		Name:   "smoke test",
+		Tenant: "smoke",
+		Role:   "supervisor",
+	})
+	if err != nil {
+		t.Fatalf("Error in creating the api key: %s", err)
+	}
+	apiKeyID = created.ID
+	return created.Key
 }
 
 func TestSmoke(t *testing.T) {
@@ -57,30 +81,14 @@ func TestSmoke(t *testing.T) {
 }
 
 func testCreateTaxObject(t *testing.T) {
-	const requestTest = `
-		{
-			"name": "KFC Burger",
-			"tax_code" : 1,
-			"price": 5000
-		}
-	`
-	resp, err := client.Post(host+"/tax", echo.MIMEApplicationJSON, strings.NewReader(requestTest))
-	if err != nil {
-		t.Fatalf("Error in creating the tax object: %s", err)
-		return
-	}
-	defer resp.Body.Close()
+	taxObj, err := api.CreateTaxObject(context.Background(), client.TaxObject{
+		Name:    "KFC Burger",
+		TaxCode: 1,
+		Price:   5000,
+	})
 	//Check if the tax object is successfully created.
-	assert.Equal(t, http.StatusCreated, resp.StatusCode)
-	taxObj := new(taxobj.TaxObject)
-	byteBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		t.Fatalf("Error in reading the response body: %s", err)
-		return
-	}
-	err = json.Unmarshal(byteBody, taxObj)
 	if err != nil {
-		t.Fatalf("Error in unmarshaling the response body: %s", err)
+		t.Fatalf("Error in creating the tax object: %s", err)
 		return
 	}
 	if taxObj.ID != 0 {
@@ -90,9 +98,9 @@ func testCreateTaxObject(t *testing.T) {
 }
 
 func testGetBill(t *testing.T) {
-	expectedResponse := billDelivery.BillResponse{
-		Bill: []bill.Bill{
-			bill.Bill{
+	expectedResponse := client.BillResponse{
+		Bill: []client.Bill{
+			client.Bill{
 				Name:       "KFC Burger",
 				TaxCode:    1,
 				Price:      5000,
@@ -102,27 +110,15 @@ func testGetBill(t *testing.T) {
 				Amount:     5500,
 			},
 		},
-		Total: bill.Total{
+		Total: client.Total{
 			PriceSubtotal: 5000,
 			TaxSubtotal:   500,
 			GrandTotal:    5500,
 		},
 	}
-	resp, err := client.Get(host + "/bill")
+	billResp, err := api.GetBill(context.Background(), false)
 	if err != nil {
-		t.Fatalf("Error in creating the tax object: %s", err)
-		return
-	}
-	defer resp.Body.Close()
-	billResp := new(billDelivery.BillResponse)
-	byteBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		t.Fatalf("Error in reading the response body: %s", err)
-		return
-	}
-	err = json.Unmarshal(byteBody, billResp)
-	if err != nil {
-		t.Fatalf("Error in unmarshaling the response body: %s", err)
+		t.Fatalf("Error in getting the bill: %s", err)
 		return
 	}
 	t.Logf("Bill List: %+v\n", billResp)
@@ -150,4 +146,9 @@ func cleanup(t *testing.T) {
 	if id != 0 {
 		t.Logf("The newly example of the created tax object have id: %d", id)
 	}
+	if apiKeyID != 0 {
+		if err := admin.RevokeAPIKey(context.Background(), apiKeyID); err != nil {
+			t.Errorf("Error in revoking the api key: %s", err)
+		}
+	}
 }